@@ -6,6 +6,7 @@ import (
 	"app/api/handler"
 	"app/config"
 	"app/pkg/logger"
+	"app/pkg/payment"
 	"app/storage"
 
 	"github.com/gin-gonic/gin"
@@ -13,35 +14,66 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger" // gin-swagger middleware
 )
 
-func NewApi(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI) {
-	handler := handler.NewHandler(cfg, store, logger)
+func NewApi(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger logger.LoggerI, provider payment.Provider) {
+	handler := handler.NewHandler(cfg, store, logger, provider)
+
+	// a replayed response is not a new mutation, so it is answered before the audit
+	r.Use(handler.IdempotencyMiddleware())
+	r.Use(handler.AuditMiddleware())
+
 	// category api
 	r.POST("/category", handler.CreateCategory)
 	r.GET("/category/:id", handler.GetByIdCategory)
 	r.GET("/category", handler.GetListCategory)
 	r.PUT("/category/:id", handler.UpdateCategory)
+	r.PATCH("/category/:id", handler.UpdatePatchCategory)
 	r.DELETE("/category/:id", handler.DeleteCategory)
+	r.POST("/category/:id/restore", handler.RestoreCategory)
+	r.POST("/category/:id/attributes", handler.CreateAttributeDefinition)
+	r.GET("/category/:id/attributes", handler.GetListAttributeDefinition)
+	r.GET("/category/:id/attributes/:attribute_id", handler.GetByIdAttributeDefinition)
+	r.PUT("/category/:id/attributes/:attribute_id", handler.UpdateAttributeDefinition)
+	r.DELETE("/category/:id/attributes/:attribute_id", handler.DeleteAttributeDefinition)
 
 	// brand api
 	r.POST("/brand", handler.CreateBrand)
 	r.GET("/brand/:id", handler.GetByIdBrand)
 	r.GET("/brand", handler.GetListBrand)
 	r.PUT("/brand/:id", handler.UpdateBrand)
+	r.PATCH("/brand/:id", handler.UpdatePatchBrand)
 	r.DELETE("/brand/:id", handler.DeleteBrand)
+	r.POST("/brand/:id/restore", handler.RestoreBrand)
 
 	// product api
 	r.POST("/product", handler.CreateProduct)
 	r.GET("/product/:id", handler.GetByIdProduct)
 	r.GET("/product", handler.GetListProduct)
+	r.GET("/product/search", handler.SearchProduct)
 	r.PUT("/product/:id", handler.UpdateProduct)
+	r.PATCH("/product/:id", handler.UpdatePatchProduct)
 	r.DELETE("/product/:id", handler.DeleteProduct)
+	r.POST("/product/:id/restore", handler.RestoreProduct)
+	r.GET("/product/:id/price", handler.GetProductPrice)
+	r.GET("/product/:id/price_history", handler.GetProductPriceHistory)
+	r.POST("/product/:id/variants", handler.CreateProductVariant)
+	r.GET("/product/:id/variants", handler.GetListProductVariant)
+	r.GET("/product/:id/variants/:variant_id", handler.GetByIdProductVariant)
+	r.PUT("/product/:id/variants/:variant_id", handler.UpdateProductVariant)
+	r.DELETE("/product/:id/variants/:variant_id", handler.DeleteProductVariant)
+	r.GET("/product/:id/attributes", handler.GetProductAttributes)
+	r.PUT("/product/:id/attributes", handler.SetProductAttributes)
 
 	// stock api  -- not ready for using
 	r.POST("/stock", handler.CreateStock)
 	r.GET("/stock/:id", handler.GetByIdStock)
 	r.GET("/stock", handler.GetListStock)
+	r.GET("/stock/low", handler.GetListLowStock)
+	r.GET("/stock/reorder_suggestion", handler.GetReorderSuggestion)
+	r.POST("/stock/reorder_suggestion/purchase_order", handler.CreateReorderPurchaseOrder)
 	r.PUT("/stock/:id", handler.UpdateStock)
+	r.PATCH("/stock/:id", handler.UpdatePatchStock)
 	r.PUT("/stock/send_product", handler.UpdateStock)
+	r.PUT("/stock/threshold", handler.UpdateStockThreshold)
 	r.DELETE("/stock/:id", handler.DeleteStock)
 
 	// store api
@@ -51,14 +83,29 @@ func NewApi(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger lo
 	r.PUT("/store/:id", handler.UpdateStore)
 	r.PATCH("/store/:id", handler.UpdatePatchStore)
 	r.DELETE("/store/:id", handler.DeleteStore)
+	r.POST("/store/:id/restore", handler.RestoreStore)
 
 	// customer api
 	r.POST("/customer", handler.CreateCustomer)
 	r.GET("/customer/:id", handler.GetByIdCustomer)
 	r.GET("/customer", handler.GetListCustomer)
+	r.GET("/customer/duplicates", handler.GetListDuplicateCustomer)
+	r.POST("/customer/merge", handler.MergeCustomer)
 	r.PUT("/customer/:id", handler.UpdateCustomer)
 	r.PATCH("/customer/:id", handler.UpdatePatchCustomer)
 	r.DELETE("/customer/:id", handler.DeleteCustomer)
+	r.POST("/customer/:id/restore", handler.RestoreCustomer)
+	r.GET("/customer/:id/credit", handler.GetCustomerCredit)
+	r.GET("/customer/:id/loyalty", handler.GetCustomerLoyalty)
+	r.GET("/customer/:id/orders", handler.GetCustomerOrders)
+	r.GET("/customer/:id/summary", handler.GetCustomerSummary)
+	r.GET("/customer/:id/export", handler.ExportCustomer)
+	r.POST("/customer/:id/anonymize", handler.AnonymizeCustomer)
+	r.POST("/customer/:id/addresses", handler.CreateCustomerAddress)
+	r.GET("/customer/:id/addresses", handler.GetListCustomerAddress)
+	r.GET("/customer/:id/addresses/:address_id", handler.GetByIdCustomerAddress)
+	r.PUT("/customer/:id/addresses/:address_id", handler.UpdateCustomerAddress)
+	r.DELETE("/customer/:id/addresses/:address_id", handler.DeleteCustomerAddress)
 
 	// staff api
 	r.POST("/staff", handler.CreateStaff)
@@ -68,6 +115,7 @@ func NewApi(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger lo
 	r.PUT("/staff/:id", handler.UpdateStaff)
 	r.PATCH("/staff/:id", handler.UpdatePatchStaff)
 	r.DELETE("/staff/:id", handler.DeleteStaff)
+	r.POST("/staff/:id/restore", handler.RestoreStaff)
 
 	// order api
 	r.POST("/order", handler.CreateOrder)
@@ -76,8 +124,16 @@ func NewApi(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger lo
 	r.GET("/order/total_sum", handler.OrderTotalSum)
 	r.PUT("/order/:id", handler.UpdateOrder)
 	r.PATCH("/order/:id", handler.UpdatePatchOrder)
+	r.POST("/order/:id/complete", handler.CompleteOrder)
+	r.POST("/order/:id/payment", handler.CreatePayment)
+	r.GET("/order/:id/balance", handler.GetOrderBalance)
+	r.GET("/order/:id/receipt", handler.GetOrderReceipt)
+	r.POST("/order/:id/loyalty", handler.RedeemLoyaltyPoints)
+	r.DELETE("/order/:id/loyalty", handler.ReleaseLoyaltyPoints)
 	r.DELETE("/order/:id", handler.DeleteOrder)
 	r.POST("/order_item/", handler.CreateOrderItem)
+	r.POST("/order/:id/items:batch", handler.CreateOrderItems)
+	r.POST("/checkout", handler.Checkout)
 	r.DELETE("/order_item/:id", handler.DeleteOrderItem)
 
 	// code api
@@ -85,8 +141,76 @@ func NewApi(r *gin.Engine, cfg *config.Config, store storage.StorageI, logger lo
 	r.GET("/code/:id", handler.GetByIdCode)
 	r.GET("/code", handler.GetListCode)
 	r.PUT("/code/:id", handler.UpdateCode)
+	r.PATCH("/code/:id", handler.UpdatePatchCode)
 	r.DELETE("/code/:id", handler.DeleteCode)
 
+	// supplier api
+	r.POST("/supplier", handler.CreateSupplier)
+	r.GET("/supplier/:id", handler.GetByIdSupplier)
+	r.GET("/supplier", handler.GetListSupplier)
+	r.PUT("/supplier/:id", handler.UpdateSupplier)
+	r.DELETE("/supplier/:id", handler.DeleteSupplier)
+
+	// purchase order api
+	r.POST("/purchase_order", handler.CreatePurchaseOrder)
+	r.GET("/purchase_order/:id", handler.GetByIdPurchaseOrder)
+	r.GET("/purchase_order", handler.GetListPurchaseOrder)
+	r.PUT("/purchase_order/:id", handler.UpdatePurchaseOrder)
+	r.PUT("/purchase_order/:id/status", handler.UpdatePurchaseOrderStatus)
+	r.DELETE("/purchase_order/:id", handler.DeletePurchaseOrder)
+	r.POST("/purchase_order/:id/item", handler.CreatePurchaseOrderItem)
+	r.DELETE("/purchase_order/:id/item/:item_id", handler.DeletePurchaseOrderItem)
+	r.POST("/purchase_order/:id/receive", handler.ReceivePurchaseOrder)
+
+	// return api
+	r.POST("/return", handler.CreateReturn)
+	r.GET("/return/:id", handler.GetByIdReturn)
+	r.GET("/return", handler.GetListReturn)
+	r.PUT("/return/:id/inspect", handler.InspectReturn)
+	r.PUT("/return/:id/approve", handler.ApproveReturn)
+	r.PUT("/return/:id/reject", handler.RejectReturn)
+
+	// payment api
+	r.GET("/payment/:id", handler.GetByIdPayment)
+	r.GET("/payment", handler.GetListPayment)
+	r.POST("/payment/:id/refund", handler.RefundPayment)
+
+	// gift card api
+	r.POST("/gift_card", handler.CreateGiftCard)
+	r.GET("/gift_card/:id", handler.GetByIdGiftCard)
+	r.GET("/gift_card", handler.GetListGiftCard)
+	r.PUT("/gift_card/:id/status", handler.UpdateGiftCardStatus)
+
+	// loyalty api
+	r.GET("/loyalty/tier", handler.GetListLoyaltyTier)
+	r.PUT("/loyalty/tier", handler.UpsertLoyaltyTier)
+	r.GET("/loyalty/multiplier", handler.GetListLoyaltyMultiplier)
+	r.PUT("/loyalty/multiplier", handler.UpsertLoyaltyMultiplier)
+	r.DELETE("/loyalty/multiplier/:id", handler.DeleteLoyaltyMultiplier)
+
+	// price list api
+	r.POST("/price_list", handler.CreatePriceList)
+	r.GET("/price_list", handler.GetListPriceList)
+	r.POST("/price_list/:id/item", handler.CreatePriceListItem)
+	r.GET("/price_list/:id/item", handler.GetListPriceListItem)
+	r.GET("/price_list/:id/item/:item_id", handler.GetByIdPriceListItem)
+	r.PUT("/price_list/:id/item/:item_id", handler.UpdatePriceListItem)
+	r.DELETE("/price_list/:id/item/:item_id", handler.DeletePriceListItem)
+
+	// tax api
+	r.POST("/tax_rate", handler.CreateTaxRate)
+	r.GET("/tax_rate", handler.GetListTaxRate)
+	r.GET("/tax_rate/:id", handler.GetByIdTaxRate)
+	r.PUT("/tax_rate/:id", handler.UpdateTaxRate)
+	r.DELETE("/tax_rate/:id", handler.DeleteTaxRate)
+
+	// exchange rate api
+	r.POST("/exchange_rate", handler.CreateExchangeRate)
+	r.GET("/exchange_rate", handler.GetListExchangeRate)
+
+	// audit api
+	r.GET("/audit", handler.GetListAudit)
+
 	url := ginSwagger.URL("swagger/doc.json") // The url pointing to API definition
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
 }
@@ -0,0 +1,368 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Attribute Definition godoc
+// @ID create_attribute_definition
+// @Router /category/{id}/attributes [POST]
+// @Summary Create Attribute Definition
+// @Description Define an attribute products of the category are described by, e.g. suspension travel in mm or battery capacity in Wh.
+// @Description data_type is text, integer, decimal, boolean or enum, min_value and max_value bound numbers, allowed_values lists the options of an enum
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param attribute body models.CreateAttributeDefinition true "CreateAttributeDefinitionRequest"
+// @Success 201 {object} Response{data=models.AttributeDefinition} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateAttributeDefinition(c *gin.Context) {
+
+	var createAttribute models.CreateAttributeDefinition
+
+	err := c.ShouldBindJSON(&createAttribute) // parse req body to given type struct
+	if err != nil {
+		h.handlerResponse(c, "create attribute definition", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.category.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	createAttribute.CategoryId = idInt
+
+	id, err := h.storages.Attribute().Create(context.Background(), &createAttribute)
+	if err != nil {
+		h.handlerResponse(c, "storage.attribute.create", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Attribute().GetByID(context.Background(), &models.AttributeDefinitionPrimaryKey{CategoryId: idInt, AttributeId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.attribute.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create attribute definition", http.StatusCreated, resp)
+}
+
+// Get By ID Attribute Definition godoc
+// @ID get_by_id_attribute_definition
+// @Router /category/{id}/attributes/{attribute_id} [GET]
+// @Summary Get By ID Attribute Definition
+// @Description Get By ID Attribute Definition
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param attribute_id path string true "attribute_id"
+// @Success 200 {object} Response{data=models.AttributeDefinition} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdAttributeDefinition(c *gin.Context) {
+
+	key, ok := h.attributeKey(c, "storage.attribute.getByID")
+	if !ok {
+		return
+	}
+
+	resp, err := h.storages.Attribute().GetByID(context.Background(), key)
+	if err != nil {
+		h.handlerResponse(c, "storage.attribute.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "get attribute definition by id", http.StatusOK, resp)
+}
+
+// Get List Attribute Definition godoc
+// @ID get_list_attribute_definition
+// @Router /category/{id}/attributes [GET]
+// @Summary Get List Attribute Definition
+// @Description Attributes products of the category are described by
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.GetListAttributeDefinitionResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListAttributeDefinition(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.category.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	resp, err := h.storages.Attribute().GetList(context.Background(), &models.GetListAttributeDefinitionRequest{
+		CategoryId: idInt,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.attribute.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list attribute definition response", http.StatusOK, resp)
+}
+
+// Update Attribute Definition godoc
+// @ID update_attribute_definition
+// @Router /category/{id}/attributes/{attribute_id} [PUT]
+// @Summary Update Attribute Definition
+// @Description Update the attribute, its data_type can not change while products have a value for it
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param attribute_id path string true "attribute_id"
+// @Param attribute body models.UpdateAttributeDefinition true "UpdateAttributeDefinitionRequest"
+// @Param If-Match header string true "ETag of the attribute as last read"
+// @Success 202 {object} Response{data=models.AttributeDefinition} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateAttributeDefinition(c *gin.Context) {
+
+	var updateAttribute models.UpdateAttributeDefinition
+
+	err := c.ShouldBindJSON(&updateAttribute)
+	if err != nil {
+		h.handlerResponse(c, "update attribute definition", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, ok := h.attributeKey(c, "storage.attribute.update")
+	if !ok {
+		return
+	}
+
+	updateAttribute.CategoryId = key.CategoryId
+	updateAttribute.AttributeId = key.AttributeId
+
+	version, ok := h.getIfMatch(c, "update attribute definition")
+	if !ok {
+		return
+	}
+
+	updateAttribute.Version = version
+
+	rowsAffected, err := h.storages.Attribute().Update(context.Background(), &updateAttribute)
+	if err != nil {
+		h.handlerResponse(c, "storage.attribute.update", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.Attribute().GetByID(context.Background(), key)
+		h.notWritten(c, "storage.attribute.update", err)
+		return
+	}
+
+	resp, err := h.storages.Attribute().GetByID(context.Background(), key)
+	if err != nil {
+		h.handlerResponse(c, "storage.attribute.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update attribute definition", http.StatusAccepted, resp)
+}
+
+// Delete Attribute Definition godoc
+// @ID delete_attribute_definition
+// @Router /category/{id}/attributes/{attribute_id} [DELETE]
+// @Summary Delete Attribute Definition
+// @Description Delete the attribute together with the values products have for it
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param attribute_id path string true "attribute_id"
+// @Param If-Match header string true "ETag of the attribute as last read"
+// @Success 204 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeleteAttributeDefinition(c *gin.Context) {
+
+	key, ok := h.attributeKey(c, "storage.attribute.delete")
+	if !ok {
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "delete attribute definition")
+	if !ok {
+		return
+	}
+
+	key.Version = version
+
+	rowsAffected, err := h.storages.Attribute().Delete(context.Background(), key)
+	if err != nil {
+		h.handlerResponse(c, "storage.attribute.delete", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.Attribute().GetByID(context.Background(), key)
+		h.notWritten(c, "storage.attribute.delete", err)
+		return
+	}
+
+	h.handlerResponse(c, "delete attribute definition", http.StatusNoContent, nil)
+}
+
+// Get Product Attributes godoc
+// @ID get_product_attributes
+// @Router /product/{id}/attributes [GET]
+// @Summary Get Product Attributes
+// @Description Attribute values of the product
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=[]models.ProductAttribute} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetProductAttributes(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.product.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	resp, err := h.storages.Attribute().GetProductValues(context.Background(), &models.ProductPrimaryKey{ProductId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.attribute.getProductValues", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get product attributes", http.StatusOK, resp)
+}
+
+// Set Product Attributes godoc
+// @ID set_product_attributes
+// @Router /product/{id}/attributes [PUT]
+// @Summary Set Product Attributes
+// @Description Replace the attribute values of the product, keyed by attribute code, e.g. {"values": {"travel_mm": 150, "battery_wh": 625, "motor": "mid-drive"}}.
+// @Description Every value is checked against the attribute of the product's category, required attributes must be given
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param attributes body models.SetProductAttributes true "SetProductAttributesRequest"
+// @Success 202 {object} Response{data=[]models.ProductAttribute} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) SetProductAttributes(c *gin.Context) {
+
+	var setAttributes models.SetProductAttributes
+
+	err := c.ShouldBindJSON(&setAttributes)
+	if err != nil {
+		h.handlerResponse(c, "set product attributes", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.product.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	setAttributes.ProductId = idInt
+
+	err = h.storages.Attribute().SetProductValues(context.Background(), &setAttributes)
+	if err != nil {
+		h.handlerResponse(c, "storage.attribute.setProductValues", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Attribute().GetProductValues(context.Background(), &models.ProductPrimaryKey{ProductId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.attribute.getProductValues", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "set product attributes", http.StatusAccepted, resp)
+}
+
+func (h *Handler) attributeKey(c *gin.Context, path string) (*models.AttributeDefinitionPrimaryKey, bool) {
+
+	categoryId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, path, http.StatusBadRequest, "id incorrect")
+		return nil, false
+	}
+
+	attributeId, err := strconv.Atoi(c.Param("attribute_id"))
+	if err != nil {
+		h.handlerResponse(c, path, http.StatusBadRequest, "attribute_id incorrect")
+		return nil, false
+	}
+
+	return &models.AttributeDefinitionPrimaryKey{CategoryId: categoryId, AttributeId: attributeId}, true
+}
+
+// attributeFilters reads the attr.<code>, attr.<code>.min and attr.<code>.max query parameters.
+func (h *Handler) attributeFilters(c *gin.Context) ([]*models.AttributeFilter, error) {
+	var (
+		query   = c.Request.URL.Query()
+		keys    []string
+		filters []*models.AttributeFilter
+	)
+
+	for key := range query {
+		if strings.HasPrefix(key, "attr.") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var (
+			code = strings.TrimPrefix(key, "attr.")
+			op   = models.AttributeFilterEqual
+		)
+
+		if strings.HasSuffix(code, ".min") {
+			code, op = strings.TrimSuffix(code, ".min"), models.AttributeFilterMin
+		} else if strings.HasSuffix(code, ".max") {
+			code, op = strings.TrimSuffix(code, ".max"), models.AttributeFilterMax
+		}
+
+		if len(code) <= 0 || strings.Contains(code, ".") {
+			return nil, fmt.Errorf("invalid attribute filter %s", key)
+		}
+
+		for _, value := range query[key] {
+			filters = append(filters, &models.AttributeFilter{Code: code, Op: op, Value: value})
+		}
+	}
+
+	return filters, nil
+}
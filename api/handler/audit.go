@@ -0,0 +1,411 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerRequestId = "X-Request-Id"
+	headerStaffId   = "X-Staff-Id"
+)
+
+type auditLoader func(ctx context.Context, id int) (interface{}, error)
+
+// auditWriter keeps a copy of the response body for the audit record and the idempotency replay.
+type auditWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// AuditMiddleware gives every request an id (X-Request-Id, generated when missing) and
+// writes an audit record for every successful POST, PUT, PATCH and DELETE. The actor is
+// the staff id sent in X-Staff-Id, the entity is the first segment of the route. The API has
+// no authentication, X-Staff-Id is trusted as sent.
+func (h *Handler) AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		requestId := c.GetHeader(headerRequestId)
+		if len(requestId) <= 0 {
+			requestId, _ = helper.NewRequestId()
+		}
+		c.Set("request_id", requestId)
+		c.Header(headerRequestId, requestId)
+
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		var (
+			entity, action = auditAction(c.Request.Method, c.FullPath())
+			entityId, _    = strconv.Atoi(c.Param("id"))
+			staffId, _     = strconv.Atoi(c.GetHeader(headerStaffId))
+			payload        []byte
+			before         interface{}
+		)
+
+		if c.Request.Body != nil {
+			payload, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(payload))
+		}
+
+		// routes without the id in the path send it in the body, a line is recorded
+		// against its order and a stock row against its store
+		switch {
+		case entityId > 0:
+		case entity == "order_item":
+			entityId = auditEntityId("order", payload)
+		case entity == "stock":
+			entityId = auditEntityId("store", payload)
+		}
+
+		load := h.auditLoader(entity, c, payload)
+
+		// the state before an anonymization is the personal data it erases
+		if load != nil && entityId > 0 && action != "anonymize" {
+			if state, err := load(context.Background(), entityId); err == nil {
+				before = state
+			}
+		}
+
+		writer := &auditWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusMultipleChoices {
+			return
+		}
+
+		var response struct {
+			Data json.RawMessage
+		}
+		_ = json.Unmarshal(writer.body.Bytes(), &response)
+
+		if entityId <= 0 {
+			entityId = auditEntityId(entity, response.Data)
+		}
+
+		var after interface{}
+		if load != nil && entityId > 0 {
+			if state, err := load(context.Background(), entityId); err == nil {
+				after = state
+			}
+		}
+		if after == nil && bytes.HasPrefix(bytes.TrimSpace(response.Data), []byte("{")) {
+			after = response.Data
+		}
+
+		beforeData, afterData := auditDiff(before, after, auditKeys[entity]...)
+		if !json.Valid(payload) {
+			payload = nil
+		}
+
+		_, err := h.storages.Audit().Create(context.Background(), &models.CreateAuditLog{
+			StaffId:    staffId,
+			EntityType: entity,
+			EntityId:   entityId,
+			Action:     action,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Status:     status,
+			RequestId:  requestId,
+			Payload:    payload,
+			Before:     beforeData,
+			After:      afterData,
+		})
+		if err != nil {
+			h.logger.Error("storage.audit.create", logger.Error(err), logger.Any("request_id", requestId))
+		}
+	}
+}
+
+// Get List Audit godoc
+// @ID get_list_audit
+// @Router /audit [GET]
+// @Summary Get List Audit
+// @Description Audit records of the mutations, newest first. Before and after hold only the fields that changed, the actor is the X-Staff-Id header of the request
+// @Tags Audit
+// @Accept json
+// @Produce json
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param staff_id query string false "staff_id"
+// @Param entity_type query string false "entity_type"
+// @Param entity_id query string false "entity_id"
+// @Param action query string false "action"
+// @Param request_id query string false "request_id"
+// @Param from_date query string false "from_date"
+// @Param to_date query string false "to_date"
+// @Success 200 {object} Response{data=models.GetListAuditLogResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListAudit(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get list audit", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get list audit", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	staffId, err := h.getIntQuery(c.Query("staff_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list audit", http.StatusBadRequest, "invalid staff_id")
+		return
+	}
+
+	entityId, err := h.getIntQuery(c.Query("entity_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list audit", http.StatusBadRequest, "invalid entity_id")
+		return
+	}
+
+	resp, err := h.storages.Audit().GetList(context.Background(), &models.GetListAuditLogRequest{
+		Offset:     offset,
+		Limit:      limit,
+		StaffId:    staffId,
+		EntityType: c.Query("entity_type"),
+		EntityId:   entityId,
+		Action:     c.Query("action"),
+		RequestId:  c.Query("request_id"),
+		FromDate:   c.Query("from_date"),
+		ToDate:     c.Query("to_date"),
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.audit.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list audit response", http.StatusOK, resp)
+}
+
+// auditAction names the entity and the action of a route: /brand/:id with PUT is an
+// "update" of "brand", /order/:id/complete is "complete" and a DELETE of
+// /purchase_order/:id/item/:item_id is "delete_item" of "purchase_order".
+func auditAction(method, route string) (string, string) {
+	var (
+		segments = strings.Split(strings.Trim(route, "/"), "/")
+		entity   = segments[0]
+		sub      string
+		verb     string
+	)
+
+	for _, segment := range segments[1:] {
+		if !strings.HasPrefix(segment, ":") {
+			sub = segment
+		}
+	}
+
+	switch method {
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		verb = "delete"
+	}
+
+	switch {
+	case len(sub) <= 0:
+		return entity, verb
+	case strings.HasPrefix(segments[len(segments)-1], ":"), method == http.MethodDelete, method == http.MethodPatch:
+		return entity, verb + "_" + sub
+	}
+
+	return entity, sub
+}
+
+// auditEntityId picks the id of a created entity out of the response data, which is
+// either the id itself or the entity with its <entity>_id field.
+func auditEntityId(entity string, data json.RawMessage) int {
+	var id int
+	if json.Unmarshal(data, &id) == nil {
+		return id
+	}
+
+	var object map[string]interface{}
+	if json.Unmarshal(data, &object) != nil {
+		return 0
+	}
+
+	if value, ok := object[entity+"_id"].(float64); ok {
+		return int(value)
+	}
+
+	return 0
+}
+
+// auditKeys are the fields that tell which row of the entity a record is for when the
+// entity id alone does not, they are kept even when they did not change.
+var auditKeys = map[string][]string{
+	"stock": {"product_id", "variant_id"},
+}
+
+// auditDiff keeps the top level fields that differ between the two states and the key fields.
+func auditDiff(before, after interface{}, keys ...string) ([]byte, []byte) {
+	var (
+		beforeFields = auditFields(before)
+		afterFields  = auditFields(after)
+		keep         = map[string]bool{}
+	)
+
+	for _, key := range keys {
+		keep[key] = true
+	}
+
+	for field, value := range beforeFields {
+		if keep[field] {
+			continue
+		}
+
+		if other, ok := afterFields[field]; ok && reflect.DeepEqual(value, other) {
+			delete(beforeFields, field)
+			delete(afterFields, field)
+		}
+	}
+
+	return auditMarshal(beforeFields), auditMarshal(afterFields)
+}
+
+func auditFields(state interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	if state == nil {
+		return fields
+	}
+
+	data, ok := state.(json.RawMessage)
+	if !ok {
+		var err error
+		data, err = json.Marshal(state)
+		if err != nil {
+			return fields
+		}
+	}
+
+	_ = json.Unmarshal(data, &fields)
+
+	return fields
+}
+
+func auditMarshal(fields map[string]interface{}) []byte {
+	if len(fields) <= 0 {
+		return nil
+	}
+
+	data, _ := json.Marshal(fields)
+	return data
+}
+
+// auditStockKey is the stock row a request is for, from the product_id or variant_id query
+// or the fields of the body.
+func auditStockKey(c *gin.Context, payload []byte) (int, int) {
+	var (
+		productId, _ = strconv.Atoi(c.Query("product_id"))
+		variantId, _ = strconv.Atoi(c.Query("variant_id"))
+	)
+
+	if productId <= 0 && variantId <= 0 {
+		productId = auditEntityId("product", payload)
+		variantId = auditEntityId("variant", payload)
+	}
+
+	return productId, variantId
+}
+
+func (h *Handler) auditLoader(entity string, c *gin.Context, payload []byte) auditLoader {
+	switch entity {
+	case "category":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Category().GetByID(ctx, &models.CategoryPrimaryKey{CategoryId: id})
+		}
+	case "brand":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Brand().GetByID(ctx, &models.BrandPrimaryKey{BrandId: id})
+		}
+	case "product":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Product().GetByID(ctx, &models.ProductPrimaryKey{ProductId: id})
+		}
+	case "stock":
+		// a stock row is the variant in the store, the store alone would snapshot all its stock
+		productId, variantId := auditStockKey(c, payload)
+		if productId <= 0 && variantId <= 0 {
+			return nil
+		}
+
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Stock().GetItem(ctx, &models.StockPrimaryKey{StoreId: id, ProductId: productId, VariantId: variantId})
+		}
+	case "store":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Store().GetByID(ctx, &models.StorePrimaryKey{StoreId: id})
+		}
+	case "customer":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Customer().GetByID(ctx, &models.CustomerPrimaryKey{CustomerId: id})
+		}
+	case "staff":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Staff().GetByID(ctx, &models.StaffPrimaryKey{StaffId: id})
+		}
+	case "order", "order_item":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Order().GetByID(ctx, &models.OrderPrimaryKey{OrderId: id})
+		}
+	case "code":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Code().GetByID(ctx, &models.CodePrimaryKey{Code_Id: id})
+		}
+	case "supplier":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Supplier().GetByID(ctx, &models.SupplierPrimaryKey{SupplierId: id})
+		}
+	case "purchase_order":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.PurchaseOrder().GetByID(ctx, &models.PurchaseOrderPrimaryKey{PurchaseOrderId: id})
+		}
+	case "return":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Return().GetByID(ctx, &models.ReturnPrimaryKey{ReturnId: id})
+		}
+	case "payment":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.Payment().GetByID(ctx, &models.PaymentPrimaryKey{PaymentId: id})
+		}
+	case "gift_card":
+		return func(ctx context.Context, id int) (interface{}, error) {
+			return h.storages.GiftCard().GetByID(ctx, &models.GiftCardPrimaryKey{GiftCardId: id})
+		}
+	}
+
+	return nil
+}
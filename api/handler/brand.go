@@ -56,6 +56,7 @@ func (h *Handler) CreateBrand(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdBrand(c *gin.Context) {
@@ -74,6 +75,8 @@ func (h *Handler) GetByIdBrand(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "get brand by id", http.StatusCreated, resp)
 }
 
@@ -88,6 +91,7 @@ func (h *Handler) GetByIdBrand(c *gin.Context) {
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
 // @Param search query string false "search"
+// @Param include_deleted query string false "include_deleted"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
@@ -105,10 +109,17 @@ func (h *Handler) GetListBrand(c *gin.Context) {
 		return
 	}
 
+	includeDeleted, err := h.getBoolQuery(c.Query("include_deleted"))
+	if err != nil {
+		h.handlerResponse(c, "get list brand", http.StatusBadRequest, "invalid include_deleted")
+		return
+	}
+
 	resp, err := h.storages.Brand().GetList(context.Background(), &models.GetListBrandRequest{
-		Offset: offset,
-		Limit:  limit,
-		Search: c.Query("search"),
+		Offset:         offset,
+		Limit:          limit,
+		Search:         c.Query("search"),
+		IncludeDeleted: includeDeleted,
 	})
 	if err != nil {
 		h.handlerResponse(c, "storage.brand.getlist", http.StatusInternalServerError, err.Error())
@@ -128,8 +139,13 @@ func (h *Handler) GetListBrand(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param brand body models.UpdateBrand true "UpdateBrandRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdateBrand(c *gin.Context) {
 
@@ -149,7 +165,13 @@ func (h *Handler) UpdateBrand(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update brand")
+	if !ok {
+		return
+	}
+
 	updateBrand.BrandId = idInt
+	updateBrand.Version = version
 
 	rowsAffected, err := h.storages.Brand().Update(context.Background(), &updateBrand)
 	if err != nil {
@@ -158,7 +180,8 @@ func (h *Handler) UpdateBrand(c *gin.Context) {
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.brand.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Brand().GetByID(context.Background(), &models.BrandPrimaryKey{BrandId: idInt})
+		h.notWritten(c, "storage.brand.update", err)
 		return
 	}
 
@@ -168,6 +191,75 @@ func (h *Handler) UpdateBrand(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update brand", http.StatusAccepted, resp)
+}
+
+// Update PATCH Brand godoc
+// @ID update_patch_brand
+// @Router /brand/{id} [PATCH]
+// @Summary Update PATCH Brand
+// @Description Update Brand fields by a JSON Merge Patch, null clears an optional field
+// @Tags Brand
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param brand body object true "JSON Merge Patch (RFC 7396)"
+// @Param If-Match header string true "ETag of the entity as last read"
+// @Success 202 {object} Response{data=models.Brand} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdatePatchBrand(c *gin.Context) {
+
+	var obj models.PatchRequest
+
+	id := c.Param("id")
+
+	err := c.ShouldBindJSON(&obj.Fields)
+	if err != nil {
+		h.handlerResponse(c, "update brand", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.brand.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "update brand")
+	if !ok {
+		return
+	}
+
+	obj.ID = idInt
+	obj.Version = version
+
+	rowsAffected, err := h.storages.Brand().UpdatePatch(context.Background(), &obj)
+	if err != nil {
+		h.handlerResponse(c, "storage.brand.update", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.Brand().GetByID(context.Background(), &models.BrandPrimaryKey{BrandId: idInt})
+		h.notWritten(c, "storage.brand.update", err)
+		return
+	}
+
+	resp, err := h.storages.Brand().GetByID(context.Background(), &models.BrandPrimaryKey{BrandId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.brand.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update brand", http.StatusAccepted, resp)
 }
 
@@ -175,14 +267,18 @@ func (h *Handler) UpdateBrand(c *gin.Context) {
 // @ID delete_brand
 // @Router /brand/{id} [DELETE]
 // @Summary Delete Brand
-// @Description Delete Brand
+// @Description Soft delete the brand, it is hidden from lists and can be restored
 // @Tags Brand
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
 // @Param brand body models.BrandPrimaryKey true "DeleteBrandRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 204 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) DeleteBrand(c *gin.Context) {
 
@@ -194,15 +290,61 @@ func (h *Handler) DeleteBrand(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, err := h.storages.Brand().Delete(context.Background(), &models.BrandPrimaryKey{BrandId: idInt})
+	version, ok := h.getIfMatch(c, "delete brand")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.Brand().Delete(context.Background(), &models.BrandPrimaryKey{BrandId: idInt, Version: version})
 	if err != nil {
 		h.handlerResponse(c, "storage.brand.delete", http.StatusInternalServerError, err.Error())
 		return
 	}
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.brand.delete", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Brand().GetByID(context.Background(), &models.BrandPrimaryKey{BrandId: idInt})
+		h.notWritten(c, "storage.brand.delete", err)
 		return
 	}
 
 	h.handlerResponse(c, "delete brand", http.StatusNoContent, nil)
 }
+
+// Restore Brand godoc
+// @ID restore_brand
+// @Router /brand/{id}/restore [POST]
+// @Summary Restore Brand
+// @Description Bring back a soft deleted brand
+// @Tags Brand
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Brand} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreBrand(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.brand.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	rowsAffected, err := h.storages.Brand().Restore(context.Background(), &models.BrandPrimaryKey{BrandId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.brand.restore", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "storage.brand.restore", http.StatusBadRequest, "now rows affected")
+		return
+	}
+
+	resp, err := h.storages.Brand().GetByID(context.Background(), &models.BrandPrimaryKey{BrandId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.brand.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "restore brand", http.StatusOK, resp)
+}
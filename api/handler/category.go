@@ -56,6 +56,7 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdCategory(c *gin.Context) {
@@ -74,6 +75,8 @@ func (h *Handler) GetByIdCategory(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "get category by id", http.StatusCreated, resp)
 }
 
@@ -88,6 +91,7 @@ func (h *Handler) GetByIdCategory(c *gin.Context) {
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
 // @Param search query string false "search"
+// @Param include_deleted query string false "include_deleted"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
@@ -105,10 +109,17 @@ func (h *Handler) GetListCategory(c *gin.Context) {
 		return
 	}
 
+	includeDeleted, err := h.getBoolQuery(c.Query("include_deleted"))
+	if err != nil {
+		h.handlerResponse(c, "get list category", http.StatusBadRequest, "invalid include_deleted")
+		return
+	}
+
 	resp, err := h.storages.Category().GetList(context.Background(), &models.GetListCategoryRequest{
-		Offset: offset,
-		Limit:  limit,
-		Search: c.Query("search"),
+		Offset:         offset,
+		Limit:          limit,
+		Search:         c.Query("search"),
+		IncludeDeleted: includeDeleted,
 	})
 	if err != nil {
 		h.handlerResponse(c, "storage.category.getlist", http.StatusInternalServerError, err.Error())
@@ -128,8 +139,13 @@ func (h *Handler) GetListCategory(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param category body models.UpdateCategory true "UpdateCategoryRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdateCategory(c *gin.Context) {
 
@@ -149,7 +165,13 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update category")
+	if !ok {
+		return
+	}
+
 	updateCategory.CategoryId = idInt
+	updateCategory.Version = version
 
 	rowsAffected, err := h.storages.Category().Update(context.Background(), &updateCategory)
 	if err != nil {
@@ -158,7 +180,8 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.category.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Category().GetByID(context.Background(), &models.CategoryPrimaryKey{CategoryId: idInt})
+		h.notWritten(c, "storage.category.update", err)
 		return
 	}
 
@@ -168,6 +191,75 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update category", http.StatusAccepted, resp)
+}
+
+// Update PATCH Category godoc
+// @ID update_patch_category
+// @Router /category/{id} [PATCH]
+// @Summary Update PATCH Category
+// @Description Update Category fields by a JSON Merge Patch, null clears an optional field
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param category body object true "JSON Merge Patch (RFC 7396)"
+// @Param If-Match header string true "ETag of the entity as last read"
+// @Success 202 {object} Response{data=models.Category} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdatePatchCategory(c *gin.Context) {
+
+	var obj models.PatchRequest
+
+	id := c.Param("id")
+
+	err := c.ShouldBindJSON(&obj.Fields)
+	if err != nil {
+		h.handlerResponse(c, "update category", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.category.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "update category")
+	if !ok {
+		return
+	}
+
+	obj.ID = idInt
+	obj.Version = version
+
+	rowsAffected, err := h.storages.Category().UpdatePatch(context.Background(), &obj)
+	if err != nil {
+		h.handlerResponse(c, "storage.category.update", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.Category().GetByID(context.Background(), &models.CategoryPrimaryKey{CategoryId: idInt})
+		h.notWritten(c, "storage.category.update", err)
+		return
+	}
+
+	resp, err := h.storages.Category().GetByID(context.Background(), &models.CategoryPrimaryKey{CategoryId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.category.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update category", http.StatusAccepted, resp)
 }
 
@@ -175,14 +267,18 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 // @ID delete_category
 // @Router /category/{id} [DELETE]
 // @Summary Delete Category
-// @Description Delete Category
+// @Description Soft delete the category, it is hidden from lists and can be restored
 // @Tags Category
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
 // @Param category body models.CategoryPrimaryKey true "DeleteCategoryRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 204 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) DeleteCategory(c *gin.Context) {
 
@@ -194,15 +290,61 @@ func (h *Handler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, err := h.storages.Category().Delete(context.Background(), &models.CategoryPrimaryKey{CategoryId: idInt})
+	version, ok := h.getIfMatch(c, "delete category")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.Category().Delete(context.Background(), &models.CategoryPrimaryKey{CategoryId: idInt, Version: version})
 	if err != nil {
 		h.handlerResponse(c, "storage.category.delete", http.StatusInternalServerError, err.Error())
 		return
 	}
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.category.delete", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Category().GetByID(context.Background(), &models.CategoryPrimaryKey{CategoryId: idInt})
+		h.notWritten(c, "storage.category.delete", err)
 		return
 	}
 
 	h.handlerResponse(c, "delete category", http.StatusNoContent, nil)
 }
+
+// Restore Category godoc
+// @ID restore_category
+// @Router /category/{id}/restore [POST]
+// @Summary Restore Category
+// @Description Bring back a soft deleted category
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Category} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreCategory(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.category.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	rowsAffected, err := h.storages.Category().Restore(context.Background(), &models.CategoryPrimaryKey{CategoryId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.category.restore", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "storage.category.restore", http.StatusBadRequest, "now rows affected")
+		return
+	}
+
+	resp, err := h.storages.Category().GetByID(context.Background(), &models.CategoryPrimaryKey{CategoryId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.category.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "restore category", http.StatusOK, resp)
+}
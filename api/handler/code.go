@@ -56,6 +56,7 @@ func (h *Handler) CreateCode(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdCode(c *gin.Context) {
@@ -74,6 +75,8 @@ func (h *Handler) GetByIdCode(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "get store by id", http.StatusCreated, resp)
 }
 
@@ -128,8 +131,13 @@ func (h *Handler) GetListCode(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param store body models.UpdateCode true "UpdateCodeRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdateCode(c *gin.Context) {
 
@@ -149,7 +157,13 @@ func (h *Handler) UpdateCode(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update code")
+	if !ok {
+		return
+	}
+
 	updateCode.Code_Id = idInt
+	updateCode.Version = version
 
 	rowsAffected, err := h.storages.Code().Update(context.Background(), &updateCode)
 	if err != nil {
@@ -158,7 +172,8 @@ func (h *Handler) UpdateCode(c *gin.Context) {
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.code.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Code().GetByID(context.Background(), &models.CodePrimaryKey{Code_Id: idInt})
+		h.notWritten(c, "storage.code.update", err)
 		return
 	}
 
@@ -168,6 +183,75 @@ func (h *Handler) UpdateCode(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update code", http.StatusAccepted, resp)
+}
+
+// Update PATCH Code godoc
+// @ID update_patch_code
+// @Router /code/{id} [PATCH]
+// @Summary Update PATCH Code
+// @Description Update Code fields by a JSON Merge Patch, null clears an optional field
+// @Tags Code
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param code body object true "JSON Merge Patch (RFC 7396)"
+// @Param If-Match header string true "ETag of the entity as last read"
+// @Success 202 {object} Response{data=models.Code} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdatePatchCode(c *gin.Context) {
+
+	var obj models.PatchRequest
+
+	id := c.Param("id")
+
+	err := c.ShouldBindJSON(&obj.Fields)
+	if err != nil {
+		h.handlerResponse(c, "update code", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.code.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "update code")
+	if !ok {
+		return
+	}
+
+	obj.ID = idInt
+	obj.Version = version
+
+	rowsAffected, err := h.storages.Code().UpdatePatch(context.Background(), &obj)
+	if err != nil {
+		h.handlerResponse(c, "storage.code.update", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.Code().GetByID(context.Background(), &models.CodePrimaryKey{Code_Id: idInt})
+		h.notWritten(c, "storage.code.update", err)
+		return
+	}
+
+	resp, err := h.storages.Code().GetByID(context.Background(), &models.CodePrimaryKey{Code_Id: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.code.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update code", http.StatusAccepted, resp)
 }
 
@@ -181,8 +265,12 @@ func (h *Handler) UpdateCode(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param store body models.CodePrimaryKey true "DeleteCodeRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 204 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) DeleteCode(c *gin.Context) {
 
@@ -194,13 +282,19 @@ func (h *Handler) DeleteCode(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, err := h.storages.Code().Delete(context.Background(), &models.CodePrimaryKey{Code_Id: idInt})
+	version, ok := h.getIfMatch(c, "delete code")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.Code().Delete(context.Background(), &models.CodePrimaryKey{Code_Id: idInt, Version: version})
 	if err != nil {
 		h.handlerResponse(c, "storage.code.delete", http.StatusInternalServerError, err.Error())
 		return
 	}
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.code.delete", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Code().GetByID(context.Background(), &models.CodePrimaryKey{Code_Id: idInt})
+		h.notWritten(c, "storage.code.delete", err)
 		return
 	}
 
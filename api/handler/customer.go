@@ -56,6 +56,7 @@ func (h *Handler) CreateCustomer(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdCustomer(c *gin.Context) {
@@ -74,6 +75,8 @@ func (h *Handler) GetByIdCustomer(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "get customer by id", http.StatusCreated, resp)
 }
 
@@ -88,6 +91,7 @@ func (h *Handler) GetByIdCustomer(c *gin.Context) {
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
 // @Param search query string false "search"
+// @Param include_deleted query string false "include_deleted"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
@@ -105,10 +109,17 @@ func (h *Handler) GetListCustomer(c *gin.Context) {
 		return
 	}
 
+	includeDeleted, err := h.getBoolQuery(c.Query("include_deleted"))
+	if err != nil {
+		h.handlerResponse(c, "get list customer", http.StatusBadRequest, "invalid include_deleted")
+		return
+	}
+
 	resp, err := h.storages.Customer().GetList(context.Background(), &models.GetListCustomerRequest{
-		Offset: offset,
-		Limit:  limit,
-		Search: c.Query("search"),
+		Offset:         offset,
+		Limit:          limit,
+		Search:         c.Query("search"),
+		IncludeDeleted: includeDeleted,
 	})
 	if err != nil {
 		h.handlerResponse(c, "storage.customer.getlist", http.StatusInternalServerError, err.Error())
@@ -128,8 +139,13 @@ func (h *Handler) GetListCustomer(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param customer body models.UpdateCustomer true "UpdateCustomerRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdateCustomer(c *gin.Context) {
 
@@ -149,7 +165,13 @@ func (h *Handler) UpdateCustomer(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update customer")
+	if !ok {
+		return
+	}
+
 	updateCustomer.CustomerId = idInt
+	updateCustomer.Version = version
 
 	rowsAffected, err := h.storages.Customer().UpdatePut(context.Background(), &updateCustomer)
 	if err != nil {
@@ -158,7 +180,8 @@ func (h *Handler) UpdateCustomer(c *gin.Context) {
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.customer.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Customer().GetByID(context.Background(), &models.CustomerPrimaryKey{CustomerId: idInt})
+		h.notWritten(c, "storage.customer.update", err)
 		return
 	}
 
@@ -168,6 +191,8 @@ func (h *Handler) UpdateCustomer(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update customer", http.StatusAccepted, resp)
 }
 
@@ -180,9 +205,14 @@ func (h *Handler) UpdateCustomer(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
-// @Param customer body models.PatchRequest true "UpdatePatchRequest"
+// @Param customer body object true "JSON Merge Patch (RFC 7396)"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdatePatchCustomer(c *gin.Context) {
 
@@ -190,7 +220,7 @@ func (h *Handler) UpdatePatchCustomer(c *gin.Context) {
 
 	id := c.Param("id")
 
-	err := c.ShouldBindJSON(&obj)
+	err := c.ShouldBindJSON(&obj.Fields)
 	if err != nil {
 		h.handlerResponse(c, "update customer", http.StatusBadRequest, err.Error())
 		return
@@ -202,16 +232,23 @@ func (h *Handler) UpdatePatchCustomer(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update customer")
+	if !ok {
+		return
+	}
+
 	obj.ID = idInt
+	obj.Version = version
 
 	rowsAffected, err := h.storages.Customer().UpdatePatch(context.Background(), &obj)
 	if err != nil {
-		h.handlerResponse(c, "storage.customer.update", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.customer.update", http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.customer.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Customer().GetByID(context.Background(), &models.CustomerPrimaryKey{CustomerId: idInt})
+		h.notWritten(c, "storage.customer.update", err)
 		return
 	}
 
@@ -221,6 +258,8 @@ func (h *Handler) UpdatePatchCustomer(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update customer", http.StatusAccepted, resp)
 }
 
@@ -228,14 +267,18 @@ func (h *Handler) UpdatePatchCustomer(c *gin.Context) {
 // @ID delete_customer
 // @Router /customer/{id} [DELETE]
 // @Summary Delete Customer
-// @Description Delete Customer
+// @Description Soft delete the customer, it is hidden from lists and can be restored, orders are kept
 // @Tags Customer
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
 // @Param customer body models.CustomerPrimaryKey true "DeleteCustomerRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 204 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) DeleteCustomer(c *gin.Context) {
 
@@ -247,15 +290,365 @@ func (h *Handler) DeleteCustomer(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, err := h.storages.Customer().Delete(context.Background(), &models.CustomerPrimaryKey{CustomerId: idInt})
+	version, ok := h.getIfMatch(c, "delete customer")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.Customer().Delete(context.Background(), &models.CustomerPrimaryKey{CustomerId: idInt, Version: version})
 	if err != nil {
 		h.handlerResponse(c, "storage.customer.delete", http.StatusInternalServerError, err.Error())
 		return
 	}
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.customer.delete", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Customer().GetByID(context.Background(), &models.CustomerPrimaryKey{CustomerId: idInt})
+		h.notWritten(c, "storage.customer.delete", err)
 		return
 	}
 
 	h.handlerResponse(c, "delete customer", http.StatusNoContent, nil)
 }
+
+// Get Customer Credit godoc
+// @ID get_customer_credit
+// @Router /customer/{id}/credit [GET]
+// @Summary Get Customer Credit
+// @Description Store credit balance of the customer with its ledger
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Success 200 {object} Response{data=models.CustomerCredit} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetCustomerCredit(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get customer credit", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get customer credit", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	resp, err := h.storages.Customer().Credit(context.Background(), &models.GetCustomerCreditRequest{
+		CustomerId: idInt,
+		Offset:     offset,
+		Limit:      limit,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.credit", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get customer credit", http.StatusOK, resp)
+}
+
+// Get Customer Orders godoc
+// @ID get_customer_orders
+// @Router /customer/{id}/orders [GET]
+// @Summary Get Customer Orders
+// @Description Purchase history of the customer, newest first, with items and totals
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param order_status query string false "order_status"
+// @Success 200 {object} Response{data=models.GetListCustomerOrderResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetCustomerOrders(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get customer orders", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get customer orders", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	status, err := h.getIntQuery(c.Query("order_status"))
+	if err != nil {
+		h.handlerResponse(c, "get customer orders", http.StatusBadRequest, "invalid order_status")
+		return
+	}
+
+	resp, err := h.storages.Customer().Orders(context.Background(), &models.GetListCustomerOrderRequest{
+		CustomerId:  idInt,
+		Offset:      offset,
+		Limit:       limit,
+		OrderStatus: int16(status),
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.orders", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get customer orders", http.StatusOK, resp)
+}
+
+// Get Customer Summary godoc
+// @ID get_customer_summary
+// @Router /customer/{id}/summary [GET]
+// @Summary Get Customer Summary
+// @Description First and last purchase, order count, lifetime spend, average order value, favorite brand and category of the customer
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param currency query string false "reporting currency, the configured one by default"
+// @Success 200 {object} Response{data=models.CustomerSummary} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetCustomerSummary(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	currency := c.Query("currency")
+	if len(currency) <= 0 {
+		currency = h.cfg.ReportingCurrency
+	}
+
+	resp, err := h.storages.Customer().Summary(context.Background(), &models.GetCustomerSummaryRequest{
+		CustomerId: idInt,
+		Currency:   currency,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.summary", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get customer summary", http.StatusOK, resp)
+}
+
+// Get List Duplicate Customer godoc
+// @ID get_list_duplicate_customer
+// @Router /customer/duplicates [GET]
+// @Summary Get List Duplicate Customer
+// @Description Groups of customers matching by normalized email, normalized phone or zip code with a similar name
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Success 200 {object} Response{data=models.GetListDuplicateCustomerResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListDuplicateCustomer(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get list duplicate customer", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get list duplicate customer", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	resp, err := h.storages.Customer().Duplicates(context.Background(), &models.GetListDuplicateCustomerRequest{
+		Offset: offset,
+		Limit:  limit,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.duplicates", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list duplicate customer response", http.StatusOK, resp)
+}
+
+// Merge Customer godoc
+// @ID merge_customer
+// @Router /customer/merge [POST]
+// @Summary Merge Customer
+// @Description Move orders, returns, addresses, gift cards, store credit and loyalty points of the duplicates to the survivor and delete the duplicates.
+// @Description Credit and points are added to the survivor as one merge entry, the survivor can not be deleted or anonymized
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param merge body models.MergeCustomer true "MergeCustomerRequest"
+// @Success 200 {object} Response{data=models.Customer} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) MergeCustomer(c *gin.Context) {
+
+	var mergeCustomer models.MergeCustomer
+
+	err := c.ShouldBindJSON(&mergeCustomer)
+	if err != nil {
+		h.handlerResponse(c, "merge customer", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.storages.Customer().Merge(context.Background(), &mergeCustomer)
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.merge", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Customer().GetByID(context.Background(), &models.CustomerPrimaryKey{CustomerId: mergeCustomer.SurvivorId})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "merge customer", http.StatusOK, resp)
+}
+
+// Export Customer godoc
+// @ID export_customer
+// @Router /customer/{id}/export [GET]
+// @Summary Export Customer
+// @Description Everything held on the customer (profile, addresses, orders with items, payments, gift cards, store credit and loyalty) as one JSON bundle, the export is logged
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param staff_id query string false "staff_id"
+// @Param reason query string false "reason"
+// @Success 200 {object} Response{data=models.CustomerExport} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) ExportCustomer(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	staffId, err := h.getIntQuery(c.Query("staff_id"))
+	if err != nil {
+		h.handlerResponse(c, "export customer", http.StatusBadRequest, "invalid staff_id")
+		return
+	}
+
+	resp, err := h.storages.Customer().Export(context.Background(), &models.CustomerPrivacyRequest{
+		CustomerId: idInt,
+		StaffId:    staffId,
+		Reason:     c.Query("reason"),
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.export", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "export customer", http.StatusOK, resp)
+}
+
+// Anonymize Customer godoc
+// @ID anonymize_customer
+// @Router /customer/{id}/anonymize [POST]
+// @Summary Anonymize Customer
+// @Description Scrub the personal data of the customer while keeping orders, payments and ledgers for accounting, the action is logged
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param anonymize body models.CustomerPrivacyRequest true "AnonymizeCustomerRequest"
+// @Success 200 {object} Response{data=models.Customer} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) AnonymizeCustomer(c *gin.Context) {
+
+	var anonymizeCustomer models.CustomerPrivacyRequest
+
+	err := c.ShouldBindJSON(&anonymizeCustomer)
+	if err != nil {
+		h.handlerResponse(c, "anonymize customer", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	anonymizeCustomer.CustomerId = idInt
+
+	err = h.storages.Customer().Anonymize(context.Background(), &anonymizeCustomer)
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.anonymize", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Customer().GetByID(context.Background(), &models.CustomerPrimaryKey{CustomerId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "anonymize customer", http.StatusOK, resp)
+}
+
+// Restore Customer godoc
+// @ID restore_customer
+// @Router /customer/{id}/restore [POST]
+// @Summary Restore Customer
+// @Description Bring back a soft deleted customer
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Customer} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreCustomer(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	rowsAffected, err := h.storages.Customer().Restore(context.Background(), &models.CustomerPrimaryKey{CustomerId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.restore", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "storage.customer.restore", http.StatusBadRequest, "now rows affected")
+		return
+	}
+
+	resp, err := h.storages.Customer().GetByID(context.Background(), &models.CustomerPrimaryKey{CustomerId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "restore customer", http.StatusOK, resp)
+}
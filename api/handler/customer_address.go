@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Customer Address godoc
+// @ID create_customer_address
+// @Router /customer/{id}/addresses [POST]
+// @Summary Create Customer Address
+// @Description Add a billing or shipping address to the customer, a new default replaces the previous default of the same type
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param address body models.CreateCustomerAddress true "CreateCustomerAddressRequest"
+// @Success 201 {object} Response{data=models.CustomerAddress} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateCustomerAddress(c *gin.Context) {
+
+	var createAddress models.CreateCustomerAddress
+
+	err := c.ShouldBindJSON(&createAddress) // parse req body to given type struct
+	if err != nil {
+		h.handlerResponse(c, "create customer address", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	createAddress.CustomerId = idInt
+
+	if !validAddressType(createAddress.AddressType) {
+		h.handlerResponse(c, "create customer address", http.StatusBadRequest, "invalid address_type")
+		return
+	}
+
+	id, err := h.storages.CustomerAddress().Create(context.Background(), &createAddress)
+	if err != nil {
+		h.handlerResponse(c, "storage.customer_address.create", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.CustomerAddress().GetByID(context.Background(), &models.CustomerAddressPrimaryKey{CustomerId: idInt, AddressId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer_address.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create customer address", http.StatusCreated, resp)
+}
+
+// Get By ID Customer Address godoc
+// @ID get_by_id_customer_address
+// @Router /customer/{id}/addresses/{address_id} [GET]
+// @Summary Get By ID Customer Address
+// @Description Get By ID Customer Address
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param address_id path string true "address_id"
+// @Success 200 {object} Response{data=models.CustomerAddress} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdCustomerAddress(c *gin.Context) {
+
+	key, ok := h.customerAddressKey(c, "storage.customer_address.getByID")
+	if !ok {
+		return
+	}
+
+	resp, err := h.storages.CustomerAddress().GetByID(context.Background(), key)
+	if err != nil {
+		h.handlerResponse(c, "storage.customer_address.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "get customer address by id", http.StatusOK, resp)
+}
+
+// Get List Customer Address godoc
+// @ID get_list_customer_address
+// @Router /customer/{id}/addresses [GET]
+// @Summary Get List Customer Address
+// @Description Addresses of the customer, defaults first
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param address_type query string false "address_type"
+// @Success 200 {object} Response{data=models.GetListCustomerAddressResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListCustomerAddress(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	resp, err := h.storages.CustomerAddress().GetList(context.Background(), &models.GetListCustomerAddressRequest{
+		CustomerId:  idInt,
+		AddressType: c.Query("address_type"),
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.customer_address.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list customer address response", http.StatusOK, resp)
+}
+
+// Update Customer Address godoc
+// @ID update_customer_address
+// @Router /customer/{id}/addresses/{address_id} [PUT]
+// @Summary Update Customer Address
+// @Description Update Customer Address, orders already placed keep their delivery address snapshot
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param address_id path string true "address_id"
+// @Param address body models.UpdateCustomerAddress true "UpdateCustomerAddressRequest"
+// @Param If-Match header string true "ETag of the address as last read"
+// @Success 202 {object} Response{data=models.CustomerAddress} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateCustomerAddress(c *gin.Context) {
+
+	var updateAddress models.UpdateCustomerAddress
+
+	err := c.ShouldBindJSON(&updateAddress)
+	if err != nil {
+		h.handlerResponse(c, "update customer address", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, ok := h.customerAddressKey(c, "storage.customer_address.update")
+	if !ok {
+		return
+	}
+
+	updateAddress.CustomerId = key.CustomerId
+	updateAddress.AddressId = key.AddressId
+
+	if !validAddressType(updateAddress.AddressType) {
+		h.handlerResponse(c, "update customer address", http.StatusBadRequest, "invalid address_type")
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "update customer address")
+	if !ok {
+		return
+	}
+
+	updateAddress.Version = version
+
+	rowsAffected, err := h.storages.CustomerAddress().Update(context.Background(), &updateAddress)
+	if err != nil {
+		h.handlerResponse(c, "storage.customer_address.update", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.CustomerAddress().GetByID(context.Background(), key)
+		h.notWritten(c, "storage.customer_address.update", err)
+		return
+	}
+
+	resp, err := h.storages.CustomerAddress().GetByID(context.Background(), key)
+	if err != nil {
+		h.handlerResponse(c, "storage.customer_address.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update customer address", http.StatusAccepted, resp)
+}
+
+// Delete Customer Address godoc
+// @ID delete_customer_address
+// @Router /customer/{id}/addresses/{address_id} [DELETE]
+// @Summary Delete Customer Address
+// @Description Delete Customer Address
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param address_id path string true "address_id"
+// @Param If-Match header string true "ETag of the address as last read"
+// @Success 204 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeleteCustomerAddress(c *gin.Context) {
+
+	key, ok := h.customerAddressKey(c, "storage.customer_address.delete")
+	if !ok {
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "delete customer address")
+	if !ok {
+		return
+	}
+
+	key.Version = version
+
+	rowsAffected, err := h.storages.CustomerAddress().Delete(context.Background(), key)
+	if err != nil {
+		h.handlerResponse(c, "storage.customer_address.delete", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.CustomerAddress().GetByID(context.Background(), key)
+		h.notWritten(c, "storage.customer_address.delete", err)
+		return
+	}
+
+	h.handlerResponse(c, "delete customer address", http.StatusNoContent, nil)
+}
+
+func (h *Handler) customerAddressKey(c *gin.Context, path string) (*models.CustomerAddressPrimaryKey, bool) {
+
+	customerId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, path, http.StatusBadRequest, "id incorrect")
+		return nil, false
+	}
+
+	addressId, err := strconv.Atoi(c.Param("address_id"))
+	if err != nil {
+		h.handlerResponse(c, path, http.StatusBadRequest, "address_id incorrect")
+		return nil, false
+	}
+
+	return &models.CustomerAddressPrimaryKey{CustomerId: customerId, AddressId: addressId}, true
+}
+
+func validAddressType(addressType string) bool {
+	return addressType == models.AddressTypeBilling || addressType == models.AddressTypeShipping
+}
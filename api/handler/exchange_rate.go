@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Exchange Rate godoc
+// @ID create_exchange_rate
+// @Router /exchange_rate [POST]
+// @Summary Create Exchange Rate
+// @Description Add a rate of a currency pair, one unit of the base currency buys rate units of the quote currency from valid_from on. An empty valid_from is now, the latest rate in effect is used
+// @Tags Exchange Rate
+// @Accept json
+// @Produce json
+// @Param exchange_rate body models.CreateExchangeRate true "CreateExchangeRateRequest"
+// @Success 201 {object} Response{data=models.ExchangeRate} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateExchangeRate(c *gin.Context) {
+
+	var createExchangeRate models.CreateExchangeRate
+
+	err := c.ShouldBindJSON(&createExchangeRate)
+	if err != nil {
+		h.handlerResponse(c, "create exchange rate", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.storages.ExchangeRate().Create(context.Background(), &createExchangeRate)
+	if err != nil {
+		h.handlerResponse(c, "storage.exchange_rate.create", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.ExchangeRate().GetByID(context.Background(), &models.ExchangeRatePrimaryKey{ExchangeRateId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.exchange_rate.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create exchange rate", http.StatusCreated, resp)
+}
+
+// Get List Exchange Rate godoc
+// @ID get_list_exchange_rate
+// @Router /exchange_rate [GET]
+// @Summary Get List Exchange Rate
+// @Description Get List Exchange Rate, the newest first per currency pair
+// @Tags Exchange Rate
+// @Accept json
+// @Produce json
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param currency query string false "either currency of the pair"
+// @Success 200 {object} Response{data=models.GetListExchangeRateResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListExchangeRate(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get list exchange rate", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get list exchange rate", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	resp, err := h.storages.ExchangeRate().GetList(context.Background(), &models.GetListExchangeRateRequest{
+		Currency: c.Query("currency"),
+		Offset:   offset,
+		Limit:    limit,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.exchange_rate.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list exchange rate response", http.StatusOK, resp)
+}
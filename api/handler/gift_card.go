@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Gift Card godoc
+// @ID create_gift_card
+// @Router /gift_card [POST]
+// @Summary Create Gift Card
+// @Description Issue a gift card with a unique code, the balance stays in the currency of the amount
+// @Tags Gift Card
+// @Accept json
+// @Produce json
+// @Param gift_card body models.CreateGiftCard true "CreateGiftCardRequest"
+// @Success 201 {object} Response{data=models.GiftCard} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateGiftCard(c *gin.Context) {
+
+	var createGiftCard models.CreateGiftCard
+
+	err := c.ShouldBindJSON(&createGiftCard) // parse req body to given type struct
+	if err != nil {
+		h.handlerResponse(c, "create gift card", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.storages.GiftCard().Create(context.Background(), &createGiftCard)
+	if err != nil {
+		h.handlerResponse(c, "storage.gift_card.create", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.GiftCard().GetByID(context.Background(), &models.GiftCardPrimaryKey{GiftCardId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.gift_card.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create gift card", http.StatusCreated, resp)
+}
+
+// Get By ID Gift Card godoc
+// @ID get_by_id_gift_card
+// @Router /gift_card/{id} [GET]
+// @Summary Get By ID Gift Card
+// @Description Get By ID Gift Card with its balance ledger
+// @Tags Gift Card
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.GiftCard} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdGiftCard(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.gift_card.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	resp, err := h.storages.GiftCard().GetByID(context.Background(), &models.GiftCardPrimaryKey{GiftCardId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.gift_card.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "get gift card by id", http.StatusOK, resp)
+}
+
+// Get List Gift Card godoc
+// @ID get_list_gift_card
+// @Router /gift_card [GET]
+// @Summary Get List Gift Card
+// @Description Get List Gift Card
+// @Tags Gift Card
+// @Accept json
+// @Produce json
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param code query string false "code"
+// @Param customer_id query string false "customer_id"
+// @Param status query string false "status"
+// @Success 200 {object} Response{data=models.GetListGiftCardResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListGiftCard(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get list gift card", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get list gift card", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	customerId, err := h.getIntQuery(c.Query("customer_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list gift card", http.StatusBadRequest, "invalid customer_id")
+		return
+	}
+
+	resp, err := h.storages.GiftCard().GetList(context.Background(), &models.GetListGiftCardRequest{
+		Offset:     offset,
+		Limit:      limit,
+		Code:       c.Query("code"),
+		CustomerId: customerId,
+		Status:     c.Query("status"),
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.gift_card.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list gift card response", http.StatusOK, resp)
+}
+
+// Update Gift Card Status godoc
+// @ID update_gift_card_status
+// @Router /gift_card/{id}/status [PUT]
+// @Summary Update Gift Card Status
+// @Description Enable or disable a gift card (active, disabled)
+// @Tags Gift Card
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param status body models.UpdateGiftCardStatus true "UpdateGiftCardStatusRequest"
+// @Param If-Match header string true "ETag of the gift card as last read"
+// @Success 202 {object} Response{data=models.GiftCard} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateGiftCardStatus(c *gin.Context) {
+
+	var updateStatus models.UpdateGiftCardStatus
+
+	err := c.ShouldBindJSON(&updateStatus)
+	if err != nil {
+		h.handlerResponse(c, "update gift card status", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.gift_card.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	updateStatus.GiftCardId = idInt
+
+	version, ok := h.getIfMatch(c, "update gift card status")
+	if !ok {
+		return
+	}
+
+	updateStatus.Version = version
+
+	rowsAffected, err := h.storages.GiftCard().UpdateStatus(context.Background(), &updateStatus)
+	if err != nil {
+		h.handlerResponse(c, "storage.gift_card.update_status", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.GiftCard().GetByID(context.Background(), &models.GiftCardPrimaryKey{GiftCardId: idInt})
+		h.notWritten(c, "storage.gift_card.update_status", err)
+		return
+	}
+
+	resp, err := h.storages.GiftCard().GetByID(context.Background(), &models.GiftCardPrimaryKey{GiftCardId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.gift_card.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update gift card status", http.StatusAccepted, resp)
+}
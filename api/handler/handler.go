@@ -3,16 +3,22 @@ package handler
 import (
 	"app/config"
 	"app/pkg/logger"
+	"app/pkg/payment"
 	"app/storage"
+	"errors"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v4"
 )
 
 type Handler struct {
 	cfg      *config.Config
 	logger   logger.LoggerI
 	storages storage.StorageI
+	payment  payment.Provider
 }
 
 type Response struct {
@@ -21,11 +27,12 @@ type Response struct {
 	Data        interface{}
 }
 
-func NewHandler(cfg *config.Config, store storage.StorageI, logger logger.LoggerI) *Handler {
+func NewHandler(cfg *config.Config, store storage.StorageI, logger logger.LoggerI, provider payment.Provider) *Handler {
 	return &Handler{
 		cfg:      cfg,
 		logger:   logger,
 		storages: store,
+		payment:  provider,
 	}
 }
 
@@ -62,3 +69,60 @@ func (h *Handler) getLimitQuery(limit string) (int, error) {
 
 	return strconv.Atoi(limit)
 }
+
+func (h *Handler) getIntQuery(value string) (int, error) {
+
+	if len(value) <= 0 {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+func (h *Handler) getBoolQuery(value string) (bool, error) {
+
+	if len(value) <= 0 {
+		return false, nil
+	}
+
+	return strconv.ParseBool(value)
+}
+
+// setETag sends the row version as the entity tag, writes must send it back in If-Match.
+func (h *Handler) setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// getIfMatch reads the row version the client last saw from the If-Match header. A write
+// without it could silently overwrite someone else's change, so it is refused.
+func (h *Handler) getIfMatch(c *gin.Context, path string) (int, bool) {
+
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if len(value) <= 0 {
+		h.handlerResponse(c, path, http.StatusPreconditionRequired, "If-Match header is required")
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(value, "W/"), `"`))
+	if err != nil || version <= 0 {
+		h.handlerResponse(c, path, http.StatusBadRequest, "invalid If-Match header")
+		return 0, false
+	}
+
+	return version, true
+}
+
+// notWritten answers a write that changed no row, err is of reading the row afterwards. A
+// missing row is 404, a row that is there did not have the version sent in If-Match, 412.
+func (h *Handler) notWritten(c *gin.Context, path string, err error) {
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.handlerResponse(c, path, http.StatusNotFound, "not found")
+		return
+	} else if err != nil {
+		h.handlerResponse(c, path, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, path, http.StatusPreconditionFailed, "version mismatch")
+}
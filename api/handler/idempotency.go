@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/logger"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	headerIdempotencyKey      = "Idempotency-Key"
+	headerIdempotencyReplayed = "Idempotent-Replayed"
+
+	idempotencyKeyMaxLength = 255
+)
+
+// IdempotencyMiddleware makes a POST sent with an Idempotency-Key header safe to retry.
+// The first successful (2xx) response is stored and replayed for the same key within the
+// configured TTL, reusing the key for a different request or while the first one still runs
+// is a conflict. Any other outcome, an error answer, a handler that panics or one that ends
+// without writing a response, releases the key so the client can retry.
+func (h *Handler) IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		key := strings.TrimSpace(c.GetHeader(headerIdempotencyKey))
+		if c.Request.Method != http.MethodPost || len(key) <= 0 {
+			c.Next()
+			return
+		}
+
+		if len(key) > idempotencyKeyMaxLength {
+			h.handlerResponse(c, "idempotency", http.StatusBadRequest, "Idempotency-Key is too long")
+			c.Abort()
+			return
+		}
+
+		var payload []byte
+		if c.Request.Body != nil {
+			payload, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(payload))
+		}
+
+		hash := idempotencyHash(c.Request.Method, c.Request.URL.RequestURI(), payload)
+
+		stored, err := h.storages.Idempotency().Reserve(context.Background(), &models.ReserveIdempotencyKey{
+			Key:         key,
+			RequestHash: hash,
+			TTL:         h.cfg.IdempotencyTTL,
+		})
+		if err != nil {
+			h.handlerResponse(c, "storage.idempotency.reserve", http.StatusInternalServerError, err.Error())
+			c.Abort()
+			return
+		}
+
+		if stored != nil {
+			switch {
+			case stored.RequestHash != hash:
+				h.handlerResponse(c, "idempotency", http.StatusConflict, "Idempotency-Key was already used for a different request")
+			case stored.Status <= 0:
+				h.handlerResponse(c, "idempotency", http.StatusConflict, "a request with this Idempotency-Key is still in progress")
+			default:
+				c.Header(headerIdempotencyReplayed, "true")
+				c.Data(stored.Status, "application/json; charset=utf-8", stored.Response)
+			}
+			c.Abort()
+			return
+		}
+
+		writer := &auditWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// runs on a panic too, before the recovery middleware answers it
+		completed := false
+		defer func() {
+			if !completed {
+				h.releaseIdempotencyKey(key)
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if !writer.Written() || status < http.StatusOK || status >= http.StatusMultipleChoices {
+			return
+		}
+
+		err = h.storages.Idempotency().Complete(context.Background(), &models.CompleteIdempotencyKey{
+			Key:      key,
+			Status:   status,
+			Response: writer.body.Bytes(),
+		})
+		if err != nil {
+			h.logger.Error("storage.idempotency.complete", logger.Error(err), logger.Any("idempotency_key", key))
+			return
+		}
+
+		completed = true
+	}
+}
+
+// releaseIdempotencyKey forgets a key whose request did not succeed, so it is not locked
+// as in progress until the TTL.
+func (h *Handler) releaseIdempotencyKey(key string) {
+	err := h.storages.Idempotency().Delete(context.Background(), &models.IdempotencyKeyPrimaryKey{Key: key})
+	if err != nil {
+		h.logger.Error("storage.idempotency.delete", logger.Error(err), logger.Any("idempotency_key", key))
+	}
+}
+
+// idempotencyHash fingerprints the request a key was first used for.
+func idempotencyHash(method, uri string, payload []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(method + " " + uri + "\n"))
+	sum.Write(payload)
+
+	return hex.EncodeToString(sum.Sum(nil))
+}
@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// Get List Loyalty Tier godoc
+// @ID get_list_loyalty_tier
+// @Router /loyalty/tier [GET]
+// @Summary Get List Loyalty Tier
+// @Description Loyalty tiers by the rolling 12 month spend they require, in the base currency
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response{data=[]models.LoyaltyTier} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListLoyaltyTier(c *gin.Context) {
+
+	resp, err := h.storages.Loyalty().GetListTier(context.Background())
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.getlist_tier", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list loyalty tier response", http.StatusOK, resp)
+}
+
+// Upsert Loyalty Tier godoc
+// @ID upsert_loyalty_tier
+// @Router /loyalty/tier [PUT]
+// @Summary Upsert Loyalty Tier
+// @Description Create or update a loyalty tier by its name
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param tier body models.UpsertLoyaltyTier true "UpsertLoyaltyTierRequest"
+// @Success 202 {object} Response{data=[]models.LoyaltyTier} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpsertLoyaltyTier(c *gin.Context) {
+
+	var upsertTier models.UpsertLoyaltyTier
+
+	err := c.ShouldBindJSON(&upsertTier)
+	if err != nil {
+		h.handlerResponse(c, "upsert loyalty tier", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, err = h.storages.Loyalty().UpsertTier(context.Background(), &upsertTier)
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.upsert_tier", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Loyalty().GetListTier(context.Background())
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.getlist_tier", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "upsert loyalty tier", http.StatusAccepted, resp)
+}
+
+// Get List Loyalty Multiplier godoc
+// @ID get_list_loyalty_multiplier
+// @Router /loyalty/multiplier [GET]
+// @Summary Get List Loyalty Multiplier
+// @Description Points multipliers of brands and categories
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response{data=[]models.LoyaltyMultiplier} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListLoyaltyMultiplier(c *gin.Context) {
+
+	resp, err := h.storages.Loyalty().GetListMultiplier(context.Background())
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.getlist_multiplier", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list loyalty multiplier response", http.StatusOK, resp)
+}
+
+// Upsert Loyalty Multiplier godoc
+// @ID upsert_loyalty_multiplier
+// @Router /loyalty/multiplier [PUT]
+// @Summary Upsert Loyalty Multiplier
+// @Description Set the points multiplier of a brand or a category
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param multiplier body models.UpsertLoyaltyMultiplier true "UpsertLoyaltyMultiplierRequest"
+// @Success 202 {object} Response{data=[]models.LoyaltyMultiplier} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpsertLoyaltyMultiplier(c *gin.Context) {
+
+	var upsertMultiplier models.UpsertLoyaltyMultiplier
+
+	err := c.ShouldBindJSON(&upsertMultiplier)
+	if err != nil {
+		h.handlerResponse(c, "upsert loyalty multiplier", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, err = h.storages.Loyalty().UpsertMultiplier(context.Background(), &upsertMultiplier)
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.upsert_multiplier", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Loyalty().GetListMultiplier(context.Background())
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.getlist_multiplier", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "upsert loyalty multiplier", http.StatusAccepted, resp)
+}
+
+// Delete Loyalty Multiplier godoc
+// @ID delete_loyalty_multiplier
+// @Router /loyalty/multiplier/{id} [DELETE]
+// @Summary Delete Loyalty Multiplier
+// @Description Delete Loyalty Multiplier
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 204 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeleteLoyaltyMultiplier(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.delete_multiplier", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	rowsAffected, err := h.storages.Loyalty().DeleteMultiplier(context.Background(), &models.LoyaltyMultiplierPrimaryKey{MultiplierId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.delete_multiplier", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "storage.loyalty.delete_multiplier", http.StatusBadRequest, "now rows affected")
+		return
+	}
+
+	h.handlerResponse(c, "delete loyalty multiplier", http.StatusNoContent, nil)
+}
+
+// Get Customer Loyalty godoc
+// @ID get_customer_loyalty
+// @Router /customer/{id}/loyalty [GET]
+// @Summary Get Customer Loyalty
+// @Description Points balance, tier by rolling 12 month spend and points ledger of the customer
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Success 200 {object} Response{data=models.CustomerLoyalty} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetCustomerLoyalty(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.customer.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get customer loyalty", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get customer loyalty", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	resp, err := h.storages.Loyalty().Customer(context.Background(), &models.GetCustomerLoyaltyRequest{
+		CustomerId: idInt,
+		Offset:     offset,
+		Limit:      limit,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.customer", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get customer loyalty", http.StatusOK, resp)
+}
+
+// Redeem Loyalty Points godoc
+// @ID redeem_loyalty_points
+// @Router /order/{id}/loyalty [POST]
+// @Summary Redeem Loyalty Points
+// @Description Redeem customer points as a discount on the open order, applied after the promo code.
+// @Description Points are valued in the base currency and converted to the currency of the order, the discount can not exceed what is left of the order before tax
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param redeem body models.RedeemLoyaltyPoints true "RedeemLoyaltyPointsRequest"
+// @Success 202 {object} Response{data=models.OrderBalance} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RedeemLoyaltyPoints(c *gin.Context) {
+
+	var redeem models.RedeemLoyaltyPoints
+
+	err := c.ShouldBindJSON(&redeem)
+	if err != nil {
+		h.handlerResponse(c, "redeem loyalty points", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	redeem.OrderId = idInt
+	redeem.PointValue = decimal.NewFromFloat(h.cfg.LoyaltyPointValue)
+
+	err = h.storages.Loyalty().Redeem(context.Background(), &redeem)
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.redeem", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Payment().Balance(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.balance", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "redeem loyalty points", http.StatusAccepted, resp)
+}
+
+// Release Loyalty Points godoc
+// @ID release_loyalty_points
+// @Router /order/{id}/loyalty [DELETE]
+// @Summary Release Loyalty Points
+// @Description Give the points redeemed on an open order back to the customer
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 202 {object} Response{data=models.OrderBalance} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) ReleaseLoyaltyPoints(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	err = h.storages.Loyalty().Release(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.loyalty.release", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Payment().Balance(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.balance", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "release loyalty points", http.StatusAccepted, resp)
+}
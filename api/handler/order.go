@@ -13,7 +13,7 @@ import (
 // @ID create_order
 // @Router /order [POST]
 // @Summary Create Order
-// @Description Create Order
+// @Description Create Order, delivery_address_id defaults to the customer's default shipping address and is kept as a snapshot on the order
 // @Tags Order
 // @Accept json
 // @Produce json
@@ -56,6 +56,7 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdOrder(c *gin.Context) {
@@ -74,6 +75,8 @@ func (h *Handler) GetByIdOrder(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "get order by id", http.StatusCreated, resp)
 }
 
@@ -128,8 +131,13 @@ func (h *Handler) GetListOrder(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param order body models.UpdateOrder true "UpdateOrderRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdateOrder(c *gin.Context) {
 
@@ -149,7 +157,13 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update order")
+	if !ok {
+		return
+	}
+
 	updateOrder.OrderId = idInt
+	updateOrder.Version = version
 
 	rowsAffected, err := h.storages.Order().Update(context.Background(), &updateOrder)
 	if err != nil {
@@ -158,7 +172,8 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.order.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Order().GetByID(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+		h.notWritten(c, "storage.order.update", err)
 		return
 	}
 
@@ -168,6 +183,8 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update order", http.StatusAccepted, resp)
 }
 
@@ -180,9 +197,14 @@ func (h *Handler) UpdateOrder(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
-// @Param order body models.PatchRequest true "UpdatePatchRequest"
+// @Param order body object true "JSON Merge Patch (RFC 7396)"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdatePatchOrder(c *gin.Context) {
 
@@ -190,7 +212,7 @@ func (h *Handler) UpdatePatchOrder(c *gin.Context) {
 
 	id := c.Param("id")
 
-	err := c.ShouldBindJSON(&obj)
+	err := c.ShouldBindJSON(&obj.Fields)
 	if err != nil {
 		h.handlerResponse(c, "update order", http.StatusBadRequest, err.Error())
 		return
@@ -202,16 +224,23 @@ func (h *Handler) UpdatePatchOrder(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update order")
+	if !ok {
+		return
+	}
+
 	obj.ID = idInt
+	obj.Version = version
 
 	rowsAffected, err := h.storages.Order().UpdatePatch(context.Background(), &obj)
 	if err != nil {
-		h.handlerResponse(c, "storage.order.update", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.order.update", http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.order.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Order().GetByID(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+		h.notWritten(c, "storage.order.update", err)
 		return
 	}
 
@@ -221,6 +250,8 @@ func (h *Handler) UpdatePatchOrder(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update order", http.StatusAccepted, resp)
 }
 
@@ -234,8 +265,12 @@ func (h *Handler) UpdatePatchOrder(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param order body models.OrderPrimaryKey true "DeleteOrderRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 204 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) DeleteOrder(c *gin.Context) {
 
@@ -247,28 +282,74 @@ func (h *Handler) DeleteOrder(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, err := h.storages.Order().Delete(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+	version, ok := h.getIfMatch(c, "delete order")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.Order().Delete(context.Background(), &models.OrderPrimaryKey{OrderId: idInt, Version: version})
 	if err != nil {
 		h.handlerResponse(c, "storage.order.delete", http.StatusInternalServerError, err.Error())
 		return
 	}
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.order.delete", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Order().GetByID(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+		h.notWritten(c, "storage.order.delete", err)
 		return
 	}
 
 	h.handlerResponse(c, "delete order", http.StatusNoContent, nil)
 }
 
+// Complete Order godoc
+// @ID complete_order
+// @Router /order/{id}/complete [POST]
+// @Summary Complete Order
+// @Description Complete Order, reserved stock of its items is deducted, the customer earns loyalty points and the order gets the next invoice number of its store
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Order} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CompleteOrder(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	err = h.storages.Order().Complete(context.Background(), &models.CompleteOrder{
+		OrderId:              idInt,
+		LoyaltyPointsPerUnit: h.cfg.LoyaltyPointsPerUnit,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.order.complete", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Order().GetByID(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.order.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "complete order", http.StatusOK, resp)
+}
+
 // -------------------------------------------------------------------------------------------
 // Create Order Item godoc
 // @ID create_order_item
 // @Router /order_item [POST]
 // @Summary Create Order Item
-// @Description Create Order Item
+// @Description Create Order Item, the line is priced from the catalog. A different list_price or a discount is an override which needs a reason and the X-Staff-Id of an active staff of the order's store with the discount permission.
+// @Description X-Staff-Id is trusted as sent, the API does not authenticate it. Every override is written to the audit log
 // @Tags Order
 // @Accept json
 // @Produce json
+// @Param X-Staff-Id header int false "staff giving the price override, trusted as sent"
 // @Param order_item body models.CreateOrderItem true "CreateOrderItemRequest"
 // @Success 201 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
@@ -283,6 +364,10 @@ func (h *Handler) CreateOrderItem(c *gin.Context) {
 		return
 	}
 
+	if !h.setPriceOverride(c, &createOrderItem) {
+		return
+	}
+
 	err = h.storages.Order().Check(context.Background(), &createOrderItem)
 	if err != nil {
 		h.handlerResponse(c, "Check stock", http.StatusBadRequest, err.Error())
@@ -291,7 +376,7 @@ func (h *Handler) CreateOrderItem(c *gin.Context) {
 
 	err = h.storages.Order().AddOrderItem(context.Background(), &createOrderItem)
 	if err != nil {
-		h.handlerResponse(c, "storage.order.create", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.order.create", http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -304,19 +389,170 @@ func (h *Handler) CreateOrderItem(c *gin.Context) {
 	h.handlerResponse(c, "create order", http.StatusCreated, "Order Item Added")
 }
 
+// Create Order Items godoc
+// @ID create_order_items
+// @Router /order/{id}/items:batch [POST]
+// @Summary Create Order Items
+// @Description Add all the lines to the order in one transaction, stock is checked for every line. When a line fails nothing is added and the errors of the failing lines are returned
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param X-Staff-Id header int false "staff giving the price overrides, trusted as sent"
+// @Param order_items body []models.CreateOrderItem true "CreateOrderItemsRequest"
+// @Success 201 {object} Response{data=models.Order} "Success Request"
+// @Response 400 {object} Response{data=[]models.OrderItemError} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateOrderItems(c *gin.Context) {
+
+	var createOrderItems models.CreateOrderItems
+
+	// the route is /order/:id/items:batch, gin reads ":batch" as a parameter
+	if c.Param("batch") != ":batch" {
+		h.handlerResponse(c, "create order items", http.StatusNotFound, "not found")
+		return
+	}
+
+	err := c.ShouldBindJSON(&createOrderItems.Items)
+	if err != nil {
+		h.handlerResponse(c, "create order items", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	createOrderItems.OrderId = idInt
+
+	if !h.setPriceOverride(c, createOrderItems.Items...) {
+		return
+	}
+
+	lineErrors, err := h.storages.Order().AddOrderItems(context.Background(), &createOrderItems)
+	if err != nil {
+		h.handlerResponse(c, "storage.order.add_items", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(lineErrors) > 0 {
+		h.handlerResponse(c, "storage.order.add_items", http.StatusBadRequest, lineErrors)
+		return
+	}
+
+	resp, err := h.storages.Order().GetByID(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.order.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create order items", http.StatusCreated, resp)
+}
+
+// Checkout godoc
+// @ID checkout
+// @Router /checkout [POST]
+// @Summary Checkout
+// @Description Create the order and all its items in one transaction. When a line fails no order is created and the errors of the failing lines are returned
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param X-Staff-Id header int false "staff giving the price overrides, trusted as sent"
+// @Param checkout body models.Checkout true "CheckoutRequest"
+// @Success 201 {object} Response{data=models.Order} "Success Request"
+// @Response 400 {object} Response{data=[]models.OrderItemError} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) Checkout(c *gin.Context) {
+
+	var checkout models.Checkout
+
+	err := c.ShouldBindJSON(&checkout)
+	if err != nil {
+		h.handlerResponse(c, "checkout", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if checkout.Order == nil {
+		h.handlerResponse(c, "checkout", http.StatusBadRequest, "order is required")
+		return
+	}
+
+	if !h.setPriceOverride(c, checkout.Items...) {
+		return
+	}
+
+	id, lineErrors, err := h.storages.Order().Checkout(context.Background(), &checkout)
+	if err != nil {
+		h.handlerResponse(c, "storage.order.checkout", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(lineErrors) > 0 {
+		h.handlerResponse(c, "storage.order.checkout", http.StatusBadRequest, lineErrors)
+		return
+	}
+
+	resp, err := h.storages.Order().GetByID(context.Background(), &models.OrderPrimaryKey{OrderId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.order.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "checkout", http.StatusCreated, resp)
+}
+
+// setPriceOverride gives the items the staff of the X-Staff-Id header, the max discount the
+// staff may give and the request an override is audited against. The API has no authentication,
+// the header is trusted as sent, the storage only checks the staff is active, works at the
+// order's store and may discount.
+func (h *Handler) setPriceOverride(c *gin.Context, items ...*models.CreateOrderItem) bool {
+
+	staffId := 0
+	if header := c.GetHeader(headerStaffId); header != "" {
+		var err error
+		staffId, err = strconv.Atoi(header)
+		if err != nil || staffId <= 0 {
+			h.handlerResponse(c, "price override", http.StatusBadRequest, headerStaffId+" incorrect")
+			return false
+		}
+	}
+
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		item.StaffId = staffId
+		item.MaxDiscountPercent = h.cfg.MaxDiscountPercent
+		item.Audit = &models.CreateAuditLog{
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    http.StatusCreated,
+			RequestId: c.GetString("request_id"),
+		}
+	}
+
+	return true
+}
+
 // DELETE Order Item godoc
 // @ID delete_order_item
 // @Router /order_item/{id} [DELETE]
 // @Summary Delete Order Item
-// @Description Delete Order Item
+// @Description Delete Order Item of an open order, the line of a completed or rejected order can not be deleted
 // @Tags Order
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
 // @Param item_id query string true "item_id"
 // @Param orderItem body models.OrderItemPrimaryKey true "DeleteOrderItemRequest"
+// @Param If-Match header string true "ETag of the order as last read"
 // @Success 204 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) DeleteOrderItem(c *gin.Context) {
 
@@ -335,9 +571,19 @@ func (h *Handler) DeleteOrderItem(c *gin.Context) {
 		return
 	}
 
-	err = h.storages.Order().RemoveOrderItem(context.Background(), &models.OrderItemPrimaryKey{OrderId: idInt, ItemId: idItemInt})
+	version, ok := h.getIfMatch(c, "delete order item")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.Order().RemoveOrderItem(context.Background(), &models.OrderItemPrimaryKey{OrderId: idInt, ItemId: idItemInt, Version: version})
 	if err != nil {
-		h.handlerResponse(c, "storage.order.delete", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.order.delete", http.StatusBadRequest, err.Error())
+		return
+	}
+	if rowsAffected <= 0 {
+		_, err = h.storages.Order().GetByID(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+		h.notWritten(c, "storage.order.delete", err)
 		return
 	}
 
@@ -348,13 +594,13 @@ func (h *Handler) DeleteOrderItem(c *gin.Context) {
 // @ID total_sum_order
 // @Router /order/total_sum [GET]
 // @Summary Total Sum Order
-// @Description Total Sum Order
+// @Description Total of the order as its amount prices it, with the given promo code in place of the attached one
 // @Tags Order
 // @Accept json
 // @Produce json
 // @Param order_id query string true "order_id"
 // @Param promocode_name query string false "promocode_name"
-// @Success 200 {object} Response{data=string} "Success Request"
+// @Success 200 {object} Response{data=money.Money} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) OrderTotalSum(c *gin.Context) {
@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/logger"
+	"app/pkg/payment"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Payment godoc
+// @ID create_payment
+// @Router /order/{id}/payment [POST]
+// @Summary Create Payment
+// @Description Record a tender (cash, card, bank_transfer, gift_card, store_credit) against the order, partial payments are allowed up to the balance due. For gift_card the card code goes in reference, a gift card or store credit in another currency is charged at the current exchange rate
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param payment body models.CreatePayment true "CreatePaymentRequest"
+// @Success 201 {object} Response{data=models.Payment} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreatePayment(c *gin.Context) {
+
+	var createPayment models.CreatePayment
+
+	err := c.ShouldBindJSON(&createPayment) // parse req body to given type struct
+	if err != nil {
+		h.handlerResponse(c, "create payment", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	createPayment.OrderId = idInt
+
+	if !validTender(createPayment.Tender) {
+		h.handlerResponse(c, "create payment", http.StatusBadRequest, "invalid tender")
+		return
+	}
+
+	if !createPayment.Amount.Amount.IsPositive() {
+		h.handlerResponse(c, "create payment", http.StatusBadRequest, "invalid amount")
+		return
+	}
+
+	balance, err := h.storages.Payment().Balance(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.balance", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	createPayment.Amount, err = createPayment.Amount.In(balance.BalanceDue.Currency)
+	if err != nil {
+		h.handlerResponse(c, "create payment", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if createPayment.Amount.Amount.GreaterThan(balance.BalanceDue.Amount) {
+		h.handlerResponse(c, "create payment", http.StatusBadRequest, "payment exceeds the balance due")
+		return
+	}
+
+	if createPayment.Tender == models.PaymentTenderGiftCard && len(createPayment.Reference) <= 0 {
+		h.handlerResponse(c, "create payment", http.StatusBadRequest, "gift card code is required as reference")
+		return
+	}
+
+	createPayment.Provider = createPayment.Tender
+	if !inHouseTender(createPayment.Tender) {
+		result, err := h.payment.Charge(context.Background(), &payment.Charge{
+			OrderId:   createPayment.OrderId,
+			Tender:    createPayment.Tender,
+			Amount:    createPayment.Amount,
+			Reference: createPayment.Reference,
+		})
+		if err != nil {
+			h.handlerResponse(c, "payment.provider.charge", http.StatusBadRequest, err.Error())
+			return
+		}
+
+		createPayment.Provider = result.Provider
+		createPayment.TransactionId = result.TransactionId
+	}
+
+	id, err := h.storages.Payment().Create(context.Background(), &createPayment)
+	if err != nil {
+		// the charge went through but could not be recorded, give the money back
+		if !inHouseTender(createPayment.Tender) {
+			_, refundErr := h.payment.Refund(context.Background(), &payment.Refund{
+				OrderId:       createPayment.OrderId,
+				Tender:        createPayment.Tender,
+				Amount:        createPayment.Amount,
+				TransactionId: createPayment.TransactionId,
+			})
+			if refundErr != nil {
+				h.logger.Error("payment.provider.refund", logger.Error(refundErr), logger.Any("transaction_id", createPayment.TransactionId))
+			}
+		}
+
+		h.handlerResponse(c, "storage.payment.create", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Payment().GetByID(context.Background(), &models.PaymentPrimaryKey{PaymentId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create payment", http.StatusCreated, resp)
+}
+
+// Get By ID Payment godoc
+// @ID get_by_id_payment
+// @Router /payment/{id} [GET]
+// @Summary Get By ID Payment
+// @Description Get By ID Payment
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Payment} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdPayment(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	resp, err := h.storages.Payment().GetByID(context.Background(), &models.PaymentPrimaryKey{PaymentId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get payment by id", http.StatusOK, resp)
+}
+
+// Get List Payment godoc
+// @ID get_list_payment
+// @Router /payment [GET]
+// @Summary Get List Payment
+// @Description Get List Payment
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param order_id query string false "order_id"
+// @Param tender query string false "tender"
+// @Success 200 {object} Response{data=models.GetListPaymentResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListPayment(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get list payment", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get list payment", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	orderId, err := h.getIntQuery(c.Query("order_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list payment", http.StatusBadRequest, "invalid order_id")
+		return
+	}
+
+	resp, err := h.storages.Payment().GetList(context.Background(), &models.GetListPaymentRequest{
+		Offset:  offset,
+		Limit:   limit,
+		OrderId: orderId,
+		Tender:  c.Query("tender"),
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list payment response", http.StatusOK, resp)
+}
+
+// Refund Payment godoc
+// @ID refund_payment
+// @Router /payment/{id}/refund [POST]
+// @Summary Refund Payment
+// @Description Refund part or all of a payment, recorded as a negative payment
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param refund body models.RefundPayment true "RefundPaymentRequest"
+// @Success 201 {object} Response{data=models.Payment} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RefundPayment(c *gin.Context) {
+
+	var refundPayment models.RefundPayment
+
+	err := c.ShouldBindJSON(&refundPayment)
+	if err != nil {
+		h.handlerResponse(c, "refund payment", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	refundPayment.PaymentId = idInt
+
+	if !refundPayment.Amount.Amount.IsPositive() {
+		h.handlerResponse(c, "refund payment", http.StatusBadRequest, "invalid amount")
+		return
+	}
+
+	original, err := h.storages.Payment().GetByID(context.Background(), &models.PaymentPrimaryKey{PaymentId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.getByID", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if original.RefundOf > 0 {
+		h.handlerResponse(c, "refund payment", http.StatusBadRequest, "a refund can not be refunded")
+		return
+	}
+
+	refundPayment.Amount, err = refundPayment.Amount.In(original.Amount.Currency)
+	if err != nil {
+		h.handlerResponse(c, "refund payment", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if refundPayment.Amount.Amount.GreaterThan(original.Amount.Amount.Sub(original.Refunded.Amount)) {
+		h.handlerResponse(c, "refund payment", http.StatusBadRequest, "refund exceeds the refundable amount")
+		return
+	}
+
+	refundPayment.Provider = original.Provider
+	if !inHouseTender(original.Tender) {
+		result, err := h.payment.Refund(context.Background(), &payment.Refund{
+			OrderId:       original.OrderId,
+			Tender:        original.Tender,
+			Amount:        refundPayment.Amount,
+			TransactionId: original.TransactionId,
+		})
+		if err != nil {
+			h.handlerResponse(c, "payment.provider.refund", http.StatusBadRequest, err.Error())
+			return
+		}
+
+		refundPayment.Provider = result.Provider
+		refundPayment.TransactionId = result.TransactionId
+	}
+
+	id, err := h.storages.Payment().Refund(context.Background(), &refundPayment)
+	if err != nil {
+		if refundPayment.TransactionId != "" {
+			// the provider already gave the money back, keep a trace for reconciliation
+			h.logger.Error("storage.payment.refund", logger.Error(err), logger.Any("transaction_id", refundPayment.TransactionId))
+		}
+
+		h.handlerResponse(c, "storage.payment.refund", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Payment().GetByID(context.Background(), &models.PaymentPrimaryKey{PaymentId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "refund payment", http.StatusCreated, resp)
+}
+
+// Get Order Balance godoc
+// @ID get_order_balance
+// @Router /order/{id}/balance [GET]
+// @Summary Get Order Balance
+// @Description Order total, approved returns, payments, refunds and the balance due
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.OrderBalance} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetOrderBalance(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	resp, err := h.storages.Payment().Balance(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.payment.balance", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get order balance", http.StatusOK, resp)
+}
+
+func validTender(tender string) bool {
+	switch tender {
+	case models.PaymentTenderCash, models.PaymentTenderCard, models.PaymentTenderBankTransfer,
+		models.PaymentTenderGiftCard, models.PaymentTenderStoreCredit:
+		return true
+	}
+
+	return false
+}
+
+// inHouseTender tells whether the tender is settled by the store itself (cash drawer,
+// gift card or store credit ledger) rather than by the payment provider.
+func inHouseTender(tender string) bool {
+	switch tender {
+	case models.PaymentTenderCash, models.PaymentTenderGiftCard, models.PaymentTenderStoreCredit:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,437 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Price List godoc
+// @ID create_price_list
+// @Router /price_list [POST]
+// @Summary Create Price List
+// @Description Create a price list of kind retail, wholesale or staff
+// @Tags Price List
+// @Accept json
+// @Produce json
+// @Param price_list body models.CreatePriceList true "CreatePriceListRequest"
+// @Success 201 {object} Response{data=models.PriceList} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreatePriceList(c *gin.Context) {
+
+	var createPriceList models.CreatePriceList
+
+	err := c.ShouldBindJSON(&createPriceList)
+	if err != nil {
+		h.handlerResponse(c, "create price list", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.storages.PriceList().Create(context.Background(), &createPriceList)
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.create", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.PriceList().GetByID(context.Background(), &models.PriceListPrimaryKey{PriceListId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create price list", http.StatusCreated, resp)
+}
+
+// Get List Price List godoc
+// @ID get_list_price_list
+// @Router /price_list [GET]
+// @Summary Get List Price List
+// @Description Get List Price List
+// @Tags Price List
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response{data=[]models.PriceList} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListPriceList(c *gin.Context) {
+
+	resp, err := h.storages.PriceList().GetList(context.Background())
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list price list response", http.StatusOK, resp)
+}
+
+// Create Price List Item godoc
+// @ID create_price_list_item
+// @Router /price_list/{id}/item [POST]
+// @Summary Create Price List Item
+// @Description Price a product on the list. store_id makes it a price of that store only, valid_from and valid_to schedule it. The price is in the currency of the product. Every change is written to the price history
+// @Tags Price List
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param item body models.CreatePriceListItem true "CreatePriceListItemRequest"
+// @Success 201 {object} Response{data=int} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreatePriceListItem(c *gin.Context) {
+
+	var createItem models.CreatePriceListItem
+
+	err := c.ShouldBindJSON(&createItem)
+	if err != nil {
+		h.handlerResponse(c, "create price list item", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "create price list item", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	createItem.PriceListId = idInt
+
+	id, err := h.storages.PriceList().CreateItem(context.Background(), &createItem)
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.create_item", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create price list item", http.StatusCreated, id)
+}
+
+// Get List Price List Item godoc
+// @ID get_list_price_list_item
+// @Router /price_list/{id}/item [GET]
+// @Summary Get List Price List Item
+// @Description Get List Price List Item
+// @Tags Price List
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param product_id query string false "product_id"
+// @Param store_id query string false "store_id"
+// @Success 200 {object} Response{data=models.GetListPriceListItemResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListPriceListItem(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "get list price list item", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get list price list item", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get list price list item", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	productId, err := h.getIntQuery(c.Query("product_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list price list item", http.StatusBadRequest, "invalid product_id")
+		return
+	}
+
+	storeId, err := h.getIntQuery(c.Query("store_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list price list item", http.StatusBadRequest, "invalid store_id")
+		return
+	}
+
+	resp, err := h.storages.PriceList().GetListItem(context.Background(), &models.GetListPriceListItemRequest{
+		PriceListId: idInt,
+		ProductId:   productId,
+		StoreId:     storeId,
+		Offset:      offset,
+		Limit:       limit,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.getlist_item", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list price list item response", http.StatusOK, resp)
+}
+
+// Get By ID Price List Item godoc
+// @ID get_by_id_price_list_item
+// @Router /price_list/{id}/item/{item_id} [GET]
+// @Summary Get By ID Price List Item
+// @Description Get By ID Price List Item
+// @Tags Price List
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param item_id path string true "item_id"
+// @Success 200 {object} Response{data=models.PriceListItem} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdPriceListItem(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "get price list item", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	itemIdInt, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil {
+		h.handlerResponse(c, "get price list item", http.StatusBadRequest, "item_id incorrect")
+		return
+	}
+
+	resp, err := h.storages.PriceList().GetItem(context.Background(), &models.PriceListItemPrimaryKey{
+		PriceListId:     idInt,
+		PriceListItemId: itemIdInt,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.get_item", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "get price list item", http.StatusOK, resp)
+}
+
+// Update Price List Item godoc
+// @ID update_price_list_item
+// @Router /price_list/{id}/item/{item_id} [PUT]
+// @Summary Update Price List Item
+// @Description Update Price List Item, the change is written to the price history
+// @Tags Price List
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param item_id path string true "item_id"
+// @Param item body models.UpdatePriceListItem true "UpdatePriceListItemRequest"
+// @Param If-Match header string true "ETag of the item as last read"
+// @Success 202 {object} Response{data=models.PriceListItem} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdatePriceListItem(c *gin.Context) {
+
+	var updateItem models.UpdatePriceListItem
+
+	err := c.ShouldBindJSON(&updateItem)
+	if err != nil {
+		h.handlerResponse(c, "update price list item", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "update price list item", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	itemIdInt, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil {
+		h.handlerResponse(c, "update price list item", http.StatusBadRequest, "item_id incorrect")
+		return
+	}
+
+	updateItem.PriceListId = idInt
+	updateItem.PriceListItemId = itemIdInt
+
+	version, ok := h.getIfMatch(c, "update price list item")
+	if !ok {
+		return
+	}
+
+	updateItem.Version = version
+
+	rowsAffected, err := h.storages.PriceList().UpdateItem(context.Background(), &updateItem)
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.update_item", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.PriceList().GetItem(context.Background(), &models.PriceListItemPrimaryKey{PriceListId: idInt, PriceListItemId: itemIdInt})
+		h.notWritten(c, "storage.price_list.update_item", err)
+		return
+	}
+
+	resp, err := h.storages.PriceList().GetItem(context.Background(), &models.PriceListItemPrimaryKey{PriceListId: idInt, PriceListItemId: itemIdInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.get_item", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update price list item", http.StatusAccepted, resp)
+}
+
+// Delete Price List Item godoc
+// @ID delete_price_list_item
+// @Router /price_list/{id}/item/{item_id} [DELETE]
+// @Summary Delete Price List Item
+// @Description Delete Price List Item, the removal is written to the price history
+// @Tags Price List
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param item_id path string true "item_id"
+// @Param If-Match header string true "ETag of the item as last read"
+// @Success 204 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeletePriceListItem(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "delete price list item", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	itemIdInt, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil {
+		h.handlerResponse(c, "delete price list item", http.StatusBadRequest, "item_id incorrect")
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "delete price list item")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.PriceList().DeleteItem(context.Background(), &models.PriceListItemPrimaryKey{
+		PriceListId:     idInt,
+		PriceListItemId: itemIdInt,
+		Version:         version,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.delete_item", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.PriceList().GetItem(context.Background(), &models.PriceListItemPrimaryKey{PriceListId: idInt, PriceListItemId: itemIdInt})
+		h.notWritten(c, "storage.price_list.delete_item", err)
+		return
+	}
+
+	h.handlerResponse(c, "delete price list item", http.StatusNoContent, nil)
+}
+
+// Get Product Price godoc
+// @ID get_product_price
+// @Router /product/{id}/price [GET]
+// @Summary Get Product Price
+// @Description The price the product sells at now. The customer's price list comes before the retail list, a store override before the price for every store and a scheduled price before an open one. Without a price list item the product list_price applies
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param store_id query string false "store_id"
+// @Param customer_id query string false "customer_id"
+// @Success 200 {object} Response{data=models.ProductPrice} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetProductPrice(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "get product price", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	storeId, err := h.getIntQuery(c.Query("store_id"))
+	if err != nil {
+		h.handlerResponse(c, "get product price", http.StatusBadRequest, "invalid store_id")
+		return
+	}
+
+	customerId, err := h.getIntQuery(c.Query("customer_id"))
+	if err != nil {
+		h.handlerResponse(c, "get product price", http.StatusBadRequest, "invalid customer_id")
+		return
+	}
+
+	resp, err := h.storages.PriceList().ProductPrice(context.Background(), &models.GetProductPriceRequest{
+		ProductId:  idInt,
+		StoreId:    storeId,
+		CustomerId: customerId,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.product_price", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get product price response", http.StatusOK, resp)
+}
+
+// Get Product Price History godoc
+// @ID get_product_price_history
+// @Router /product/{id}/price_history [GET]
+// @Summary Get Product Price History
+// @Description Changes of the product list_price and its price list items, newest first
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Success 200 {object} Response{data=models.GetListPriceHistoryResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetProductPriceHistory(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "get product price history", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get product price history", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get product price history", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	resp, err := h.storages.PriceList().GetListHistory(context.Background(), &models.GetListPriceHistoryRequest{
+		ProductId: idInt,
+		Offset:    offset,
+		Limit:     limit,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.price_list.getlist_history", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get product price history response", http.StatusOK, resp)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,7 +14,7 @@ import (
 // @ID create_product
 // @Router /product [POST]
 // @Summary Create Product
-// @Description Create Product
+// @Description Create Product with the variants it comes in, without variants it is sold as a single variant under sku
 // @Tags Product
 // @Accept json
 // @Produce json
@@ -50,12 +51,13 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 // @ID get_by_id_product
 // @Router /product/{id} [GET]
 // @Summary Get By ID Product
-// @Description Get By ID Product
+// @Description Product with its variants and their availability per store
 // @Tags Product
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdProduct(c *gin.Context) {
@@ -74,6 +76,8 @@ func (h *Handler) GetByIdProduct(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "get product by id", http.StatusCreated, resp)
 }
 
@@ -81,13 +85,15 @@ func (h *Handler) GetByIdProduct(c *gin.Context) {
 // @ID get_list_product
 // @Router /product [GET]
 // @Summary Get List Product
-// @Description Get List Product
+// @Description Products with their variants, availability per store and attributes.
+// @Description Filter by attribute with attr.<code>=<value>, numbers also with attr.<code>.min and attr.<code>.max, e.g. attr.travel_mm.min=120&attr.battery_wh.max=500
 // @Tags Product
 // @Accept json
 // @Produce json
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
 // @Param search query string false "search"
+// @Param include_deleted query string false "include_deleted"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
@@ -105,10 +111,24 @@ func (h *Handler) GetListProduct(c *gin.Context) {
 		return
 	}
 
+	includeDeleted, err := h.getBoolQuery(c.Query("include_deleted"))
+	if err != nil {
+		h.handlerResponse(c, "get list product", http.StatusBadRequest, "invalid include_deleted")
+		return
+	}
+
+	attributes, err := h.attributeFilters(c)
+	if err != nil {
+		h.handlerResponse(c, "get list product", http.StatusBadRequest, err.Error())
+		return
+	}
+
 	resp, err := h.storages.Product().GetList(context.Background(), &models.GetListProductRequest{
-		Offset: offset,
-		Limit:  limit,
-		Search: c.Query("search"),
+		Offset:         offset,
+		Limit:          limit,
+		Search:         c.Query("search"),
+		IncludeDeleted: includeDeleted,
+		Attributes:     attributes,
 	})
 	if err != nil {
 		h.handlerResponse(c, "storage.product.getlist", http.StatusInternalServerError, err.Error())
@@ -118,6 +138,90 @@ func (h *Handler) GetListProduct(c *gin.Context) {
 	h.handlerResponse(c, "get list product response", http.StatusOK, resp)
 }
 
+// Search Product godoc
+// @ID search_product
+// @Router /product/search [GET]
+// @Summary Search Product
+// @Description Full-text search over the name, brand, category and attributes of products, every word of q has to match a word by its beginning or, for a typo like treck, by trigram similarity.
+// @Description Products come best match first with the name and a snippet of the matched text, matched words marked with <mark>, and facet counts by brand, category, model_year and price_band over everything found.
+// @Description brand_id, category_id, model_year and price_band take the value of a facet to narrow the search, price bands are in currency
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param q query string true "q"
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param brand_id query string false "brand_id"
+// @Param category_id query string false "category_id"
+// @Param model_year query string false "model_year"
+// @Param price_band query string false "price_band, e.g. 1000-2000"
+// @Param currency query string false "currency of prices and price bands, the reporting currency by default"
+// @Success 200 {object} Response{data=models.SearchProductResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) SearchProduct(c *gin.Context) {
+
+	q := strings.TrimSpace(c.Query("q"))
+	if len(q) <= 0 {
+		h.handlerResponse(c, "search product", http.StatusBadRequest, "q is required")
+		return
+	}
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "search product", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "search product", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	brandId, err := h.getIntQuery(c.Query("brand_id"))
+	if err != nil {
+		h.handlerResponse(c, "search product", http.StatusBadRequest, "invalid brand_id")
+		return
+	}
+
+	categoryId, err := h.getIntQuery(c.Query("category_id"))
+	if err != nil {
+		h.handlerResponse(c, "search product", http.StatusBadRequest, "invalid category_id")
+		return
+	}
+
+	modelYear, err := h.getIntQuery(c.Query("model_year"))
+	if err != nil {
+		h.handlerResponse(c, "search product", http.StatusBadRequest, "invalid model_year")
+		return
+	}
+
+	currency := c.Query("currency")
+	if len(currency) <= 0 {
+		currency = h.cfg.ReportingCurrency
+	}
+
+	resp, err := h.storages.Product().Search(context.Background(), &models.SearchProductRequest{
+		Query:      q,
+		Offset:     offset,
+		Limit:      limit,
+		BrandId:    brandId,
+		CategoryId: categoryId,
+		ModelYear:  modelYear,
+		PriceBand:  c.Query("price_band"),
+		Currency:   currency,
+		PriceBands: h.cfg.SearchPriceBands,
+		Similarity: h.cfg.SearchSimilarity,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.product.search", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "search product response", http.StatusOK, resp)
+}
+
 // Update Product godoc
 // @ID update_product
 // @Router /product/{id} [PUT]
@@ -128,8 +232,13 @@ func (h *Handler) GetListProduct(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param product body models.UpdateProduct true "UpdateProductRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdateProduct(c *gin.Context) {
 
@@ -149,7 +258,13 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update product")
+	if !ok {
+		return
+	}
+
 	updateProduct.ProductId = idInt
+	updateProduct.Version = version
 
 	rowsAffected, err := h.storages.Product().Update(context.Background(), &updateProduct)
 	if err != nil {
@@ -158,7 +273,8 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.product.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Product().GetByID(context.Background(), &models.ProductPrimaryKey{ProductId: idInt})
+		h.notWritten(c, "storage.product.update", err)
 		return
 	}
 
@@ -168,6 +284,75 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update product", http.StatusAccepted, resp)
+}
+
+// Update PATCH Product godoc
+// @ID update_patch_product
+// @Router /product/{id} [PATCH]
+// @Summary Update PATCH Product
+// @Description Update Product fields by a JSON Merge Patch, null clears an optional field
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param product body object true "JSON Merge Patch (RFC 7396)"
+// @Param If-Match header string true "ETag of the entity as last read"
+// @Success 202 {object} Response{data=models.Product} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdatePatchProduct(c *gin.Context) {
+
+	var obj models.PatchRequest
+
+	id := c.Param("id")
+
+	err := c.ShouldBindJSON(&obj.Fields)
+	if err != nil {
+		h.handlerResponse(c, "update product", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.product.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "update product")
+	if !ok {
+		return
+	}
+
+	obj.ID = idInt
+	obj.Version = version
+
+	rowsAffected, err := h.storages.Product().UpdatePatch(context.Background(), &obj)
+	if err != nil {
+		h.handlerResponse(c, "storage.product.update", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.Product().GetByID(context.Background(), &models.ProductPrimaryKey{ProductId: idInt})
+		h.notWritten(c, "storage.product.update", err)
+		return
+	}
+
+	resp, err := h.storages.Product().GetByID(context.Background(), &models.ProductPrimaryKey{ProductId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.product.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update product", http.StatusAccepted, resp)
 }
 
@@ -175,14 +360,18 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 // @ID delete_product
 // @Router /product/{id} [DELETE]
 // @Summary Delete Product
-// @Description Delete Product
+// @Description Soft delete the product, it is hidden from lists and can be restored
 // @Tags Product
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
 // @Param product body models.ProductPrimaryKey true "DeleteProductRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 204 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) DeleteProduct(c *gin.Context) {
 
@@ -194,15 +383,61 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, err := h.storages.Product().Delete(context.Background(), &models.ProductPrimaryKey{ProductId: idInt})
+	version, ok := h.getIfMatch(c, "delete product")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.Product().Delete(context.Background(), &models.ProductPrimaryKey{ProductId: idInt, Version: version})
 	if err != nil {
 		h.handlerResponse(c, "storage.product.delete", http.StatusInternalServerError, err.Error())
 		return
 	}
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.product.delete", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Product().GetByID(context.Background(), &models.ProductPrimaryKey{ProductId: idInt})
+		h.notWritten(c, "storage.product.delete", err)
 		return
 	}
 
 	h.handlerResponse(c, "delete product", http.StatusNoContent, nil)
 }
+
+// Restore Product godoc
+// @ID restore_product
+// @Router /product/{id}/restore [POST]
+// @Summary Restore Product
+// @Description Bring back a soft deleted product
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Product} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreProduct(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.product.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	rowsAffected, err := h.storages.Product().Restore(context.Background(), &models.ProductPrimaryKey{ProductId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.product.restore", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "storage.product.restore", http.StatusBadRequest, "now rows affected")
+		return
+	}
+
+	resp, err := h.storages.Product().GetByID(context.Background(), &models.ProductPrimaryKey{ProductId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.product.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "restore product", http.StatusOK, resp)
+}
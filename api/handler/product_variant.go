@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Product Variant godoc
+// @ID create_product_variant
+// @Router /product/{id}/variants [POST]
+// @Summary Create Product Variant
+// @Description Add a size, color and frame material combination of the product under its own SKU
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param variant body models.CreateProductVariant true "CreateProductVariantRequest"
+// @Success 201 {object} Response{data=models.ProductVariant} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateProductVariant(c *gin.Context) {
+
+	var createVariant models.CreateProductVariant
+
+	err := c.ShouldBindJSON(&createVariant) // parse req body to given type struct
+	if err != nil {
+		h.handlerResponse(c, "create product variant", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.product.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	createVariant.ProductId = idInt
+
+	id, err := h.storages.ProductVariant().Create(context.Background(), &createVariant)
+	if err != nil {
+		h.handlerResponse(c, "storage.product_variant.create", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.ProductVariant().GetByID(context.Background(), &models.ProductVariantPrimaryKey{ProductId: idInt, VariantId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.product_variant.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create product variant", http.StatusCreated, resp)
+}
+
+// Get By ID Product Variant godoc
+// @ID get_by_id_product_variant
+// @Router /product/{id}/variants/{variant_id} [GET]
+// @Summary Get By ID Product Variant
+// @Description Variant with its stock, reserved and available quantity per store
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param variant_id path string true "variant_id"
+// @Success 200 {object} Response{data=models.ProductVariant} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdProductVariant(c *gin.Context) {
+
+	key, ok := h.productVariantKey(c, "storage.product_variant.getByID")
+	if !ok {
+		return
+	}
+
+	resp, err := h.storages.ProductVariant().GetByID(context.Background(), key)
+	if err != nil {
+		h.handlerResponse(c, "storage.product_variant.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "get product variant by id", http.StatusOK, resp)
+}
+
+// Get List Product Variant godoc
+// @ID get_list_product_variant
+// @Router /product/{id}/variants [GET]
+// @Summary Get List Product Variant
+// @Description Variant matrix of the product, the sizes, colors and frame materials it comes in and every variant with its availability per store
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param store_id query string false "store_id"
+// @Success 200 {object} Response{data=models.GetListProductVariantResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListProductVariant(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.product.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	storeId, err := h.getIntQuery(c.Query("store_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list product variant", http.StatusBadRequest, "invalid store_id")
+		return
+	}
+
+	resp, err := h.storages.ProductVariant().GetList(context.Background(), &models.GetListProductVariantRequest{
+		ProductId: idInt,
+		StoreId:   storeId,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.product_variant.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list product variant response", http.StatusOK, resp)
+}
+
+// Update Product Variant godoc
+// @ID update_product_variant
+// @Router /product/{id}/variants/{variant_id} [PUT]
+// @Summary Update Product Variant
+// @Description Update the SKU and options of the variant
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param variant_id path string true "variant_id"
+// @Param variant body models.UpdateProductVariant true "UpdateProductVariantRequest"
+// @Param If-Match header string true "ETag of the variant as last read"
+// @Success 202 {object} Response{data=models.ProductVariant} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateProductVariant(c *gin.Context) {
+
+	var updateVariant models.UpdateProductVariant
+
+	err := c.ShouldBindJSON(&updateVariant)
+	if err != nil {
+		h.handlerResponse(c, "update product variant", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, ok := h.productVariantKey(c, "storage.product_variant.update")
+	if !ok {
+		return
+	}
+
+	updateVariant.ProductId = key.ProductId
+	updateVariant.VariantId = key.VariantId
+
+	version, ok := h.getIfMatch(c, "update product variant")
+	if !ok {
+		return
+	}
+
+	updateVariant.Version = version
+
+	rowsAffected, err := h.storages.ProductVariant().Update(context.Background(), &updateVariant)
+	if err != nil {
+		h.handlerResponse(c, "storage.product_variant.update", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.ProductVariant().GetByID(context.Background(), key)
+		h.notWritten(c, "storage.product_variant.update", err)
+		return
+	}
+
+	resp, err := h.storages.ProductVariant().GetByID(context.Background(), key)
+	if err != nil {
+		h.handlerResponse(c, "storage.product_variant.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update product variant", http.StatusAccepted, resp)
+}
+
+// Delete Product Variant godoc
+// @ID delete_product_variant
+// @Router /product/{id}/variants/{variant_id} [DELETE]
+// @Summary Delete Product Variant
+// @Description Delete a variant that has no stock and was never ordered
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param variant_id path string true "variant_id"
+// @Param If-Match header string true "ETag of the variant as last read"
+// @Success 204 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeleteProductVariant(c *gin.Context) {
+
+	key, ok := h.productVariantKey(c, "storage.product_variant.delete")
+	if !ok {
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "delete product variant")
+	if !ok {
+		return
+	}
+
+	key.Version = version
+
+	rowsAffected, err := h.storages.ProductVariant().Delete(context.Background(), key)
+	if err != nil {
+		h.handlerResponse(c, "storage.product_variant.delete", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.ProductVariant().GetByID(context.Background(), key)
+		h.notWritten(c, "storage.product_variant.delete", err)
+		return
+	}
+
+	h.handlerResponse(c, "delete product variant", http.StatusNoContent, nil)
+}
+
+func (h *Handler) productVariantKey(c *gin.Context, path string) (*models.ProductVariantPrimaryKey, bool) {
+
+	productId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, path, http.StatusBadRequest, "id incorrect")
+		return nil, false
+	}
+
+	variantId, err := strconv.Atoi(c.Param("variant_id"))
+	if err != nil {
+		h.handlerResponse(c, path, http.StatusBadRequest, "variant_id incorrect")
+		return nil, false
+	}
+
+	return &models.ProductVariantPrimaryKey{ProductId: productId, VariantId: variantId}, true
+}
@@ -0,0 +1,482 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Purchase Order godoc
+// @ID create_purchase_order
+// @Router /purchase_order [POST]
+// @Summary Create Purchase Order
+// @Description Create Purchase Order in draft status, cost prices of the items are in the currency of the order
+// @Tags PurchaseOrder
+// @Accept json
+// @Produce json
+// @Param purchase_order body models.CreatePurchaseOrder true "CreatePurchaseOrderRequest"
+// @Success 201 {object} Response{data=models.PurchaseOrder} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreatePurchaseOrder(c *gin.Context) {
+
+	var createPurchaseOrder models.CreatePurchaseOrder
+
+	err := c.ShouldBindJSON(&createPurchaseOrder) // parse req body to given type struct
+	if err != nil {
+		h.handlerResponse(c, "create purchase order", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.storages.PurchaseOrder().Create(context.Background(), &createPurchaseOrder)
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.create", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp, err := h.storages.PurchaseOrder().GetByID(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create purchase order", http.StatusCreated, resp)
+}
+
+// Get By ID Purchase Order godoc
+// @ID get_by_id_purchase_order
+// @Router /purchase_order/{id} [GET]
+// @Summary Get By ID Purchase Order
+// @Description Get By ID Purchase Order
+// @Tags PurchaseOrder
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.PurchaseOrder} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdPurchaseOrder(c *gin.Context) {
+
+	id := c.Param("id")
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	resp, err := h.storages.PurchaseOrder().GetByID(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "get purchase order by id", http.StatusOK, resp)
+}
+
+// Get List Purchase Order godoc
+// @ID get_list_purchase_order
+// @Router /purchase_order [GET]
+// @Summary Get List Purchase Order
+// @Description Get List Purchase Order
+// @Tags PurchaseOrder
+// @Accept json
+// @Produce json
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param supplier_id query string false "supplier_id"
+// @Param store_id query string false "store_id"
+// @Param status query string false "status"
+// @Success 200 {object} Response{data=models.GetListPurchaseOrderResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListPurchaseOrder(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get list purchase order", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get list purchase order", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	supplierId, err := h.getIntQuery(c.Query("supplier_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list purchase order", http.StatusBadRequest, "invalid supplier_id")
+		return
+	}
+
+	storeId, err := h.getIntQuery(c.Query("store_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list purchase order", http.StatusBadRequest, "invalid store_id")
+		return
+	}
+
+	resp, err := h.storages.PurchaseOrder().GetList(context.Background(), &models.GetListPurchaseOrderRequest{
+		Offset:     offset,
+		Limit:      limit,
+		SupplierId: supplierId,
+		StoreId:    storeId,
+		Status:     c.Query("status"),
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list purchase order response", http.StatusOK, resp)
+}
+
+// Update Purchase Order godoc
+// @ID update_purchase_order
+// @Router /purchase_order/{id} [PUT]
+// @Summary Update Purchase Order
+// @Description Update a draft Purchase Order
+// @Tags PurchaseOrder
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param purchase_order body models.UpdatePurchaseOrder true "UpdatePurchaseOrderRequest"
+// @Param If-Match header string true "ETag of the purchase order as last read"
+// @Success 202 {object} Response{data=models.PurchaseOrder} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdatePurchaseOrder(c *gin.Context) {
+
+	var updatePurchaseOrder models.UpdatePurchaseOrder
+
+	id := c.Param("id")
+
+	err := c.ShouldBindJSON(&updatePurchaseOrder)
+	if err != nil {
+		h.handlerResponse(c, "update purchase order", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	updatePurchaseOrder.PurchaseOrderId = idInt
+
+	version, ok := h.getIfMatch(c, "update purchase order")
+	if !ok {
+		return
+	}
+
+	updatePurchaseOrder.Version = version
+
+	rowsAffected, err := h.storages.PurchaseOrder().Update(context.Background(), &updatePurchaseOrder)
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.update", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		current, err := h.storages.PurchaseOrder().GetByID(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: idInt})
+		if err == nil && current.Version == version {
+			h.handlerResponse(c, "storage.purchase_order.update", http.StatusBadRequest, "purchase order is not a draft")
+			return
+		}
+		h.notWritten(c, "storage.purchase_order.update", err)
+		return
+	}
+
+	resp, err := h.storages.PurchaseOrder().GetByID(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update purchase order", http.StatusAccepted, resp)
+}
+
+// Update Purchase Order Status godoc
+// @ID update_purchase_order_status
+// @Router /purchase_order/{id}/status [PUT]
+// @Summary Update Purchase Order Status
+// @Description Send a draft Purchase Order to the supplier or move a sent one back to draft
+// @Tags PurchaseOrder
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param status body models.UpdatePurchaseOrderStatus true "UpdatePurchaseOrderStatusRequest"
+// @Param If-Match header string true "ETag of the purchase order as last read"
+// @Success 202 {object} Response{data=models.PurchaseOrder} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdatePurchaseOrderStatus(c *gin.Context) {
+
+	var updateStatus models.UpdatePurchaseOrderStatus
+
+	id := c.Param("id")
+
+	err := c.ShouldBindJSON(&updateStatus)
+	if err != nil {
+		h.handlerResponse(c, "update purchase order status", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	updateStatus.PurchaseOrderId = idInt
+
+	version, ok := h.getIfMatch(c, "update purchase order status")
+	if !ok {
+		return
+	}
+
+	updateStatus.Version = version
+
+	rowsAffected, err := h.storages.PurchaseOrder().UpdateStatus(context.Background(), &updateStatus)
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.update_status", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		current, err := h.storages.PurchaseOrder().GetByID(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: idInt})
+		if err == nil && current.Version == version {
+			h.handlerResponse(c, "storage.purchase_order.update_status", http.StatusBadRequest, "purchase order can not be moved to this status")
+			return
+		}
+		h.notWritten(c, "storage.purchase_order.update_status", err)
+		return
+	}
+
+	resp, err := h.storages.PurchaseOrder().GetByID(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update purchase order status", http.StatusAccepted, resp)
+}
+
+// DELETE Purchase Order godoc
+// @ID delete_purchase_order
+// @Router /purchase_order/{id} [DELETE]
+// @Summary Delete Purchase Order
+// @Description Delete a draft Purchase Order
+// @Tags PurchaseOrder
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param If-Match header string true "ETag of the purchase order as last read"
+// @Success 204 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeletePurchaseOrder(c *gin.Context) {
+
+	id := c.Param("id")
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "delete purchase order")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.PurchaseOrder().Delete(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: idInt, Version: version})
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.delete", http.StatusInternalServerError, err.Error())
+		return
+	}
+	if rowsAffected <= 0 {
+		current, err := h.storages.PurchaseOrder().GetByID(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: idInt})
+		if err == nil && current.Version == version {
+			h.handlerResponse(c, "storage.purchase_order.delete", http.StatusBadRequest, "purchase order is not a draft")
+			return
+		}
+		h.notWritten(c, "storage.purchase_order.delete", err)
+		return
+	}
+
+	h.handlerResponse(c, "delete purchase order", http.StatusNoContent, nil)
+}
+
+// -------------------------------------------------------------------------------------------
+// Create Purchase Order Item godoc
+// @ID create_purchase_order_item
+// @Router /purchase_order/{id}/item [POST]
+// @Summary Create Purchase Order Item
+// @Description Add a line to a draft Purchase Order
+// @Tags PurchaseOrder
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param item body models.CreatePurchaseOrderItem true "CreatePurchaseOrderItemRequest"
+// @Success 201 {object} Response{data=models.PurchaseOrder} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreatePurchaseOrderItem(c *gin.Context) {
+
+	var createItem models.CreatePurchaseOrderItem
+
+	err := c.ShouldBindJSON(&createItem) // parse req body to given type struct
+	if err != nil {
+		h.handlerResponse(c, "create purchase order item", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	createItem.PurchaseOrderId = idInt
+
+	err = h.storages.PurchaseOrder().AddItem(context.Background(), &createItem)
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.add_item", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.PurchaseOrder().GetByID(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create purchase order item", http.StatusCreated, resp)
+}
+
+// DELETE Purchase Order Item godoc
+// @ID delete_purchase_order_item
+// @Router /purchase_order/{id}/item/{item_id} [DELETE]
+// @Summary Delete Purchase Order Item
+// @Description Remove a line from a draft Purchase Order
+// @Tags PurchaseOrder
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param item_id path string true "item_id"
+// @Param If-Match header string true "ETag of the purchase order as last read"
+// @Success 204 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeletePurchaseOrderItem(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	itemIdInt, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusBadRequest, "item_id incorrect")
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "delete purchase order item")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.PurchaseOrder().RemoveItem(context.Background(), &models.PurchaseOrderItemPrimaryKey{
+		PurchaseOrderId: idInt,
+		ItemId:          itemIdInt,
+		Version:         version,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.remove_item", http.StatusBadRequest, err.Error())
+		return
+	}
+	if rowsAffected <= 0 {
+		current, err := h.storages.PurchaseOrder().GetByID(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: idInt})
+		if err == nil && current.Version == version {
+			h.handlerResponse(c, "storage.purchase_order.remove_item", http.StatusBadRequest, "purchase order is not a draft")
+			return
+		}
+		h.notWritten(c, "storage.purchase_order.remove_item", err)
+		return
+	}
+
+	h.handlerResponse(c, "delete purchase order item", http.StatusNoContent, nil)
+}
+
+// Receive Purchase Order godoc
+// @ID receive_purchase_order
+// @Router /purchase_order/{id}/receive [POST]
+// @Summary Receive Purchase Order
+// @Description Receive delivered lines of a sent Purchase Order into store stocks
+// @Tags PurchaseOrder
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param receive body models.ReceivePurchaseOrder true "ReceivePurchaseOrderRequest"
+// @Success 200 {object} Response{data=models.PurchaseOrder} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) ReceivePurchaseOrder(c *gin.Context) {
+
+	var receive models.ReceivePurchaseOrder
+
+	err := c.ShouldBindJSON(&receive)
+	if err != nil {
+		h.handlerResponse(c, "receive purchase order", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	receive.PurchaseOrderId = idInt
+
+	err = h.storages.PurchaseOrder().Receive(context.Background(), &receive)
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.receive", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.PurchaseOrder().GetByID(context.Background(), &models.PurchaseOrderPrimaryKey{PurchaseOrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.purchase_order.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "receive purchase order", http.StatusOK, resp)
+}
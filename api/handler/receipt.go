@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"app/api/models"
+	"app/pkg/money"
+	"app/pkg/pdf"
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// receiptWidth is what an 80 mm thermal printer fits on a line in its default font.
+const receiptWidth = 42
+
+// Get Order Receipt godoc
+// @ID get_order_receipt
+// @Router /order/{id}/receipt [GET]
+// @Summary Get Order Receipt
+// @Description Receipt of the order as plain text for thermal printers, HTML or PDF. A completed order carries its invoice number, numbers run per store without gaps
+// @Tags Order
+// @Accept json
+// @Produce plain
+// @Produce html
+// @Produce application/pdf
+// @Param id path string true "id"
+// @Param format query string false "text (default), html or pdf"
+// @Param width query integer false "characters per line of the text and PDF receipt, default 42 for text and a full page for PDF"
+// @Success 200 {string} string "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetOrderReceipt(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.order.receipt", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if len(format) <= 0 {
+		format = models.ReceiptFormatText
+	}
+
+	width, err := h.getIntQuery(c.Query("width"))
+	if err != nil || width < 0 {
+		h.handlerResponse(c, "storage.order.receipt", http.StatusBadRequest, "width incorrect")
+		return
+	}
+	if width == 0 {
+		width = receiptWidth
+		if format == models.ReceiptFormatPDF {
+			width = pdf.Columns
+		}
+	}
+	if width < 24 || width > pdf.Columns {
+		h.handlerResponse(c, "storage.order.receipt", http.StatusBadRequest, fmt.Sprintf("width must be between 24 and %d", pdf.Columns))
+		return
+	}
+
+	receipt, err := h.storages.Order().Receipt(context.Background(), &models.OrderPrimaryKey{OrderId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.order.receipt", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	switch format {
+	case models.ReceiptFormatText:
+		var text strings.Builder
+		for _, line := range receiptLines(receipt, width) {
+			text.WriteString(line.Text)
+			text.WriteString("\n")
+		}
+
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(text.String()))
+	case models.ReceiptFormatHTML:
+		var html bytes.Buffer
+
+		err = receiptTemplate.Execute(&html, receipt)
+		if err != nil {
+			h.handlerResponse(c, "order.receipt.html", http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		c.Data(http.StatusOK, "text/html; charset=utf-8", html.Bytes())
+	case models.ReceiptFormatPDF:
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, receiptTitle(receipt)))
+		c.Data(http.StatusOK, "application/pdf", pdf.Render(receiptTitle(receipt), receiptLines(receipt, width)))
+	default:
+		h.handlerResponse(c, "storage.order.receipt", http.StatusBadRequest, "format must be text, html or pdf")
+	}
+}
+
+func receiptTitle(receipt *models.Receipt) string {
+	if len(receipt.InvoiceNumber) > 0 {
+		return "invoice-" + receipt.InvoiceNumber
+	}
+
+	return fmt.Sprintf("order-%d", receipt.OrderId)
+}
+
+// receiptLines lays the receipt out in columns of the given width, the same layout is
+// printed as text and set in a monospaced font in the PDF.
+func receiptLines(receipt *models.Receipt, width int) []pdf.Line {
+	var (
+		lines  []pdf.Line
+		amount = receipt.Amount
+		store  = receipt.Store
+	)
+
+	add := func(text string, bold bool) {
+		lines = append(lines, pdf.Line{Text: text, Bold: bold})
+	}
+	center := func(text string, bold bool) {
+		if len(text) <= 0 {
+			return
+		}
+		text = cut(text, width)
+		add(strings.Repeat(" ", (width-utf8.RuneCountInString(text))/2)+text, bold)
+	}
+	row := func(label, value string, bold bool) {
+		label = cut(label, width-utf8.RuneCountInString(value)-1)
+
+		pad := width - utf8.RuneCountInString(label) - utf8.RuneCountInString(value)
+		if pad < 1 {
+			pad = 1
+		}
+		add(label+strings.Repeat(" ", pad)+value, bold)
+	}
+	rule := func() {
+		add(strings.Repeat("-", width), false)
+	}
+
+	center(store.StoreName, true)
+	center(store.Street, false)
+	center(strings.TrimSpace(fmt.Sprintf("%s %s %s", store.City, store.State, store.ZipCode)), false)
+	center(store.Phone, false)
+	center(store.Email, false)
+	add("", false)
+
+	if len(receipt.InvoiceNumber) > 0 {
+		row("Invoice", receipt.InvoiceNumber, true)
+		row("Issued", receipt.IssuedAt, false)
+	} else {
+		center("PRO FORMA - NOT AN INVOICE", true)
+	}
+	row("Order", strconv.Itoa(receipt.OrderId), false)
+	row("Date", receipt.OrderDate, false)
+	row("Staff", receipt.StaffName, false)
+	if len(receipt.CustomerName) > 0 {
+		row("Customer", receipt.CustomerName, false)
+	}
+	rule()
+
+	for _, line := range receipt.Lines {
+		add(cut(line.ProductName, width), false)
+		if len(line.Variant) > 0 {
+			add(cut("  "+line.Variant, width), false)
+		}
+		add(cut("  SKU "+line.Sku, width), false)
+		row(fmt.Sprintf("  %d x %s", line.Quantity, amountString(line.UnitPrice)), amountString(line.LineTotal), false)
+		if line.Discount > 0 {
+			add("  less "+percent(line.Discount), false)
+		}
+		if line.TaxRate > 0 {
+			add("  "+line.TaxName+" "+percent(line.TaxRate), false)
+		}
+	}
+	rule()
+
+	row("Subtotal", amountString(amount.Subtotal), false)
+	if !amount.LineDiscount.Amount.IsZero() {
+		row("Line discounts", "-"+amountString(amount.LineDiscount), false)
+	}
+	if !amount.PromoDiscount.Amount.IsZero() {
+		row("Promo "+receipt.PromoCode, "-"+amountString(amount.PromoDiscount), false)
+	}
+	if !amount.LoyaltyDiscount.Amount.IsZero() {
+		row("Loyalty points", "-"+amountString(amount.LoyaltyDiscount), false)
+	}
+	for _, tax := range amount.Taxes {
+		label := tax.TaxName + " " + percent(tax.Rate)
+		if amount.TaxInclusive {
+			label += " incl."
+		}
+		row(label, amountString(tax.Tax), false)
+	}
+	if amount.TaxExempt {
+		row("Tax exempt", "", false)
+	}
+	row("TOTAL "+amount.Total.Currency, amountString(amount.Total), true)
+	rule()
+
+	for _, payment := range receipt.Payments {
+		label := strings.ReplaceAll(payment.Tender, "_", " ")
+		if payment.RefundOf > 0 {
+			label += " refund"
+		}
+		row(label, amountString(payment.Amount), false)
+	}
+	if !receipt.Balance.Returned.Amount.IsZero() {
+		row("Returned", "-"+amountString(receipt.Balance.Returned), false)
+	}
+	row("Balance due", amountString(receipt.Balance.BalanceDue), true)
+
+	add("", false)
+	center("Thank you", false)
+
+	return lines
+}
+
+func amountString(m money.Money) string {
+	return m.Amount.StringFixed(money.Cents)
+}
+
+func percent(rate float64) string {
+	return decimal.NewFromFloat(rate).Mul(decimal.NewFromInt(100)).String() + "%"
+}
+
+func cut(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if runes := []rune(text); len(runes) > width {
+		return string(runes[:width])
+	}
+
+	return text
+}
+
+var receiptTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"amount":  amountString,
+	"percent": percent,
+	"zero":    func(m money.Money) bool { return m.Amount.IsZero() },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if .InvoiceNumber}}Invoice {{.InvoiceNumber}}{{else}}Order {{.OrderId}}{{end}}</title>
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 2em auto; }
+table { width: 100%; border-collapse: collapse; }
+td, th { padding: 0.2em 0.4em; text-align: left; }
+.num { text-align: right; }
+.total td { font-weight: bold; border-top: 1px solid #000; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Store.StoreName}}</h1>
+<p>{{.Store.Street}}<br>{{.Store.City}} {{.Store.State}} {{.Store.ZipCode}}<br>{{.Store.Phone}} {{.Store.Email}}</p>
+</header>
+<table>
+{{if .InvoiceNumber}}<tr><th>Invoice</th><td>{{.InvoiceNumber}}</td></tr>
+<tr><th>Issued</th><td>{{.IssuedAt}}</td></tr>
+{{else}}<tr><th colspan="2">Pro forma, not an invoice</th></tr>
+{{end}}<tr><th>Order</th><td>{{.OrderId}}</td></tr>
+<tr><th>Date</th><td>{{.OrderDate}}</td></tr>
+<tr><th>Staff</th><td>{{.StaffName}}</td></tr>
+{{if .CustomerName}}<tr><th>Customer</th><td>{{.CustomerName}}</td></tr>
+{{end}}</table>
+<table>
+<tr><th>Product</th><th>SKU</th><th class="num">Qty</th><th class="num">Price</th><th class="num">Discount</th><th>Tax</th><th class="num">Amount</th></tr>
+{{range .Lines}}<tr><td>{{.ProductName}}{{if .Variant}}<br>{{.Variant}}{{end}}</td><td>{{.Sku}}</td><td class="num">{{.Quantity}}</td><td class="num">{{amount .UnitPrice}}</td><td class="num">{{if .Discount}}{{percent .Discount}}{{end}}</td><td>{{if .TaxRate}}{{.TaxName}} {{percent .TaxRate}}{{end}}</td><td class="num">{{amount .LineTotal}}</td></tr>
+{{end}}</table>
+<table>
+{{with .Amount}}<tr><td>Subtotal</td><td class="num">{{amount .Subtotal}}</td></tr>
+{{if not (zero .LineDiscount)}}<tr><td>Line discounts</td><td class="num">-{{amount .LineDiscount}}</td></tr>
+{{end}}{{if not (zero .PromoDiscount)}}<tr><td>Promo {{$.PromoCode}}</td><td class="num">-{{amount .PromoDiscount}}</td></tr>
+{{end}}{{if not (zero .LoyaltyDiscount)}}<tr><td>Loyalty points</td><td class="num">-{{amount .LoyaltyDiscount}}</td></tr>
+{{end}}{{range .Taxes}}<tr><td>{{.TaxName}} {{percent .Rate}}{{if $.Amount.TaxInclusive}} incl.{{end}}</td><td class="num">{{amount .Tax}}</td></tr>
+{{end}}{{if .TaxExempt}}<tr><td colspan="2">Tax exempt</td></tr>
+{{end}}<tr class="total"><td>Total {{.Total.Currency}}</td><td class="num">{{amount .Total}}</td></tr>
+{{end}}</table>
+<table>
+{{range .Payments}}<tr><td>{{.Tender}}{{if .RefundOf}} refund{{end}}</td><td>{{.CreatedAt}}</td><td class="num">{{amount .Amount}}</td></tr>
+{{end}}{{with .Balance}}{{if not (zero .Returned)}}<tr><td colspan="2">Returned</td><td class="num">-{{amount .Returned}}</td></tr>
+{{end}}<tr class="total"><td colspan="2">Balance due</td><td class="num">{{amount .BalanceDue}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Return godoc
+// @ID create_return
+// @Router /return [POST]
+// @Summary Create Return
+// @Description Create Return (RMA) against a completed order
+// @Tags Return
+// @Accept json
+// @Produce json
+// @Param return body models.CreateReturn true "CreateReturnRequest"
+// @Success 201 {object} Response{data=models.Return} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateReturn(c *gin.Context) {
+
+	var createReturn models.CreateReturn
+
+	err := c.ShouldBindJSON(&createReturn) // parse req body to given type struct
+	if err != nil {
+		h.handlerResponse(c, "create return", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.storages.Return().Create(context.Background(), &createReturn)
+	if err != nil {
+		h.handlerResponse(c, "storage.return.create", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Return().GetByID(context.Background(), &models.ReturnPrimaryKey{ReturnId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.return.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create return", http.StatusCreated, resp)
+}
+
+// Get By ID Return godoc
+// @ID get_by_id_return
+// @Router /return/{id} [GET]
+// @Summary Get By ID Return
+// @Description Get By ID Return
+// @Tags Return
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Return} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdReturn(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.return.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	resp, err := h.storages.Return().GetByID(context.Background(), &models.ReturnPrimaryKey{ReturnId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.return.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get return by id", http.StatusOK, resp)
+}
+
+// Get List Return godoc
+// @ID get_list_return
+// @Router /return [GET]
+// @Summary Get List Return
+// @Description Get List Return
+// @Tags Return
+// @Accept json
+// @Produce json
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param order_id query string false "order_id"
+// @Param status query string false "status"
+// @Success 200 {object} Response{data=models.GetListReturnResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListReturn(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get list return", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get list return", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	orderId, err := h.getIntQuery(c.Query("order_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list return", http.StatusBadRequest, "invalid order_id")
+		return
+	}
+
+	resp, err := h.storages.Return().GetList(context.Background(), &models.GetListReturnRequest{
+		Offset:  offset,
+		Limit:   limit,
+		OrderId: orderId,
+		Status:  c.Query("status"),
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.return.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list return response", http.StatusOK, resp)
+}
+
+// Inspect Return godoc
+// @ID inspect_return
+// @Router /return/{id}/inspect [PUT]
+// @Summary Inspect Return
+// @Description Mark which returned items can be restocked
+// @Tags Return
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param inspect body models.InspectReturn true "InspectReturnRequest"
+// @Success 202 {object} Response{data=models.Return} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) InspectReturn(c *gin.Context) {
+
+	var inspectReturn models.InspectReturn
+
+	err := c.ShouldBindJSON(&inspectReturn)
+	if err != nil {
+		h.handlerResponse(c, "inspect return", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.return.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	inspectReturn.ReturnId = idInt
+
+	err = h.storages.Return().Inspect(context.Background(), &inspectReturn)
+	if err != nil {
+		h.handlerResponse(c, "storage.return.inspect", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Return().GetByID(context.Background(), &models.ReturnPrimaryKey{ReturnId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.return.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "inspect return", http.StatusAccepted, resp)
+}
+
+// Approve Return godoc
+// @ID approve_return
+// @Router /return/{id}/approve [PUT]
+// @Summary Approve Return
+// @Description Approve Return, restock its items to the chosen store and refund to the original tender or as store credit
+// @Tags Return
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param approve body models.ApproveReturn true "ApproveReturnRequest"
+// @Success 202 {object} Response{data=models.Return} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) ApproveReturn(c *gin.Context) {
+
+	var approveReturn models.ApproveReturn
+
+	err := c.ShouldBindJSON(&approveReturn)
+	if err != nil {
+		h.handlerResponse(c, "approve return", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.return.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	approveReturn.ReturnId = idInt
+
+	err = h.storages.Return().Approve(context.Background(), &approveReturn)
+	if err != nil {
+		h.handlerResponse(c, "storage.return.approve", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Return().GetByID(context.Background(), &models.ReturnPrimaryKey{ReturnId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.return.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "approve return", http.StatusAccepted, resp)
+}
+
+// Reject Return godoc
+// @ID reject_return
+// @Router /return/{id}/reject [PUT]
+// @Summary Reject Return
+// @Description Reject Return
+// @Tags Return
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param reject body models.RejectReturn true "RejectReturnRequest"
+// @Success 202 {object} Response{data=models.Return} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RejectReturn(c *gin.Context) {
+
+	var rejectReturn models.RejectReturn
+
+	err := c.ShouldBindJSON(&rejectReturn)
+	if err != nil {
+		h.handlerResponse(c, "reject return", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.return.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	rejectReturn.ReturnId = idInt
+
+	err = h.storages.Return().Reject(context.Background(), &rejectReturn)
+	if err != nil {
+		h.handlerResponse(c, "storage.return.reject", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Return().GetByID(context.Background(), &models.ReturnPrimaryKey{ReturnId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.return.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "reject return", http.StatusAccepted, resp)
+}
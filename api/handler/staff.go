@@ -57,6 +57,7 @@ func (h *Handler) CreateStaff(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdStaff(c *gin.Context) {
@@ -75,6 +76,8 @@ func (h *Handler) GetByIdStaff(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "get staff by id", http.StatusCreated, resp)
 }
 
@@ -89,6 +92,7 @@ func (h *Handler) GetByIdStaff(c *gin.Context) {
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
 // @Param search query string false "search"
+// @Param include_deleted query string false "include_deleted"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
@@ -106,10 +110,17 @@ func (h *Handler) GetListStaff(c *gin.Context) {
 		return
 	}
 
+	includeDeleted, err := h.getBoolQuery(c.Query("include_deleted"))
+	if err != nil {
+		h.handlerResponse(c, "get list staff", http.StatusBadRequest, "invalid include_deleted")
+		return
+	}
+
 	resp, err := h.storages.Staff().GetList(context.Background(), &models.GetListStaffRequest{
-		Offset: offset,
-		Limit:  limit,
-		Search: c.Query("search"),
+		Offset:         offset,
+		Limit:          limit,
+		Search:         c.Query("search"),
+		IncludeDeleted: includeDeleted,
 	})
 	if err != nil {
 		h.handlerResponse(c, "storage.staff.getlist", http.StatusInternalServerError, err.Error())
@@ -130,6 +141,7 @@ func (h *Handler) GetListStaff(c *gin.Context) {
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
 // @Param search query string false "search"
+// @Param currency query string false "reporting currency, the configured one by default"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
@@ -147,10 +159,16 @@ func (h *Handler) GetListReportStaff(c *gin.Context) {
 		return
 	}
 
+	currency := c.Query("currency")
+	if len(currency) <= 0 {
+		currency = h.cfg.ReportingCurrency
+	}
+
 	resp, err := h.storages.Staff().GetListReport(context.Background(), &models.GetListReportStaffRequest{
-		Offset: offset,
-		Limit:  limit,
-		Search: c.Query("search"),
+		Offset:   offset,
+		Limit:    limit,
+		Search:   c.Query("search"),
+		Currency: currency,
 	})
 	if err != nil {
 		fmt.Println("xato")
@@ -171,8 +189,13 @@ func (h *Handler) GetListReportStaff(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param staff body models.UpdateStaff true "UpdateStaffRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdateStaff(c *gin.Context) {
 
@@ -192,7 +215,13 @@ func (h *Handler) UpdateStaff(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update staff")
+	if !ok {
+		return
+	}
+
 	updateStaff.StaffId = idInt
+	updateStaff.Version = version
 
 	rowsAffected, err := h.storages.Staff().UpdatePut(context.Background(), &updateStaff)
 	if err != nil {
@@ -201,7 +230,8 @@ func (h *Handler) UpdateStaff(c *gin.Context) {
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.staff.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Staff().GetByID(context.Background(), &models.StaffPrimaryKey{StaffId: idInt})
+		h.notWritten(c, "storage.staff.update", err)
 		return
 	}
 
@@ -211,6 +241,8 @@ func (h *Handler) UpdateStaff(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update staff", http.StatusAccepted, resp)
 }
 
@@ -223,9 +255,14 @@ func (h *Handler) UpdateStaff(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
-// @Param staff body models.PatchRequest true "UpdatePatchRequest"
+// @Param staff body object true "JSON Merge Patch (RFC 7396)"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdatePatchStaff(c *gin.Context) {
 
@@ -233,7 +270,7 @@ func (h *Handler) UpdatePatchStaff(c *gin.Context) {
 
 	id := c.Param("id")
 
-	err := c.ShouldBindJSON(&obj)
+	err := c.ShouldBindJSON(&obj.Fields)
 	if err != nil {
 		h.handlerResponse(c, "update staff", http.StatusBadRequest, err.Error())
 		return
@@ -245,16 +282,23 @@ func (h *Handler) UpdatePatchStaff(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update staff")
+	if !ok {
+		return
+	}
+
 	obj.ID = idInt
+	obj.Version = version
 
 	rowsAffected, err := h.storages.Staff().UpdatePatch(context.Background(), &obj)
 	if err != nil {
-		h.handlerResponse(c, "storage.staff.update", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.staff.update", http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.staff.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Staff().GetByID(context.Background(), &models.StaffPrimaryKey{StaffId: idInt})
+		h.notWritten(c, "storage.staff.update", err)
 		return
 	}
 
@@ -264,6 +308,8 @@ func (h *Handler) UpdatePatchStaff(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update staff", http.StatusAccepted, resp)
 }
 
@@ -271,14 +317,18 @@ func (h *Handler) UpdatePatchStaff(c *gin.Context) {
 // @ID delete_staff
 // @Router /staff/{id} [DELETE]
 // @Summary Delete Staff
-// @Description Delete Staff
+// @Description Soft delete the staff, it is hidden from lists and can be restored
 // @Tags Staff
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
 // @Param staff body models.StaffPrimaryKey true "DeleteStaffRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 204 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) DeleteStaff(c *gin.Context) {
 
@@ -290,16 +340,61 @@ func (h *Handler) DeleteStaff(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, err := h.storages.Staff().Delete(context.Background(), &models.StaffPrimaryKey{StaffId: idInt})
+	version, ok := h.getIfMatch(c, "delete staff")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.Staff().Delete(context.Background(), &models.StaffPrimaryKey{StaffId: idInt, Version: version})
 	if err != nil {
 		h.handlerResponse(c, "storage.staff.delete", http.StatusInternalServerError, err.Error())
 		return
 	}
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.staff.delete", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Staff().GetByID(context.Background(), &models.StaffPrimaryKey{StaffId: idInt})
+		h.notWritten(c, "storage.staff.delete", err)
 		return
 	}
 
 	h.handlerResponse(c, "delete staff", http.StatusNoContent, nil)
 }
 
+// Restore Staff godoc
+// @ID restore_staff
+// @Router /staff/{id}/restore [POST]
+// @Summary Restore Staff
+// @Description Bring back a soft deleted staff
+// @Tags Staff
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Staff} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreStaff(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.staff.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	rowsAffected, err := h.storages.Staff().Restore(context.Background(), &models.StaffPrimaryKey{StaffId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.staff.restore", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "storage.staff.restore", http.StatusBadRequest, "now rows affected")
+		return
+	}
+
+	resp, err := h.storages.Staff().GetByID(context.Background(), &models.StaffPrimaryKey{StaffId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.staff.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "restore staff", http.StatusOK, resp)
+}
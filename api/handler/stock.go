@@ -3,6 +3,8 @@ package handler
 import (
 	"app/api/models"
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -124,14 +126,19 @@ func (h *Handler) GetListStock(c *gin.Context) {
 // @ID update_stock
 // @Router /stock/{id} [PUT]
 // @Summary Update Stock
-// @Description Update Stock
+// @Description Update the quantity of a variant in the store
 // @Tags Stock
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
 // @Param stock body models.UpdateStock true "UpdateStockRequest"
-// @Success 202 {object} Response{data=string} "Success Request"
+// @Param If-Match header string true "ETag of the stock row as last read"
+// @Success 202 {object} Response{data=models.ProductData} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdateStock(c *gin.Context) {
 
@@ -151,25 +158,104 @@ func (h *Handler) UpdateStock(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update stock")
+	if !ok {
+		return
+	}
+
 	updateStock.StoreId = idInt
+	updateStock.Version = version
+
+	key := &models.StockPrimaryKey{StoreId: idInt, ProductId: updateStock.ProductId, VariantId: updateStock.VariantId}
 
 	rowsAffected, err := h.storages.Stock().Update(context.Background(), &updateStock)
 	if err != nil {
-		h.handlerResponse(c, "storage.stock.update", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.stock.update", http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.stock.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Stock().GetItem(context.Background(), key)
+		h.notWritten(c, "storage.stock.update", err)
 		return
 	}
 
-	resp, err := h.storages.Stock().GetByID(context.Background(), &models.StockPrimaryKey{StoreId: idInt})
+	resp, err := h.storages.Stock().GetItem(context.Background(), key)
 	if err != nil {
-		h.handlerResponse(c, "storage.stock.getByID", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.stock.getItem", http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update stock", http.StatusAccepted, resp)
+}
+
+// Update PATCH Stock godoc
+// @ID update_patch_stock
+// @Router /stock/{id} [PATCH]
+// @Summary Update PATCH Stock
+// @Description Update quantity, reorder point or target level of a product in the store by a JSON Merge Patch
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param id path string true "store id"
+// @Param product_id query string false "product_id, enough for a product sold as a single variant"
+// @Param variant_id query string false "variant_id"
+// @Param stock body object true "JSON Merge Patch (RFC 7396)"
+// @Param If-Match header string true "ETag of the stock row as last read"
+// @Success 202 {object} Response{data=models.ProductData} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdatePatchStock(c *gin.Context) {
+
+	var obj models.PatchStockRequest
+
+	err := c.ShouldBindJSON(&obj.Fields)
+	if err != nil {
+		h.handlerResponse(c, "update stock", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, ok := h.stockKey(c, "update stock")
+	if !ok {
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "update stock")
+	if !ok {
+		return
+	}
+
+	obj.StoreId = key.StoreId
+	obj.ProductId = key.ProductId
+	obj.VariantId = key.VariantId
+	obj.Version = version
+
+	rowsAffected, err := h.storages.Stock().UpdatePatch(context.Background(), &obj)
+	if err != nil {
+		h.handlerResponse(c, "storage.stock.update", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.Stock().GetItem(context.Background(), key)
+		h.notWritten(c, "storage.stock.update", err)
+		return
+	}
+
+	resp, err := h.storages.Stock().GetItem(context.Background(), key)
+	if err != nil {
+		h.handlerResponse(c, "storage.stock.getItem", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update stock", http.StatusAccepted, resp)
 }
 
@@ -177,32 +263,42 @@ func (h *Handler) UpdateStock(c *gin.Context) {
 // @ID delete_stock
 // @Router /stock/{id} [DELETE]
 // @Summary Delete Stock
-// @Description Delete Stock
+// @Description Delete the stock of a variant in the store
 // @Tags Stock
 // @Accept json
 // @Produce json
-// @Param id path string true "id"
-// @Param stock body models.StockPrimaryKey true "DeleteStockRequest"
+// @Param id path string true "store id"
+// @Param product_id query string false "product_id, enough for a product sold as a single variant"
+// @Param variant_id query string false "variant_id"
+// @Param If-Match header string true "ETag of the stock row as last read"
 // @Success 204 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) DeleteStock(c *gin.Context) {
 
-	id := c.Param("id")
+	key, ok := h.stockKey(c, "delete stock")
+	if !ok {
+		return
+	}
 
-	idInt, err := strconv.Atoi(id)
-	if err != nil {
-		h.handlerResponse(c, "storage.stock.getByID", http.StatusBadRequest, "id incorrect")
+	version, ok := h.getIfMatch(c, "delete stock")
+	if !ok {
 		return
 	}
 
-	rowsAffected, err := h.storages.Stock().Delete(context.Background(), &models.StockPrimaryKey{StoreId: idInt})
+	key.Version = version
+
+	rowsAffected, err := h.storages.Stock().Delete(context.Background(), key)
 	if err != nil {
 		h.handlerResponse(c, "storage.stock.delete", http.StatusInternalServerError, err.Error())
 		return
 	}
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.stock.delete", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Stock().GetItem(context.Background(), key)
+		h.notWritten(c, "storage.stock.delete", err)
 		return
 	}
 
@@ -238,3 +334,261 @@ func (h *Handler) SendProductToStore(c *gin.Context) {
 
 	h.handlerResponse(c, "Get store by id", http.StatusOK, "Success")
 }
+
+// Update Stock Threshold godoc
+// @ID update_stock_threshold
+// @Router /stock/threshold [PUT]
+// @Summary Update Stock Threshold
+// @Description Set reorder point and target level of a product in a store
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param threshold body models.StockThreshold true "StockThresholdRequest"
+// @Success 202 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateStockThreshold(c *gin.Context) {
+	var threshold models.StockThreshold
+
+	err := c.ShouldBindJSON(&threshold)
+	if err != nil {
+		h.handlerResponse(c, "update stock threshold", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rowsAffected, err := h.storages.Stock().UpdateThreshold(context.Background(), &threshold)
+	if err != nil {
+		h.handlerResponse(c, "storage.stock.update_threshold", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "storage.stock.update_threshold", http.StatusBadRequest, "now rows affected")
+		return
+	}
+
+	h.handlerResponse(c, "update stock threshold", http.StatusAccepted, "Success")
+}
+
+// Get List Low Stock godoc
+// @ID get_list_low_stock
+// @Router /stock/low [GET]
+// @Summary Get List Low Stock
+// @Description Stocks at or below their reorder point across stores
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param store_id query string false "store_id"
+// @Success 200 {object} Response{data=models.GetListLowStockResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListLowStock(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get list low stock", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get list low stock", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	storeId, err := h.getIntQuery(c.Query("store_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list low stock", http.StatusBadRequest, "invalid store_id")
+		return
+	}
+
+	resp, err := h.storages.Stock().GetListLow(context.Background(), &models.GetListLowStockRequest{
+		Offset:  offset,
+		Limit:   limit,
+		StoreId: storeId,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.stock.getlistlow", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list low stock response", http.StatusOK, resp)
+}
+
+// Get Reorder Suggestion godoc
+// @ID get_reorder_suggestion
+// @Router /stock/reorder_suggestion [GET]
+// @Summary Get Reorder Suggestion
+// @Description Suggested reorder quantities computed from recent sales velocity
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param store_id query string false "store_id"
+// @Param sales_days query string false "sales_days"
+// @Param cover_days query string false "cover_days"
+// @Success 200 {object} Response{data=models.ReorderSuggestionResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetReorderSuggestion(c *gin.Context) {
+
+	req, err := h.getReorderSuggestionQuery(c)
+	if err != nil {
+		h.handlerResponse(c, "get reorder suggestion", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Stock().ReorderSuggestion(context.Background(), req)
+	if err != nil {
+		h.handlerResponse(c, "storage.stock.reorder_suggestion", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get reorder suggestion", http.StatusOK, resp)
+}
+
+// Create Reorder Purchase Order godoc
+// @ID create_reorder_purchase_order
+// @Router /stock/reorder_suggestion/purchase_order [POST]
+// @Summary Create Reorder Purchase Order
+// @Description Generate draft purchase orders, one per supplier, from the reorder suggestion
+// @Tags Stock
+// @Accept json
+// @Produce json
+// @Param reorder body models.ReorderSuggestionRequest true "ReorderSuggestionRequest"
+// @Success 201 {object} Response{data=models.ReorderSuggestionResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateReorderPurchaseOrder(c *gin.Context) {
+	var req models.ReorderSuggestionRequest
+
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		h.handlerResponse(c, "create reorder purchase order", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.SalesDays <= 0 {
+		req.SalesDays = h.cfg.ReorderSalesDays
+	}
+
+	if req.CoverDays <= 0 {
+		req.CoverDays = h.cfg.ReorderCoverDays
+	}
+
+	resp, err := h.storages.Stock().ReorderSuggestion(context.Background(), &req)
+	if err != nil {
+		h.handlerResponse(c, "storage.stock.reorder_suggestion", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var (
+		keys           []string
+		purchaseOrders = map[string]*models.CreatePurchaseOrder{}
+	)
+
+	for _, suggestion := range resp.Suggestions {
+		// products whose brand has no supplier stay in the suggestion for manual ordering
+		if suggestion.SupplierId <= 0 {
+			continue
+		}
+
+		// one purchase order per supplier and currency the goods were last bought in
+		key := fmt.Sprintf("%d/%s", suggestion.SupplierId, suggestion.LastCostPrice.Currency)
+
+		purchaseOrder, ok := purchaseOrders[key]
+		if !ok {
+			purchaseOrder = &models.CreatePurchaseOrder{
+				SupplierId: suggestion.SupplierId,
+				Currency:   suggestion.LastCostPrice.Currency,
+				Note:       "generated from reorder suggestion",
+			}
+			purchaseOrders[key] = purchaseOrder
+			keys = append(keys, key)
+		}
+
+		purchaseOrder.Items = append(purchaseOrder.Items, &models.CreatePurchaseOrderItem{
+			ProductId: suggestion.ProductId,
+			VariantId: suggestion.VariantId,
+			StoreId:   suggestion.StoreId,
+			Quantity:  suggestion.SuggestedQuantity,
+			CostPrice: suggestion.LastCostPrice,
+		})
+	}
+
+	for _, key := range keys {
+		id, err := h.storages.PurchaseOrder().Create(context.Background(), purchaseOrders[key])
+		if err != nil {
+			h.handlerResponse(c, "storage.purchase_order.create", http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		resp.PurchaseOrderIds = append(resp.PurchaseOrderIds, id)
+	}
+
+	h.handlerResponse(c, "create reorder purchase order", http.StatusCreated, resp)
+}
+
+// stockKey is the stock row of a variant in the store, from the store id in the path and
+// the product_id or variant_id query.
+func (h *Handler) stockKey(c *gin.Context, path string) (*models.StockPrimaryKey, bool) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, path, http.StatusBadRequest, "id incorrect")
+		return nil, false
+	}
+
+	productId, err := h.getIntQuery(c.Query("product_id"))
+	if err != nil || productId < 0 {
+		h.handlerResponse(c, path, http.StatusBadRequest, "invalid product_id")
+		return nil, false
+	}
+
+	variantId, err := h.getIntQuery(c.Query("variant_id"))
+	if err != nil || variantId < 0 {
+		h.handlerResponse(c, path, http.StatusBadRequest, "invalid variant_id")
+		return nil, false
+	}
+
+	if productId == 0 && variantId == 0 {
+		h.handlerResponse(c, path, http.StatusBadRequest, "product_id or variant_id is required")
+		return nil, false
+	}
+
+	return &models.StockPrimaryKey{StoreId: idInt, ProductId: productId, VariantId: variantId}, true
+}
+
+func (h *Handler) getReorderSuggestionQuery(c *gin.Context) (*models.ReorderSuggestionRequest, error) {
+	var req models.ReorderSuggestionRequest
+
+	storeId, err := h.getIntQuery(c.Query("store_id"))
+	if err != nil {
+		return nil, errors.New("invalid store_id")
+	}
+
+	salesDays, err := h.getIntQuery(c.Query("sales_days"))
+	if err != nil {
+		return nil, errors.New("invalid sales_days")
+	}
+
+	coverDays, err := h.getIntQuery(c.Query("cover_days"))
+	if err != nil {
+		return nil, errors.New("invalid cover_days")
+	}
+
+	if salesDays <= 0 {
+		salesDays = h.cfg.ReorderSalesDays
+	}
+
+	if coverDays <= 0 {
+		coverDays = h.cfg.ReorderCoverDays
+	}
+
+	req.StoreId = storeId
+	req.SalesDays = salesDays
+	req.CoverDays = coverDays
+
+	return &req, nil
+}
@@ -56,6 +56,7 @@ func (h *Handler) CreateStore(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Success 200 {object} Response{data=string} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) GetByIdStore(c *gin.Context) {
@@ -74,6 +75,8 @@ func (h *Handler) GetByIdStore(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "get store by id", http.StatusCreated, resp)
 }
 
@@ -88,6 +91,7 @@ func (h *Handler) GetByIdStore(c *gin.Context) {
 // @Param offset query string false "offset"
 // @Param limit query string false "limit"
 // @Param search query string false "search"
+// @Param include_deleted query string false "include_deleted"
 // @Success 200 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server Error"
@@ -105,10 +109,17 @@ func (h *Handler) GetListStore(c *gin.Context) {
 		return
 	}
 
+	includeDeleted, err := h.getBoolQuery(c.Query("include_deleted"))
+	if err != nil {
+		h.handlerResponse(c, "get list store", http.StatusBadRequest, "invalid include_deleted")
+		return
+	}
+
 	resp, err := h.storages.Store().GetList(context.Background(), &models.GetListStoreRequest{
-		Offset: offset,
-		Limit:  limit,
-		Search: c.Query("search"),
+		Offset:         offset,
+		Limit:          limit,
+		Search:         c.Query("search"),
+		IncludeDeleted: includeDeleted,
 	})
 	if err != nil {
 		h.handlerResponse(c, "storage.store.getlist", http.StatusInternalServerError, err.Error())
@@ -128,8 +139,13 @@ func (h *Handler) GetListStore(c *gin.Context) {
 // @Produce json
 // @Param id path string true "id"
 // @Param store body models.UpdateStore true "UpdateStoreRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdateStore(c *gin.Context) {
 
@@ -149,7 +165,13 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update store")
+	if !ok {
+		return
+	}
+
 	updateStore.StoreId = idInt
+	updateStore.Version = version
 
 	rowsAffected, err := h.storages.Store().UpdatePut(context.Background(), &updateStore)
 	if err != nil {
@@ -158,7 +180,8 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.store.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Store().GetByID(context.Background(), &models.StorePrimaryKey{StoreId: idInt})
+		h.notWritten(c, "storage.store.update", err)
 		return
 	}
 
@@ -168,6 +191,8 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update store", http.StatusAccepted, resp)
 }
 
@@ -180,9 +205,14 @@ func (h *Handler) UpdateStore(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
-// @Param store body models.PatchRequest true "UpdatePatchRequest"
+// @Param store body object true "JSON Merge Patch (RFC 7396)"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 202 {object} Response{data=string} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) UpdatePatchStore(c *gin.Context) {
 
@@ -190,7 +220,7 @@ func (h *Handler) UpdatePatchStore(c *gin.Context) {
 
 	id := c.Param("id")
 
-	err := c.ShouldBindJSON(&obj)
+	err := c.ShouldBindJSON(&obj.Fields)
 	if err != nil {
 		h.handlerResponse(c, "update store", http.StatusBadRequest, err.Error())
 		return
@@ -202,16 +232,23 @@ func (h *Handler) UpdatePatchStore(c *gin.Context) {
 		return
 	}
 
+	version, ok := h.getIfMatch(c, "update store")
+	if !ok {
+		return
+	}
+
 	obj.ID = idInt
+	obj.Version = version
 
 	rowsAffected, err := h.storages.Store().UpdatePatch(context.Background(), &obj)
 	if err != nil {
-		h.handlerResponse(c, "storage.store.update", http.StatusInternalServerError, err.Error())
+		h.handlerResponse(c, "storage.store.update", http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.store.update", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Store().GetByID(context.Background(), &models.StorePrimaryKey{StoreId: idInt})
+		h.notWritten(c, "storage.store.update", err)
 		return
 	}
 
@@ -221,6 +258,8 @@ func (h *Handler) UpdatePatchStore(c *gin.Context) {
 		return
 	}
 
+	h.setETag(c, resp.Version)
+
 	h.handlerResponse(c, "update store", http.StatusAccepted, resp)
 }
 
@@ -228,14 +267,18 @@ func (h *Handler) UpdatePatchStore(c *gin.Context) {
 // @ID delete_store
 // @Router /store/{id} [DELETE]
 // @Summary Delete Store
-// @Description Delete Store
+// @Description Soft delete the store, it is hidden from lists and can be restored
 // @Tags Store
 // @Accept json
 // @Produce json
 // @Param id path string true "id"
 // @Param store body models.StorePrimaryKey true "DeleteStoreRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
 // @Success 204 {object} Response{data=string} "Success Request"
 // @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
 // @Failure 500 {object} Response{data=string} "Server Error"
 func (h *Handler) DeleteStore(c *gin.Context) {
 
@@ -247,15 +290,61 @@ func (h *Handler) DeleteStore(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, err := h.storages.Store().Delete(context.Background(), &models.StorePrimaryKey{StoreId: idInt})
+	version, ok := h.getIfMatch(c, "delete store")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.Store().Delete(context.Background(), &models.StorePrimaryKey{StoreId: idInt, Version: version})
 	if err != nil {
 		h.handlerResponse(c, "storage.store.delete", http.StatusInternalServerError, err.Error())
 		return
 	}
 	if rowsAffected <= 0 {
-		h.handlerResponse(c, "storage.store.delete", http.StatusBadRequest, "now rows affected")
+		_, err = h.storages.Store().GetByID(context.Background(), &models.StorePrimaryKey{StoreId: idInt})
+		h.notWritten(c, "storage.store.delete", err)
 		return
 	}
 
 	h.handlerResponse(c, "delete store", http.StatusNoContent, nil)
 }
+
+// Restore Store godoc
+// @ID restore_store
+// @Router /store/{id}/restore [POST]
+// @Summary Restore Store
+// @Description Bring back a soft deleted store
+// @Tags Store
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Store} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) RestoreStore(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "storage.store.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	rowsAffected, err := h.storages.Store().Restore(context.Background(), &models.StorePrimaryKey{StoreId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.store.restore", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		h.handlerResponse(c, "storage.store.restore", http.StatusBadRequest, "now rows affected")
+		return
+	}
+
+	resp, err := h.storages.Store().GetByID(context.Background(), &models.StorePrimaryKey{StoreId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.store.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "restore store", http.StatusOK, resp)
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Supplier godoc
+// @ID create_supplier
+// @Router /supplier [POST]
+// @Summary Create Supplier
+// @Description Create Supplier
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param supplier body models.CreateSupplier true "CreateSupplierRequest"
+// @Success 201 {object} Response{data=models.Supplier} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateSupplier(c *gin.Context) {
+
+	var createSupplier models.CreateSupplier
+
+	err := c.ShouldBindJSON(&createSupplier) // parse req body to given type struct
+	if err != nil {
+		h.handlerResponse(c, "create supplier", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.storages.Supplier().Create(context.Background(), &createSupplier)
+	if err != nil {
+		h.handlerResponse(c, "storage.supplier.create", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp, err := h.storages.Supplier().GetByID(context.Background(), &models.SupplierPrimaryKey{SupplierId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.supplier.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create supplier", http.StatusCreated, resp)
+}
+
+// Get By ID Supplier godoc
+// @ID get_by_id_supplier
+// @Router /supplier/{id} [GET]
+// @Summary Get By ID Supplier
+// @Description Get By ID Supplier
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.Supplier} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdSupplier(c *gin.Context) {
+
+	id := c.Param("id")
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.supplier.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	resp, err := h.storages.Supplier().GetByID(context.Background(), &models.SupplierPrimaryKey{SupplierId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.supplier.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "get supplier by id", http.StatusOK, resp)
+}
+
+// Get List Supplier godoc
+// @ID get_list_supplier
+// @Router /supplier [GET]
+// @Summary Get List Supplier
+// @Description Get List Supplier
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param offset query string false "offset"
+// @Param limit query string false "limit"
+// @Param search query string false "search"
+// @Param brand_id query string false "brand_id"
+// @Success 200 {object} Response{data=models.GetListSupplierResponse} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListSupplier(c *gin.Context) {
+
+	offset, err := h.getOffsetQuery(c.Query("offset"))
+	if err != nil {
+		h.handlerResponse(c, "get list supplier", http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	limit, err := h.getLimitQuery(c.Query("limit"))
+	if err != nil {
+		h.handlerResponse(c, "get list supplier", http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	brandId, err := h.getIntQuery(c.Query("brand_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list supplier", http.StatusBadRequest, "invalid brand_id")
+		return
+	}
+
+	resp, err := h.storages.Supplier().GetList(context.Background(), &models.GetListSupplierRequest{
+		Offset:  offset,
+		Limit:   limit,
+		Search:  c.Query("search"),
+		BrandId: brandId,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.supplier.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list supplier response", http.StatusOK, resp)
+}
+
+// Update Supplier godoc
+// @ID update_supplier
+// @Router /supplier/{id} [PUT]
+// @Summary Update Supplier
+// @Description Update Supplier
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param supplier body models.UpdateSupplier true "UpdateSupplierRequest"
+// @Param If-Match header string true "ETag of the entity as last read"
+// @Success 202 {object} Response{data=models.Supplier} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateSupplier(c *gin.Context) {
+
+	var updateSupplier models.UpdateSupplier
+
+	id := c.Param("id")
+
+	err := c.ShouldBindJSON(&updateSupplier)
+	if err != nil {
+		h.handlerResponse(c, "update supplier", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.supplier.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	updateSupplier.SupplierId = idInt
+
+	version, ok := h.getIfMatch(c, "update supplier")
+	if !ok {
+		return
+	}
+
+	updateSupplier.Version = version
+
+	rowsAffected, err := h.storages.Supplier().Update(context.Background(), &updateSupplier)
+	if err != nil {
+		h.handlerResponse(c, "storage.supplier.update", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.Supplier().GetByID(context.Background(), &models.SupplierPrimaryKey{SupplierId: idInt})
+		h.notWritten(c, "storage.supplier.update", err)
+		return
+	}
+
+	resp, err := h.storages.Supplier().GetByID(context.Background(), &models.SupplierPrimaryKey{SupplierId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.supplier.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update supplier", http.StatusAccepted, resp)
+}
+
+// DELETE Supplier godoc
+// @ID delete_supplier
+// @Router /supplier/{id} [DELETE]
+// @Summary Delete Supplier
+// @Description Delete Supplier
+// @Tags Supplier
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param If-Match header string true "ETag of the entity as last read"
+// @Success 204 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeleteSupplier(c *gin.Context) {
+
+	id := c.Param("id")
+
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		h.handlerResponse(c, "storage.supplier.getByID", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "delete supplier")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.Supplier().Delete(context.Background(), &models.SupplierPrimaryKey{SupplierId: idInt, Version: version})
+	if err != nil {
+		h.handlerResponse(c, "storage.supplier.delete", http.StatusInternalServerError, err.Error())
+		return
+	}
+	if rowsAffected <= 0 {
+		_, err = h.storages.Supplier().GetByID(context.Background(), &models.SupplierPrimaryKey{SupplierId: idInt})
+		h.notWritten(c, "storage.supplier.delete", err)
+		return
+	}
+
+	h.handlerResponse(c, "delete supplier", http.StatusNoContent, nil)
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"app/api/models"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Create Tax Rate godoc
+// @ID create_tax_rate
+// @Router /tax_rate [POST]
+// @Summary Create Tax Rate
+// @Description Create a tax rate for the stores of a state or one store and for one category, an empty state and a 0 id match everything. The rate is a fraction, 0.08 for 8%
+// @Tags Tax
+// @Accept json
+// @Produce json
+// @Param tax_rate body models.CreateTaxRate true "CreateTaxRateRequest"
+// @Success 201 {object} Response{data=models.TaxRate} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) CreateTaxRate(c *gin.Context) {
+
+	var createTaxRate models.CreateTaxRate
+
+	err := c.ShouldBindJSON(&createTaxRate)
+	if err != nil {
+		h.handlerResponse(c, "create tax rate", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, err := h.storages.TaxRate().Create(context.Background(), &createTaxRate)
+	if err != nil {
+		h.handlerResponse(c, "storage.tax_rate.create", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.storages.TaxRate().GetByID(context.Background(), &models.TaxRatePrimaryKey{TaxRateId: id})
+	if err != nil {
+		h.handlerResponse(c, "storage.tax_rate.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "create tax rate", http.StatusCreated, resp)
+}
+
+// Get List Tax Rate godoc
+// @ID get_list_tax_rate
+// @Router /tax_rate [GET]
+// @Summary Get List Tax Rate
+// @Description Get List Tax Rate
+// @Tags Tax
+// @Accept json
+// @Produce json
+// @Param state query string false "state"
+// @Param store_id query string false "store_id"
+// @Param category_id query string false "category_id"
+// @Success 200 {object} Response{data=[]models.TaxRate} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetListTaxRate(c *gin.Context) {
+
+	storeId, err := h.getIntQuery(c.Query("store_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list tax rate", http.StatusBadRequest, "invalid store_id")
+		return
+	}
+
+	categoryId, err := h.getIntQuery(c.Query("category_id"))
+	if err != nil {
+		h.handlerResponse(c, "get list tax rate", http.StatusBadRequest, "invalid category_id")
+		return
+	}
+
+	resp, err := h.storages.TaxRate().GetList(context.Background(), &models.GetListTaxRateRequest{
+		State:      c.Query("state"),
+		StoreId:    storeId,
+		CategoryId: categoryId,
+	})
+	if err != nil {
+		h.handlerResponse(c, "storage.tax_rate.getlist", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.handlerResponse(c, "get list tax rate response", http.StatusOK, resp)
+}
+
+// Get By ID Tax Rate godoc
+// @ID get_by_id_tax_rate
+// @Router /tax_rate/{id} [GET]
+// @Summary Get By ID Tax Rate
+// @Description Get By ID Tax Rate
+// @Tags Tax
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} Response{data=models.TaxRate} "Success Request"
+// @Header 200 {string} ETag "version of the entity, send it back in If-Match"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) GetByIdTaxRate(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "get tax rate", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	resp, err := h.storages.TaxRate().GetByID(context.Background(), &models.TaxRatePrimaryKey{TaxRateId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.tax_rate.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "get tax rate", http.StatusOK, resp)
+}
+
+// Update Tax Rate godoc
+// @ID update_tax_rate
+// @Router /tax_rate/{id} [PUT]
+// @Summary Update Tax Rate
+// @Description Update Tax Rate, lines already on orders keep the rate they were added with
+// @Tags Tax
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param tax_rate body models.UpdateTaxRate true "UpdateTaxRateRequest"
+// @Param If-Match header string true "ETag of the tax rate as last read"
+// @Success 202 {object} Response{data=models.TaxRate} "Success Request"
+// @Header 202 {string} ETag "new version of the entity"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) UpdateTaxRate(c *gin.Context) {
+
+	var updateTaxRate models.UpdateTaxRate
+
+	err := c.ShouldBindJSON(&updateTaxRate)
+	if err != nil {
+		h.handlerResponse(c, "update tax rate", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "update tax rate", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	updateTaxRate.TaxRateId = idInt
+
+	version, ok := h.getIfMatch(c, "update tax rate")
+	if !ok {
+		return
+	}
+
+	updateTaxRate.Version = version
+
+	rowsAffected, err := h.storages.TaxRate().Update(context.Background(), &updateTaxRate)
+	if err != nil {
+		h.handlerResponse(c, "storage.tax_rate.update", http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.TaxRate().GetByID(context.Background(), &models.TaxRatePrimaryKey{TaxRateId: idInt})
+		h.notWritten(c, "storage.tax_rate.update", err)
+		return
+	}
+
+	resp, err := h.storages.TaxRate().GetByID(context.Background(), &models.TaxRatePrimaryKey{TaxRateId: idInt})
+	if err != nil {
+		h.handlerResponse(c, "storage.tax_rate.getByID", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.setETag(c, resp.Version)
+
+	h.handlerResponse(c, "update tax rate", http.StatusAccepted, resp)
+}
+
+// Delete Tax Rate godoc
+// @ID delete_tax_rate
+// @Router /tax_rate/{id} [DELETE]
+// @Summary Delete Tax Rate
+// @Description Delete Tax Rate
+// @Tags Tax
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param If-Match header string true "ETag of the tax rate as last read"
+// @Success 204 {object} Response{data=string} "Success Request"
+// @Response 400 {object} Response{data=string} "Bad Request"
+// @Response 404 {object} Response{data=string} "Not Found"
+// @Response 412 {object} Response{data=string} "Precondition Failed"
+// @Response 428 {object} Response{data=string} "Precondition Required"
+// @Failure 500 {object} Response{data=string} "Server Error"
+func (h *Handler) DeleteTaxRate(c *gin.Context) {
+
+	idInt, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		h.handlerResponse(c, "delete tax rate", http.StatusBadRequest, "id incorrect")
+		return
+	}
+
+	version, ok := h.getIfMatch(c, "delete tax rate")
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.storages.TaxRate().Delete(context.Background(), &models.TaxRatePrimaryKey{TaxRateId: idInt, Version: version})
+	if err != nil {
+		h.handlerResponse(c, "storage.tax_rate.delete", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if rowsAffected <= 0 {
+		_, err = h.storages.TaxRate().GetByID(context.Background(), &models.TaxRatePrimaryKey{TaxRateId: idInt})
+		h.notWritten(c, "storage.tax_rate.delete", err)
+		return
+	}
+
+	h.handlerResponse(c, "delete tax rate", http.StatusNoContent, nil)
+}
@@ -0,0 +1,99 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+const (
+	AttributeTypeText    = "text"
+	AttributeTypeInteger = "integer"
+	AttributeTypeDecimal = "decimal"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeEnum    = "enum"
+)
+
+const (
+	AttributeFilterEqual = "eq"
+	AttributeFilterMin   = "min"
+	AttributeFilterMax   = "max"
+)
+
+// AttributeDefinition is a specification products of the category are described by. Min and
+// max bound integer and decimal values, AllowedValues lists the options of an enum.
+type AttributeDefinition struct {
+	AttributeId   int                 `json:"attribute_id"`
+	CategoryId    int                 `json:"category_id"`
+	Code          string              `json:"code"`
+	AttributeName string              `json:"attribute_name"`
+	DataType      string              `json:"data_type"`
+	Unit          string              `json:"unit"`
+	Required      bool                `json:"required"`
+	MinValue      decimal.NullDecimal `json:"min_value" swaggertype:"string"`
+	MaxValue      decimal.NullDecimal `json:"max_value" swaggertype:"string"`
+	AllowedValues []string            `json:"allowed_values"`
+	Version       int                 `json:"version"`
+}
+
+type AttributeDefinitionPrimaryKey struct {
+	CategoryId  int `json:"category_id"`
+	AttributeId int `json:"attribute_id"`
+	Version     int `json:"-"`
+}
+
+type CreateAttributeDefinition struct {
+	CategoryId    int                 `json:"category_id"`
+	Code          string              `json:"code"`
+	AttributeName string              `json:"attribute_name"`
+	DataType      string              `json:"data_type"`
+	Unit          string              `json:"unit"`
+	Required      bool                `json:"required"`
+	MinValue      decimal.NullDecimal `json:"min_value" swaggertype:"string"`
+	MaxValue      decimal.NullDecimal `json:"max_value" swaggertype:"string"`
+	AllowedValues []string            `json:"allowed_values"`
+}
+
+type UpdateAttributeDefinition struct {
+	AttributeId   int                 `json:"attribute_id"`
+	CategoryId    int                 `json:"category_id"`
+	Code          string              `json:"code"`
+	AttributeName string              `json:"attribute_name"`
+	DataType      string              `json:"data_type"`
+	Unit          string              `json:"unit"`
+	Required      bool                `json:"required"`
+	MinValue      decimal.NullDecimal `json:"min_value" swaggertype:"string"`
+	MaxValue      decimal.NullDecimal `json:"max_value" swaggertype:"string"`
+	AllowedValues []string            `json:"allowed_values"`
+	Version       int                 `json:"-"`
+}
+
+type GetListAttributeDefinitionRequest struct {
+	CategoryId int `json:"category_id"`
+}
+
+type GetListAttributeDefinitionResponse struct {
+	Count      int                    `json:"count"`
+	Attributes []*AttributeDefinition `json:"attributes"`
+}
+
+// ProductAttribute is the value of a product for one attribute of its category, Value is a
+// string, number or boolean depending on the data type.
+type ProductAttribute struct {
+	AttributeId   int         `json:"attribute_id"`
+	Code          string      `json:"code"`
+	AttributeName string      `json:"attribute_name"`
+	DataType      string      `json:"data_type"`
+	Unit          string      `json:"unit"`
+	Value         interface{} `json:"value"`
+}
+
+// SetProductAttributes replaces the attribute values of the product, keyed by attribute code.
+type SetProductAttributes struct {
+	ProductId int                    `json:"product_id"`
+	Values    map[string]interface{} `json:"values"`
+}
+
+// AttributeFilter keeps products whose value of the attribute equals Value or, for numbers,
+// is at least or at most Value.
+type AttributeFilter struct {
+	Code  string `json:"code"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
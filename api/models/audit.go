@@ -0,0 +1,56 @@
+package models
+
+import "encoding/json"
+
+// AuditActionPriceOverride is written next to the record of the request for every order line
+// whose price a staff overrode.
+const AuditActionPriceOverride = "price_override"
+
+// AuditLog is one mutation request. Before and After only hold the top level fields the
+// request changed, Payload is the request body as sent.
+type AuditLog struct {
+	AuditId    int             `json:"audit_id"`
+	StaffId    int             `json:"staff_id"`
+	EntityType string          `json:"entity_type"`
+	EntityId   int             `json:"entity_id"`
+	Action     string          `json:"action"`
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	Status     int             `json:"status"`
+	RequestId  string          `json:"request_id"`
+	Payload    json.RawMessage `json:"payload" swaggertype:"object"`
+	Before     json.RawMessage `json:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" swaggertype:"object"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+type CreateAuditLog struct {
+	StaffId    int
+	EntityType string
+	EntityId   int
+	Action     string
+	Method     string
+	Path       string
+	Status     int
+	RequestId  string
+	Payload    []byte
+	Before     []byte
+	After      []byte
+}
+
+type GetListAuditLogRequest struct {
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`
+	StaffId    int    `json:"staff_id"`
+	EntityType string `json:"entity_type"`
+	EntityId   int    `json:"entity_id"`
+	Action     string `json:"action"`
+	RequestId  string `json:"request_id"`
+	FromDate   string `json:"from_date"`
+	ToDate     string `json:"to_date"`
+}
+
+type GetListAuditLogResponse struct {
+	Count     int         `json:"count"`
+	AuditLogs []*AuditLog `json:"audit_logs"`
+}
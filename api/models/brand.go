@@ -3,9 +3,12 @@ package models
 type Brand struct {
 	BrandId   int    `json:"brand_id"`
 	BrandName string `json:"brand_name"`
+	DeletedAt string `json:"deleted_at"`
+	Version   int    `json:"version"`
 }
 type BrandPrimaryKey struct {
 	BrandId int `json:"brand_id"`
+	Version int `json:"-"`
 }
 
 type BrandCategory struct {
@@ -19,12 +22,14 @@ type CreateBrand struct {
 type UpdateBrand struct {
 	BrandId   int    `json:"brand_id"`
 	BrandName string `json:"brand_name"`
+	Version   int    `json:"-"`
 }
 
 type GetListBrandRequest struct {
-	Offset int    `json:"offset"`
-	Limit  int    `json:"limit"`
-	Search string `json:"search"`
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+	Search         string `json:"search"`
+	IncludeDeleted bool   `json:"include_deleted"`
 }
 
 type GetListBrandResponse struct {
@@ -3,9 +3,12 @@ package models
 type Category struct {
 	CategoryId   int    `json:"category_id"`
 	CategoryName string `json:"category_name"`
+	DeletedAt    string `json:"deleted_at"`
+	Version      int    `json:"version"`
 }
 type CategoryPrimaryKey struct {
 	CategoryId int `json:"category_id"`
+	Version    int `json:"-"`
 }
 
 type CreateCategory struct {
@@ -15,12 +18,14 @@ type CreateCategory struct {
 type UpdateCategory struct {
 	CategoryId   int    `json:"category_id"`
 	CategoryName string `json:"category_name"`
+	Version      int    `json:"-"`
 }
 
 type GetListCategoryRequest struct {
-	Offset int    `json:"offset"`
-	Limit  int    `json:"limit"`
-	Search string `json:"search"`
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+	Search         string `json:"search"`
+	IncludeDeleted bool   `json:"include_deleted"`
 }
 
 type GetListCategoryResponse struct {
@@ -1,11 +1,22 @@
 package models
 
+import "github.com/shopspring/decimal"
+
+const (
+	CodeDiscountTypeFixed   = "fixed"
+	CodeDiscountTypePercent = "proced"
+)
+
+// Code discounts a percent or a fixed amount, the fixed amount and order_limit_price are in
+// its currency.
 type Code struct {
-	Code_Id         int     `json:"code_id"`
-	CodeName        string  `json:"code_name"`
-	Discount        float64 `json:"discount"`
-	DiscountType    string  `json:"discount_type"`
-	OrderLimitPrice float64 `json:"order_limit_price"`
+	Code_Id         int             `json:"code_id"`
+	CodeName        string          `json:"code_name"`
+	Discount        decimal.Decimal `json:"discount" swaggertype:"string"`
+	DiscountType    string          `json:"discount_type"`
+	OrderLimitPrice decimal.Decimal `json:"order_limit_price" swaggertype:"string"`
+	Currency        string          `json:"currency"`
+	Version         int             `json:"version"`
 }
 type Promocode struct {
 	PromocodeId     int     `json:"promocode_id"`
@@ -17,21 +28,25 @@ type Promocode struct {
 
 type CodePrimaryKey struct {
 	Code_Id int `json:"code_id"`
+	Version int `json:"-"`
 }
 
 type CreateCode struct {
-	CodeName        string  `json:"code_name"`
-	Discount        float64 `json:"discount"`
-	DiscountType    string  `json:"discount_type"`
-	OrderLimitPrice float64 `json:"order_limit_price"`
+	CodeName        string          `json:"code_name"`
+	Discount        decimal.Decimal `json:"discount" swaggertype:"string"`
+	DiscountType    string          `json:"discount_type"`
+	OrderLimitPrice decimal.Decimal `json:"order_limit_price" swaggertype:"string"`
+	Currency        string          `json:"currency"` // empty is USD
 }
 
 type UpdateCode struct {
-	Code_Id         int     `json:"code_id"`
-	CodeName        string  `json:"code_name"`
-	Discount        float64 `json:"discount"`
-	DiscountType    string  `json:"discount_type"`
-	OrderLimitPrice float64 `json:"order_limit_price"`
+	Code_Id         int             `json:"code_id"`
+	CodeName        string          `json:"code_name"`
+	Discount        decimal.Decimal `json:"discount" swaggertype:"string"`
+	DiscountType    string          `json:"discount_type"`
+	OrderLimitPrice decimal.Decimal `json:"order_limit_price" swaggertype:"string"`
+	Currency        string          `json:"currency"`
+	Version         int             `json:"-"`
 }
 
 type GetListCodeRequest struct {
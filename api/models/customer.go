@@ -1,5 +1,7 @@
 package models
 
+import "app/pkg/money"
+
 type Customer struct {
 	CustomerId int    `json:"customer_id"`
 	FirstName  string `json:"first_name"`
@@ -10,10 +12,17 @@ type Customer struct {
 	City       string `json:"city"`
 	State      string `json:"state"`
 	ZipCode    string `json:"zip_code"`
+
+	PriceListId  int    `json:"price_list_id"` // 0 is the retail price list
+	TaxExempt    bool   `json:"tax_exempt"`
+	AnonymizedAt string `json:"anonymized_at"`
+	DeletedAt    string `json:"deleted_at"`
+	Version      int    `json:"version"`
 }
 
 type CustomerPrimaryKey struct {
 	CustomerId int `json:"customer_id"`
+	Version    int `json:"-"`
 }
 
 type CreateCustomer struct {
@@ -24,7 +33,10 @@ type CreateCustomer struct {
 	Street    string `json:"street"`
 	City      string `json:"city"`
 	State     string `json:"state"`
-	ZipCode   int    `json:"zip_code"`
+	ZipCode   string `json:"zip_code"`
+
+	PriceListId int  `json:"price_list_id"`
+	TaxExempt   bool `json:"tax_exempt"`
 }
 
 type UpdateCustomer struct {
@@ -36,16 +48,192 @@ type UpdateCustomer struct {
 	Street     string `json:"street"`
 	City       string `json:"city"`
 	State      string `json:"state"`
-	ZipCode    int    `json:"zip_code"`
+	ZipCode    string `json:"zip_code"`
+	Version    int    `json:"-"`
+
+	PriceListId int  `json:"price_list_id"`
+	TaxExempt   bool `json:"tax_exempt"`
 }
 
 type GetListCustomerRequest struct {
-	Offset int    `json:"offset"`
-	Limit  int    `json:"limit"`
-	Search string `json:"search"`
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+	Search         string `json:"search"`
+	IncludeDeleted bool   `json:"include_deleted"`
 }
 
 type GetListCustomerResponse struct {
 	Count     int         `json:"count"`
 	Customers []*Customer `json:"customers"`
 }
+
+type CustomerOrder struct {
+	OrderId      int          `json:"order_id"`
+	OrderStatus  int16        `json:"order_status"`
+	OrderDate    string       `json:"order_date"`
+	RequiredDate string       `json:"required_date"`
+	ShippedDate  string       `json:"shipped_date"`
+	StoreId      int          `json:"store_id"`
+	StaffId      int          `json:"staff_id"`
+	ReturnStatus string       `json:"return_status"`
+	Items        []*OrderItem `json:"items"`
+	Amount       *OrderAmount `json:"amount"`
+}
+
+type GetListCustomerOrderRequest struct {
+	CustomerId  int   `json:"customer_id"`
+	Offset      int   `json:"offset"`
+	Limit       int   `json:"limit"`
+	OrderStatus int16 `json:"order_status"`
+}
+
+type GetListCustomerOrderResponse struct {
+	Count  int              `json:"count"`
+	Orders []*CustomerOrder `json:"orders"`
+}
+
+type GetCustomerSummaryRequest struct {
+	CustomerId int    `json:"customer_id"`
+	Currency   string `json:"currency"` // reporting currency
+}
+
+// CustomerSummary is computed from the customer's completed orders, the amounts are converted
+// to the reporting currency.
+type CustomerSummary struct {
+	CustomerId        int               `json:"customer_id"`
+	FirstPurchaseDate string            `json:"first_purchase_date"`
+	LastPurchaseDate  string            `json:"last_purchase_date"`
+	OrderCount        int               `json:"order_count"`
+	LifetimeSpend     money.Money       `json:"lifetime_spend"`
+	AverageOrderValue money.Money       `json:"average_order_value"`
+	FavoriteBrand     *CustomerFavorite `json:"favorite_brand"`
+	FavoriteCategory  *CustomerFavorite `json:"favorite_category"`
+}
+
+type CustomerFavorite struct {
+	Id       int         `json:"id"`
+	Name     string      `json:"name"`
+	Quantity int         `json:"quantity"`
+	Spend    money.Money `json:"spend"`
+}
+
+const (
+	DuplicateReasonEmail = "email"
+	DuplicateReasonPhone = "phone"
+	DuplicateReasonName  = "name_zip"
+)
+
+type DuplicateCustomerGroup struct {
+	Reasons   []string    `json:"reasons"`
+	Customers []*Customer `json:"customers"`
+}
+
+type GetListDuplicateCustomerRequest struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+type GetListDuplicateCustomerResponse struct {
+	Count  int                       `json:"count"`
+	Groups []*DuplicateCustomerGroup `json:"groups"`
+}
+
+// MergeCustomer folds the duplicates into the survivor and deletes them.
+type MergeCustomer struct {
+	SurvivorId   int   `json:"survivor_id"`
+	DuplicateIds []int `json:"duplicate_ids"`
+}
+
+const (
+	AddressTypeBilling  = "billing"
+	AddressTypeShipping = "shipping"
+)
+
+type CustomerAddress struct {
+	AddressId   int    `json:"address_id"`
+	CustomerId  int    `json:"customer_id"`
+	Label       string `json:"label"`
+	AddressType string `json:"address_type"`
+	Street      string `json:"street"`
+	City        string `json:"city"`
+	State       string `json:"state"`
+	ZipCode     string `json:"zip_code"`
+	IsDefault   bool   `json:"is_default"`
+	Version     int    `json:"version"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type CustomerAddressPrimaryKey struct {
+	CustomerId int `json:"customer_id"`
+	AddressId  int `json:"address_id"`
+	Version    int `json:"-"`
+}
+
+type CreateCustomerAddress struct {
+	CustomerId  int    `json:"customer_id"`
+	Label       string `json:"label"`
+	AddressType string `json:"address_type"`
+	Street      string `json:"street"`
+	City        string `json:"city"`
+	State       string `json:"state"`
+	ZipCode     string `json:"zip_code"`
+	IsDefault   bool   `json:"is_default"`
+}
+
+type UpdateCustomerAddress struct {
+	AddressId   int    `json:"address_id"`
+	CustomerId  int    `json:"customer_id"`
+	Label       string `json:"label"`
+	AddressType string `json:"address_type"`
+	Street      string `json:"street"`
+	City        string `json:"city"`
+	State       string `json:"state"`
+	ZipCode     string `json:"zip_code"`
+	IsDefault   bool   `json:"is_default"`
+	Version     int    `json:"-"`
+}
+
+type GetListCustomerAddressRequest struct {
+	CustomerId  int    `json:"customer_id"`
+	AddressType string `json:"address_type"`
+}
+
+type GetListCustomerAddressResponse struct {
+	Count     int                `json:"count"`
+	Addresses []*CustomerAddress `json:"addresses"`
+}
+
+const (
+	PrivacyActionExport    = "export"
+	PrivacyActionAnonymize = "anonymize"
+)
+
+// CustomerExport is everything held on a customer, as handed out on a privacy request.
+type CustomerExport struct {
+	ExportedAt    string                 `json:"exported_at"`
+	Customer      *Customer              `json:"customer"`
+	Addresses     []*CustomerAddress     `json:"addresses"`
+	Orders        []*CustomerOrder       `json:"orders"`
+	Payments      []*Payment             `json:"payments"`
+	GiftCards     []*GiftCard            `json:"gift_cards"`
+	StoreCredit   money.Money            `json:"store_credit"`
+	CreditLedger  []*CustomerCreditEntry `json:"credit_ledger"`
+	LoyaltyPoints int                    `json:"loyalty_points"`
+	LoyaltyLedger []*LoyaltyLedgerEntry  `json:"loyalty_ledger"`
+	PrivacyLog    []*CustomerPrivacyLog  `json:"privacy_log"`
+}
+
+type CustomerPrivacyLog struct {
+	LogId      int    `json:"log_id"`
+	CustomerId int    `json:"customer_id"`
+	Action     string `json:"action"`
+	StaffId    int    `json:"staff_id"`
+	Reason     string `json:"reason"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type CustomerPrivacyRequest struct {
+	CustomerId int    `json:"customer_id"`
+	StaffId    int    `json:"staff_id"`
+	Reason     string `json:"reason"`
+}
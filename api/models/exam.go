@@ -6,6 +6,7 @@ type SendProduct struct {
 	SenderId   int `json:"sender_id"`
 	ReceiverId int `json:"receiver_id"`
 	ProductId  int `json:"product_id"`
+	VariantId  int `json:"variant_id"`
 	Quantity   int `json:"quantity"`
 }
 
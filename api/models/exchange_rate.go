@@ -0,0 +1,36 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// ExchangeRate is how much of the quote currency one unit of the base currency buys from
+// valid_from on. The inverse conversion uses 1 / rate.
+type ExchangeRate struct {
+	ExchangeRateId int             `json:"exchange_rate_id"`
+	BaseCurrency   string          `json:"base_currency"`
+	QuoteCurrency  string          `json:"quote_currency"`
+	Rate           decimal.Decimal `json:"rate" swaggertype:"string"`
+	ValidFrom      string          `json:"valid_from"`
+}
+
+type ExchangeRatePrimaryKey struct {
+	ExchangeRateId int `json:"exchange_rate_id"`
+}
+
+// CreateExchangeRate takes effect now when valid_from is empty.
+type CreateExchangeRate struct {
+	BaseCurrency  string          `json:"base_currency"`
+	QuoteCurrency string          `json:"quote_currency"`
+	Rate          decimal.Decimal `json:"rate" swaggertype:"string"`
+	ValidFrom     string          `json:"valid_from"`
+}
+
+type GetListExchangeRateRequest struct {
+	Currency string `json:"currency"` // either side of the pair
+	Offset   int    `json:"offset"`
+	Limit    int    `json:"limit"`
+}
+
+type GetListExchangeRateResponse struct {
+	Count int             `json:"count"`
+	Rates []*ExchangeRate `json:"rates"`
+}
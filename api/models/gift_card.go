@@ -0,0 +1,65 @@
+package models
+
+import "app/pkg/money"
+
+const (
+	GiftCardStatusActive   = "active"
+	GiftCardStatusDisabled = "disabled"
+
+	GiftCardLedgerIssue  = "issue"
+	GiftCardLedgerRedeem = "redeem"
+	GiftCardLedgerRefund = "refund"
+)
+
+type GiftCard struct {
+	GiftCardId    int                    `json:"gift_card_id"`
+	Code          string                 `json:"code"`
+	CustomerId    int                    `json:"customer_id"`
+	InitialAmount money.Money            `json:"initial_amount"`
+	Balance       money.Money            `json:"balance"`
+	Status        string                 `json:"status"`
+	ExpiresAt     string                 `json:"expires_at"`
+	Version       int                    `json:"version"`
+	CreatedAt     string                 `json:"created_at"`
+	Ledger        []*GiftCardLedgerEntry `json:"ledger"`
+}
+
+type GiftCardPrimaryKey struct {
+	GiftCardId int `json:"gift_card_id"`
+}
+
+type CreateGiftCard struct {
+	CustomerId int         `json:"customer_id"`
+	Amount     money.Money `json:"amount"` // an empty currency is USD
+	ExpiresAt  string      `json:"expires_at"`
+}
+
+type UpdateGiftCardStatus struct {
+	GiftCardId int    `json:"gift_card_id"`
+	Status     string `json:"status"`
+	Version    int    `json:"-"`
+}
+
+type GetListGiftCardRequest struct {
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`
+	Code       string `json:"code"`
+	CustomerId int    `json:"customer_id"`
+	Status     string `json:"status"`
+}
+
+type GetListGiftCardResponse struct {
+	Count     int         `json:"count"`
+	GiftCards []*GiftCard `json:"gift_cards"`
+}
+
+type GiftCardLedgerEntry struct {
+	EntryId      int         `json:"entry_id"`
+	GiftCardId   int         `json:"gift_card_id"`
+	Kind         string      `json:"kind"`
+	Amount       money.Money `json:"amount"`
+	BalanceAfter money.Money `json:"balance_after"`
+	OrderId      int         `json:"order_id"`
+	PaymentId    int         `json:"payment_id"`
+	CreatedAt    string      `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// IdempotencyKey is the stored outcome of a POST sent with an Idempotency-Key header,
+// Status stays 0 while the first request is still running.
+type IdempotencyKey struct {
+	Key         string
+	RequestHash string
+	Status      int
+	Response    []byte
+	CreatedAt   string
+}
+
+type IdempotencyKeyPrimaryKey struct {
+	Key string
+}
+
+// ReserveIdempotencyKey claims the key for a request, keys older than TTL are forgotten.
+type ReserveIdempotencyKey struct {
+	Key         string
+	RequestHash string
+	TTL         time.Duration
+}
+
+type CompleteIdempotencyKey struct {
+	Key      string
+	Status   int
+	Response []byte
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"app/pkg/money"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	LoyaltyLedgerEarn    = "earn"
+	LoyaltyLedgerRedeem  = "redeem"
+	LoyaltyLedgerRelease = "release"
+	LoyaltyLedgerMerge   = "merge"
+)
+
+type LoyaltyTier struct {
+	TierId     int         `json:"tier_id"`
+	TierName   string      `json:"tier_name"`
+	MinSpend   money.Money `json:"min_spend"`
+	Multiplier float64     `json:"multiplier"`
+}
+
+// UpsertLoyaltyTier sets a tier, min_spend is in the base currency USD, an empty currency is USD.
+type UpsertLoyaltyTier struct {
+	TierName   string      `json:"tier_name"`
+	MinSpend   money.Money `json:"min_spend"`
+	Multiplier float64     `json:"multiplier"`
+}
+
+type LoyaltyMultiplier struct {
+	MultiplierId int     `json:"multiplier_id"`
+	BrandId      int     `json:"brand_id"`
+	CategoryId   int     `json:"category_id"`
+	Multiplier   float64 `json:"multiplier"`
+}
+
+type LoyaltyMultiplierPrimaryKey struct {
+	MultiplierId int `json:"multiplier_id"`
+}
+
+// UpsertLoyaltyMultiplier sets the multiplier of exactly one brand or category.
+type UpsertLoyaltyMultiplier struct {
+	BrandId    int     `json:"brand_id"`
+	CategoryId int     `json:"category_id"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+type CustomerLoyalty struct {
+	CustomerId int                   `json:"customer_id"`
+	Points     int                   `json:"points"`
+	Spend      money.Money           `json:"spend"` // rolling 12 month spend in the base currency
+	Tier       *LoyaltyTier          `json:"tier"`
+	NextTier   *LoyaltyTier          `json:"next_tier"`
+	Count      int                   `json:"count"`
+	Ledger     []*LoyaltyLedgerEntry `json:"ledger"`
+}
+
+type GetCustomerLoyaltyRequest struct {
+	CustomerId int `json:"customer_id"`
+	Offset     int `json:"offset"`
+	Limit      int `json:"limit"`
+}
+
+type LoyaltyLedgerEntry struct {
+	EntryId      int    `json:"entry_id"`
+	CustomerId   int    `json:"customer_id"`
+	Kind         string `json:"kind"`
+	Points       int    `json:"points"`
+	BalanceAfter int    `json:"balance_after"`
+	OrderId      int    `json:"order_id"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type RedeemLoyaltyPoints struct {
+	OrderId    int             `json:"order_id"`
+	Points     int             `json:"points"`
+	PointValue decimal.Decimal `json:"-"` // in the base currency
+}
@@ -1,5 +1,14 @@
 package models
 
+import "app/pkg/money"
+
+const (
+	OrderStatusPending    int16 = 1
+	OrderStatusProcessing int16 = 2
+	OrderStatusRejected   int16 = 3
+	OrderStatusCompleted  int16 = 4
+)
+
 type Order struct {
 	OrderId      int          `json:"order_id"`
 	CustomerId   int          `json:"customer_id"`
@@ -12,18 +21,45 @@ type Order struct {
 	StoreData    *Store       `json:"store_data"`
 	StaffId      int          `json:"staff_id"`
 	PromoCode    int          `json:"promo_code"`
+	ReturnStatus string       `json:"return_status"`
 	StaffData    *Staff       `json:"staff_data"`
 	OrderItems   []*OrderItem `json:"order_items"`
+	Amount       *OrderAmount `json:"amount"`
+	Currency     string       `json:"currency"` // the base currency of the store when the order was made
+
+	DeliveryAddressId int              `json:"delivery_address_id"`
+	DeliveryAddress   *CustomerAddress `json:"delivery_address"`
+	Version           int              `json:"version"`
 }
 
 type OrderTotalSumm struct {
-	OrderId   int     `json:"order_id"`
-	PromoCode string  `json:"promo_code"`
-	TotalSumm float64 `json:"total_summ"`
+	OrderId   int         `json:"order_id"`
+	PromoCode string      `json:"promo_code"`
+	TotalSumm money.Money `json:"total_summ"`
+}
+
+type OrderAmount struct {
+	OrderId         int         `json:"order_id"`
+	Subtotal        money.Money `json:"subtotal"`
+	LineDiscount    money.Money `json:"line_discount"`
+	PromoDiscount   money.Money `json:"promo_discount"`
+	LoyaltyDiscount money.Money `json:"loyalty_discount"`
+	Tax             money.Money `json:"tax"`
+	Total           money.Money `json:"total"`
+
+	TaxInclusive bool        `json:"tax_inclusive"` // the prices include the tax, it is not added to the total
+	TaxExempt    bool        `json:"tax_exempt"`
+	Taxes        []*OrderTax `json:"taxes"`
+}
+
+type CompleteOrder struct {
+	OrderId              int     `json:"order_id"`
+	LoyaltyPointsPerUnit float64 `json:"-"`
 }
 
 type OrderPrimaryKey struct {
 	OrderId int `json:"order_id"`
+	Version int `json:"-"`
 }
 
 type CreateOrder struct {
@@ -35,6 +71,8 @@ type CreateOrder struct {
 	StoreId      int    `json:"store_id"`
 	StaffId      int    `json:"staff_id"`
 	PromoCode    int    `json:"promo_code"`
+
+	DeliveryAddressId int `json:"delivery_address_id"`
 }
 
 type UpdateOrder struct {
@@ -47,6 +85,7 @@ type UpdateOrder struct {
 	StoreId      int    `json:"store_id"`
 	StaffId      int    `json:"staff_id"`
 	PromoCode    string `json:"promo_code"`
+	Version      int    `json:"-"`
 }
 
 type GetListOrderRequest struct {
@@ -62,26 +101,67 @@ type GetListOrderResponse struct {
 
 // -----------------------ITEM------------------
 type OrderItem struct {
-	OrderId     int      `json:"order_id"`
-	ItemId      int      `json:"item_id"`
-	ProductId   int      `json:"product_id"`
-	ProductData *Product `json:"product_data"`
-	Quantity    int      `json:"quantity"`
-	ListPrice   float64  `json:"list_price"`
-	Discount    float64  `json:"discount"`
+	OrderId     int         `json:"order_id"`
+	ItemId      int         `json:"item_id"`
+	ProductId   int         `json:"product_id"`
+	ProductData *Product    `json:"product_data"`
+	VariantId   int         `json:"variant_id"`
+	Sku         string      `json:"sku"`
+	Quantity    int         `json:"quantity"`
+	ListPrice   money.Money `json:"list_price"`
+	Discount    float64     `json:"discount"`
+	Reservation string      `json:"reservation"`
+
+	CatalogPrice    money.Money `json:"catalog_price"`
+	OverrideStaffId int         `json:"override_staff_id"`
+	OverrideReason  string      `json:"override_reason"`
+
+	TaxRateId int         `json:"tax_rate_id"`
+	TaxName   string      `json:"tax_name"`
+	TaxRate   float64     `json:"tax_rate"`
+	Tax       money.Money `json:"tax"`
 }
 
 type OrderItemPrimaryKey struct {
 	OrderId int `json:"order_id"`
 	ItemId  int `json:"item_id"`
+	Version int `json:"-"` // of the order
 }
 
+// CreateOrderItem is priced from the catalog, converted to the currency of the order. A
+// list_price other than the catalog price or a discount is an override, allowed only for a
+// staff with the discount permission. A list_price is in the currency of the order.
 type CreateOrderItem struct {
 	OrderId int `json:"order_id"`
 	// ItemId      int     `json:"item_id"`
 	ProductId int `json:"product_id"`
+	VariantId int `json:"variant_id"` // required when the product comes in several variants
 	// ProductData *Product `json:"product_data"`
-	Quantity  int     `json:"quantity"`
-	ListPrice float64 `json:"list_price"`
-	Discount  float64 `json:"discount"`
+	Quantity       int         `json:"quantity"`
+	ListPrice      money.Money `json:"list_price"`
+	Discount       float64     `json:"discount"`
+	OverrideReason string      `json:"override_reason"`
+
+	StaffId            int             `json:"-"`
+	MaxDiscountPercent float64         `json:"-"`
+	Audit              *CreateAuditLog `json:"-"` // request an override is recorded against
+}
+
+type CreateOrderItems struct {
+	OrderId int                `json:"order_id"`
+	Items   []*CreateOrderItem `json:"items"`
+}
+
+// OrderItemError is why a line of a batch could not be added, Line is its index in the request.
+type OrderItemError struct {
+	Line      int    `json:"line"`
+	ProductId int    `json:"product_id"`
+	VariantId int    `json:"variant_id"`
+	Error     string `json:"error"`
+}
+
+// Checkout opens an order together with all its items, nothing is kept when a line fails.
+type Checkout struct {
+	Order *CreateOrder       `json:"order"`
+	Items []*CreateOrderItem `json:"items"`
 }
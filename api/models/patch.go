@@ -1,6 +1,17 @@
 package models
 
+// PatchRequest is an RFC 7396 JSON Merge Patch of the entity with the given id,
+// a null clears an optional field.
 type PatchRequest struct {
-	ID     int `json:"id"`
-	Fields map[string]interface{}
+	ID      int `json:"id"`
+	Version int `json:"-"`
+	Fields  map[string]interface{}
+}
+
+type PatchStockRequest struct {
+	StoreId   int `json:"store_id"`
+	ProductId int `json:"product_id"`
+	VariantId int `json:"variant_id"`
+	Version   int `json:"-"`
+	Fields    map[string]interface{}
 }
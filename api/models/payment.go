@@ -0,0 +1,71 @@
+package models
+
+import "app/pkg/money"
+
+const (
+	PaymentTenderCash         = "cash"
+	PaymentTenderCard         = "card"
+	PaymentTenderBankTransfer = "bank_transfer"
+	PaymentTenderGiftCard     = "gift_card"
+	PaymentTenderStoreCredit  = "store_credit"
+)
+
+type Payment struct {
+	PaymentId     int         `json:"payment_id"`
+	OrderId       int         `json:"order_id"`
+	Tender        string      `json:"tender"`
+	Amount        money.Money `json:"amount"`
+	Provider      string      `json:"provider"`
+	TransactionId string      `json:"transaction_id"`
+	Reference     string      `json:"reference"`
+	RefundOf      int         `json:"refund_of"`
+	Refunded      money.Money `json:"refunded"`
+	Note          string      `json:"note"`
+	CreatedAt     string      `json:"created_at"`
+}
+
+type PaymentPrimaryKey struct {
+	PaymentId int `json:"payment_id"`
+}
+
+// CreatePayment is in the currency of the order, an empty currency is taken as that one.
+type CreatePayment struct {
+	OrderId       int         `json:"order_id"`
+	Tender        string      `json:"tender"`
+	Amount        money.Money `json:"amount"`
+	Reference     string      `json:"reference"`
+	Note          string      `json:"note"`
+	Provider      string      `json:"-"`
+	TransactionId string      `json:"-"`
+}
+
+type RefundPayment struct {
+	PaymentId     int         `json:"payment_id"`
+	Amount        money.Money `json:"amount"`
+	Note          string      `json:"note"`
+	Provider      string      `json:"-"`
+	TransactionId string      `json:"-"`
+}
+
+type GetListPaymentRequest struct {
+	Offset  int    `json:"offset"`
+	Limit   int    `json:"limit"`
+	OrderId int    `json:"order_id"`
+	Tender  string `json:"tender"`
+}
+
+type GetListPaymentResponse struct {
+	Count    int        `json:"count"`
+	Payments []*Payment `json:"payments"`
+}
+
+// OrderBalance is what the customer still owes, a negative balance due is owed back to the customer.
+type OrderBalance struct {
+	OrderId    int         `json:"order_id"`
+	Total      money.Money `json:"total"`
+	Returned   money.Money `json:"returned"`
+	Credited   money.Money `json:"credited"`
+	Paid       money.Money `json:"paid"`
+	Refunded   money.Money `json:"refunded"`
+	BalanceDue money.Money `json:"balance_due"`
+}
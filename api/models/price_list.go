@@ -0,0 +1,126 @@
+package models
+
+import "app/pkg/money"
+
+const (
+	PriceListKindRetail    = "retail"
+	PriceListKindWholesale = "wholesale"
+	PriceListKindStaff     = "staff"
+
+	PriceChangeCreate = "create"
+	PriceChangeUpdate = "update"
+	PriceChangeDelete = "delete"
+)
+
+type PriceList struct {
+	PriceListId   int    `json:"price_list_id"`
+	PriceListName string `json:"price_list_name"`
+	Kind          string `json:"kind"`
+}
+
+type PriceListPrimaryKey struct {
+	PriceListId int `json:"price_list_id"`
+}
+
+type CreatePriceList struct {
+	PriceListName string `json:"price_list_name"`
+	Kind          string `json:"kind"`
+}
+
+type PriceListItem struct {
+	PriceListItemId int         `json:"price_list_item_id"`
+	PriceListId     int         `json:"price_list_id"`
+	ProductId       int         `json:"product_id"`
+	StoreId         int         `json:"store_id"` // 0 is every store
+	Price           money.Money `json:"price"`
+	ValidFrom       string      `json:"valid_from"`
+	ValidTo         string      `json:"valid_to"`
+	CreatedAt       string      `json:"created_at"`
+	Version         int         `json:"version"`
+}
+
+type PriceListItemPrimaryKey struct {
+	PriceListId     int `json:"price_list_id"`
+	PriceListItemId int `json:"price_list_item_id"`
+	Version         int `json:"-"`
+}
+
+// CreatePriceListItem prices a product on a list in the currency of the product. A store_id
+// limits the price to that store, valid_from and valid_to schedule it, an empty bound is open.
+type CreatePriceListItem struct {
+	PriceListId int         `json:"price_list_id"`
+	ProductId   int         `json:"product_id"`
+	StoreId     int         `json:"store_id"`
+	Price       money.Money `json:"price"`
+	ValidFrom   string      `json:"valid_from"`
+	ValidTo     string      `json:"valid_to"`
+}
+
+type UpdatePriceListItem struct {
+	PriceListId     int         `json:"price_list_id"`
+	PriceListItemId int         `json:"price_list_item_id"`
+	StoreId         int         `json:"store_id"`
+	Price           money.Money `json:"price"`
+	ValidFrom       string      `json:"valid_from"`
+	ValidTo         string      `json:"valid_to"`
+	Version         int         `json:"-"`
+}
+
+type GetListPriceListItemRequest struct {
+	PriceListId int `json:"price_list_id"`
+	ProductId   int `json:"product_id"`
+	StoreId     int `json:"store_id"`
+	Offset      int `json:"offset"`
+	Limit       int `json:"limit"`
+}
+
+type GetListPriceListItemResponse struct {
+	Count int              `json:"count"`
+	Items []*PriceListItem `json:"items"`
+}
+
+type GetProductPriceRequest struct {
+	ProductId  int `json:"product_id"`
+	StoreId    int `json:"store_id"`
+	CustomerId int `json:"customer_id"`
+}
+
+// ProductPrice is the price a product sells at now, in the currency of the product. PriceListId
+// is 0 when no price list applies and the product list_price is used.
+type ProductPrice struct {
+	ProductId       int         `json:"product_id"`
+	StoreId         int         `json:"store_id"`
+	CustomerId      int         `json:"customer_id"`
+	ListPrice       money.Money `json:"list_price"`
+	Price           money.Money `json:"price"`
+	PriceListId     int         `json:"price_list_id"`
+	PriceListName   string      `json:"price_list_name"`
+	PriceListItemId int         `json:"price_list_item_id"`
+	StoreOverride   bool        `json:"store_override"`
+	ValidTo         string      `json:"valid_to"`
+}
+
+type PriceHistory struct {
+	HistoryId       int         `json:"history_id"`
+	ProductId       int         `json:"product_id"`
+	PriceListId     int         `json:"price_list_id"`
+	PriceListItemId int         `json:"price_list_item_id"`
+	StoreId         int         `json:"store_id"`
+	Change          string      `json:"change"`
+	OldPrice        money.Money `json:"old_price"`
+	NewPrice        money.Money `json:"new_price"`
+	ValidFrom       string      `json:"valid_from"`
+	ValidTo         string      `json:"valid_to"`
+	ChangedAt       string      `json:"changed_at"`
+}
+
+type GetListPriceHistoryRequest struct {
+	ProductId int `json:"product_id"`
+	Offset    int `json:"offset"`
+	Limit     int `json:"limit"`
+}
+
+type GetListPriceHistoryResponse struct {
+	Count   int             `json:"count"`
+	History []*PriceHistory `json:"history"`
+}
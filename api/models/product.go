@@ -1,43 +1,123 @@
 package models
 
+import "app/pkg/money"
+
 type Product struct {
-	ProductId    int       `json:"product_id"`
-	ProductName  string    `json:"product_name"`
-	BrandId      int       `json:"brand_id"`
-	BrandData    *Brand    `json:"brand_data"`
-	CategoryId   int       `json:"category_id"`
-	CategoryData *Category `json:"category_data"`
-	ModelYear    int       `json:"model_year"`
-	ListPrice    float64   `json:"list_price"`
+	ProductId    int         `json:"product_id"`
+	ProductName  string      `json:"product_name"`
+	BrandId      int         `json:"brand_id"`
+	BrandData    *Brand      `json:"brand_data"`
+	CategoryId   int         `json:"category_id"`
+	CategoryData *Category   `json:"category_data"`
+	ModelYear    int         `json:"model_year"`
+	ListPrice    money.Money `json:"list_price"`
+	DeletedAt    string      `json:"deleted_at"`
+	Version      int         `json:"version"`
+
+	Variants   []*ProductVariant   `json:"variants"`
+	Attributes []*ProductAttribute `json:"attributes"`
 }
 type ProductPrimaryKey struct {
 	ProductId int `json:"product_id"`
+	Version   int `json:"-"`
 }
 
 type CreateProduct struct {
-	ProductName string  `json:"product_name"`
-	BrandId     int     `json:"brand_id"`
-	CategoryId  int     `json:"category_id"`
-	ModelYear   int     `json:"model_year"`
-	ListPrice   float64 `json:"list_price"`
+	ProductName string      `json:"product_name"`
+	BrandId     int         `json:"brand_id"`
+	CategoryId  int         `json:"category_id"`
+	ModelYear   int         `json:"model_year"`
+	ListPrice   money.Money `json:"list_price"` // an empty currency is USD
+
+	// Variants the product comes in, without them it is sold as a single variant under Sku.
+	Sku      string                  `json:"sku"`
+	Variants []*CreateProductVariant `json:"variants"`
 }
 
 type UpdateProduct struct {
-	ProductId   int     `json:"product_id"`
-	ProductName string  `json:"product_name"`
-	BrandId     int     `json:"brand_id"`
-	CategoryId  int     `json:"category_id"`
-	ModelYear   int     `json:"model_year"`
-	ListPrice   float64 `json:"list_price"`
+	ProductId   int         `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	BrandId     int         `json:"brand_id"`
+	CategoryId  int         `json:"category_id"`
+	ModelYear   int         `json:"model_year"`
+	ListPrice   money.Money `json:"list_price"`
+	Version     int         `json:"-"`
 }
 
 type GetListProductRequest struct {
-	Offset int    `json:"offset"`
-	Limit  int    `json:"limit"`
-	Search string `json:"search"`
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+	Search         string `json:"search"`
+	IncludeDeleted bool   `json:"include_deleted"`
+
+	Attributes []*AttributeFilter `json:"attributes"`
 }
 
 type GetListProductResponse struct {
 	Count    int        `json:"count"`
 	Products []*Product `json:"products"`
 }
+
+// ProductVariant is one option combination of a product with its own SKU, stock is kept
+// per variant. Quantity and Available add up the stores.
+type ProductVariant struct {
+	VariantId     int             `json:"variant_id"`
+	ProductId     int             `json:"product_id"`
+	Sku           string          `json:"sku"`
+	Size          string          `json:"size"`
+	Color         string          `json:"color"`
+	FrameMaterial string          `json:"frame_material"`
+	Version       int             `json:"version"`
+	CreatedAt     string          `json:"created_at"`
+	Quantity      int             `json:"quantity"`
+	Available     int             `json:"available"`
+	Stores        []*VariantStock `json:"stores"`
+}
+
+type VariantStock struct {
+	StoreId   int    `json:"store_id"`
+	StoreName string `json:"store_name"`
+	Quantity  int    `json:"quantity"`
+	Reserved  int    `json:"reserved"`
+	Available int    `json:"available"`
+}
+
+type ProductVariantPrimaryKey struct {
+	ProductId int `json:"product_id"`
+	VariantId int `json:"variant_id"`
+	Version   int `json:"-"`
+}
+
+type CreateProductVariant struct {
+	ProductId     int    `json:"product_id"`
+	Sku           string `json:"sku"`
+	Size          string `json:"size"`
+	Color         string `json:"color"`
+	FrameMaterial string `json:"frame_material"`
+}
+
+type UpdateProductVariant struct {
+	VariantId     int    `json:"variant_id"`
+	ProductId     int    `json:"product_id"`
+	Sku           string `json:"sku"`
+	Size          string `json:"size"`
+	Color         string `json:"color"`
+	FrameMaterial string `json:"frame_material"`
+	Version       int    `json:"-"`
+}
+
+// GetListProductVariantRequest limits the availability to one store when StoreId is set.
+type GetListProductVariantRequest struct {
+	ProductId int `json:"product_id"`
+	StoreId   int `json:"store_id"`
+}
+
+// GetListProductVariantResponse is the variant matrix, the options in use along each axis
+// and the variant of every combination.
+type GetListProductVariantResponse struct {
+	Count          int               `json:"count"`
+	Sizes          []string          `json:"sizes"`
+	Colors         []string          `json:"colors"`
+	FrameMaterials []string          `json:"frame_materials"`
+	Variants       []*ProductVariant `json:"variants"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "app/pkg/money"
+
+const (
+	SearchFacetBrand     = "brand"
+	SearchFacetCategory  = "category"
+	SearchFacetModelYear = "model_year"
+	SearchFacetPriceBand = "price_band"
+)
+
+// SearchProductRequest finds products by every word of Query, a word matches a word of the
+// product starting with it or, for a typo, one close enough to it by trigram similarity.
+// PriceBands are the upper bounds of the price bands in Currency.
+type SearchProductRequest struct {
+	Query      string  `json:"query"`
+	Offset     int     `json:"offset"`
+	Limit      int     `json:"limit"`
+	BrandId    int     `json:"brand_id"`
+	CategoryId int     `json:"category_id"`
+	ModelYear  int     `json:"model_year"`
+	PriceBand  string  `json:"price_band"`
+	Currency   string  `json:"currency"`
+	PriceBands []int   `json:"price_bands"`
+	Similarity float64 `json:"similarity"`
+}
+
+// SearchProduct is a product found by a search. Headline is the product name and Snippet the
+// best fragments of the name, brand, category and attributes, matched words marked with <mark>.
+// Price is the list price in the currency of the search.
+type SearchProduct struct {
+	ProductId    int         `json:"product_id"`
+	ProductName  string      `json:"product_name"`
+	BrandId      int         `json:"brand_id"`
+	BrandName    string      `json:"brand_name"`
+	CategoryId   int         `json:"category_id"`
+	CategoryName string      `json:"category_name"`
+	ModelYear    int         `json:"model_year"`
+	ListPrice    money.Money `json:"list_price"`
+	Price        money.Money `json:"price"`
+	Rank         float64     `json:"rank"`
+	Headline     string      `json:"headline"`
+	Snippet      string      `json:"snippet"`
+}
+
+// SearchFacet is how many products found share a brand, category, model year or price band,
+// Value is what the filter of the facet takes.
+type SearchFacet struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+type SearchProductFacets struct {
+	Brands     []*SearchFacet `json:"brands"`
+	Categories []*SearchFacet `json:"categories"`
+	ModelYears []*SearchFacet `json:"model_years"`
+	PriceBands []*SearchFacet `json:"price_bands"`
+}
+
+type SearchProductResponse struct {
+	Count    int                  `json:"count"`
+	Products []*SearchProduct     `json:"products"`
+	Facets   *SearchProductFacets `json:"facets"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import "app/pkg/money"
+
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusSent              = "sent"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+)
+
+type PurchaseOrder struct {
+	PurchaseOrderId int                  `json:"purchase_order_id"`
+	SupplierId      int                  `json:"supplier_id"`
+	SupplierData    *Supplier            `json:"supplier_data"`
+	Status          string               `json:"status"`
+	OrderDate       string               `json:"order_date"`
+	ExpectedDate    string               `json:"expected_date"`
+	Note            string               `json:"note"`
+	Currency        string               `json:"currency"`
+	TotalCost       money.Money          `json:"total_cost"`
+	Items           []*PurchaseOrderItem `json:"items"`
+	Version         int                  `json:"version"` // bumped by any change of the order or its items
+}
+
+type PurchaseOrderPrimaryKey struct {
+	PurchaseOrderId int `json:"purchase_order_id"`
+	Version         int `json:"-"`
+}
+
+// CreatePurchaseOrder orders from a supplier in one currency, an empty one is USD. Cost prices
+// of the items are in that currency.
+type CreatePurchaseOrder struct {
+	SupplierId   int                        `json:"supplier_id"`
+	Currency     string                     `json:"currency"`
+	ExpectedDate string                     `json:"expected_date"`
+	Note         string                     `json:"note"`
+	Items        []*CreatePurchaseOrderItem `json:"items"`
+}
+
+type UpdatePurchaseOrder struct {
+	PurchaseOrderId int    `json:"purchase_order_id"`
+	SupplierId      int    `json:"supplier_id"`
+	ExpectedDate    string `json:"expected_date"`
+	Note            string `json:"note"`
+	Version         int    `json:"-"`
+}
+
+type UpdatePurchaseOrderStatus struct {
+	PurchaseOrderId int    `json:"purchase_order_id"`
+	Status          string `json:"status"`
+	Version         int    `json:"-"`
+}
+
+type GetListPurchaseOrderRequest struct {
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit"`
+	SupplierId int    `json:"supplier_id"`
+	StoreId    int    `json:"store_id"`
+	Status     string `json:"status"`
+}
+
+type GetListPurchaseOrderResponse struct {
+	Count          int              `json:"count"`
+	PurchaseOrders []*PurchaseOrder `json:"purchase_orders"`
+}
+
+// -----------------------ITEM------------------
+type PurchaseOrderItem struct {
+	PurchaseOrderId  int         `json:"purchase_order_id"`
+	ItemId           int         `json:"item_id"`
+	ProductId        int         `json:"product_id"`
+	VariantId        int         `json:"variant_id"`
+	StoreId          int         `json:"store_id"`
+	Quantity         int         `json:"quantity"`
+	ReceivedQuantity int         `json:"received_quantity"`
+	CostPrice        money.Money `json:"cost_price"`
+}
+
+type PurchaseOrderItemPrimaryKey struct {
+	PurchaseOrderId int `json:"purchase_order_id"`
+	ItemId          int `json:"item_id"`
+	Version         int `json:"-"` // of the purchase order
+}
+
+type CreatePurchaseOrderItem struct {
+	PurchaseOrderId int         `json:"purchase_order_id"`
+	ProductId       int         `json:"product_id"`
+	VariantId       int         `json:"variant_id"` // required when the product comes in several variants
+	StoreId         int         `json:"store_id"`
+	Quantity        int         `json:"quantity"`
+	CostPrice       money.Money `json:"cost_price"`
+}
+
+// -----------------------RECEIVE------------------
+type ReceivePurchaseOrderLine struct {
+	ItemId    int         `json:"item_id"`
+	Quantity  int         `json:"quantity"`
+	CostPrice money.Money `json:"cost_price"` // a zero amount keeps the cost price of the item
+}
+
+type ReceivePurchaseOrder struct {
+	PurchaseOrderId int                         `json:"purchase_order_id"`
+	Lines           []*ReceivePurchaseOrderLine `json:"lines"`
+}
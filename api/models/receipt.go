@@ -0,0 +1,41 @@
+package models
+
+import "app/pkg/money"
+
+const (
+	ReceiptFormatText = "text"
+	ReceiptFormatHTML = "html"
+	ReceiptFormatPDF  = "pdf"
+)
+
+// Receipt is everything printed for an order. InvoiceNumber is given when the order is
+// completed, numbers run per store without gaps, an open order prints without one.
+type Receipt struct {
+	OrderId       int    `json:"order_id"`
+	OrderDate     string `json:"order_date"`
+	OrderStatus   int16  `json:"order_status"`
+	InvoiceNumber string `json:"invoice_number"`
+	IssuedAt      string `json:"issued_at"`
+	Store         *Store `json:"store"`
+	StaffName     string `json:"staff_name"`
+	CustomerName  string `json:"customer_name"`
+	PromoCode     string `json:"promo_code"`
+
+	Lines    []*ReceiptLine `json:"lines"`
+	Amount   *OrderAmount   `json:"amount"`
+	Payments []*Payment     `json:"payments"`
+	Balance  *OrderBalance  `json:"balance"`
+}
+
+type ReceiptLine struct {
+	ItemId      int         `json:"item_id"`
+	ProductName string      `json:"product_name"`
+	Sku         string      `json:"sku"`
+	Variant     string      `json:"variant"` // options of the variant, empty for a product without any
+	Quantity    int         `json:"quantity"`
+	UnitPrice   money.Money `json:"unit_price"`
+	Discount    float64     `json:"discount"`
+	LineTotal   money.Money `json:"line_total"` // after the line discount
+	TaxName     string      `json:"tax_name"`
+	TaxRate     float64     `json:"tax_rate"`
+}
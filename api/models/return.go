@@ -0,0 +1,94 @@
+package models
+
+import "app/pkg/money"
+
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusInspected = "inspected"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+
+	OrderReturnStatusNone              = "none"
+	OrderReturnStatusPartiallyReturned = "partially_returned"
+	OrderReturnStatusReturned          = "returned"
+
+	ReturnRefundMethodOriginal    = "original"
+	ReturnRefundMethodStoreCredit = "store_credit"
+)
+
+type Return struct {
+	ReturnId     int           `json:"return_id"`
+	OrderId      int           `json:"order_id"`
+	Status       string        `json:"status"`
+	Reason       string        `json:"reason"`
+	StoreId      int           `json:"store_id"`
+	RefundAmount money.Money   `json:"refund_amount"`
+	RefundMethod string        `json:"refund_method"`
+	Note         string        `json:"note"`
+	CreatedAt    string        `json:"created_at"`
+	ProcessedAt  string        `json:"processed_at"`
+	Items        []*ReturnItem `json:"items"`
+}
+
+type ReturnPrimaryKey struct {
+	ReturnId int `json:"return_id"`
+}
+
+type CreateReturn struct {
+	OrderId int                 `json:"order_id"`
+	Reason  string              `json:"reason"`
+	Items   []*CreateReturnItem `json:"items"`
+}
+
+type GetListReturnRequest struct {
+	Offset  int    `json:"offset"`
+	Limit   int    `json:"limit"`
+	OrderId int    `json:"order_id"`
+	Status  string `json:"status"`
+}
+
+type GetListReturnResponse struct {
+	Count   int       `json:"count"`
+	Returns []*Return `json:"returns"`
+}
+
+// -----------------------ITEM------------------
+type ReturnItem struct {
+	ReturnId     int         `json:"return_id"`
+	ItemId       int         `json:"item_id"`
+	ProductId    int         `json:"product_id"`
+	Quantity     int         `json:"quantity"`
+	Reason       string      `json:"reason"`
+	Restock      bool        `json:"restock"`
+	RefundAmount money.Money `json:"refund_amount"`
+}
+
+type CreateReturnItem struct {
+	ItemId   int    `json:"item_id"`
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+}
+
+// -----------------------PROCESS------------------
+type InspectReturnItem struct {
+	ItemId  int  `json:"item_id"`
+	Restock bool `json:"restock"`
+}
+
+type InspectReturn struct {
+	ReturnId int                  `json:"return_id"`
+	Note     string               `json:"note"`
+	Items    []*InspectReturnItem `json:"items"`
+}
+
+type ApproveReturn struct {
+	ReturnId     int    `json:"return_id"`
+	StoreId      int    `json:"store_id"`
+	RefundMethod string `json:"refund_method"` // original or store_credit
+	Note         string `json:"note"`
+}
+
+type RejectReturn struct {
+	ReturnId int    `json:"return_id"`
+	Note     string `json:"note"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "app/pkg/money"
+
 type Staff struct {
 	StaffId     int    `json:"staff_id"`
 	FirstName   string `json:"first_name"`
@@ -11,10 +13,14 @@ type Staff struct {
 	StoreData   *Store `json:"store_data"`
 	ManagerId   int    `json:"manager_id"`
 	ManagerData *Staff `json:"manager_data"`
+	CanDiscount bool   `json:"can_discount"`
+	DeletedAt   string `json:"deleted_at"`
+	Version     int    `json:"version"`
 }
 
 type StaffPrimaryKey struct {
 	StaffId int `json:"staff_id"`
+	Version int `json:"-"`
 }
 
 type CreateStaff struct {
@@ -25,6 +31,8 @@ type CreateStaff struct {
 	Active    int    `json:"active"`
 	StoreId   int    `json:"store_id"`
 	ManagerId int    `json:"manager_id"`
+
+	CanDiscount bool `json:"can_discount"`
 }
 
 type UpdateStaff struct {
@@ -36,12 +44,16 @@ type UpdateStaff struct {
 	Active    int    `json:"active"`
 	StoreId   int    `json:"store_id"`
 	ManagerId int    `json:"manager_id"`
+	Version   int    `json:"-"`
+
+	CanDiscount bool `json:"can_discount"`
 }
 
 type GetListStaffRequest struct {
-	Offset int    `json:"offset"`
-	Limit  int    `json:"limit"`
-	Search string `json:"search"`
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+	Search         string `json:"search"`
+	IncludeDeleted bool   `json:"include_deleted"`
 }
 
 type GetListStaffResponse struct {
@@ -50,18 +62,19 @@ type GetListStaffResponse struct {
 }
 
 type GetListReportStaffRequest struct {
-	Offset int    `json:"offset"`
-	Limit  int    `json:"limit"`
-	Search string `json:"search"`
+	Offset   int    `json:"offset"`
+	Limit    int    `json:"limit"`
+	Search   string `json:"search"`
+	Currency string `json:"currency"` // reporting currency the totals are converted to
 }
 
 type Report struct {
-	FullName  string  `json:"full_name"`
-	Category  string  `json:"category"`
-	Product   string  `json:"product"`
-	Count     int     `json:"count"`
-	TotalSumm float64 `json:"total_summ"`
-	Date      string  `json:"date"`
+	FullName  string      `json:"full_name"`
+	Category  string      `json:"category"`
+	Product   string      `json:"product"`
+	Count     int         `json:"count"`
+	TotalSumm money.Money `json:"total_summ"`
+	Date      string      `json:"date"`
 }
 type GetListReportStaffResponse struct {
 	Count   int       `json:"count"`
@@ -1,5 +1,7 @@
 package models
 
+import "app/pkg/money"
+
 type Stock struct {
 	StoreId     int      `json:"store_id"`
 	StoreData   *Store   `json:"store_data"`
@@ -7,20 +9,35 @@ type Stock struct {
 	ProductData *Product `json:"product_data"`
 	Quantity    int      `json:"quantity"`
 }
+// StockPrimaryKey is the stock of a store, or of one variant in it when ProductId or
+// VariantId is set.
 type StockPrimaryKey struct {
-	StoreId int `json:"store_id"`
+	StoreId   int `json:"store_id"`
+	ProductId int `json:"product_id"`
+	VariantId int `json:"variant_id"`
+	Version   int `json:"-"`
 }
 
 type ProductData struct {
-	ProductId    int       `json:"product_id"`
-	ProductName  string    `json:"product_name"`
-	BrandId      int       `json:"brand_id"`
-	BrandData    *Brand    `json:"brand_data"`
-	CategoryId   int       `json:"category_id"`
-	CategoryData *Category `json:"category_data"`
-	ModelYear    int       `json:"model_year"`
-	ListPrice    float64   `json:"list_price"`
-	Quantity     int       `json:"quantity"`
+	ProductId     int         `json:"product_id"`
+	VariantId     int         `json:"variant_id"`
+	Sku           string      `json:"sku"`
+	Size          string      `json:"size"`
+	Color         string      `json:"color"`
+	FrameMaterial string      `json:"frame_material"`
+	ProductName   string      `json:"product_name"`
+	BrandId       int         `json:"brand_id"`
+	BrandData     *Brand      `json:"brand_data"`
+	CategoryId    int         `json:"category_id"`
+	CategoryData  *Category   `json:"category_data"`
+	ModelYear     int         `json:"model_year"`
+	ListPrice     money.Money `json:"list_price"`
+	Quantity      int         `json:"quantity"`
+	Reserved      int         `json:"reserved"`
+	Available     int         `json:"available"`
+	ReorderPoint  int         `json:"reorder_point"`
+	TargetLevel   int         `json:"target_level"`
+	Version       int         `json:"version"`
 }
 
 type GetStock struct {
@@ -29,16 +46,20 @@ type GetStock struct {
 	Products []*ProductData `json:"products"`
 }
 
+// CreateStock is for a variant, a product sold as a single variant can be given by product_id alone.
 type CreateStock struct {
 	StoreId   int `json:"store_id"`
 	ProductId int `json:"product_id"`
+	VariantId int `json:"variant_id"`
 	Quantity  int `json:"quantity"`
 }
 
 type UpdateStock struct {
 	StoreId   int `json:"store_id"`
 	ProductId int `json:"product_id"`
+	VariantId int `json:"variant_id"`
 	Quantity  int `json:"quantity"`
+	Version   int `json:"-"`
 }
 
 type GetListStockRequest struct {
@@ -51,3 +72,72 @@ type GetListStockResponse struct {
 	Count  int         `json:"count"`
 	Stocks []*GetStock `json:"stocks"`
 }
+
+const (
+	ReservationStatusActive   = "active"
+	ReservationStatusConsumed = "consumed"
+	ReservationStatusReleased = "released"
+	ReservationStatusExpired  = "expired"
+)
+
+// -----------------------REORDER------------------
+type StockThreshold struct {
+	StoreId      int `json:"store_id"`
+	ProductId    int `json:"product_id"`
+	VariantId    int `json:"variant_id"`
+	ReorderPoint int `json:"reorder_point"`
+	TargetLevel  int `json:"target_level"`
+}
+
+type LowStock struct {
+	StoreId      int    `json:"store_id"`
+	StoreName    string `json:"store_name"`
+	ProductId    int    `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	VariantId    int    `json:"variant_id"`
+	Sku          string `json:"sku"`
+	Quantity     int    `json:"quantity"`
+	ReorderPoint int    `json:"reorder_point"`
+	TargetLevel  int    `json:"target_level"`
+	Shortage     int    `json:"shortage"`
+}
+
+type GetListLowStockRequest struct {
+	Offset  int `json:"offset"`
+	Limit   int `json:"limit"`
+	StoreId int `json:"store_id"`
+}
+
+type GetListLowStockResponse struct {
+	Count  int         `json:"count"`
+	Stocks []*LowStock `json:"stocks"`
+}
+
+type ReorderSuggestionRequest struct {
+	StoreId   int `json:"store_id"`
+	SalesDays int `json:"sales_days"`
+	CoverDays int `json:"cover_days"`
+}
+
+type ReorderSuggestion struct {
+	StoreId           int         `json:"store_id"`
+	ProductId         int         `json:"product_id"`
+	ProductName       string      `json:"product_name"`
+	VariantId         int         `json:"variant_id"`
+	Sku               string      `json:"sku"`
+	BrandId           int         `json:"brand_id"`
+	SupplierId        int         `json:"supplier_id"`
+	Quantity          int         `json:"quantity"`
+	ReorderPoint      int         `json:"reorder_point"`
+	TargetLevel       int         `json:"target_level"`
+	SoldQuantity      int         `json:"sold_quantity"`
+	DailyVelocity     float64     `json:"daily_velocity"`
+	SuggestedQuantity int         `json:"suggested_quantity"`
+	LastCostPrice     money.Money `json:"last_cost_price"`
+}
+
+type ReorderSuggestionResponse struct {
+	Count            int                  `json:"count"`
+	Suggestions      []*ReorderSuggestion `json:"suggestions"`
+	PurchaseOrderIds []int                `json:"purchase_order_ids"`
+}
@@ -9,10 +9,16 @@ type Store struct {
 	City      string `json:"city"`
 	State     string `json:"state"`
 	ZipCode   string `json:"zip_code"`
+	DeletedAt string `json:"deleted_at"`
+	Version   int    `json:"version"`
+
+	TaxInclusive bool   `json:"tax_inclusive"` // prices of the store already include tax
+	Currency     string `json:"currency"`      // base currency the store sells in
 }
 
 type StorePrimaryKey struct {
 	StoreId int `json:"store_id"`
+	Version int `json:"-"`
 }
 
 type CreateStore struct {
@@ -24,6 +30,9 @@ type CreateStore struct {
 	City      string `json:"city"`
 	State     string `json:"state"`
 	ZipCode   string `json:"zip_code"`
+
+	TaxInclusive bool   `json:"tax_inclusive"`
+	Currency     string `json:"currency"` // empty is USD
 }
 
 type UpdateStore struct {
@@ -35,12 +44,17 @@ type UpdateStore struct {
 	City      string `json:"city"`
 	State     string `json:"state"`
 	ZipCode   string `json:"zip_code"`
+	Version   int    `json:"-"`
+
+	TaxInclusive bool   `json:"tax_inclusive"`
+	Currency     string `json:"currency"`
 }
 
 type GetListStoreRequest struct {
-	Offset int    `json:"offset"`
-	Limit  int    `json:"limit"`
-	Search string `json:"search"`
+	Offset         int    `json:"offset"`
+	Limit          int    `json:"limit"`
+	Search         string `json:"search"`
+	IncludeDeleted bool   `json:"include_deleted"`
 }
 
 type GetListStoreResponse struct {
@@ -0,0 +1,35 @@
+package models
+
+import "app/pkg/money"
+
+const (
+	CreditLedgerReturn = "return"
+	CreditLedgerRedeem = "redeem"
+	CreditLedgerRefund = "refund"
+	CreditLedgerMerge  = "merge"
+)
+
+type CustomerCredit struct {
+	CustomerId int                    `json:"customer_id"`
+	Balance    money.Money            `json:"balance"`
+	Count      int                    `json:"count"`
+	Ledger     []*CustomerCreditEntry `json:"ledger"`
+}
+
+type GetCustomerCreditRequest struct {
+	CustomerId int `json:"customer_id"`
+	Offset     int `json:"offset"`
+	Limit      int `json:"limit"`
+}
+
+type CustomerCreditEntry struct {
+	EntryId      int         `json:"entry_id"`
+	CustomerId   int         `json:"customer_id"`
+	Kind         string      `json:"kind"`
+	Amount       money.Money `json:"amount"`
+	BalanceAfter money.Money `json:"balance_after"`
+	OrderId      int         `json:"order_id"`
+	ReturnId     int         `json:"return_id"`
+	PaymentId    int         `json:"payment_id"`
+	CreatedAt    string      `json:"created_at"`
+}
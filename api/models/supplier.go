@@ -0,0 +1,55 @@
+package models
+
+type Supplier struct {
+	SupplierId   int      `json:"supplier_id"`
+	SupplierName string   `json:"supplier_name"`
+	Phone        string   `json:"phone"`
+	Email        string   `json:"email"`
+	Street       string   `json:"street"`
+	City         string   `json:"city"`
+	State        string   `json:"state"`
+	ZipCode      string   `json:"zip_code"`
+	Brands       []*Brand `json:"brands"`
+	Version      int      `json:"version"`
+}
+
+type SupplierPrimaryKey struct {
+	SupplierId int `json:"supplier_id"`
+	Version    int `json:"-"`
+}
+
+type CreateSupplier struct {
+	SupplierName string `json:"supplier_name"`
+	Phone        string `json:"phone"`
+	Email        string `json:"email"`
+	Street       string `json:"street"`
+	City         string `json:"city"`
+	State        string `json:"state"`
+	ZipCode      string `json:"zip_code"`
+	BrandIds     []int  `json:"brand_ids"`
+}
+
+type UpdateSupplier struct {
+	SupplierId   int    `json:"supplier_id"`
+	SupplierName string `json:"supplier_name"`
+	Phone        string `json:"phone"`
+	Email        string `json:"email"`
+	Street       string `json:"street"`
+	City         string `json:"city"`
+	State        string `json:"state"`
+	ZipCode      string `json:"zip_code"`
+	BrandIds     []int  `json:"brand_ids"`
+	Version      int    `json:"-"`
+}
+
+type GetListSupplierRequest struct {
+	Offset  int    `json:"offset"`
+	Limit   int    `json:"limit"`
+	Search  string `json:"search"`
+	BrandId int    `json:"brand_id"`
+}
+
+type GetListSupplierResponse struct {
+	Count     int         `json:"count"`
+	Suppliers []*Supplier `json:"suppliers"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "app/pkg/money"
+
+// TaxRate applies to the stores of a state or to one store and to one category, an empty
+// state and a 0 id match everything. Rate is a fraction, 0.08 for 8%.
+type TaxRate struct {
+	TaxRateId  int     `json:"tax_rate_id"`
+	TaxName    string  `json:"tax_name"`
+	State      string  `json:"state"`
+	StoreId    int     `json:"store_id"`
+	CategoryId int     `json:"category_id"`
+	Rate       float64 `json:"rate"`
+	Version    int     `json:"version"`
+}
+
+type TaxRatePrimaryKey struct {
+	TaxRateId int `json:"tax_rate_id"`
+	Version   int `json:"-"`
+}
+
+type CreateTaxRate struct {
+	TaxName    string  `json:"tax_name"`
+	State      string  `json:"state"`
+	StoreId    int     `json:"store_id"`
+	CategoryId int     `json:"category_id"`
+	Rate       float64 `json:"rate"`
+}
+
+type UpdateTaxRate struct {
+	TaxRateId  int     `json:"tax_rate_id"`
+	TaxName    string  `json:"tax_name"`
+	State      string  `json:"state"`
+	StoreId    int     `json:"store_id"`
+	CategoryId int     `json:"category_id"`
+	Rate       float64 `json:"rate"`
+	Version    int     `json:"-"`
+}
+
+type GetListTaxRateRequest struct {
+	State      string `json:"state"`
+	StoreId    int    `json:"store_id"`
+	CategoryId int    `json:"category_id"`
+}
+
+// OrderTax sums the lines of an order taxed at one rate.
+type OrderTax struct {
+	TaxRateId int         `json:"tax_rate_id"`
+	TaxName   string      `json:"tax_name"`
+	Rate      float64     `json:"rate"`
+	Taxable   money.Money `json:"taxable"`
+	Tax       money.Money `json:"tax"`
+}
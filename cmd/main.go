@@ -4,8 +4,12 @@ import (
 	"app/api"
 	"app/config"
 	"app/pkg/logger"
+	"app/pkg/payment"
+	"app/storage"
 	"app/storage/postgresql"
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -39,6 +43,12 @@ func main() {
 
 	// ----------------------------------------------
 
+	provider, err := payment.NewProvider(cfg.PaymentProvider)
+	if err != nil {
+		log.Panic("Error payment provider: ", logger.Error(err))
+		return
+	}
+
 	store, err := postgresql.NewConnectPostgresql(&cfg)
 	if err != nil {
 		log.Panic("Error connect to postgresql: ", logger.Error(err))
@@ -46,12 +56,14 @@ func main() {
 	}
 	defer store.CloseDB()
 
+	go runReservationSweeper(store, &cfg, log)
+
 	r := gin.New()
 
 	// call logger
 	r.Use(gin.Recovery(), gin.Logger())
 
-	api.NewApi(r, &cfg, store, log)
+	api.NewApi(r, &cfg, store, log, provider)
 
 	fmt.Println("Server running on port", cfg.ServerHost+cfg.ServerPort)
 	err = r.Run(cfg.ServerHost + cfg.ServerPort)
@@ -60,3 +72,21 @@ func main() {
 		return
 	}
 }
+
+// runReservationSweeper periodically expires stock reservations of pending orders.
+func runReservationSweeper(store storage.StorageI, cfg *config.Config, log logger.LoggerI) {
+	ticker := time.NewTicker(cfg.ReservationSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		expired, err := store.Order().ExpireReservations(context.Background(), cfg.ReservationTTL)
+		if err != nil {
+			log.Error("Error expire reservations: ", logger.Error(err))
+			continue
+		}
+
+		if expired > 0 {
+			log.Info("Expired reservations", logger.Any("count", expired))
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package config
 
+import (
+	"os"
+	"time"
+)
+
 const (
 	// DebugMode indicates service mode is debug.
 	DebugMode = "debug"
@@ -23,6 +28,26 @@ type Config struct {
 
 	DefaultOffset int
 	DefaultLimit  int
+
+	ReorderSalesDays int // sales window used to compute velocity
+	ReorderCoverDays int // days of sales a reorder should cover
+
+	ReservationTTL           time.Duration // how long a pending order holds its stock
+	ReservationSweepInterval time.Duration
+
+	LoyaltyPointsPerUnit float64 // points earned per currency unit spent
+	LoyaltyPointValue    float64 // discount one point gives on redemption, in the base currency
+
+	MaxDiscountPercent float64 // largest price override a staff may give on a line
+
+	ReportingCurrency string // currency reports are converted to unless they ask for another
+
+	IdempotencyTTL time.Duration // how long a POST response is replayed for its Idempotency-Key
+
+	PaymentProvider string // provider of card and bank transfer payments from PAYMENT_PROVIDER, the server does not start without one
+
+	SearchSimilarity float64 // how close, 0 to 1, a misspelled word must be to a word of a product
+	SearchPriceBands []int   // upper bounds of the price bands search facets count, in the reporting currency
 }
 
 func Load() Config {
@@ -39,5 +64,26 @@ func Load() Config {
 	cfg.DefaultOffset = 0
 	cfg.DefaultLimit = 10
 
+	cfg.ReorderSalesDays = 30
+	cfg.ReorderCoverDays = 14
+
+	cfg.ReservationTTL = 30 * time.Minute
+	cfg.ReservationSweepInterval = time.Minute
+
+	cfg.LoyaltyPointsPerUnit = 1
+	cfg.LoyaltyPointValue = 0.01
+
+	cfg.MaxDiscountPercent = 20
+
+	cfg.ReportingCurrency = "USD"
+
+	cfg.IdempotencyTTL = 24 * time.Hour
+
+	// no default, the fake provider approves every charge and is only used when asked for
+	cfg.PaymentProvider = os.Getenv("PAYMENT_PROVIDER")
+
+	cfg.SearchSimilarity = 0.5
+	cfg.SearchPriceBands = []int{500, 1000, 2000, 5000}
+
 	return cfg
 }
@@ -8,6 +8,7 @@ require (
 	github.com/jackc/pgx v3.6.2+incompatible
 	github.com/jackc/pgx/v4 v4.18.1
 	github.com/lib/pq v1.10.2
+	github.com/shopspring/decimal v1.2.0
 	github.com/streamingfast/logging v0.0.0-20221209193439-bff11742bf4c
 	github.com/swaggo/files v1.0.1
 	github.com/swaggo/gin-swagger v1.5.3
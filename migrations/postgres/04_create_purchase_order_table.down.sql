@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS purchase_receipts;
+DROP TABLE IF EXISTS purchase_order_items;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TABLE IF EXISTS supplier_brands;
+DROP TABLE IF EXISTS suppliers;
@@ -0,0 +1,56 @@
+CREATE TABLE suppliers (
+	supplier_id SERIAL PRIMARY KEY,
+	supplier_name VARCHAR (255) NOT NULL,
+	phone VARCHAR (25),
+	email VARCHAR (255),
+	street VARCHAR (255),
+	city VARCHAR (255),
+	state VARCHAR (25),
+	zip_code VARCHAR (5),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE supplier_brands (
+	supplier_id INT,
+	brand_id INT,
+	PRIMARY KEY (supplier_id, brand_id),
+	FOREIGN KEY (supplier_id) REFERENCES suppliers (supplier_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (brand_id) REFERENCES brands (brand_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE TABLE purchase_orders (
+	purchase_order_id SERIAL PRIMARY KEY,
+	supplier_id INT NOT NULL,
+	status VARCHAR (25) NOT NULL DEFAULT 'draft',
+	order_date DATE NOT NULL DEFAULT CURRENT_DATE,
+	expected_date DATE,
+	note VARCHAR (255),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CHECK (status IN ('draft', 'sent', 'partially_received', 'received')),
+	FOREIGN KEY (supplier_id) REFERENCES suppliers (supplier_id) ON DELETE NO ACTION ON UPDATE CASCADE
+);
+
+CREATE TABLE purchase_order_items (
+	purchase_order_id INT,
+	item_id INT,
+	product_id INT NOT NULL,
+	store_id INT NOT NULL,
+	quantity INT NOT NULL CHECK (quantity > 0),
+	received_quantity INT NOT NULL DEFAULT 0,
+	cost_price DECIMAL (10, 2) NOT NULL DEFAULT 0,
+	PRIMARY KEY (purchase_order_id, item_id),
+	CHECK (received_quantity <= quantity),
+	FOREIGN KEY (purchase_order_id) REFERENCES purchase_orders (purchase_order_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (product_id) REFERENCES products (product_id) ON DELETE NO ACTION ON UPDATE CASCADE,
+	FOREIGN KEY (store_id) REFERENCES stores (store_id) ON DELETE NO ACTION ON UPDATE CASCADE
+);
+
+CREATE TABLE purchase_receipts (
+	receipt_id SERIAL PRIMARY KEY,
+	purchase_order_id INT NOT NULL,
+	item_id INT NOT NULL,
+	quantity INT NOT NULL CHECK (quantity > 0),
+	cost_price DECIMAL (10, 2) NOT NULL,
+	received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (purchase_order_id, item_id) REFERENCES purchase_order_items (purchase_order_id, item_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
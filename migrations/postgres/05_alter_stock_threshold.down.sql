@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS order_items_product_idx;
+
+ALTER TABLE stocks
+    DROP CONSTRAINT IF EXISTS stocks_threshold_check,
+    DROP COLUMN IF EXISTS reorder_point,
+    DROP COLUMN IF EXISTS target_level;
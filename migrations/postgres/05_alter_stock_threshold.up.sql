@@ -0,0 +1,8 @@
+ALTER TABLE stocks
+    ADD COLUMN reorder_point INT NOT NULL DEFAULT 0,
+    ADD COLUMN target_level INT NOT NULL DEFAULT 0;
+
+ALTER TABLE stocks
+    ADD CONSTRAINT stocks_threshold_check CHECK (reorder_point >= 0 AND target_level >= reorder_point);
+
+CREATE INDEX IF NOT EXISTS order_items_product_idx ON order_items (product_id);
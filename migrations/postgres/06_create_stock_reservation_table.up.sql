@@ -0,0 +1,17 @@
+CREATE TABLE stock_reservations (
+	reservation_id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL,
+	item_id INT NOT NULL,
+	store_id INT NOT NULL,
+	product_id INT NOT NULL,
+	quantity INT NOT NULL CHECK (quantity > 0),
+	status VARCHAR (25) NOT NULL DEFAULT 'active',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP,
+	CHECK (status IN ('active', 'consumed', 'released', 'expired')),
+	FOREIGN KEY (order_id, item_id) REFERENCES order_items (order_id, item_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (store_id, product_id) REFERENCES stocks (store_id, product_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX stock_reservations_active_idx ON stock_reservations (store_id, product_id) WHERE status = 'active';
+CREATE INDEX stock_reservations_order_idx ON stock_reservations (order_id);
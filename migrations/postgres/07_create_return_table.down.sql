@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS return_items;
+DROP TABLE IF EXISTS returns;
+
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS orders_return_status_check,
+    DROP COLUMN IF EXISTS return_status,
+    DROP COLUMN IF EXISTS promo_code;
+
+DROP TABLE IF EXISTS promo_code;
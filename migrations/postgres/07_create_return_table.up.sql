@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS promo_code (
+	code_id INT PRIMARY KEY,
+	code_name VARCHAR (50) NOT NULL,
+	discount NUMERIC,
+	discount_type VARCHAR,
+	order_limit_price NUMERIC
+);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS promo_code INT,
+    ADD COLUMN return_status VARCHAR (25) NOT NULL DEFAULT 'none',
+    ADD CONSTRAINT orders_return_status_check CHECK (return_status IN ('none', 'partially_returned', 'returned'));
+
+CREATE TABLE returns (
+	return_id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL,
+	status VARCHAR (25) NOT NULL DEFAULT 'requested',
+	reason VARCHAR (255),
+	store_id INT,
+	refund_amount DECIMAL (10, 2) NOT NULL DEFAULT 0,
+	note VARCHAR (255),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	processed_at TIMESTAMP,
+	CHECK (status IN ('requested', 'inspected', 'approved', 'rejected')),
+	FOREIGN KEY (order_id) REFERENCES orders (order_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (store_id) REFERENCES stores (store_id) ON DELETE NO ACTION ON UPDATE CASCADE
+);
+
+CREATE TABLE return_items (
+	return_id INT,
+	item_id INT,
+	order_id INT NOT NULL,
+	product_id INT NOT NULL,
+	quantity INT NOT NULL CHECK (quantity > 0),
+	reason VARCHAR (255),
+	restock BOOLEAN NOT NULL DEFAULT TRUE,
+	refund_amount DECIMAL (10, 2) NOT NULL DEFAULT 0,
+	PRIMARY KEY (return_id, item_id),
+	FOREIGN KEY (return_id) REFERENCES returns (return_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (order_id, item_id) REFERENCES order_items (order_id, item_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX returns_order_idx ON returns (order_id);
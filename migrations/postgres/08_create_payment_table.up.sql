@@ -0,0 +1,20 @@
+CREATE TABLE payments (
+	payment_id SERIAL PRIMARY KEY,
+	order_id INT NOT NULL,
+	tender VARCHAR (25) NOT NULL,
+	amount DECIMAL (10, 2) NOT NULL,
+	provider VARCHAR (50) NOT NULL,
+	transaction_id VARCHAR (100),
+	reference VARCHAR (100),
+	refund_of INT,
+	note VARCHAR (255),
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK (tender IN ('cash', 'card', 'bank_transfer', 'gift_card')),
+	CHECK (amount <> 0),
+	CHECK ((refund_of IS NULL AND amount > 0) OR (refund_of IS NOT NULL AND amount < 0)),
+	FOREIGN KEY (order_id) REFERENCES orders (order_id) ON DELETE NO ACTION ON UPDATE CASCADE,
+	FOREIGN KEY (refund_of) REFERENCES payments (payment_id) ON DELETE NO ACTION ON UPDATE CASCADE
+);
+
+CREATE INDEX payments_order_idx ON payments (order_id);
+CREATE INDEX payments_refund_of_idx ON payments (refund_of) WHERE refund_of IS NOT NULL;
@@ -0,0 +1,16 @@
+ALTER TABLE payments
+    DROP CONSTRAINT IF EXISTS payments_tender_check,
+    ADD CONSTRAINT payments_tender_check CHECK (tender IN ('cash', 'card', 'bank_transfer', 'gift_card'));
+
+ALTER TABLE returns
+    DROP CONSTRAINT IF EXISTS returns_refund_method_check,
+    DROP COLUMN IF EXISTS refund_method;
+
+DROP TABLE IF EXISTS customer_credit_ledger;
+
+ALTER TABLE customers
+    DROP CONSTRAINT IF EXISTS customers_store_credit_check,
+    DROP COLUMN IF EXISTS store_credit;
+
+DROP TABLE IF EXISTS gift_card_ledger;
+DROP TABLE IF EXISTS gift_cards;
@@ -0,0 +1,58 @@
+CREATE TABLE gift_cards (
+	gift_card_id SERIAL PRIMARY KEY,
+	code VARCHAR (32) NOT NULL UNIQUE,
+	customer_id INT,
+	initial_amount DECIMAL (10, 2) NOT NULL CHECK (initial_amount > 0),
+	balance DECIMAL (10, 2) NOT NULL CHECK (balance >= 0),
+	status VARCHAR (25) NOT NULL DEFAULT 'active',
+	expires_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK (status IN ('active', 'disabled')),
+	FOREIGN KEY (customer_id) REFERENCES customers (customer_id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE TABLE gift_card_ledger (
+	entry_id SERIAL PRIMARY KEY,
+	gift_card_id INT NOT NULL,
+	kind VARCHAR (25) NOT NULL,
+	amount DECIMAL (10, 2) NOT NULL,
+	balance_after DECIMAL (10, 2) NOT NULL,
+	order_id INT,
+	payment_id INT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK (kind IN ('issue', 'redeem', 'refund')),
+	FOREIGN KEY (gift_card_id) REFERENCES gift_cards (gift_card_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (payment_id) REFERENCES payments (payment_id) ON DELETE NO ACTION ON UPDATE CASCADE
+);
+
+CREATE INDEX gift_card_ledger_card_idx ON gift_card_ledger (gift_card_id);
+
+ALTER TABLE customers
+    ADD COLUMN store_credit DECIMAL (10, 2) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT customers_store_credit_check CHECK (store_credit >= 0);
+
+CREATE TABLE customer_credit_ledger (
+	entry_id SERIAL PRIMARY KEY,
+	customer_id INT NOT NULL,
+	kind VARCHAR (25) NOT NULL,
+	amount DECIMAL (10, 2) NOT NULL,
+	balance_after DECIMAL (10, 2) NOT NULL,
+	order_id INT,
+	return_id INT,
+	payment_id INT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK (kind IN ('return', 'redeem', 'refund')),
+	FOREIGN KEY (customer_id) REFERENCES customers (customer_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (return_id) REFERENCES returns (return_id) ON DELETE NO ACTION ON UPDATE CASCADE,
+	FOREIGN KEY (payment_id) REFERENCES payments (payment_id) ON DELETE NO ACTION ON UPDATE CASCADE
+);
+
+CREATE INDEX customer_credit_ledger_customer_idx ON customer_credit_ledger (customer_id);
+
+ALTER TABLE returns
+    ADD COLUMN refund_method VARCHAR (25) NOT NULL DEFAULT 'original',
+    ADD CONSTRAINT returns_refund_method_check CHECK (refund_method IN ('original', 'store_credit'));
+
+ALTER TABLE payments
+    DROP CONSTRAINT IF EXISTS payments_tender_check,
+    ADD CONSTRAINT payments_tender_check CHECK (tender IN ('cash', 'card', 'bank_transfer', 'gift_card', 'store_credit'));
@@ -0,0 +1,12 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS loyalty_discount,
+    DROP COLUMN IF EXISTS loyalty_points;
+
+DROP TABLE IF EXISTS loyalty_ledger;
+
+ALTER TABLE customers
+    DROP CONSTRAINT IF EXISTS customers_loyalty_points_check,
+    DROP COLUMN IF EXISTS loyalty_points;
+
+DROP TABLE IF EXISTS loyalty_multipliers;
+DROP TABLE IF EXISTS loyalty_tiers;
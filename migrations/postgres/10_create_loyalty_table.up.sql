@@ -0,0 +1,43 @@
+CREATE TABLE loyalty_tiers (
+	tier_id SERIAL PRIMARY KEY,
+	tier_name VARCHAR (50) NOT NULL UNIQUE,
+	min_spend DECIMAL (10, 2) NOT NULL UNIQUE CHECK (min_spend >= 0),
+	multiplier NUMERIC (4, 2) NOT NULL DEFAULT 1 CHECK (multiplier > 0)
+);
+
+INSERT INTO loyalty_tiers (tier_name, min_spend, multiplier) VALUES
+	('bronze', 0, 1),
+	('silver', 1000, 1.25),
+	('gold', 5000, 1.5);
+
+CREATE TABLE loyalty_multipliers (
+	multiplier_id SERIAL PRIMARY KEY,
+	brand_id INT UNIQUE,
+	category_id INT UNIQUE,
+	multiplier NUMERIC (4, 2) NOT NULL CHECK (multiplier > 0),
+	CHECK ((brand_id IS NULL) <> (category_id IS NULL)),
+	FOREIGN KEY (brand_id) REFERENCES brands (brand_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (category_id) REFERENCES categories (category_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+ALTER TABLE customers
+    ADD COLUMN loyalty_points INT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT customers_loyalty_points_check CHECK (loyalty_points >= 0);
+
+CREATE TABLE loyalty_ledger (
+	entry_id SERIAL PRIMARY KEY,
+	customer_id INT NOT NULL,
+	kind VARCHAR (25) NOT NULL,
+	points INT NOT NULL,
+	balance_after INT NOT NULL,
+	order_id INT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK (kind IN ('earn', 'redeem', 'release')),
+	FOREIGN KEY (customer_id) REFERENCES customers (customer_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX loyalty_ledger_customer_idx ON loyalty_ledger (customer_id);
+
+ALTER TABLE orders
+    ADD COLUMN loyalty_points INT NOT NULL DEFAULT 0,
+    ADD COLUMN loyalty_discount DECIMAL (10, 2) NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+ALTER TABLE loyalty_ledger
+    DROP CONSTRAINT IF EXISTS loyalty_ledger_kind_check,
+    ADD CONSTRAINT loyalty_ledger_kind_check CHECK (kind IN ('earn', 'redeem', 'release'));
+
+ALTER TABLE customer_credit_ledger
+    DROP CONSTRAINT IF EXISTS customer_credit_ledger_kind_check,
+    ADD CONSTRAINT customer_credit_ledger_kind_check CHECK (kind IN ('return', 'redeem', 'refund'));
+
+DROP TABLE IF EXISTS customer_merges;
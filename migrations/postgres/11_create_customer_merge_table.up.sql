@@ -0,0 +1,18 @@
+CREATE TABLE customer_merges (
+	merge_id SERIAL PRIMARY KEY,
+	survivor_id INT NOT NULL,
+	merged_id INT NOT NULL,
+	merged_data JSONB NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (survivor_id) REFERENCES customers (customer_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX customer_merges_survivor_idx ON customer_merges (survivor_id);
+
+ALTER TABLE customer_credit_ledger
+    DROP CONSTRAINT IF EXISTS customer_credit_ledger_kind_check,
+    ADD CONSTRAINT customer_credit_ledger_kind_check CHECK (kind IN ('return', 'redeem', 'refund', 'merge'));
+
+ALTER TABLE loyalty_ledger
+    DROP CONSTRAINT IF EXISTS loyalty_ledger_kind_check,
+    ADD CONSTRAINT loyalty_ledger_kind_check CHECK (kind IN ('earn', 'redeem', 'release', 'merge'));
@@ -0,0 +1,13 @@
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS orders_delivery_address_fkey,
+    DROP COLUMN IF EXISTS delivery_address,
+    DROP COLUMN IF EXISTS delivery_address_id;
+
+DROP TABLE IF EXISTS customer_addresses;
+
+-- zip_code goes back to NUMERIC, which is lossy: leading zeros are dropped, a ZIP+4
+-- "12345-6789" keeps its first part and any other non-digit is stripped, a code without
+-- digits becomes NULL. Running the up migration again pads the codes back to 5 digits.
+ALTER TABLE customers
+    ALTER COLUMN zip_code TYPE NUMERIC
+    USING CAST(NULLIF(REGEXP_REPLACE(SPLIT_PART(zip_code, '-', 1), '[^0-9]', '', 'g'), '') AS NUMERIC);
@@ -0,0 +1,32 @@
+ALTER TABLE customers
+    ALTER COLUMN zip_code TYPE VARCHAR (10) USING LPAD(CAST(zip_code AS VARCHAR), 5, '0');
+
+CREATE TABLE customer_addresses (
+	address_id SERIAL PRIMARY KEY,
+	customer_id INT NOT NULL,
+	label VARCHAR (50),
+	address_type VARCHAR (25) NOT NULL,
+	street VARCHAR (255),
+	city VARCHAR (50),
+	state VARCHAR (25),
+	zip_code VARCHAR (10),
+	is_default BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK (address_type IN ('billing', 'shipping')),
+	FOREIGN KEY (customer_id) REFERENCES customers (customer_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX customer_addresses_customer_idx ON customer_addresses (customer_id);
+CREATE UNIQUE INDEX customer_addresses_default_idx ON customer_addresses (customer_id, address_type) WHERE is_default;
+
+-- the flat address of existing customers becomes their default billing and shipping address
+INSERT INTO customer_addresses (customer_id, label, address_type, street, city, state, zip_code, is_default)
+SELECT c.customer_id, 'primary', t.address_type, c.street, c.city, c.state, c.zip_code, TRUE
+FROM customers AS c
+CROSS JOIN (VALUES ('billing'), ('shipping')) AS t (address_type)
+WHERE c.street IS NOT NULL OR c.city IS NOT NULL OR c.zip_code IS NOT NULL;
+
+ALTER TABLE orders
+    ADD COLUMN delivery_address_id INT,
+    ADD COLUMN delivery_address JSONB,
+    ADD CONSTRAINT orders_delivery_address_fkey FOREIGN KEY (delivery_address_id) REFERENCES customer_addresses (address_id) ON DELETE SET NULL ON UPDATE CASCADE;
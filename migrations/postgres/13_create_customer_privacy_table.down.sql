@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS customer_privacy_log;
+
+ALTER TABLE customers
+    DROP COLUMN IF EXISTS anonymized_at;
+
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS orders_customer_id_fkey,
+    ADD CONSTRAINT orders_customer_id_fkey FOREIGN KEY (customer_id) REFERENCES customers (customer_id) ON DELETE CASCADE ON UPDATE CASCADE;
@@ -0,0 +1,20 @@
+-- deleting a customer must not take their orders with it, customers with history are anonymized instead
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS orders_customer_id_fkey,
+    ADD CONSTRAINT orders_customer_id_fkey FOREIGN KEY (customer_id) REFERENCES customers (customer_id) ON DELETE RESTRICT ON UPDATE CASCADE;
+
+ALTER TABLE customers
+    ADD COLUMN anonymized_at TIMESTAMP;
+
+-- no foreign key, the log outlives the customer row
+CREATE TABLE customer_privacy_log (
+	log_id SERIAL PRIMARY KEY,
+	customer_id INT NOT NULL,
+	action VARCHAR (25) NOT NULL,
+	staff_id INT,
+	reason VARCHAR (255),
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK (action IN ('export', 'anonymize'))
+);
+
+CREATE INDEX customer_privacy_log_customer_idx ON customer_privacy_log (customer_id);
@@ -0,0 +1,30 @@
+ALTER TABLE stocks
+    DROP CONSTRAINT IF EXISTS stocks_store_id_fkey,
+    DROP CONSTRAINT IF EXISTS stocks_product_id_fkey,
+    ADD CONSTRAINT stocks_store_id_fkey FOREIGN KEY (store_id) REFERENCES stores (store_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD CONSTRAINT stocks_product_id_fkey FOREIGN KEY (product_id) REFERENCES products (product_id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS order_items_product_id_fkey,
+    ADD CONSTRAINT order_items_product_id_fkey FOREIGN KEY (product_id) REFERENCES products (product_id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS orders_store_id_fkey,
+    ADD CONSTRAINT orders_store_id_fkey FOREIGN KEY (store_id) REFERENCES stores (store_id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE staffs
+    DROP CONSTRAINT IF EXISTS staffs_store_id_fkey,
+    ADD CONSTRAINT staffs_store_id_fkey FOREIGN KEY (store_id) REFERENCES stores (store_id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS products_category_id_fkey,
+    DROP CONSTRAINT IF EXISTS products_brand_id_fkey,
+    ADD CONSTRAINT products_category_id_fkey FOREIGN KEY (category_id) REFERENCES categories (category_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    ADD CONSTRAINT products_brand_id_fkey FOREIGN KEY (brand_id) REFERENCES brands (brand_id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE staffs DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE stores DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE categories DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE brands DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,20 @@
+CREATE TABLE audit_log (
+	audit_id SERIAL PRIMARY KEY,
+	staff_id INT,
+	entity_type VARCHAR (50) NOT NULL,
+	entity_id INT,
+	action VARCHAR (50) NOT NULL,
+	method VARCHAR (10) NOT NULL,
+	path VARCHAR (255) NOT NULL,
+	status INT NOT NULL,
+	request_id VARCHAR (64) NOT NULL,
+	payload JSONB,
+	before_data JSONB,
+	after_data JSONB,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id);
+CREATE INDEX audit_log_staff_idx ON audit_log (staff_id);
+CREATE INDEX audit_log_request_idx ON audit_log (request_id);
+CREATE INDEX audit_log_created_idx ON audit_log (created_at);
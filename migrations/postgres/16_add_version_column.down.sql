@@ -0,0 +1,8 @@
+ALTER TABLE promo_code DROP COLUMN IF EXISTS version;
+ALTER TABLE orders DROP COLUMN IF EXISTS version;
+ALTER TABLE customers DROP COLUMN IF EXISTS version;
+ALTER TABLE staffs DROP COLUMN IF EXISTS version;
+ALTER TABLE stores DROP COLUMN IF EXISTS version;
+ALTER TABLE products DROP COLUMN IF EXISTS version;
+ALTER TABLE categories DROP COLUMN IF EXISTS version;
+ALTER TABLE brands DROP COLUMN IF EXISTS version;
@@ -0,0 +1,9 @@
+-- every write bumps the version, a client must send back the version it read in If-Match
+ALTER TABLE brands ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE categories ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE products ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE stores ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE staffs ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE customers ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE orders ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE promo_code ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+CREATE TABLE idempotency_keys (
+	idempotency_key VARCHAR (255) PRIMARY KEY,
+	request_hash VARCHAR (64) NOT NULL,
+	status INT,
+	response BYTEA,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idempotency_keys_created_idx ON idempotency_keys (created_at);
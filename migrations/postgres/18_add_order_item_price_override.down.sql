@@ -0,0 +1,6 @@
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS override_reason,
+    DROP COLUMN IF EXISTS override_staff_id,
+    DROP COLUMN IF EXISTS catalog_price;
+
+ALTER TABLE staffs DROP COLUMN IF EXISTS can_discount;
@@ -0,0 +1,9 @@
+ALTER TABLE staffs ADD COLUMN can_discount BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- the line price comes from the catalog, a staff override keeps who did it and why
+ALTER TABLE order_items
+    ADD COLUMN catalog_price DECIMAL (10, 2),
+    ADD COLUMN override_staff_id INT REFERENCES staffs (staff_id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    ADD COLUMN override_reason VARCHAR (255);
+
+UPDATE order_items SET catalog_price = list_price;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS price_history;
+
+ALTER TABLE customers
+    DROP CONSTRAINT IF EXISTS customers_price_list_fk,
+    DROP COLUMN IF EXISTS price_list_id;
+
+DROP TABLE IF EXISTS price_list_items;
+DROP TABLE IF EXISTS price_lists;
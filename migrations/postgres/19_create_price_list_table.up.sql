@@ -0,0 +1,54 @@
+CREATE TABLE price_lists (
+	price_list_id SERIAL PRIMARY KEY,
+	price_list_name VARCHAR (50) NOT NULL UNIQUE,
+	kind VARCHAR (25) NOT NULL,
+	CHECK (kind IN ('retail', 'wholesale', 'staff'))
+);
+
+INSERT INTO price_lists (price_list_name, kind) VALUES
+	('retail', 'retail'),
+	('wholesale', 'wholesale'),
+	('staff', 'staff');
+
+CREATE TABLE price_list_items (
+	price_list_item_id SERIAL PRIMARY KEY,
+	price_list_id INT NOT NULL,
+	product_id INT NOT NULL,
+	store_id INT,
+	price DECIMAL (10, 2) NOT NULL CHECK (price >= 0),
+	valid_from TIMESTAMP,
+	valid_to TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from < valid_to),
+	FOREIGN KEY (price_list_id) REFERENCES price_lists (price_list_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (product_id) REFERENCES products (product_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (store_id) REFERENCES stores (store_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX price_list_items_product_idx ON price_list_items (product_id, price_list_id);
+
+ALTER TABLE customers
+    ADD COLUMN price_list_id INT,
+    ADD CONSTRAINT customers_price_list_fk FOREIGN KEY (price_list_id) REFERENCES price_lists (price_list_id) ON DELETE SET NULL ON UPDATE CASCADE;
+
+-- price_list_id and store_id are kept without a foreign key, the history outlives the lists
+CREATE TABLE price_history (
+	history_id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL,
+	price_list_id INT,
+	price_list_item_id INT,
+	store_id INT,
+	change VARCHAR (25) NOT NULL,
+	old_price DECIMAL (10, 2),
+	new_price DECIMAL (10, 2),
+	valid_from TIMESTAMP,
+	valid_to TIMESTAMP,
+	changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK (change IN ('create', 'update', 'delete')),
+	FOREIGN KEY (product_id) REFERENCES products (product_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX price_history_product_idx ON price_history (product_id, changed_at);
+
+INSERT INTO price_history (product_id, change, new_price)
+SELECT product_id, 'create', list_price FROM products;
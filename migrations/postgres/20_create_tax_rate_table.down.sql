@@ -0,0 +1,13 @@
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS order_items_tax_rate_fk,
+    DROP COLUMN IF EXISTS tax_rate,
+    DROP COLUMN IF EXISTS tax_name,
+    DROP COLUMN IF EXISTS tax_rate_id;
+
+ALTER TABLE customers
+    DROP COLUMN IF EXISTS tax_exempt;
+
+ALTER TABLE stores
+    DROP COLUMN IF EXISTS tax_inclusive;
+
+DROP TABLE IF EXISTS tax_rates;
@@ -0,0 +1,25 @@
+-- a rate applies to the stores of a state or to one store, to one category or to all of them.
+-- an empty column matches everything.
+CREATE TABLE tax_rates (
+	tax_rate_id SERIAL PRIMARY KEY,
+	tax_name VARCHAR (50) NOT NULL,
+	state VARCHAR (25),
+	store_id INT,
+	category_id INT,
+	rate DECIMAL (6, 4) NOT NULL CHECK (rate >= 0 AND rate < 1),
+	FOREIGN KEY (store_id) REFERENCES stores (store_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (category_id) REFERENCES categories (category_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+ALTER TABLE stores
+    ADD COLUMN tax_inclusive BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE customers
+    ADD COLUMN tax_exempt BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- the rate in effect when the line was added, later rate changes do not touch placed orders
+ALTER TABLE order_items
+    ADD COLUMN tax_rate_id INT,
+    ADD COLUMN tax_name VARCHAR (50),
+    ADD COLUMN tax_rate DECIMAL (6, 4) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT order_items_tax_rate_fk FOREIGN KEY (tax_rate_id) REFERENCES tax_rates (tax_rate_id) ON DELETE SET NULL ON UPDATE CASCADE;
@@ -0,0 +1,8 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS currency;
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE promo_code DROP COLUMN IF EXISTS currency;
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
+ALTER TABLE stores DROP COLUMN IF EXISTS currency;
+
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,18 @@
+-- one unit of base_currency buys rate units of quote_currency from valid_from on
+CREATE TABLE exchange_rates (
+	exchange_rate_id SERIAL PRIMARY KEY,
+	base_currency CHAR (3) NOT NULL,
+	quote_currency CHAR (3) NOT NULL,
+	rate DECIMAL (18, 8) NOT NULL CHECK (rate > 0),
+	valid_from TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CHECK (base_currency <> quote_currency),
+	UNIQUE (base_currency, quote_currency, valid_from)
+);
+
+ALTER TABLE stores ADD COLUMN currency CHAR (3) NOT NULL DEFAULT 'USD';
+ALTER TABLE products ADD COLUMN currency CHAR (3) NOT NULL DEFAULT 'USD';
+ALTER TABLE promo_code ADD COLUMN currency CHAR (3) NOT NULL DEFAULT 'USD';
+
+-- an order, its items and its payments are in the currency of its store
+ALTER TABLE orders ADD COLUMN currency CHAR (3) NOT NULL DEFAULT 'USD';
+ALTER TABLE payments ADD COLUMN currency CHAR (3) NOT NULL DEFAULT 'USD';
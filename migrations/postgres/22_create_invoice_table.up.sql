@@ -0,0 +1,34 @@
+-- the last invoice number given out by a store. The row is bumped in the transaction that
+-- completes the order, a rolled back completion gives its number back so none is skipped.
+CREATE TABLE invoice_sequences (
+	store_id INT PRIMARY KEY,
+	last_number INT NOT NULL CHECK (last_number > 0),
+	FOREIGN KEY (store_id) REFERENCES stores (store_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- an invoiced order can no longer be deleted, its number has to stay in the books
+CREATE TABLE invoices (
+	invoice_id SERIAL PRIMARY KEY,
+	store_id INT NOT NULL,
+	invoice_number INT NOT NULL,
+	order_id INT NOT NULL UNIQUE,
+	issued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (store_id, invoice_number),
+	FOREIGN KEY (store_id) REFERENCES stores (store_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (order_id) REFERENCES orders (order_id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+-- orders completed before invoices existed are numbered in the order they were placed
+INSERT INTO invoices (store_id, invoice_number, order_id, issued_at)
+SELECT
+	store_id,
+	ROW_NUMBER() OVER (PARTITION BY store_id ORDER BY order_date, order_id),
+	order_id,
+	order_date
+FROM orders
+WHERE order_status = 4;
+
+INSERT INTO invoice_sequences (store_id, last_number)
+SELECT store_id, MAX(invoice_number)
+FROM invoices
+GROUP BY store_id;
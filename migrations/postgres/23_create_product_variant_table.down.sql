@@ -0,0 +1,45 @@
+ALTER TABLE purchase_order_items
+    DROP CONSTRAINT IF EXISTS purchase_order_items_variant_fk,
+    DROP COLUMN IF EXISTS variant_id;
+
+DROP INDEX IF EXISTS order_items_variant_idx;
+
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS order_items_variant_fk,
+    DROP COLUMN IF EXISTS variant_id;
+
+ALTER TABLE stock_reservations
+    DROP CONSTRAINT IF EXISTS stock_reservations_stock_fk;
+
+-- the stock of all variants of a product goes back into a single row
+UPDATE stocks AS s
+SET quantity = t.quantity
+FROM (
+	SELECT store_id, product_id, MIN(variant_id) AS variant_id, SUM(quantity) AS quantity
+	FROM stocks
+	GROUP BY store_id, product_id
+) AS t
+WHERE s.store_id = t.store_id AND s.variant_id = t.variant_id;
+
+DELETE FROM stocks AS s
+USING (
+	SELECT store_id, product_id, MIN(variant_id) AS variant_id
+	FROM stocks
+	GROUP BY store_id, product_id
+) AS t
+WHERE s.store_id = t.store_id AND s.product_id = t.product_id AND s.variant_id <> t.variant_id;
+
+ALTER TABLE stocks
+    DROP CONSTRAINT IF EXISTS stocks_variant_fk,
+    DROP CONSTRAINT stocks_pkey,
+    DROP COLUMN variant_id,
+    ADD PRIMARY KEY (store_id, product_id);
+
+DROP INDEX IF EXISTS stock_reservations_active_idx;
+CREATE INDEX stock_reservations_active_idx ON stock_reservations (store_id, product_id) WHERE status = 'active';
+
+ALTER TABLE stock_reservations
+    DROP COLUMN IF EXISTS variant_id,
+    ADD CONSTRAINT stock_reservations_store_id_product_id_fkey FOREIGN KEY (store_id, product_id) REFERENCES stocks (store_id, product_id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+DROP TABLE IF EXISTS product_variants;
@@ -0,0 +1,82 @@
+-- a sellable option combination of a product, stock and order lines are kept per variant
+CREATE TABLE product_variants (
+	variant_id SERIAL PRIMARY KEY,
+	product_id INT NOT NULL,
+	sku VARCHAR (50) NOT NULL UNIQUE,
+	size VARCHAR (25),
+	color VARCHAR (50),
+	frame_material VARCHAR (50),
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (variant_id, product_id),
+	FOREIGN KEY (product_id) REFERENCES products (product_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- an option the product does not come in is left empty
+CREATE UNIQUE INDEX product_variants_options_idx ON product_variants (
+	product_id,
+	COALESCE(size, ''),
+	COALESCE(color, ''),
+	COALESCE(frame_material, '')
+);
+
+-- every existing product becomes a single variant without options
+INSERT INTO product_variants (product_id, sku)
+SELECT product_id, 'P' || LPAD(CAST(product_id AS VARCHAR), 6, '0')
+FROM products;
+
+ALTER TABLE stock_reservations
+    DROP CONSTRAINT IF EXISTS stock_reservations_store_id_product_id_fkey,
+    ADD COLUMN variant_id INT;
+
+UPDATE stock_reservations AS sr
+SET variant_id = v.variant_id
+FROM product_variants AS v
+WHERE v.product_id = sr.product_id;
+
+ALTER TABLE stocks
+    ADD COLUMN variant_id INT;
+
+UPDATE stocks AS s
+SET variant_id = v.variant_id
+FROM product_variants AS v
+WHERE v.product_id = s.product_id;
+
+-- product_id stays on the stock row so product totals do not need the variants
+ALTER TABLE stocks
+    DROP CONSTRAINT stocks_pkey,
+    ALTER COLUMN variant_id SET NOT NULL,
+    ADD PRIMARY KEY (store_id, variant_id),
+    ADD CONSTRAINT stocks_variant_fk FOREIGN KEY (variant_id, product_id) REFERENCES product_variants (variant_id, product_id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE stock_reservations
+    ALTER COLUMN variant_id SET NOT NULL,
+    ADD CONSTRAINT stock_reservations_stock_fk FOREIGN KEY (store_id, variant_id) REFERENCES stocks (store_id, variant_id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+DROP INDEX IF EXISTS stock_reservations_active_idx;
+CREATE INDEX stock_reservations_active_idx ON stock_reservations (store_id, variant_id) WHERE status = 'active';
+
+ALTER TABLE order_items
+    ADD COLUMN variant_id INT;
+
+UPDATE order_items AS oi
+SET variant_id = v.variant_id
+FROM product_variants AS v
+WHERE v.product_id = oi.product_id;
+
+ALTER TABLE order_items
+    ALTER COLUMN variant_id SET NOT NULL,
+    ADD CONSTRAINT order_items_variant_fk FOREIGN KEY (variant_id, product_id) REFERENCES product_variants (variant_id, product_id) ON DELETE RESTRICT ON UPDATE CASCADE;
+
+CREATE INDEX order_items_variant_idx ON order_items (variant_id);
+
+ALTER TABLE purchase_order_items
+    ADD COLUMN variant_id INT;
+
+UPDATE purchase_order_items AS poi
+SET variant_id = v.variant_id
+FROM product_variants AS v
+WHERE v.product_id = poi.product_id;
+
+ALTER TABLE purchase_order_items
+    ALTER COLUMN variant_id SET NOT NULL,
+    ADD CONSTRAINT purchase_order_items_variant_fk FOREIGN KEY (variant_id, product_id) REFERENCES product_variants (variant_id, product_id) ON DELETE RESTRICT ON UPDATE CASCADE;
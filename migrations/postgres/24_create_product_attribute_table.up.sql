@@ -0,0 +1,36 @@
+-- what products of a category can be described by, code is what filters use
+CREATE TABLE attribute_definitions (
+	attribute_id SERIAL PRIMARY KEY,
+	category_id INT NOT NULL,
+	code VARCHAR (50) NOT NULL,
+	attribute_name VARCHAR (100) NOT NULL,
+	data_type VARCHAR (10) NOT NULL,
+	unit VARCHAR (20),
+	required BOOLEAN NOT NULL DEFAULT FALSE,
+	min_value DECIMAL (12, 3),
+	max_value DECIMAL (12, 3),
+	allowed_values TEXT [],
+	UNIQUE (category_id, code),
+	CHECK (data_type IN ('text', 'integer', 'decimal', 'boolean', 'enum')),
+	CHECK (data_type = 'enum' OR allowed_values IS NULL),
+	CHECK (data_type IN ('integer', 'decimal') OR (min_value IS NULL AND max_value IS NULL)),
+	CHECK (min_value IS NULL OR max_value IS NULL OR min_value <= max_value),
+	FOREIGN KEY (category_id) REFERENCES categories (category_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX attribute_definitions_code_idx ON attribute_definitions (code);
+
+-- the value sits in the column of its type so numbers compare as numbers
+CREATE TABLE product_attributes (
+	product_id INT,
+	attribute_id INT,
+	value_text VARCHAR (255),
+	value_number DECIMAL (12, 3),
+	value_boolean BOOLEAN,
+	PRIMARY KEY (product_id, attribute_id),
+	CHECK (num_nonnulls(value_text, value_number, value_boolean) = 1),
+	FOREIGN KEY (product_id) REFERENCES products (product_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	FOREIGN KEY (attribute_id) REFERENCES attribute_definitions (attribute_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX product_attributes_attribute_idx ON product_attributes (attribute_id);
@@ -0,0 +1,18 @@
+DROP TRIGGER IF EXISTS product_attributes_search_touch ON product_attributes;
+DROP TRIGGER IF EXISTS attribute_definitions_search_touch ON attribute_definitions;
+DROP TRIGGER IF EXISTS categories_search_touch ON categories;
+DROP TRIGGER IF EXISTS brands_search_touch ON brands;
+DROP TRIGGER IF EXISTS products_search_refresh ON products;
+
+DROP FUNCTION IF EXISTS product_search_touch();
+DROP FUNCTION IF EXISTS product_search_refresh();
+DROP FUNCTION IF EXISTS product_search_attributes(INT, INT);
+
+DROP INDEX IF EXISTS products_search_text_trgm_idx;
+DROP INDEX IF EXISTS products_search_vector_idx;
+
+ALTER TABLE products
+	DROP COLUMN IF EXISTS search_vector,
+	DROP COLUMN IF EXISTS search_text;
+
+DROP EXTENSION IF EXISTS pg_trgm;
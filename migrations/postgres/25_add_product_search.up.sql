@@ -0,0 +1,91 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- search_text is what a product is found by: its name, brand, category and attribute values.
+-- search_vector weighs the name above the brand, the brand above the category and the
+-- category above the attributes, triggers keep both in step with the tables they come from
+ALTER TABLE products
+	ADD COLUMN search_text TEXT NOT NULL DEFAULT '',
+	ADD COLUMN search_vector TSVECTOR NOT NULL DEFAULT '';
+
+CREATE FUNCTION product_search_attributes(p_product_id INT, p_category_id INT) RETURNS TEXT AS $$
+	SELECT COALESCE(STRING_AGG(
+		CASE
+			WHEN pa.value_boolean IS NOT NULL THEN d.attribute_name
+			ELSE CONCAT_WS(' ',
+				d.attribute_name,
+				COALESCE(pa.value_text, CAST(CAST(pa.value_number AS FLOAT8) AS TEXT)),
+				d.unit
+			)
+		END, ', ' ORDER BY d.attribute_name
+	), '')
+	FROM product_attributes AS pa
+	JOIN attribute_definitions AS d ON d.attribute_id = pa.attribute_id
+	WHERE pa.product_id = p_product_id AND d.category_id = p_category_id
+		AND pa.value_boolean IS DISTINCT FROM FALSE
+$$ LANGUAGE SQL STABLE;
+
+CREATE FUNCTION product_search_refresh() RETURNS TRIGGER AS $$
+DECLARE
+	brand TEXT;
+	category TEXT;
+	attributes TEXT;
+BEGIN
+	SELECT brand_name INTO brand FROM brands WHERE brand_id = NEW.brand_id;
+	SELECT category_name INTO category FROM categories WHERE category_id = NEW.category_id;
+	attributes := product_search_attributes(NEW.product_id, NEW.category_id);
+
+	NEW.search_text := CONCAT_WS(', ', NEW.product_name, brand, category, NULLIF(attributes, ''));
+	NEW.search_vector :=
+		SETWEIGHT(TO_TSVECTOR('english', COALESCE(NEW.product_name, '')), 'A') ||
+		SETWEIGHT(TO_TSVECTOR('english', COALESCE(brand, '')), 'B') ||
+		SETWEIGHT(TO_TSVECTOR('english', COALESCE(category, '')), 'C') ||
+		SETWEIGHT(TO_TSVECTOR('english', attributes), 'D');
+
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER products_search_refresh
+	BEFORE INSERT OR UPDATE ON products
+	FOR EACH ROW EXECUTE FUNCTION product_search_refresh();
+
+-- a change elsewhere rewrites search_text of the products it shows in, which fires the trigger above
+CREATE FUNCTION product_search_touch() RETURNS TRIGGER AS $$
+BEGIN
+	CASE TG_TABLE_NAME
+	WHEN 'brands' THEN
+		UPDATE products SET search_text = search_text WHERE brand_id = NEW.brand_id;
+	WHEN 'categories' THEN
+		UPDATE products SET search_text = search_text WHERE category_id = NEW.category_id;
+	WHEN 'attribute_definitions' THEN
+		UPDATE products SET search_text = search_text
+		WHERE product_id IN (SELECT product_id FROM product_attributes WHERE attribute_id = NEW.attribute_id);
+	ELSE
+		UPDATE products SET search_text = search_text
+		WHERE product_id = CASE TG_OP WHEN 'DELETE' THEN OLD.product_id ELSE NEW.product_id END;
+	END CASE;
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER brands_search_touch
+	AFTER UPDATE OF brand_name ON brands
+	FOR EACH ROW EXECUTE FUNCTION product_search_touch();
+
+CREATE TRIGGER categories_search_touch
+	AFTER UPDATE OF category_name ON categories
+	FOR EACH ROW EXECUTE FUNCTION product_search_touch();
+
+CREATE TRIGGER attribute_definitions_search_touch
+	AFTER UPDATE OF attribute_name, unit ON attribute_definitions
+	FOR EACH ROW EXECUTE FUNCTION product_search_touch();
+
+CREATE TRIGGER product_attributes_search_touch
+	AFTER INSERT OR UPDATE OR DELETE ON product_attributes
+	FOR EACH ROW EXECUTE FUNCTION product_search_touch();
+
+UPDATE products SET search_text = search_text;
+
+CREATE INDEX products_search_vector_idx ON products USING GIN (search_vector);
+CREATE INDEX products_search_text_trgm_idx ON products USING GIN (search_text gin_trgm_ops);
@@ -0,0 +1,12 @@
+ALTER TABLE purchase_orders DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE price_history DROP COLUMN IF EXISTS new_currency;
+ALTER TABLE price_history DROP COLUMN IF EXISTS old_currency;
+ALTER TABLE price_list_items DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE returns DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE customer_credit_ledger DROP COLUMN IF EXISTS currency;
+ALTER TABLE customers DROP COLUMN IF EXISTS store_credit_currency;
+
+ALTER TABLE gift_cards DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,21 @@
+-- a gift card keeps the currency it was issued in
+ALTER TABLE gift_cards ADD COLUMN currency CHAR (3) NOT NULL DEFAULT 'USD';
+
+-- store credit is held in one currency, it may only change while the balance is zero
+ALTER TABLE customers ADD COLUMN store_credit_currency CHAR (3) NOT NULL DEFAULT 'USD';
+ALTER TABLE customer_credit_ledger ADD COLUMN currency CHAR (3) NOT NULL DEFAULT 'USD';
+
+-- a refund is in the currency of the order returned
+ALTER TABLE returns ADD COLUMN currency CHAR (3) NOT NULL DEFAULT 'USD';
+UPDATE returns AS r SET currency = o.currency FROM orders AS o WHERE o.order_id = r.order_id;
+
+-- a price list price is in the currency of its product
+ALTER TABLE price_list_items ADD COLUMN currency CHAR (3) NOT NULL DEFAULT 'USD';
+UPDATE price_list_items AS i SET currency = p.currency FROM products AS p WHERE p.product_id = i.product_id;
+
+ALTER TABLE price_history ADD COLUMN old_currency CHAR (3) NOT NULL DEFAULT 'USD';
+ALTER TABLE price_history ADD COLUMN new_currency CHAR (3) NOT NULL DEFAULT 'USD';
+UPDATE price_history AS h SET old_currency = p.currency, new_currency = p.currency FROM products AS p WHERE p.product_id = h.product_id;
+
+-- costs of a purchase order and its receipts are in the currency of the order
+ALTER TABLE purchase_orders ADD COLUMN currency CHAR (3) NOT NULL DEFAULT 'USD';
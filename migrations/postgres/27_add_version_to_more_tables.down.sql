@@ -0,0 +1,9 @@
+ALTER TABLE gift_cards DROP COLUMN IF EXISTS version;
+ALTER TABLE attribute_definitions DROP COLUMN IF EXISTS version;
+ALTER TABLE product_variants DROP COLUMN IF EXISTS version;
+ALTER TABLE customer_addresses DROP COLUMN IF EXISTS version;
+ALTER TABLE tax_rates DROP COLUMN IF EXISTS version;
+ALTER TABLE price_list_items DROP COLUMN IF EXISTS version;
+ALTER TABLE purchase_orders DROP COLUMN IF EXISTS version;
+ALTER TABLE suppliers DROP COLUMN IF EXISTS version;
+ALTER TABLE stocks DROP COLUMN IF EXISTS version;
@@ -0,0 +1,10 @@
+-- the tables left out of 16, every write bumps the version and PUT, PATCH and DELETE need If-Match
+ALTER TABLE stocks ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE suppliers ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE purchase_orders ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE price_list_items ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE tax_rates ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE customer_addresses ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE product_variants ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE attribute_definitions ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE gift_cards ADD COLUMN version INT NOT NULL DEFAULT 1;
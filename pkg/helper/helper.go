@@ -3,6 +3,7 @@ package helper
 import (
 	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"strings"
@@ -51,6 +52,17 @@ func GenerateOTP(length int) (string, error) {
 	return string(buffer), nil
 }
 
+// NewRequestId returns a random 32 character hex id.
+func NewRequestId() (string, error) {
+	buffer := make([]byte, 16)
+	_, err := rand.Read(buffer)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buffer), nil
+}
+
 func Difference(a, b []int32) []int32 {
 	mb := make(map[int32]struct{}, len(b))
 	for _, x := range b {
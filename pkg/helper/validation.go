@@ -3,6 +3,7 @@ package helper
 import (
 	"errors"
 	"regexp"
+	"strings"
 )
 
 func ValidPinfl(pinfl string) error {
@@ -63,3 +64,79 @@ func IsValidPrice(price string) bool {
 	r := regexp.MustCompile(`^\d+$`)
 	return r.MatchString(price)
 }
+
+// NormalizeEmail lowercases the address and drops a "+tag" from its local part,
+// so that variants of the same mailbox compare equal.
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return email
+	}
+
+	local, domain := email[:at], email[at:]
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+
+	return local + domain
+}
+
+// NormalizePhone brings a local or international Uzbek number to the +998XXXXXXXXX
+// form accepted by IsValidPhone, an empty string means the number can not be normalized.
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+
+	number := digits.String()
+	switch {
+	case len(number) == 9:
+		number = "+998" + number
+	case len(number) == 12 && strings.HasPrefix(number, "998"):
+		number = "+" + number
+	}
+
+	if !IsValidPhone(number) {
+		return ""
+	}
+
+	return number
+}
+
+// Levenshtein is the edit distance between two strings.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = prev[j] + 1
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+			if prev[j-1]+cost < curr[j] {
+				curr[j] = prev[j-1] + cost
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
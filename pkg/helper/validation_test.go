@@ -0,0 +1,79 @@
+package helper
+
+import "testing"
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "John.Doe@Example.COM", want: "john.doe@example.com"},
+		{email: "  john@example.com ", want: "john@example.com"},
+		{email: "john+shop@example.com", want: "john@example.com"},
+		{email: "John+Shop+2024@Example.com", want: "john@example.com"},
+		{email: "a+b@c+d.com", want: "a@c+d.com"},
+		{email: "+tag@example.com", want: "+tag@example.com"},
+		{email: "@example.com", want: "@example.com"},
+		{email: "no-at-sign", want: "no-at-sign"},
+		{email: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeEmail(tt.email); got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name  string
+		phone string
+		want  string
+	}{
+		{name: "local", phone: "901234567", want: "+998901234567"},
+		{name: "local formatted", phone: "(90) 123-45-67", want: "+998901234567"},
+		{name: "998 prefixed", phone: "998901234567", want: "+998901234567"},
+		{name: "international", phone: "+998 90 123 45 67", want: "+998901234567"},
+		{name: "already normalized", phone: "+998901234567", want: "+998901234567"},
+		{name: "other country", phone: "+997901234567", want: ""},
+		{name: "ten digits", phone: "8901234567", want: ""},
+		{name: "too short", phone: "12345", want: ""},
+		{name: "empty", phone: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizePhone(tt.phone); got != tt.want {
+				t.Errorf("NormalizePhone(%q) = %q, want %q", tt.phone, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "abc", b: "", want: 3},
+		{a: "", b: "abc", want: 3},
+		{a: "same", b: "same", want: 0},
+		{a: "Ali", b: "ali", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "flaw", b: "lawn", want: 2},
+		{a: "alexander", b: "aleksander", want: 2},
+		{a: "жора", b: "жара", want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+
+		if got := Levenshtein(tt.b, tt.a); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCurrency is the currency of stores, products and promo codes created without one.
+const DefaultCurrency = "USD"
+
+// Cents is the number of decimal places amounts are rounded to.
+const Cents = 2
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money is an exact decimal amount in an ISO 4217 currency.
+type Money struct {
+	Amount   decimal.Decimal `json:"amount" swaggertype:"string" example:"10.00"`
+	Currency string          `json:"currency" example:"USD"`
+}
+
+// New rounds the amount to cents.
+func New(amount decimal.Decimal, currency string) Money {
+	return Money{Amount: amount.Round(Cents), Currency: currency}
+}
+
+func Zero(currency string) Money {
+	return Money{Amount: decimal.Zero, Currency: currency}
+}
+
+// ValidCurrency checks the code is three upper case letters.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// In fills an empty currency with the given one and fails when another one is set.
+func (m Money) In(currency string) (Money, error) {
+	if m.Currency == "" {
+		m.Currency = currency
+	}
+
+	if m.Currency != currency {
+		return m, fmt.Errorf("%w: %s, expected %s", ErrCurrencyMismatch, m.Currency, currency)
+	}
+
+	return m, nil
+}
+
+// Convert gives the amount in another currency, rate is how much of it one unit of m buys.
+func (m Money) Convert(rate decimal.Decimal, currency string) Money {
+	return New(m.Amount.Mul(rate), currency)
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: m.Amount.Neg(), Currency: m.Currency}
+}
+
+func (m Money) Float64() float64 {
+	f, _ := m.Amount.Float64()
+	return f
+}
+
+func (m Money) String() string {
+	return m.Amount.StringFixed(Cents) + " " + m.Currency
+}
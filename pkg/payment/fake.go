@@ -0,0 +1,71 @@
+package payment
+
+import (
+	"app/pkg/money"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// FakeProvider accepts every charge up to DeclineAbove (0 means no limit)
+// and keeps transactions in memory, it is meant for local runs and tests.
+type FakeProvider struct {
+	DeclineAbove float64
+
+	mu           sync.Mutex
+	seq          int
+	transactions map[string]money.Money // transaction id -> refundable amount
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		transactions: map[string]money.Money{},
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return ProviderFake
+}
+
+func (p *FakeProvider) Charge(ctx context.Context, req *Charge) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !req.Amount.Amount.IsPositive() || (p.DeclineAbove > 0 && req.Amount.Amount.GreaterThan(decimal.NewFromFloat(p.DeclineAbove))) {
+		return nil, ErrDeclined
+	}
+
+	id := p.next("ch")
+	p.transactions[id] = req.Amount
+
+	return &Result{Provider: p.Name(), TransactionId: id}, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, req *Refund) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	remaining, ok := p.transactions[req.TransactionId]
+	if !ok {
+		return nil, ErrUnknownTransaction
+	}
+
+	if req.Amount.Currency != remaining.Currency {
+		return nil, money.ErrCurrencyMismatch
+	}
+
+	if !req.Amount.Amount.IsPositive() || req.Amount.Amount.GreaterThan(remaining.Amount) {
+		return nil, ErrRefundExceedsCharge
+	}
+
+	p.transactions[req.TransactionId] = money.New(remaining.Amount.Sub(req.Amount.Amount), remaining.Currency)
+
+	return &Result{Provider: p.Name(), TransactionId: p.next("re")}, nil
+}
+
+func (p *FakeProvider) next(prefix string) string {
+	p.seq++
+	return fmt.Sprintf("fake_%s_%d", prefix, p.seq)
+}
@@ -0,0 +1,153 @@
+package payment
+
+import (
+	"app/pkg/money"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func usd(amount string) money.Money {
+	return money.New(decimal.RequireFromString(amount), "USD")
+}
+
+func TestFakeProviderCharge(t *testing.T) {
+	p := NewFakeProvider()
+
+	res, err := p.Charge(context.Background(), &Charge{OrderId: 1, Tender: "card", Amount: usd("25.50")})
+	if err != nil {
+		t.Fatalf("charge: %v", err)
+	}
+
+	if res.Provider != ProviderFake {
+		t.Errorf("provider = %s, want %s", res.Provider, ProviderFake)
+	}
+
+	if res.TransactionId == "" {
+		t.Error("charge has no transaction id")
+	}
+
+	next, err := p.Charge(context.Background(), &Charge{OrderId: 1, Tender: "card", Amount: usd("1.00")})
+	if err != nil {
+		t.Fatalf("second charge: %v", err)
+	}
+
+	if next.TransactionId == res.TransactionId {
+		t.Errorf("transaction id %s is given twice", res.TransactionId)
+	}
+}
+
+func TestFakeProviderChargeDeclined(t *testing.T) {
+	p := NewFakeProvider()
+	p.DeclineAbove = 100
+
+	tests := []struct {
+		name   string
+		amount money.Money
+	}{
+		{name: "zero", amount: usd("0")},
+		{name: "negative", amount: usd("-5.00")},
+		{name: "above limit", amount: usd("100.01")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Charge(context.Background(), &Charge{OrderId: 1, Tender: "card", Amount: tt.amount})
+			if !errors.Is(err, ErrDeclined) {
+				t.Errorf("err = %v, want %v", err, ErrDeclined)
+			}
+		})
+	}
+
+	_, err := p.Charge(context.Background(), &Charge{OrderId: 1, Tender: "card", Amount: usd("100.00")})
+	if err != nil {
+		t.Errorf("charge at the limit: %v", err)
+	}
+}
+
+func TestFakeProviderRefund(t *testing.T) {
+	p := NewFakeProvider()
+
+	charge, err := p.Charge(context.Background(), &Charge{OrderId: 1, Tender: "card", Amount: usd("30.00")})
+	if err != nil {
+		t.Fatalf("charge: %v", err)
+	}
+
+	// partial refunds until the charge is used up
+	for _, amount := range []string{"10.00", "19.99", "0.01"} {
+		res, err := p.Refund(context.Background(), &Refund{OrderId: 1, Tender: "card", Amount: usd(amount), TransactionId: charge.TransactionId})
+		if err != nil {
+			t.Fatalf("refund %s: %v", amount, err)
+		}
+
+		if res.TransactionId == "" || res.TransactionId == charge.TransactionId {
+			t.Errorf("refund %s has transaction id %q", amount, res.TransactionId)
+		}
+	}
+
+	_, err = p.Refund(context.Background(), &Refund{OrderId: 1, Tender: "card", Amount: usd("0.01"), TransactionId: charge.TransactionId})
+	if !errors.Is(err, ErrRefundExceedsCharge) {
+		t.Errorf("refund past the charge: err = %v, want %v", err, ErrRefundExceedsCharge)
+	}
+}
+
+func TestFakeProviderRefundFailure(t *testing.T) {
+	p := NewFakeProvider()
+
+	charge, err := p.Charge(context.Background(), &Charge{OrderId: 1, Tender: "card", Amount: usd("20.00")})
+	if err != nil {
+		t.Fatalf("charge: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		amount        money.Money
+		transactionId string
+		want          error
+	}{
+		{name: "unknown transaction", amount: usd("5.00"), transactionId: "fake_ch_404", want: ErrUnknownTransaction},
+		{name: "other currency", amount: money.New(decimal.RequireFromString("5.00"), "EUR"), transactionId: charge.TransactionId, want: money.ErrCurrencyMismatch},
+		{name: "more than charged", amount: usd("20.01"), transactionId: charge.TransactionId, want: ErrRefundExceedsCharge},
+		{name: "zero", amount: usd("0"), transactionId: charge.TransactionId, want: ErrRefundExceedsCharge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Refund(context.Background(), &Refund{OrderId: 1, Tender: "card", Amount: tt.amount, TransactionId: tt.transactionId})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// a failed refund leaves the whole charge refundable
+	_, err = p.Refund(context.Background(), &Refund{OrderId: 1, Tender: "card", Amount: usd("20.00"), TransactionId: charge.TransactionId})
+	if err != nil {
+		t.Errorf("refund of the whole charge: %v", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "", wantErr: true},
+		{name: "stripe", wantErr: true},
+		{name: ProviderFake},
+	}
+
+	for _, tt := range tests {
+		provider, err := NewProvider(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewProvider(%q) err = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if err == nil && provider.Name() != tt.name {
+			t.Errorf("NewProvider(%q) gives %s", tt.name, provider.Name())
+		}
+	}
+}
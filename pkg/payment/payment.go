@@ -0,0 +1,57 @@
+package payment
+
+import (
+	"app/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrDeclined            = errors.New("payment declined")
+	ErrUnknownTransaction  = errors.New("unknown transaction")
+	ErrRefundExceedsCharge = errors.New("refund exceeds the charged amount")
+)
+
+// ProviderFake is the name of the in-memory provider.
+const ProviderFake = "fake"
+
+type Charge struct {
+	OrderId   int
+	Tender    string
+	Amount    money.Money
+	Reference string // card token, bank transfer reference, gift card code
+}
+
+type Refund struct {
+	OrderId       int
+	Tender        string
+	Amount        money.Money
+	TransactionId string // transaction of the charge being refunded
+}
+
+type Result struct {
+	Provider      string
+	TransactionId string
+}
+
+// Provider moves the money of non cash tenders, the api records a payment
+// only after the provider has accepted the charge or refund.
+type Provider interface {
+	Name() string
+	Charge(ctx context.Context, req *Charge) (*Result, error)
+	Refund(ctx context.Context, req *Refund) (*Result, error)
+}
+
+// NewProvider gives the provider configured by name, an empty or unknown name is an error
+// so a misconfigured server does not start.
+func NewProvider(name string) (Provider, error) {
+	switch name {
+	case "":
+		return nil, errors.New("payment provider is not set")
+	case ProviderFake:
+		return NewFakeProvider(), nil
+	}
+
+	return nil, fmt.Errorf("unknown payment provider %s", name)
+}
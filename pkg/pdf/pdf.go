@@ -0,0 +1,127 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 in points, text is set in Courier so columns laid out for a terminal line up on paper.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	margin     = 40
+	fontSize   = 10
+	leading    = 12
+
+	// Columns is how many characters fit on a line between the margins.
+	Columns = (pageWidth - 2*margin) * 10 / (fontSize * 6)
+
+	linesPerPage = (pageHeight - 2*margin) / leading
+)
+
+type Line struct {
+	Text string
+	Bold bool
+}
+
+// Render writes the lines as a PDF document, starting a new page whenever one is full.
+// Text longer than Columns runs off the page, it is up to the caller to wrap it.
+func Render(title string, lines []Line) []byte {
+	var (
+		buf     bytes.Buffer
+		offsets []int
+		pages   [][]Line
+	)
+
+	for len(lines) > linesPerPage {
+		pages = append(pages, lines[:linesPerPage])
+		lines = lines[linesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// 1 catalog, 2 page tree, 3 and 4 fonts, 5 info, then a page and its content per page
+	kids := make([]string, 0, len(pages))
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 6+2*i))
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (app) >>", escape(title)))
+
+	for i, page := range pages {
+		content := pageContent(page)
+
+		object(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 7+2*i,
+		))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+func pageContent(lines []Line) string {
+	var (
+		content strings.Builder
+		bold    bool
+	)
+
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, leading, margin, pageHeight-margin-fontSize)
+
+	for _, line := range lines {
+		if line.Bold != bold {
+			bold = line.Bold
+			font := "/F1"
+			if bold {
+				font = "/F2"
+			}
+			fmt.Fprintf(&content, "%s %d Tf\n", font, fontSize)
+		}
+
+		fmt.Fprintf(&content, "(%s) Tj T*\n", escape(line.Text))
+	}
+
+	content.WriteString("ET")
+
+	return content.String()
+}
+
+// escape makes the text a PDF string literal in WinAnsiEncoding, Latin-1 letters are written
+// as octal escapes and what the standard fonts can not show becomes a question mark.
+func escape(text string) string {
+	var b strings.Builder
+
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+
+	return b.String()
+}
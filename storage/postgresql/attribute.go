@@ -0,0 +1,635 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// attributeScale is the number of decimals product_attributes.value_number keeps.
+const attributeScale = 3
+
+var attributeCodeRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+type attributeRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAttributeRepo(db *pgxpool.Pool) *attributeRepo {
+	return &attributeRepo{
+		db: db,
+	}
+}
+
+func (r *attributeRepo) Create(ctx context.Context, req *models.CreateAttributeDefinition) (int, error) {
+	var id int
+
+	definition := models.AttributeDefinition{
+		CategoryId:    req.CategoryId,
+		Code:          req.Code,
+		AttributeName: req.AttributeName,
+		DataType:      req.DataType,
+		Unit:          req.Unit,
+		Required:      req.Required,
+		MinValue:      req.MinValue,
+		MaxValue:      req.MaxValue,
+		AllowedValues: req.AllowedValues,
+	}
+
+	err := validAttributeDefinition(&definition)
+	if err != nil {
+		return 0, err
+	}
+
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO attribute_definitions(
+			category_id,
+			code,
+			attribute_name,
+			data_type,
+			unit,
+			required,
+			min_value,
+			max_value,
+			allowed_values
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING attribute_id
+	`,
+		definition.CategoryId,
+		definition.Code,
+		definition.AttributeName,
+		definition.DataType,
+		helper.NewNullString(definition.Unit),
+		definition.Required,
+		definition.MinValue,
+		definition.MaxValue,
+		allowedValues(definition.AllowedValues),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *attributeRepo) GetByID(ctx context.Context, req *models.AttributeDefinitionPrimaryKey) (*models.AttributeDefinition, error) {
+
+	definitions, err := attributeDefinitions(ctx, r.db, req.CategoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, definition := range definitions {
+		if definition.AttributeId == req.AttributeId {
+			return definition, nil
+		}
+	}
+
+	return nil, pgx.ErrNoRows
+}
+
+func (r *attributeRepo) GetList(ctx context.Context, req *models.GetListAttributeDefinitionRequest) (resp *models.GetListAttributeDefinitionResponse, err error) {
+
+	resp = &models.GetListAttributeDefinitionResponse{}
+
+	resp.Attributes, err = attributeDefinitions(ctx, r.db, req.CategoryId)
+	if err != nil {
+		return nil, err
+	}
+	resp.Count = len(resp.Attributes)
+
+	return resp, nil
+}
+
+// Update changes the definition, its data type is fixed once products have a value for it.
+func (r *attributeRepo) Update(ctx context.Context, req *models.UpdateAttributeDefinition) (int64, error) {
+	var (
+		query  string
+		params map[string]interface{}
+	)
+
+	definition := models.AttributeDefinition{
+		AttributeId:   req.AttributeId,
+		CategoryId:    req.CategoryId,
+		Code:          req.Code,
+		AttributeName: req.AttributeName,
+		DataType:      req.DataType,
+		Unit:          req.Unit,
+		Required:      req.Required,
+		MinValue:      req.MinValue,
+		MaxValue:      req.MaxValue,
+		AllowedValues: req.AllowedValues,
+	}
+
+	err := validAttributeDefinition(&definition)
+	if err != nil {
+		return 0, err
+	}
+
+	var typeChanged bool
+	err = r.db.QueryRow(ctx, `
+		SELECT
+			d.data_type <> $2 AND EXISTS (SELECT 1 FROM product_attributes WHERE attribute_id = d.attribute_id)
+		FROM attribute_definitions AS d
+		WHERE d.attribute_id = $1
+	`, definition.AttributeId, definition.DataType).Scan(&typeChanged)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if typeChanged {
+		return 0, errors.New("data_type can not change while products have a value for the attribute")
+	}
+
+	query = `
+		UPDATE
+		attribute_definitions
+		SET
+			code = :code,
+			attribute_name = :attribute_name,
+			data_type = :data_type,
+			unit = :unit,
+			required = :required,
+			min_value = :min_value,
+			max_value = :max_value,
+			allowed_values = :allowed_values,
+			version = version + 1
+		WHERE attribute_id = :attribute_id AND category_id = :category_id AND version = :version
+	`
+
+	params = map[string]interface{}{
+		"attribute_id":   definition.AttributeId,
+		"category_id":    definition.CategoryId,
+		"code":           definition.Code,
+		"attribute_name": definition.AttributeName,
+		"data_type":      definition.DataType,
+		"unit":           helper.NewNullString(definition.Unit),
+		"required":       definition.Required,
+		"min_value":      definition.MinValue,
+		"max_value":      definition.MaxValue,
+		"allowed_values": allowedValues(definition.AllowedValues),
+		"version":        req.Version,
+	}
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// Delete removes the definition together with the values products had for it.
+func (r *attributeRepo) Delete(ctx context.Context, req *models.AttributeDefinitionPrimaryKey) (int64, error) {
+
+	result, err := r.db.Exec(ctx,
+		`DELETE FROM attribute_definitions WHERE attribute_id = $1 AND category_id = $2 AND version = $3`,
+		req.AttributeId,
+		req.CategoryId,
+		req.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *attributeRepo) GetProductValues(ctx context.Context, req *models.ProductPrimaryKey) ([]*models.ProductAttribute, error) {
+
+	values, err := productAttributes(ctx, r.db, []int{req.ProductId})
+	if err != nil {
+		return nil, err
+	}
+
+	return values[req.ProductId], nil
+}
+
+// SetProductValues replaces the attribute values of the product. Every value is checked
+// against the definition of the product's category, a required attribute must have one.
+func (r *attributeRepo) SetProductValues(ctx context.Context, req *models.SetProductAttributes) error {
+	var (
+		categoryId int
+		deleted    bool
+		codes      = map[string]*models.AttributeDefinition{}
+	)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`SELECT category_id, deleted_at IS NOT NULL FROM products WHERE product_id = $1 FOR UPDATE`,
+		req.ProductId,
+	).Scan(&categoryId, &deleted)
+	if err == pgx.ErrNoRows {
+		return errors.New("Product is not found")
+	} else if err != nil {
+		return err
+	}
+
+	if deleted {
+		return errors.New("Product is deleted")
+	}
+
+	definitions, err := attributeDefinitions(ctx, tx, categoryId)
+	if err != nil {
+		return err
+	}
+
+	for _, definition := range definitions {
+		codes[definition.Code] = definition
+	}
+
+	for code := range req.Values {
+		if _, ok := codes[code]; !ok {
+			return fmt.Errorf("attribute %s is not defined for the category of the product", code)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM product_attributes WHERE product_id = $1`, req.ProductId)
+	if err != nil {
+		return err
+	}
+
+	for _, definition := range definitions {
+		value, ok := req.Values[definition.Code]
+		if !ok || value == nil {
+			if definition.Required {
+				return fmt.Errorf("attribute %s is required", definition.Code)
+			}
+			continue
+		}
+
+		text, number, boolean, err := attributeValue(definition, value)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO product_attributes(product_id, attribute_id, value_text, value_number, value_boolean)
+			VALUES ($1, $2, $3, $4, $5)
+		`, req.ProductId, definition.AttributeId, text, number, boolean)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func validAttributeDefinition(definition *models.AttributeDefinition) error {
+
+	definition.Code = strings.ToLower(strings.TrimSpace(definition.Code))
+	if !attributeCodeRegexp.MatchString(definition.Code) {
+		return errors.New("code must start with a letter and hold lower case letters, digits and underscores")
+	}
+
+	definition.AttributeName = strings.TrimSpace(definition.AttributeName)
+	if len(definition.AttributeName) <= 0 {
+		return errors.New("attribute_name is required")
+	}
+
+	definition.Unit = strings.TrimSpace(definition.Unit)
+
+	switch definition.DataType {
+	case models.AttributeTypeInteger, models.AttributeTypeDecimal:
+		if definition.MinValue.Valid && definition.MaxValue.Valid && definition.MinValue.Decimal.GreaterThan(definition.MaxValue.Decimal) {
+			return errors.New("min_value must not be greater than max_value")
+		}
+	case models.AttributeTypeText, models.AttributeTypeBoolean, models.AttributeTypeEnum:
+		if definition.MinValue.Valid || definition.MaxValue.Valid {
+			return errors.New("min_value and max_value only apply to integer and decimal attributes")
+		}
+	default:
+		return errors.New("data_type must be text, integer, decimal, boolean or enum")
+	}
+
+	var options []string
+	for _, option := range definition.AllowedValues {
+		if option = strings.TrimSpace(option); len(option) > 0 {
+			options = append(options, option)
+		}
+	}
+	definition.AllowedValues = options
+
+	if definition.DataType == models.AttributeTypeEnum && len(options) <= 0 {
+		return errors.New("an enum attribute needs allowed_values")
+	}
+
+	if definition.DataType != models.AttributeTypeEnum && len(options) > 0 {
+		return errors.New("allowed_values only apply to enum attributes")
+	}
+
+	return nil
+}
+
+// allowedValues keeps the column NULL for attributes other than enums.
+func allowedValues(options []string) interface{} {
+	if len(options) <= 0 {
+		return nil
+	}
+
+	return options
+}
+
+// attributeValue checks the value against the definition and returns it in the column of its
+// type, the other two are nil. Numbers and booleans may come as strings, as query parameters do.
+func attributeValue(definition *models.AttributeDefinition, value interface{}) (text, number, boolean interface{}, err error) {
+
+	switch definition.DataType {
+	case models.AttributeTypeInteger, models.AttributeTypeDecimal:
+		var n decimal.Decimal
+
+		switch v := value.(type) {
+		case float64:
+			n = decimal.NewFromFloat(v)
+		case string:
+			n, err = decimal.NewFromString(strings.TrimSpace(v))
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("attribute %s must be a number", definition.Code)
+			}
+		default:
+			return nil, nil, nil, fmt.Errorf("attribute %s must be a number", definition.Code)
+		}
+
+		if definition.DataType == models.AttributeTypeInteger && !n.Equal(n.Truncate(0)) {
+			return nil, nil, nil, fmt.Errorf("attribute %s must be a whole number", definition.Code)
+		}
+
+		if !n.Equal(n.Round(attributeScale)) {
+			return nil, nil, nil, fmt.Errorf("attribute %s has more than %d decimals", definition.Code, attributeScale)
+		}
+
+		if definition.MinValue.Valid && n.LessThan(definition.MinValue.Decimal) {
+			return nil, nil, nil, fmt.Errorf("attribute %s must be at least %s", definition.Code, definition.MinValue.Decimal)
+		}
+
+		if definition.MaxValue.Valid && n.GreaterThan(definition.MaxValue.Decimal) {
+			return nil, nil, nil, fmt.Errorf("attribute %s must be at most %s", definition.Code, definition.MaxValue.Decimal)
+		}
+
+		return nil, n, nil, nil
+	case models.AttributeTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return nil, nil, v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("attribute %s must be true or false", definition.Code)
+			}
+			return nil, nil, b, nil
+		}
+
+		return nil, nil, nil, fmt.Errorf("attribute %s must be true or false", definition.Code)
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("attribute %s must be a string", definition.Code)
+	}
+
+	s = strings.TrimSpace(s)
+	if len(s) <= 0 {
+		return nil, nil, nil, fmt.Errorf("attribute %s must not be empty", definition.Code)
+	}
+
+	if len(s) > 255 {
+		return nil, nil, nil, fmt.Errorf("attribute %s is longer than 255 characters", definition.Code)
+	}
+
+	if definition.DataType == models.AttributeTypeEnum {
+		for _, option := range definition.AllowedValues {
+			if strings.EqualFold(option, s) {
+				return option, nil, nil, nil
+			}
+		}
+
+		return nil, nil, nil, fmt.Errorf("attribute %s must be one of %s", definition.Code, strings.Join(definition.AllowedValues, ", "))
+	}
+
+	return s, nil, nil, nil
+}
+
+func attributeDefinitions(ctx context.Context, q querier, categoryId int) ([]*models.AttributeDefinition, error) {
+	var definitions []*models.AttributeDefinition
+
+	rows, err := q.Query(ctx, `
+		SELECT
+			attribute_id,
+			category_id,
+			code,
+			attribute_name,
+			data_type,
+			COALESCE(unit, ''),
+			required,
+			min_value,
+			max_value,
+			COALESCE(allowed_values, '{}'),
+			version
+		FROM attribute_definitions
+		WHERE category_id = $1
+		ORDER BY attribute_name, attribute_id
+	`, categoryId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var definition models.AttributeDefinition
+
+		err = rows.Scan(
+			&definition.AttributeId,
+			&definition.CategoryId,
+			&definition.Code,
+			&definition.AttributeName,
+			&definition.DataType,
+			&definition.Unit,
+			&definition.Required,
+			&definition.MinValue,
+			&definition.MaxValue,
+			&definition.AllowedValues,
+			&definition.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		definitions = append(definitions, &definition)
+	}
+
+	return definitions, rows.Err()
+}
+
+// productAttributes loads the attribute values of the products. Values of attributes that do
+// not belong to the product's category, left behind when its category changed, are skipped.
+func productAttributes(ctx context.Context, q querier, productIds []int) (map[int][]*models.ProductAttribute, error) {
+	var values = map[int][]*models.ProductAttribute{}
+
+	if len(productIds) <= 0 {
+		return values, nil
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT
+			pa.product_id,
+			d.attribute_id,
+			d.code,
+			d.attribute_name,
+			d.data_type,
+			COALESCE(d.unit, ''),
+			COALESCE(pa.value_text, ''),
+			COALESCE(pa.value_number, 0),
+			COALESCE(pa.value_boolean, FALSE)
+		FROM product_attributes AS pa
+		JOIN products AS p ON p.product_id = pa.product_id
+		JOIN attribute_definitions AS d ON d.attribute_id = pa.attribute_id AND d.category_id = p.category_id
+		WHERE pa.product_id = ANY($1)
+		ORDER BY pa.product_id, d.attribute_name, d.attribute_id
+	`, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			productId int
+			value     models.ProductAttribute
+			text      string
+			number    decimal.Decimal
+			boolean   bool
+		)
+
+		err = rows.Scan(
+			&productId,
+			&value.AttributeId,
+			&value.Code,
+			&value.AttributeName,
+			&value.DataType,
+			&value.Unit,
+			&text,
+			&number,
+			&boolean,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		switch value.DataType {
+		case models.AttributeTypeInteger:
+			value.Value = number.IntPart()
+		case models.AttributeTypeDecimal:
+			value.Value = number
+		case models.AttributeTypeBoolean:
+			value.Value = boolean
+		default:
+			value.Value = text
+		}
+
+		values[productId] = append(values[productId], &value)
+	}
+
+	return values, rows.Err()
+}
+
+// attributeFilter is the condition of GET /product for one attribute filter, it matches
+// products with a value for an attribute of that code in their category.
+func attributeFilter(ctx context.Context, q querier, index int, filter *models.AttributeFilter, params map[string]interface{}) (string, error) {
+	var dataTypes []string
+
+	rows, err := q.Query(ctx,
+		`SELECT DISTINCT data_type FROM attribute_definitions WHERE code = $1`,
+		strings.ToLower(filter.Code),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	for rows.Next() {
+		var dataType string
+
+		err = rows.Scan(&dataType)
+		if err != nil {
+			rows.Close()
+			return "", err
+		}
+
+		dataTypes = append(dataTypes, dataType)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	switch {
+	case len(dataTypes) <= 0:
+		return "", fmt.Errorf("unknown attribute %s", filter.Code)
+	case len(dataTypes) > 1:
+		return "", fmt.Errorf("attribute %s has different data types across categories", filter.Code)
+	}
+
+	// the filter only needs the value parsed, the bounds of a definition do not apply to it
+	definition := &models.AttributeDefinition{Code: filter.Code, DataType: dataTypes[0]}
+	if definition.DataType == models.AttributeTypeEnum {
+		definition.DataType = models.AttributeTypeText
+	}
+
+	text, number, boolean, err := attributeValue(definition, filter.Value)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		code      = fmt.Sprintf("attr%d_code", index)
+		value     = fmt.Sprintf("attr%d_value", index)
+		condition string
+	)
+
+	switch {
+	case number != nil && filter.Op == models.AttributeFilterMin:
+		condition = "pa.value_number >= :" + value
+		params[value] = number
+	case number != nil && filter.Op == models.AttributeFilterMax:
+		condition = "pa.value_number <= :" + value
+		params[value] = number
+	case filter.Op != models.AttributeFilterEqual:
+		return "", fmt.Errorf("attribute %s can only be filtered by value, min and max are for numbers", filter.Code)
+	case number != nil:
+		condition = "pa.value_number = :" + value
+		params[value] = number
+	case boolean != nil:
+		condition = "pa.value_boolean = :" + value
+		params[value] = boolean
+	default:
+		condition = "LOWER(pa.value_text) = LOWER(:" + value + ")"
+		params[value] = text
+	}
+	params[code] = strings.ToLower(filter.Code)
+
+	return fmt.Sprintf(` AND EXISTS (
+			SELECT 1
+			FROM product_attributes AS pa
+			JOIN attribute_definitions AS d ON d.attribute_id = pa.attribute_id AND d.category_id = p.category_id
+			WHERE pa.product_id = p.product_id AND d.code = :%s AND %s
+		) `, code, condition), nil
+}
@@ -0,0 +1,200 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type auditRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepo(db *pgxpool.Pool) *auditRepo {
+	return &auditRepo{
+		db: db,
+	}
+}
+
+func (r *auditRepo) Create(ctx context.Context, req *models.CreateAuditLog) (int, error) {
+	return auditEntry(ctx, r.db, req)
+}
+
+// auditEntry writes the audit record, in the transaction of the change when given one.
+func auditEntry(ctx context.Context, q querier, req *models.CreateAuditLog) (int, error) {
+	var id int
+
+	err := q.QueryRow(ctx, `
+		INSERT INTO audit_log(
+			staff_id,
+			entity_type,
+			entity_id,
+			action,
+			method,
+			path,
+			status,
+			request_id,
+			payload,
+			before_data,
+			after_data
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING audit_id
+	`,
+		helper.NewNullInt32(req.StaffId),
+		req.EntityType,
+		helper.NewNullInt32(req.EntityId),
+		req.Action,
+		req.Method,
+		req.Path,
+		req.Status,
+		req.RequestId,
+		auditJSON(req.Payload),
+		auditJSON(req.Before),
+		auditJSON(req.After),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *auditRepo) GetList(ctx context.Context, req *models.GetListAuditLogRequest) (resp *models.GetListAuditLogResponse, err error) {
+
+	resp = &models.GetListAuditLogResponse{}
+
+	var (
+		query  string
+		filter = " WHERE TRUE "
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		params = map[string]interface{}{}
+	)
+
+	query = `
+		SELECT
+			COUNT(*) OVER(),
+			audit_id,
+			COALESCE(staff_id, 0),
+			entity_type,
+			COALESCE(entity_id, 0),
+			action,
+			method,
+			path,
+			status,
+			request_id,
+			payload,
+			before_data,
+			after_data,
+			CAST(created_at AS VARCHAR)
+		FROM audit_log
+	`
+
+	if req.StaffId > 0 {
+		filter += " AND staff_id = :staff_id "
+		params["staff_id"] = req.StaffId
+	}
+
+	if len(req.EntityType) > 0 {
+		filter += " AND entity_type = :entity_type "
+		params["entity_type"] = req.EntityType
+	}
+
+	if req.EntityId > 0 {
+		filter += " AND entity_id = :entity_id "
+		params["entity_id"] = req.EntityId
+	}
+
+	if len(req.Action) > 0 {
+		filter += " AND action = :action "
+		params["action"] = req.Action
+	}
+
+	if len(req.RequestId) > 0 {
+		filter += " AND request_id = :request_id "
+		params["request_id"] = req.RequestId
+	}
+
+	if len(req.FromDate) > 0 {
+		filter += " AND created_at >= CAST(:from_date AS DATE) "
+		params["from_date"] = req.FromDate
+	}
+
+	if len(req.ToDate) > 0 {
+		filter += " AND created_at < CAST(:to_date AS DATE) + 1 "
+		params["to_date"] = req.ToDate
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += filter + " ORDER BY audit_id DESC " + offset + limit
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			audit                  models.AuditLog
+			payload, before, after pgtype.JSONB
+		)
+
+		err = rows.Scan(
+			&resp.Count,
+			&audit.AuditId,
+			&audit.StaffId,
+			&audit.EntityType,
+			&audit.EntityId,
+			&audit.Action,
+			&audit.Method,
+			&audit.Path,
+			&audit.Status,
+			&audit.RequestId,
+			&payload,
+			&before,
+			&after,
+			&audit.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if payload.Status == pgtype.Present {
+			audit.Payload = payload.Bytes
+		}
+		if before.Status == pgtype.Present {
+			audit.Before = before.Bytes
+		}
+		if after.Status == pgtype.Present {
+			audit.After = after.Bytes
+		}
+
+		resp.AuditLogs = append(resp.AuditLogs, &audit)
+	}
+
+	return resp, rows.Err()
+}
+
+// auditJSON stores an empty document as NULL.
+func auditJSON(data []byte) pgtype.JSONB {
+	if len(data) <= 0 {
+		return pgtype.JSONB{Status: pgtype.Null}
+	}
+
+	return pgtype.JSONB{Bytes: data, Status: pgtype.Present}
+}
@@ -56,7 +56,9 @@ func (r *brandRepo) GetByID(ctx context.Context, req *models.BrandPrimaryKey) (*
 	query = `
 		SELECT
 			brand_id, 
-			brand_name 
+			brand_name,
+			COALESCE(CAST(deleted_at AS VARCHAR), ''),
+			version
 		FROM brands
 		WHERE brand_id = $1
 	`
@@ -64,6 +66,8 @@ func (r *brandRepo) GetByID(ctx context.Context, req *models.BrandPrimaryKey) (*
 	err := r.db.QueryRow(ctx, query, req.BrandId).Scan(
 		&brand.BrandId,
 		&brand.BrandName,
+		&brand.DeletedAt,
+		&brand.Version,
 	)
 	if err != nil {
 		return nil, err
@@ -87,10 +91,16 @@ func (r *brandRepo) GetList(ctx context.Context, req *models.GetListBrandRequest
 		SELECT
 			COUNT(*) OVER(),
 			brand_id,
-			brand_name
+			brand_name,
+			COALESCE(CAST(deleted_at AS VARCHAR), ''),
+			version
 		FROM brands
 	`
 
+	if !req.IncludeDeleted {
+		filter += " AND deleted_at IS NULL "
+	}
+
 	if len(req.Search) > 0 {
 		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
 	}
@@ -117,6 +127,8 @@ func (r *brandRepo) GetList(ctx context.Context, req *models.GetListBrandRequest
 			&resp.Count,
 			&brand.BrandId,
 			&brand.BrandName,
+			&brand.DeletedAt,
+			&brand.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -139,13 +151,15 @@ func (r *brandRepo) Update(ctx context.Context, req *models.UpdateBrand) (int64,
 		brands
 		SET
 			brand_id = :brand_id, 
-			brand_name = :brand_name
-		WHERE brand_id = :brand_id
+			brand_name = :brand_name,
+			version = version + 1
+		WHERE brand_id = :brand_id AND version = :version
 	`
 
 	params = map[string]interface{}{
 		"brand_id":   req.BrandId,
 		"brand_name": req.BrandName,
+		"version":    req.Version,
 	}
 
 	query, args := helper.ReplaceQueryParams(query, params)
@@ -158,11 +172,56 @@ func (r *brandRepo) Update(ctx context.Context, req *models.UpdateBrand) (int64,
 	return result.RowsAffected(), nil
 }
 
+// brandPatchFields are the columns a PATCH may change.
+var brandPatchFields = map[string]patchField{
+	"brand_name": {kind: patchString},
+}
+
+func (r *brandRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
+
+	set, args, err := patchSet(brandPatchFields, req.Fields)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE
+		brands
+		SET
+		%s, version = version + 1
+		WHERE brand_id = $%d AND version = $%d
+	`, set, len(args)+1, len(args)+2)
+
+	args = append(args, req.ID, req.Version)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
 func (r *brandRepo) Delete(ctx context.Context, req *models.BrandPrimaryKey) (int64, error) {
 	query := `
-		DELETE 
-		FROM brands
-		WHERE brand_id = $1
+		UPDATE brands
+			SET deleted_at = NOW(), version = version + 1
+		WHERE brand_id = $1 AND version = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, req.BrandId, req.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *brandRepo) Restore(ctx context.Context, req *models.BrandPrimaryKey) (int64, error) {
+	query := `
+		UPDATE brands
+			SET deleted_at = NULL, version = version + 1
+		WHERE brand_id = $1 AND deleted_at IS NOT NULL
 	`
 
 	result, err := r.db.Exec(ctx, query, req.BrandId)
@@ -56,7 +56,9 @@ func (r *categoryRepo) GetByID(ctx context.Context, req *models.CategoryPrimaryK
 	query = `
 		SELECT
 			category_id,
-			category_name
+			category_name,
+			COALESCE(CAST(deleted_at AS VARCHAR), ''),
+			version
 		FROM categories
 		WHERE category_id = $1
 	`
@@ -64,6 +66,8 @@ func (r *categoryRepo) GetByID(ctx context.Context, req *models.CategoryPrimaryK
 	err := r.db.QueryRow(ctx, query, req.CategoryId).Scan(
 		&category.CategoryId,
 		&category.CategoryName,
+		&category.DeletedAt,
+		&category.Version,
 	)
 	if err != nil {
 		return nil, err
@@ -87,10 +91,16 @@ func (r *categoryRepo) GetList(ctx context.Context, req *models.GetListCategoryR
 		SELECT
 			COUNT(*) OVER(),
 			category_id,
-			category_name
+			category_name,
+			COALESCE(CAST(deleted_at AS VARCHAR), ''),
+			version
 		FROM categories
 	`
 
+	if !req.IncludeDeleted {
+		filter += " AND deleted_at IS NULL "
+	}
+
 	if len(req.Search) > 0 {
 		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
 	}
@@ -117,6 +127,8 @@ func (r *categoryRepo) GetList(ctx context.Context, req *models.GetListCategoryR
 			&resp.Count,
 			&category.CategoryId,
 			&category.CategoryName,
+			&category.DeletedAt,
+			&category.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -139,13 +151,15 @@ func (r *categoryRepo) Update(ctx context.Context, req *models.UpdateCategory) (
 		categories
 		SET
 			category_id = :category_id, 
-			category_name = :category_name
-		WHERE category_id = :category_id
+			category_name = :category_name,
+			version = version + 1
+		WHERE category_id = :category_id AND version = :version
 	`
 
 	params = map[string]interface{}{
 		"category_id":   req.CategoryId,
 		"category_name": req.CategoryName,
+		"version":       req.Version,
 	}
 
 	query, args := helper.ReplaceQueryParams(query, params)
@@ -158,11 +172,56 @@ func (r *categoryRepo) Update(ctx context.Context, req *models.UpdateCategory) (
 	return result.RowsAffected(), nil
 }
 
+// categoryPatchFields are the columns a PATCH may change.
+var categoryPatchFields = map[string]patchField{
+	"category_name": {kind: patchString},
+}
+
+func (r *categoryRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
+
+	set, args, err := patchSet(categoryPatchFields, req.Fields)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE
+		categories
+		SET
+		%s, version = version + 1
+		WHERE category_id = $%d AND version = $%d
+	`, set, len(args)+1, len(args)+2)
+
+	args = append(args, req.ID, req.Version)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
 func (r *categoryRepo) Delete(ctx context.Context, req *models.CategoryPrimaryKey) (int64, error) {
 	query := `
-		DELETE 
-		FROM categories
-		WHERE category_id = $1
+		UPDATE categories
+			SET deleted_at = NOW(), version = version + 1
+		WHERE category_id = $1 AND version = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, req.CategoryId, req.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *categoryRepo) Restore(ctx context.Context, req *models.CategoryPrimaryKey) (int64, error) {
+	query := `
+		UPDATE categories
+			SET deleted_at = NULL, version = version + 1
+		WHERE category_id = $1 AND deleted_at IS NOT NULL
 	`
 
 	result, err := r.db.Exec(ctx, query, req.CategoryId)
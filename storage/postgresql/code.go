@@ -31,21 +31,28 @@ func (r *codeRepo) Create(ctx context.Context, req *models.CreateCode) (int, err
 			code_name, 
 			discount,
 			discount_type,
-			order_limit_price
+			order_limit_price,
+			currency
 		)
 		VALUES (
 			(
 				SELECT COALESCE(MAX(code_id), 0) + 1 FROM promo_code
 			)
-			, $1, $2, $3, $4) RETURNING code_id
+			, $1, $2, $3, $4, $5) RETURNING code_id
 	`
 
-	err := r.db.QueryRow(ctx, query,
+	currency, err := currencyOrDefault(req.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	err = r.db.QueryRow(ctx, query,
 
 		req.CodeName,
 		req.Discount,
 		req.DiscountType,
 		req.OrderLimitPrice,
+		currency,
 	).Scan(&id)
 	if err != nil {
 		return 0, err
@@ -67,7 +74,9 @@ func (r *codeRepo) GetByID(ctx context.Context, req *models.CodePrimaryKey) (*mo
 			code_name,
 			discount,
 			discount_type,
-			order_limit_price
+			order_limit_price,
+			currency,
+			version
 		FROM promo_code
 		WHERE code_id = $1
 	`
@@ -78,6 +87,8 @@ func (r *codeRepo) GetByID(ctx context.Context, req *models.CodePrimaryKey) (*mo
 		&code.Discount,
 		&code.DiscountType,
 		&code.OrderLimitPrice,
+		&code.Currency,
+		&code.Version,
 	)
 	if err != nil {
 		return nil, err
@@ -104,7 +115,9 @@ func (r *codeRepo) GetList(ctx context.Context, req *models.GetListCodeRequest)
 			code_name,
 			discount,
 			discount_type,
-			order_limit_price
+			order_limit_price,
+			currency,
+			version
 		FROM promo_code
 	`
 
@@ -137,6 +150,8 @@ func (r *codeRepo) GetList(ctx context.Context, req *models.GetListCodeRequest)
 			&code.Discount,
 			&code.DiscountType,
 			&code.OrderLimitPrice,
+			&code.Currency,
+			&code.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -154,6 +169,15 @@ func (r *codeRepo) Update(ctx context.Context, req *models.UpdateCode) (int64, e
 		params map[string]interface{}
 	)
 
+	// an empty currency keeps the one of the code
+	if len(req.Currency) > 0 {
+		currency, err := currencyOrDefault(req.Currency)
+		if err != nil {
+			return 0, err
+		}
+		req.Currency = currency
+	}
+
 	query = `
 		UPDATE
 		promo_code
@@ -162,8 +186,10 @@ func (r *codeRepo) Update(ctx context.Context, req *models.UpdateCode) (int64, e
 			code_name = :code_name,
 			discount = :discount,
 			discount_type = :discount_type,
-			order_limit_price = :order_limit_price
-		WHERE code_id = :code_id
+			order_limit_price = :order_limit_price,
+			currency = COALESCE(:currency, currency),
+			version = version + 1
+		WHERE code_id = :code_id AND version = :version
 	`
 
 	params = map[string]interface{}{
@@ -172,6 +198,8 @@ func (r *codeRepo) Update(ctx context.Context, req *models.UpdateCode) (int64, e
 		"discount":          req.Discount,
 		"discount_type":     req.DiscountType,
 		"order_limit_price": req.OrderLimitPrice,
+		"currency":          helper.NewNullString(req.Currency),
+		"version":           req.Version,
 	}
 
 	query, args := helper.ReplaceQueryParams(query, params)
@@ -184,14 +212,48 @@ func (r *codeRepo) Update(ctx context.Context, req *models.UpdateCode) (int64, e
 	return result.RowsAffected(), nil
 }
 
+// codePatchFields are the columns a PATCH may change.
+var codePatchFields = map[string]patchField{
+	"code_name":         {kind: patchString},
+	"discount":          {kind: patchDecimal, nullable: true},
+	"discount_type":     {kind: patchString, nullable: true},
+	"order_limit_price": {kind: patchDecimal, nullable: true},
+	"currency":          {kind: patchCurrency},
+}
+
+func (r *codeRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
+
+	set, args, err := patchSet(codePatchFields, req.Fields)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE
+		promo_code
+		SET
+		%s, version = version + 1
+		WHERE code_id = $%d AND version = $%d
+	`, set, len(args)+1, len(args)+2)
+
+	args = append(args, req.ID, req.Version)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
 func (r *codeRepo) Delete(ctx context.Context, req *models.CodePrimaryKey) (int64, error) {
 	query := `
 		DELETE 
 		FROM promo_code
-		WHERE code_id = $1
+		WHERE code_id = $1 AND version = $2
 	`
 
-	result, err := r.db.Exec(ctx, query, req.Code_Id)
+	result, err := r.db.Exec(ctx, query, req.Code_Id, req.Version)
 	if err != nil {
 		return 0, err
 	}
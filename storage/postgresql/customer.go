@@ -3,13 +3,21 @@ package postgresql
 import (
 	"app/api/models"
 	"app/pkg/helper"
+	"app/pkg/money"
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/shopspring/decimal"
 )
 
+const customerExportPageSize = 100
+
 type customerRepo struct {
 	db *pgxpool.Pool
 }
@@ -36,13 +44,15 @@ func (r *customerRepo) Create(ctx context.Context, req *models.CreateCustomer) (
 			street,
 			city,
 			state,
-			zip_code
+			zip_code,
+			price_list_id,
+			tax_exempt
 		)
 		VALUES (
 			(
 				SELECT MAX(customer_id) + 1 FROM customers
 			),
-			$1, $2, $3, $4, $5, $6, $7, $8) RETURNING customer_id
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING customer_id
 	`
 	err := r.db.QueryRow(ctx, query,
 		req.FirstName,
@@ -52,7 +62,9 @@ func (r *customerRepo) Create(ctx context.Context, req *models.CreateCustomer) (
 		helper.NewNullString(req.Street),
 		helper.NewNullString(req.City),
 		helper.NewNullString(req.State),
-		helper.NewNullInt32(req.ZipCode),
+		helper.NewNullString(req.ZipCode),
+		helper.NewNullInt32(req.PriceListId),
+		req.TaxExempt,
 	).Scan(&id)
 	if err != nil {
 		return 0, err
@@ -78,7 +90,12 @@ func (r *customerRepo) GetByID(ctx context.Context, req *models.CustomerPrimaryK
 			COALESCE(street, ''),
 			COALESCE(city, ''),
 			COALESCE(state, ''),
-			COALESCE(zip_code, 0)
+			COALESCE(zip_code, ''),
+			COALESCE(price_list_id, 0),
+			tax_exempt,
+			COALESCE(CAST(anonymized_at AS VARCHAR), ''),
+			COALESCE(CAST(deleted_at AS VARCHAR), ''),
+			version
 		FROM customers
 		WHERE customer_id = $1
 	`
@@ -93,6 +110,11 @@ func (r *customerRepo) GetByID(ctx context.Context, req *models.CustomerPrimaryK
 		&customer.City,
 		&customer.State,
 		&customer.ZipCode,
+		&customer.PriceListId,
+		&customer.TaxExempt,
+		&customer.AnonymizedAt,
+		&customer.DeletedAt,
+		&customer.Version,
 	)
 	if err != nil {
 		return nil, err
@@ -123,10 +145,19 @@ func (r *customerRepo) GetList(ctx context.Context, req *models.GetListCustomerR
 			COALESCE(street, ''),
 			COALESCE(city, ''),
 			COALESCE(state, ''),
-			COALESCE(zip_code, 0)
+			COALESCE(zip_code, ''),
+			COALESCE(price_list_id, 0),
+			tax_exempt,
+			COALESCE(CAST(anonymized_at AS VARCHAR), ''),
+			COALESCE(CAST(deleted_at AS VARCHAR), ''),
+			version
 		FROM customers
 	`
 
+	if !req.IncludeDeleted {
+		filter += " AND deleted_at IS NULL "
+	}
+
 	if len(req.Search) > 0 {
 		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
 	}
@@ -160,6 +191,11 @@ func (r *customerRepo) GetList(ctx context.Context, req *models.GetListCustomerR
 			&customer.City,
 			&customer.State,
 			&customer.ZipCode,
+			&customer.PriceListId,
+			&customer.TaxExempt,
+			&customer.AnonymizedAt,
+			&customer.DeletedAt,
+			&customer.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -189,8 +225,11 @@ func (r *customerRepo) UpdatePut(ctx context.Context, req *models.UpdateCustomer
 			street = :street,
 			city = :city,
 			state = :state,
-			zip_code = :zip_code
-		WHERE customer_id = :customer_id
+			zip_code = :zip_code,
+			price_list_id = :price_list_id,
+			tax_exempt = :tax_exempt,
+			version = version + 1
+		WHERE customer_id = :customer_id AND version = :version
 	`
 
 	params = map[string]interface{}{
@@ -202,7 +241,11 @@ func (r *customerRepo) UpdatePut(ctx context.Context, req *models.UpdateCustomer
 		"street":      helper.NewNullString(req.Street),
 		"city":        helper.NewNullString(req.City),
 		"state":       helper.NewNullString(req.State),
-		"zip_code":    helper.NewNullInt32(req.ZipCode),
+		"zip_code":    helper.NewNullString(req.ZipCode),
+		"version":     req.Version,
+
+		"price_list_id": helper.NewNullInt32(req.PriceListId),
+		"tax_exempt":    req.TaxExempt,
 	}
 
 	query, args := helper.ReplaceQueryParams(query, params)
@@ -215,39 +258,37 @@ func (r *customerRepo) UpdatePut(ctx context.Context, req *models.UpdateCustomer
 	return result.RowsAffected(), nil
 }
 
-func (r *customerRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
-	var (
-		query string
-		set   string
-	)
+// customerPatchFields are the columns a PATCH may change.
+var customerPatchFields = map[string]patchField{
+	"first_name": {kind: patchString},
+	"last_name":  {kind: patchString},
+	"phone":      {kind: patchString, nullable: true},
+	"email":      {kind: patchString},
+	"street":     {kind: patchString, nullable: true},
+	"city":       {kind: patchString, nullable: true},
+	"state":      {kind: patchString, nullable: true},
+	"zip_code":   {kind: patchString, nullable: true},
 
-	if len(req.Fields) <= 0 {
-		return 0, errors.New("no fields")
-	}
+	"price_list_id": {kind: patchInt, nullable: true},
+	"tax_exempt":    {kind: patchBool},
+}
 
-	i := 0
-	for key := range req.Fields {
-		if i == len(req.Fields)-1 {
-			set += fmt.Sprintf(" %s = :%s ", key, key)
-		} else {
-			set += fmt.Sprintf(" %s = :%s, ", key, key)
-		}
-		i++
+func (r *customerRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
+
+	set, args, err := patchSet(customerPatchFields, req.Fields)
+	if err != nil {
+		return 0, err
 	}
 
-	query = `
+	query := fmt.Sprintf(`
 		UPDATE
 		customers
 		SET
-		` + set + `
-		WHERE customer_id = :customer_id
-	`
+		%s, version = version + 1
+		WHERE customer_id = $%d AND version = $%d
+	`, set, len(args)+1, len(args)+2)
 
-	req.Fields["customer_id"] = req.ID
-
-	query, args := helper.ReplaceQueryParams(query, req.Fields)
-
-	fmt.Println(query)
+	args = append(args, req.ID, req.Version)
 
 	result, err := r.db.Exec(ctx, query, args...)
 	if err != nil {
@@ -259,9 +300,24 @@ func (r *customerRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest
 
 func (r *customerRepo) Delete(ctx context.Context, req *models.CustomerPrimaryKey) (int64, error) {
 	query := `
-		DELETE 
-		FROM customers
-		WHERE customer_id = $1
+		UPDATE customers
+			SET deleted_at = NOW(), version = version + 1
+		WHERE customer_id = $1 AND version = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, req.CustomerId, req.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *customerRepo) Restore(ctx context.Context, req *models.CustomerPrimaryKey) (int64, error) {
+	query := `
+		UPDATE customers
+			SET deleted_at = NULL, version = version + 1
+		WHERE customer_id = $1 AND deleted_at IS NOT NULL
 	`
 
 	result, err := r.db.Exec(ctx, query, req.CustomerId)
@@ -271,3 +327,987 @@ func (r *customerRepo) Delete(ctx context.Context, req *models.CustomerPrimaryKe
 
 	return result.RowsAffected(), nil
 }
+
+// Credit is the customer's store credit balance with its ledger, newest entries first.
+func (r *customerRepo) Credit(ctx context.Context, req *models.GetCustomerCreditRequest) (*models.CustomerCredit, error) {
+
+	var (
+		resp   = models.CustomerCredit{CustomerId: req.CustomerId}
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+	)
+
+	err := r.db.QueryRow(ctx,
+		`SELECT store_credit, store_credit_currency FROM customers WHERE customer_id = $1`,
+		req.CustomerId,
+	).Scan(&resp.Balance.Amount, &resp.Balance.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query := `
+		SELECT
+			COUNT(*) OVER(),
+			entry_id,
+			customer_id,
+			kind,
+			amount,
+			balance_after,
+			currency,
+			COALESCE(order_id, 0),
+			COALESCE(return_id, 0),
+			COALESCE(payment_id, 0),
+			CAST(created_at AS VARCHAR)
+		FROM customer_credit_ledger
+		WHERE customer_id = $1
+		ORDER BY entry_id DESC
+	` + offset + limit
+
+	rows, err := r.db.Query(ctx, query, req.CustomerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.CustomerCreditEntry
+
+		err = rows.Scan(
+			&resp.Count,
+			&entry.EntryId,
+			&entry.CustomerId,
+			&entry.Kind,
+			&entry.Amount.Amount,
+			&entry.BalanceAfter.Amount,
+			&entry.Amount.Currency,
+			&entry.OrderId,
+			&entry.ReturnId,
+			&entry.PaymentId,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.BalanceAfter.Currency = entry.Amount.Currency
+
+		resp.Ledger = append(resp.Ledger, &entry)
+	}
+
+	return &resp, nil
+}
+
+// Orders is the customer's purchase history, newest first, every order with its items and totals.
+func (r *customerRepo) Orders(ctx context.Context, req *models.GetListCustomerOrderRequest) (*models.GetListCustomerOrderResponse, error) {
+
+	var (
+		resp   = models.GetListCustomerOrderResponse{}
+		filter = " WHERE o.customer_id = :customer_id "
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		params = map[string]interface{}{"customer_id": req.CustomerId}
+		exists bool
+	)
+
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM customers WHERE customer_id = $1)`,
+		req.CustomerId,
+	).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, errors.New("Customer is not found")
+	}
+
+	query := `
+		SELECT
+			COUNT(*) OVER(),
+			o.order_id,
+			o.order_status,
+			CAST(o.order_date::timestamp AS VARCHAR),
+			CAST(o.required_date::timestamp AS VARCHAR),
+			COALESCE(CAST(o.shipped_date::timestamp AS VARCHAR), ''),
+			o.store_id,
+			o.staff_id,
+			o.return_status,
+			COALESCE(
+				(
+					SELECT
+						JSONB_AGG (
+							JSONB_BUILD_OBJECT (
+								'order_id', oi.order_id,
+								'item_id', oi.item_id,
+								'product_id', oi.product_id,
+								'product_data', JSONB_BUILD_OBJECT (
+									'product_id', p.product_id,
+									'product_name', p.product_name,
+									'brand_id', p.brand_id,
+									'category_id', p.category_id,
+									'model_year', p.model_year,
+									'list_price', JSONB_BUILD_OBJECT('amount', p.list_price, 'currency', p.currency)
+								),
+								'quantity', oi.quantity,
+								'list_price', JSONB_BUILD_OBJECT('amount', oi.list_price, 'currency', o.currency),
+								'discount', oi.discount
+							) ORDER BY oi.item_id
+						)
+					FROM order_items AS oi
+					JOIN products AS p ON p.product_id = oi.product_id
+					WHERE oi.order_id = o.order_id
+				), '[]'
+			)
+		FROM orders AS o
+	`
+
+	if req.OrderStatus > 0 {
+		filter += " AND o.order_status = :order_status "
+		params["order_status"] = req.OrderStatus
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += filter + " ORDER BY o.order_date DESC, o.order_id DESC " + offset + limit
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var (
+			order models.CustomerOrder
+			items pgtype.JSONB
+		)
+
+		err = rows.Scan(
+			&resp.Count,
+			&order.OrderId,
+			&order.OrderStatus,
+			&order.OrderDate,
+			&order.RequiredDate,
+			&order.ShippedDate,
+			&order.StoreId,
+			&order.StaffId,
+			&order.ReturnStatus,
+			&items,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		items.AssignTo(&order.Items)
+
+		resp.Orders = append(resp.Orders, &order)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, order := range resp.Orders {
+		order.Amount, _, err = orderAmount(ctx, r.db, order.OrderId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &resp, nil
+}
+
+// Summary is the customer's lifetime value, only completed orders count as purchases. The
+// spend of orders in other currencies is converted to the reporting currency.
+func (r *customerRepo) Summary(ctx context.Context, req *models.GetCustomerSummaryRequest) (*models.CustomerSummary, error) {
+
+	var (
+		summary = models.CustomerSummary{CustomerId: req.CustomerId}
+		exists  bool
+	)
+
+	rates, err := newExchangeRates(r.db, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.QueryRow(ctx, `
+		SELECT
+			EXISTS (SELECT 1 FROM customers WHERE customer_id = $1),
+			COALESCE(CAST(MIN(o.order_date)::timestamp AS VARCHAR), ''),
+			COALESCE(CAST(MAX(o.order_date)::timestamp AS VARCHAR), ''),
+			COUNT(DISTINCT o.order_id)
+		FROM orders AS o
+		JOIN order_items AS oi ON oi.order_id = o.order_id
+		WHERE o.customer_id = $1 AND o.order_status = $2
+	`, req.CustomerId, models.OrderStatusCompleted).Scan(
+		&exists,
+		&summary.FirstPurchaseDate,
+		&summary.LastPurchaseDate,
+		&summary.OrderCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, errors.New("Customer is not found")
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT
+			o.currency,
+			SUM(oi.quantity * oi.list_price * (1 - oi.discount))
+		FROM orders AS o
+		JOIN order_items AS oi ON oi.order_id = o.order_id
+		WHERE o.customer_id = $1 AND o.order_status = $2
+		GROUP BY o.currency
+	`, req.CustomerId, models.OrderStatusCompleted)
+	if err != nil {
+		return nil, err
+	}
+
+	var spend []money.Money
+	for rows.Next() {
+		var m money.Money
+
+		err = rows.Scan(&m.Currency, &m.Amount)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		spend = append(spend, m)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lifetime := decimal.Zero
+	for _, m := range spend {
+		converted, err := rates.convert(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		lifetime = lifetime.Add(converted.Amount)
+	}
+
+	summary.LifetimeSpend = money.New(lifetime, rates.to)
+	summary.AverageOrderValue = money.Zero(rates.to)
+	if summary.OrderCount > 0 {
+		summary.AverageOrderValue = money.New(lifetime.Div(decimal.NewFromInt(int64(summary.OrderCount))), rates.to)
+	}
+
+	summary.FavoriteBrand, err = r.favorite(ctx, req.CustomerId, rates, `
+		SELECT
+			b.brand_id,
+			b.brand_name,
+			SUM(oi.quantity),
+			o.currency,
+			SUM(oi.quantity * oi.list_price * (1 - oi.discount))
+		FROM orders AS o
+		JOIN order_items AS oi ON oi.order_id = o.order_id
+		JOIN products AS p ON p.product_id = oi.product_id
+		JOIN brands AS b ON b.brand_id = p.brand_id
+		WHERE o.customer_id = $1 AND o.order_status = $2
+		GROUP BY b.brand_id, b.brand_name, o.currency
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.FavoriteCategory, err = r.favorite(ctx, req.CustomerId, rates, `
+		SELECT
+			c.category_id,
+			c.category_name,
+			SUM(oi.quantity),
+			o.currency,
+			SUM(oi.quantity * oi.list_price * (1 - oi.discount))
+		FROM orders AS o
+		JOIN order_items AS oi ON oi.order_id = o.order_id
+		JOIN products AS p ON p.product_id = oi.product_id
+		JOIN categories AS c ON c.category_id = p.category_id
+		WHERE o.customer_id = $1 AND o.order_status = $2
+		GROUP BY c.category_id, c.category_name, o.currency
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
+// favorite runs a query of what the customer bought per brand or category and currency and
+// picks the one bought most of, then spent most on. nil means the customer has no completed
+// purchases yet.
+func (r *customerRepo) favorite(ctx context.Context, customerId int, rates *exchangeRates, query string) (*models.CustomerFavorite, error) {
+	var (
+		favorites = map[int]*models.CustomerFavorite{}
+		spend     []money.Money
+		ids       []int
+	)
+
+	rows, err := r.db.Query(ctx, query, customerId, models.OrderStatusCompleted)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var (
+			row models.CustomerFavorite
+			m   money.Money
+		)
+
+		err = rows.Scan(&row.Id, &row.Name, &row.Quantity, &m.Currency, &m.Amount)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		favorite, ok := favorites[row.Id]
+		if !ok {
+			favorite = &models.CustomerFavorite{Id: row.Id, Name: row.Name, Spend: money.Zero(rates.to)}
+			favorites[row.Id] = favorite
+		}
+		favorite.Quantity += row.Quantity
+
+		spend = append(spend, m)
+		ids = append(ids, row.Id)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, m := range spend {
+		converted, err := rates.convert(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+
+		favorite := favorites[ids[i]]
+		favorite.Spend = money.New(favorite.Spend.Amount.Add(converted.Amount), rates.to)
+	}
+
+	var best *models.CustomerFavorite
+	for _, favorite := range favorites {
+		switch {
+		case best == nil,
+			favorite.Quantity > best.Quantity,
+			favorite.Quantity == best.Quantity && favorite.Spend.Amount.GreaterThan(best.Spend.Amount),
+			favorite.Quantity == best.Quantity && favorite.Spend.Amount.Equal(best.Spend.Amount) && favorite.Id < best.Id:
+			best = favorite
+		}
+	}
+
+	return best, nil
+}
+
+// Duplicates groups customers that look like the same person: the same normalized email,
+// the same normalized phone, or the same zip code with nearly the same name.
+func (r *customerRepo) Duplicates(ctx context.Context, req *models.GetListDuplicateCustomerRequest) (*models.GetListDuplicateCustomerResponse, error) {
+
+	var (
+		resp      = models.GetListDuplicateCustomerResponse{}
+		customers []*models.Customer
+	)
+
+	rows, err := r.db.Query(ctx, `
+		SELECT
+			customer_id,
+			first_name,
+			last_name,
+			COALESCE(phone, ''),
+			email,
+			COALESCE(street, ''),
+			COALESCE(city, ''),
+			COALESCE(state, ''),
+			COALESCE(zip_code, '')
+		FROM customers
+		WHERE anonymized_at IS NULL AND deleted_at IS NULL
+		ORDER BY customer_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var customer models.Customer
+
+		err = rows.Scan(
+			&customer.CustomerId,
+			&customer.FirstName,
+			&customer.LastName,
+			&customer.Phone,
+			&customer.Email,
+			&customer.Street,
+			&customer.City,
+			&customer.State,
+			&customer.ZipCode,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		customers = append(customers, &customer)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	groups := groupDuplicateCustomers(customers)
+
+	resp.Count = len(groups)
+
+	if req.Offset > len(groups) {
+		req.Offset = len(groups)
+	}
+	groups = groups[req.Offset:]
+
+	if req.Limit > 0 && req.Limit < len(groups) {
+		groups = groups[:req.Limit]
+	}
+
+	resp.Groups = groups
+
+	return &resp, nil
+}
+
+// groupDuplicateCustomers links customers sharing a match key and returns the connected groups
+// ordered by their lowest customer id.
+func groupDuplicateCustomers(customers []*models.Customer) []*models.DuplicateCustomerGroup {
+	var (
+		parent  = make([]int, len(customers))
+		reasons = make([]map[string]bool, len(customers))
+		keys    = map[string]map[string][]int{
+			models.DuplicateReasonEmail: {},
+			models.DuplicateReasonPhone: {},
+		}
+		zips   = map[string][]int{}
+		groups []*models.DuplicateCustomerGroup
+	)
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	union := func(i, j int, reason string) {
+		ri, rj := find(i), find(j)
+		if ri != rj {
+			parent[rj] = ri
+			for k := range reasons[rj] {
+				reasons[ri][k] = true
+			}
+		}
+		reasons[ri][reason] = true
+	}
+
+	for i, customer := range customers {
+		parent[i] = i
+		reasons[i] = map[string]bool{}
+
+		if email := helper.NormalizeEmail(customer.Email); len(email) > 0 {
+			keys[models.DuplicateReasonEmail][email] = append(keys[models.DuplicateReasonEmail][email], i)
+		}
+
+		if phone := helper.NormalizePhone(customer.Phone); len(phone) > 0 {
+			keys[models.DuplicateReasonPhone][phone] = append(keys[models.DuplicateReasonPhone][phone], i)
+		}
+
+		if zip := strings.TrimSpace(customer.ZipCode); len(zip) > 0 {
+			zips[zip] = append(zips[zip], i)
+		}
+	}
+
+	for reason, byKey := range keys {
+		for _, members := range byKey {
+			for _, member := range members[1:] {
+				union(members[0], member, reason)
+			}
+		}
+	}
+
+	for _, members := range zips {
+		for a := 0; a < len(members); a++ {
+			for b := a + 1; b < len(members); b++ {
+				if similarNames(customers[members[a]], customers[members[b]]) {
+					union(members[a], members[b], models.DuplicateReasonName)
+				}
+			}
+		}
+	}
+
+	byRoot := map[int]*models.DuplicateCustomerGroup{}
+	for i, customer := range customers {
+		root := find(i)
+		if len(reasons[root]) == 0 {
+			continue
+		}
+
+		group, ok := byRoot[root]
+		if !ok {
+			group = &models.DuplicateCustomerGroup{}
+			for reason := range reasons[root] {
+				group.Reasons = append(group.Reasons, reason)
+			}
+			sort.Strings(group.Reasons)
+
+			byRoot[root] = group
+			groups = append(groups, group)
+		}
+
+		group.Customers = append(group.Customers, customer)
+	}
+
+	return groups
+}
+
+// similarNames allows one typo in short names and two in longer ones.
+func similarNames(a, b *models.Customer) bool {
+	nameA := strings.Join(strings.Fields(strings.ToLower(a.FirstName+" "+a.LastName)), " ")
+	nameB := strings.Join(strings.Fields(strings.ToLower(b.FirstName+" "+b.LastName)), " ")
+
+	if len(nameA) == 0 || len(nameB) == 0 {
+		return false
+	}
+
+	allowed := 1
+	if len(nameA) >= 10 {
+		allowed = 2
+	}
+
+	return helper.Levenshtein(nameA, nameB) <= allowed
+}
+
+// Merge moves the orders (and with them their returns), addresses, gift cards, store credit and
+// loyalty points of the duplicates to the survivor and deletes the duplicates, all in one transaction.
+// Credit and points arrive as one merge entry on the survivor's ledgers, the ledgers of a duplicate
+// are kept with the snapshot of its record in customer_merges.
+func (r *customerRepo) Merge(ctx context.Context, req *models.MergeCustomer) error {
+
+	var (
+		ids  = []int{req.SurvivorId}
+		seen = map[int]bool{req.SurvivorId: true}
+	)
+
+	if req.SurvivorId <= 0 || len(req.DuplicateIds) <= 0 {
+		return errors.New("survivor_id and duplicate_ids are required")
+	}
+
+	for _, id := range req.DuplicateIds {
+		if seen[id] {
+			return fmt.Errorf("customer %d is given more than once", id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var locked int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT customer_id FROM customers WHERE customer_id = ANY($1) ORDER BY customer_id FOR UPDATE
+		) AS c
+	`, ids).Scan(&locked)
+	if err != nil {
+		return err
+	}
+
+	if locked != len(ids) {
+		return errors.New("Customer is not found")
+	}
+
+	var deleted, anonymized bool
+	err = tx.QueryRow(ctx,
+		`SELECT deleted_at IS NOT NULL, anonymized_at IS NOT NULL FROM customers WHERE customer_id = $1`,
+		req.SurvivorId,
+	).Scan(&deleted, &anonymized)
+	if err != nil {
+		return err
+	}
+
+	if deleted || anonymized {
+		return errors.New("survivor is deleted or anonymized")
+	}
+
+	for _, duplicateId := range req.DuplicateIds {
+		var (
+			credit money.Money
+			points int
+		)
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO customer_merges(survivor_id, merged_id, merged_data)
+			SELECT
+				$1,
+				c.customer_id,
+				TO_JSONB(c) || JSONB_BUILD_OBJECT(
+					'credit_ledger', COALESCE(
+						(SELECT JSONB_AGG(TO_JSONB(l) ORDER BY l.entry_id) FROM customer_credit_ledger AS l WHERE l.customer_id = c.customer_id), '[]'
+					),
+					'loyalty_ledger', COALESCE(
+						(SELECT JSONB_AGG(TO_JSONB(l) ORDER BY l.entry_id) FROM loyalty_ledger AS l WHERE l.customer_id = c.customer_id), '[]'
+					)
+				)
+			FROM customers AS c WHERE c.customer_id = $2
+		`, req.SurvivorId, duplicateId)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx,
+			`SELECT store_credit, store_credit_currency, loyalty_points FROM customers WHERE customer_id = $1`,
+			duplicateId,
+		).Scan(&credit.Amount, &credit.Currency, &points)
+		if err != nil {
+			return err
+		}
+
+		for _, query := range []string{
+			`UPDATE orders SET customer_id = $1, version = version + 1 WHERE customer_id = $2`,
+			`UPDATE gift_cards SET customer_id = $1, version = version + 1 WHERE customer_id = $2`,
+			`UPDATE customer_addresses SET customer_id = $1, is_default = FALSE, version = version + 1 WHERE customer_id = $2`,
+			`UPDATE customer_merges SET survivor_id = $1 WHERE survivor_id = $2`,
+		} {
+			_, err = tx.Exec(ctx, query, req.SurvivorId, duplicateId)
+			if err != nil {
+				return err
+			}
+		}
+
+		if credit.Amount.IsPositive() {
+			err = customerCreditEntry(ctx, tx, req.SurvivorId, models.CreditLedgerMerge, credit, 0, 0, 0)
+			if err != nil {
+				return err
+			}
+		}
+
+		if points > 0 {
+			err = loyaltyEntry(ctx, tx, req.SurvivorId, models.LoyaltyLedgerMerge, points, 0)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM customers WHERE customer_id = $1`, duplicateId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Export bundles everything held on the customer and logs that it was handed out.
+func (r *customerRepo) Export(ctx context.Context, req *models.CustomerPrivacyRequest) (*models.CustomerExport, error) {
+
+	var (
+		resp = models.CustomerExport{}
+		err  error
+
+		addresses     pgtype.JSONB
+		payments      pgtype.JSONB
+		giftCards     pgtype.JSONB
+		creditLedger  pgtype.JSONB
+		loyaltyLedger pgtype.JSONB
+		privacyLog    pgtype.JSONB
+	)
+
+	resp.Customer, err = r.GetByID(ctx, &models.CustomerPrimaryKey{CustomerId: req.CustomerId})
+	if err == pgx.ErrNoRows {
+		return nil, errors.New("Customer is not found")
+	} else if err != nil {
+		return nil, err
+	}
+
+	// log first, so the bundle lists its own export
+	err = customerPrivacyLog(ctx, r.db, req, models.PrivacyActionExport)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.QueryRow(ctx, `
+		SELECT
+			CAST(NOW() AS VARCHAR),
+			c.store_credit,
+			c.store_credit_currency,
+			c.loyalty_points,
+			COALESCE(
+				(
+					SELECT
+						JSONB_AGG (
+							JSONB_BUILD_OBJECT (
+								'address_id', a.address_id,
+								'customer_id', a.customer_id,
+								'label', COALESCE(a.label, ''),
+								'address_type', a.address_type,
+								'street', COALESCE(a.street, ''),
+								'city', COALESCE(a.city, ''),
+								'state', COALESCE(a.state, ''),
+								'zip_code', COALESCE(a.zip_code, ''),
+								'is_default', a.is_default,
+								'created_at', CAST(a.created_at AS VARCHAR)
+							) ORDER BY a.address_id
+						)
+					FROM customer_addresses AS a
+					WHERE a.customer_id = c.customer_id
+				), '[]'
+			),
+			COALESCE(
+				(
+					SELECT
+						JSONB_AGG (
+							JSONB_BUILD_OBJECT (
+								'payment_id', p.payment_id,
+								'order_id', p.order_id,
+								'tender', p.tender,
+								'amount', JSONB_BUILD_OBJECT('amount', p.amount, 'currency', p.currency),
+								'provider', p.provider,
+								'transaction_id', COALESCE(p.transaction_id, ''),
+								'reference', COALESCE(p.reference, ''),
+								'refund_of', COALESCE(p.refund_of, 0),
+								'note', COALESCE(p.note, ''),
+								'created_at', CAST(p.created_at AS VARCHAR)
+							) ORDER BY p.payment_id
+						)
+					FROM payments AS p
+					JOIN orders AS o ON o.order_id = p.order_id
+					WHERE o.customer_id = c.customer_id
+				), '[]'
+			),
+			COALESCE(
+				(
+					SELECT
+						JSONB_AGG (
+							JSONB_BUILD_OBJECT (
+								'gift_card_id', g.gift_card_id,
+								'code', g.code,
+								'customer_id', g.customer_id,
+								'initial_amount', JSONB_BUILD_OBJECT('amount', g.initial_amount, 'currency', g.currency),
+								'balance', JSONB_BUILD_OBJECT('amount', g.balance, 'currency', g.currency),
+								'status', g.status,
+								'expires_at', COALESCE(CAST(g.expires_at AS VARCHAR), ''),
+								'created_at', CAST(g.created_at AS VARCHAR)
+							) ORDER BY g.gift_card_id
+						)
+					FROM gift_cards AS g
+					WHERE g.customer_id = c.customer_id
+				), '[]'
+			),
+			COALESCE(
+				(
+					SELECT
+						JSONB_AGG (
+							JSONB_BUILD_OBJECT (
+								'entry_id', l.entry_id,
+								'customer_id', l.customer_id,
+								'kind', l.kind,
+								'amount', JSONB_BUILD_OBJECT('amount', l.amount, 'currency', l.currency),
+								'balance_after', JSONB_BUILD_OBJECT('amount', l.balance_after, 'currency', l.currency),
+								'order_id', COALESCE(l.order_id, 0),
+								'return_id', COALESCE(l.return_id, 0),
+								'payment_id', COALESCE(l.payment_id, 0),
+								'created_at', CAST(l.created_at AS VARCHAR)
+							) ORDER BY l.entry_id
+						)
+					FROM customer_credit_ledger AS l
+					WHERE l.customer_id = c.customer_id
+				), '[]'
+			),
+			COALESCE(
+				(
+					SELECT
+						JSONB_AGG (
+							JSONB_BUILD_OBJECT (
+								'entry_id', l.entry_id,
+								'customer_id', l.customer_id,
+								'kind', l.kind,
+								'points', l.points,
+								'balance_after', l.balance_after,
+								'order_id', COALESCE(l.order_id, 0),
+								'created_at', CAST(l.created_at AS VARCHAR)
+							) ORDER BY l.entry_id
+						)
+					FROM loyalty_ledger AS l
+					WHERE l.customer_id = c.customer_id
+				), '[]'
+			),
+			COALESCE(
+				(
+					SELECT
+						JSONB_AGG (
+							JSONB_BUILD_OBJECT (
+								'log_id', pl.log_id,
+								'customer_id', pl.customer_id,
+								'action', pl.action,
+								'staff_id', COALESCE(pl.staff_id, 0),
+								'reason', COALESCE(pl.reason, ''),
+								'created_at', CAST(pl.created_at AS VARCHAR)
+							) ORDER BY pl.log_id
+						)
+					FROM customer_privacy_log AS pl
+					WHERE pl.customer_id = c.customer_id
+				), '[]'
+			)
+		FROM customers AS c
+		WHERE c.customer_id = $1
+	`, req.CustomerId).Scan(
+		&resp.ExportedAt,
+		&resp.StoreCredit.Amount,
+		&resp.StoreCredit.Currency,
+		&resp.LoyaltyPoints,
+		&addresses,
+		&payments,
+		&giftCards,
+		&creditLedger,
+		&loyaltyLedger,
+		&privacyLog,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses.AssignTo(&resp.Addresses)
+	payments.AssignTo(&resp.Payments)
+	giftCards.AssignTo(&resp.GiftCards)
+	creditLedger.AssignTo(&resp.CreditLedger)
+	loyaltyLedger.AssignTo(&resp.LoyaltyLedger)
+	privacyLog.AssignTo(&resp.PrivacyLog)
+
+	for offset := 0; ; {
+		page, err := r.Orders(ctx, &models.GetListCustomerOrderRequest{
+			CustomerId: req.CustomerId,
+			Offset:     offset,
+			Limit:      customerExportPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Orders = append(resp.Orders, page.Orders...)
+		offset += len(page.Orders)
+
+		if len(page.Orders) == 0 || offset >= page.Count {
+			break
+		}
+	}
+
+	return &resp, nil
+}
+
+// Anonymize scrubs the personal data of the customer. Orders, payments and ledgers stay for
+// accounting, delivery address snapshots keep only the state the order was taxed in. Audit
+// records of the customer lose their data, those of its orders the customer and the address.
+func (r *customerRepo) Anonymize(ctx context.Context, req *models.CustomerPrivacyRequest) error {
+
+	var anonymizedAt pgtype.Timestamp
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`SELECT anonymized_at FROM customers WHERE customer_id = $1 FOR UPDATE`,
+		req.CustomerId,
+	).Scan(&anonymizedAt)
+	if err == pgx.ErrNoRows {
+		return errors.New("Customer is not found")
+	} else if err != nil {
+		return err
+	}
+
+	if anonymizedAt.Status == pgtype.Present {
+		return errors.New("customer is already anonymized")
+	}
+
+	for _, query := range []string{
+		`UPDATE customers
+			SET
+				first_name = 'Anonymized',
+				last_name = 'Customer',
+				phone = NULL,
+				email = 'anonymized-' || customer_id || '@invalid',
+				street = NULL,
+				city = NULL,
+				state = NULL,
+				zip_code = NULL,
+				anonymized_at = NOW(),
+				version = version + 1
+		WHERE customer_id = $1`,
+		`DELETE FROM customer_addresses WHERE customer_id = $1`,
+		`UPDATE orders
+			SET delivery_address = JSONB_BUILD_OBJECT('state', delivery_address->'state'), version = version + 1
+		WHERE customer_id = $1 AND delivery_address IS NOT NULL`,
+		`UPDATE customer_merges SET merged_data = '{}' WHERE survivor_id = $1`,
+		// the audit trail keeps who changed the customer and when, not what the data was
+		`UPDATE audit_log
+			SET payload = NULL, before_data = NULL, after_data = NULL
+		WHERE entity_type = 'customer' AND entity_id = $1`,
+		`UPDATE audit_log
+			SET
+				before_data = before_data - ARRAY['customer_data', 'delivery_address'],
+				after_data = after_data - ARRAY['customer_data', 'delivery_address']
+		WHERE entity_type IN ('order', 'order_item')
+			AND entity_id IN (SELECT order_id FROM orders WHERE customer_id = $1)`,
+	} {
+		_, err = tx.Exec(ctx, query, req.CustomerId)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = customerPrivacyLog(ctx, tx, req, models.PrivacyActionAnonymize)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func customerPrivacyLog(ctx context.Context, db querier, req *models.CustomerPrivacyRequest, action string) error {
+
+	var id int
+
+	return db.QueryRow(ctx, `
+		INSERT INTO customer_privacy_log(customer_id, action, staff_id, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING log_id
+	`,
+		req.CustomerId,
+		action,
+		helper.NewNullInt32(req.StaffId),
+		helper.NewNullString(req.Reason),
+	).Scan(&id)
+}
@@ -0,0 +1,303 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type customerAddressRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewCustomerAddressRepo(db *pgxpool.Pool) *customerAddressRepo {
+	return &customerAddressRepo{
+		db: db,
+	}
+}
+
+// Create adds an address to the customer, a new default takes over from the previous
+// default of the same type.
+func (r *customerAddressRepo) Create(ctx context.Context, req *models.CreateCustomerAddress) (int, error) {
+	var id int
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if req.IsDefault {
+		err = clearDefaultAddress(ctx, tx, req.CustomerId, req.AddressType, 0)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO customer_addresses(
+			customer_id,
+			label,
+			address_type,
+			street,
+			city,
+			state,
+			zip_code,
+			is_default
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING address_id
+	`,
+		req.CustomerId,
+		helper.NewNullString(req.Label),
+		req.AddressType,
+		helper.NewNullString(req.Street),
+		helper.NewNullString(req.City),
+		helper.NewNullString(req.State),
+		helper.NewNullString(req.ZipCode),
+		req.IsDefault,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *customerAddressRepo) GetByID(ctx context.Context, req *models.CustomerAddressPrimaryKey) (*models.CustomerAddress, error) {
+
+	var (
+		query   string
+		address models.CustomerAddress
+	)
+
+	query = `
+		SELECT
+			address_id,
+			customer_id,
+			COALESCE(label, ''),
+			address_type,
+			COALESCE(street, ''),
+			COALESCE(city, ''),
+			COALESCE(state, ''),
+			COALESCE(zip_code, ''),
+			is_default,
+			version,
+			CAST(created_at AS VARCHAR)
+		FROM customer_addresses
+		WHERE address_id = $1 AND customer_id = $2
+	`
+
+	err := r.db.QueryRow(ctx, query, req.AddressId, req.CustomerId).Scan(
+		&address.AddressId,
+		&address.CustomerId,
+		&address.Label,
+		&address.AddressType,
+		&address.Street,
+		&address.City,
+		&address.State,
+		&address.ZipCode,
+		&address.IsDefault,
+		&address.Version,
+		&address.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
+func (r *customerAddressRepo) GetList(ctx context.Context, req *models.GetListCustomerAddressRequest) (resp *models.GetListCustomerAddressResponse, err error) {
+
+	resp = &models.GetListCustomerAddressResponse{}
+
+	var (
+		query  string
+		filter = " WHERE customer_id = :customer_id "
+		params = map[string]interface{}{
+			"customer_id": req.CustomerId,
+		}
+	)
+
+	query = `
+		SELECT
+			COUNT(*) OVER(),
+			address_id,
+			customer_id,
+			COALESCE(label, ''),
+			address_type,
+			COALESCE(street, ''),
+			COALESCE(city, ''),
+			COALESCE(state, ''),
+			COALESCE(zip_code, ''),
+			is_default,
+			version,
+			CAST(created_at AS VARCHAR)
+		FROM customer_addresses
+	`
+
+	if len(req.AddressType) > 0 {
+		filter += " AND address_type = :address_type "
+		params["address_type"] = req.AddressType
+	}
+
+	query += filter + " ORDER BY is_default DESC, address_id"
+
+	query, args := helper.ReplaceQueryParams(query, params)
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address models.CustomerAddress
+
+		err := rows.Scan(
+			&resp.Count,
+			&address.AddressId,
+			&address.CustomerId,
+			&address.Label,
+			&address.AddressType,
+			&address.Street,
+			&address.City,
+			&address.State,
+			&address.ZipCode,
+			&address.IsDefault,
+			&address.Version,
+			&address.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Addresses = append(resp.Addresses, &address)
+	}
+
+	return resp, rows.Err()
+}
+
+func (r *customerAddressRepo) Update(ctx context.Context, req *models.UpdateCustomerAddress) (int64, error) {
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE customer_addresses
+			SET
+				label = $3,
+				address_type = $4,
+				street = $5,
+				city = $6,
+				state = $7,
+				zip_code = $8,
+				is_default = $9,
+				version = version + 1
+		WHERE address_id = $1 AND customer_id = $2 AND version = $10
+	`,
+		req.AddressId,
+		req.CustomerId,
+		helper.NewNullString(req.Label),
+		req.AddressType,
+		helper.NewNullString(req.Street),
+		helper.NewNullString(req.City),
+		helper.NewNullString(req.State),
+		helper.NewNullString(req.ZipCode),
+		req.IsDefault,
+		req.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.RowsAffected() <= 0 {
+		return 0, nil
+	}
+
+	if req.IsDefault {
+		err = clearDefaultAddress(ctx, tx, req.CustomerId, req.AddressType, req.AddressId)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// Delete removes the address, orders keep their delivery address snapshot.
+func (r *customerAddressRepo) Delete(ctx context.Context, req *models.CustomerAddressPrimaryKey) (int64, error) {
+
+	result, err := r.db.Exec(ctx,
+		`DELETE FROM customer_addresses WHERE address_id = $1 AND customer_id = $2 AND version = $3`,
+		req.AddressId, req.CustomerId, req.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func clearDefaultAddress(ctx context.Context, tx pgx.Tx, customerId int, addressType string, exceptId int) error {
+
+	_, err := tx.Exec(ctx, `
+		UPDATE customer_addresses
+			SET is_default = FALSE, version = version + 1
+		WHERE customer_id = $1 AND address_type = $2 AND is_default AND address_id <> $3
+	`, customerId, addressType, exceptId)
+
+	return err
+}
+
+// deliveryAddress resolves the address an order ships to: the given one, which has to
+// belong to the customer, or else the customer's default shipping address. It returns
+// 0 when the customer has none.
+func deliveryAddress(ctx context.Context, db querier, customerId, addressId int) (int, error) {
+
+	var id int
+
+	if addressId > 0 {
+		err := db.QueryRow(ctx,
+			`SELECT address_id FROM customer_addresses WHERE address_id = $1 AND customer_id = $2`,
+			addressId, customerId,
+		).Scan(&id)
+		if err == pgx.ErrNoRows {
+			return 0, errors.New("delivery address does not belong to the customer")
+		}
+
+		return id, err
+	}
+
+	if customerId <= 0 {
+		return 0, nil
+	}
+
+	err := db.QueryRow(ctx, `
+		SELECT address_id
+		FROM customer_addresses
+		WHERE customer_id = $1 AND address_type = $2 AND is_default
+	`, customerId, models.AddressTypeShipping).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+
+	return id, err
+}
@@ -0,0 +1,85 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"reflect"
+	"testing"
+)
+
+func TestSimilarNames(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{name: "same", a: "Ali Vali", b: "Ali Vali", want: true},
+		{name: "case and spaces", a: "  ALI   vali ", b: "ali vali", want: true},
+		{name: "one typo in a short name", a: "Ali Vali", b: "Aly Vali", want: true},
+		{name: "two typos in a short name", a: "Ali Vali", b: "Aly Valy", want: false},
+		{name: "two typos in a long name", a: "Alexander Smith", b: "Aleksander Smith", want: true},
+		{name: "three typos in a long name", a: "Alexander Smith", b: "Aleksander Smyth", want: false},
+		{name: "nine characters allow one", a: "Abcd Fghi", b: "Abcd Fgxy", want: false},
+		{name: "ten characters allow two", a: "Abcde Fghi", b: "Abcde Fgxy", want: true},
+		{name: "empty", a: "", b: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &models.Customer{FirstName: tt.a}
+			b := &models.Customer{FirstName: tt.b}
+
+			if got := similarNames(a, b); got != tt.want {
+				t.Errorf("similarNames(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupDuplicateCustomers(t *testing.T) {
+	customers := []*models.Customer{
+		{CustomerId: 1, FirstName: "John", LastName: "Smith", Email: "John+shop@Mail.com", Phone: "901234567", ZipCode: "100000"},
+		// same mailbox as 1
+		{CustomerId: 2, FirstName: "Jon", LastName: "Smith", Email: "john@mail.com"},
+		// same phone as 1, so in the group of 1 and 2
+		{CustomerId: 3, FirstName: "Someone", LastName: "Else", Email: "x@mail.com", Phone: "+998 90 123 45 67"},
+		// nearly the same name at the same zip code
+		{CustomerId: 4, FirstName: "Ali", LastName: "Vali", Email: "ali@mail.com", ZipCode: "100100"},
+		{CustomerId: 5, FirstName: "Aly", LastName: "Vali", Email: "aly@mail.com", ZipCode: "100100"},
+		// the same name at another zip code is not a match
+		{CustomerId: 6, FirstName: "Ali", LastName: "Vali", Email: "ali2@mail.com", ZipCode: "200200"},
+		// phones that can not be normalized are not a match
+		{CustomerId: 7, FirstName: "Bob", LastName: "Brown", Email: "b@mail.com", Phone: "8901234567"},
+		{CustomerId: 8, FirstName: "Carl", LastName: "Green", Email: "c@mail.com", Phone: "8901234567"},
+		// empty emails and zip codes are not a match
+		{CustomerId: 9, FirstName: "Dan", LastName: "White"},
+		{CustomerId: 10, FirstName: "Dan", LastName: "Whyte"},
+	}
+
+	want := []struct {
+		reasons []string
+		ids     []int
+	}{
+		{reasons: []string{models.DuplicateReasonEmail, models.DuplicateReasonPhone}, ids: []int{1, 2, 3}},
+		{reasons: []string{models.DuplicateReasonName}, ids: []int{4, 5}},
+	}
+
+	groups := groupDuplicateCustomers(customers)
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+
+	for i, group := range groups {
+		var ids []int
+		for _, customer := range group.Customers {
+			ids = append(ids, customer.CustomerId)
+		}
+
+		if !reflect.DeepEqual(ids, want[i].ids) {
+			t.Errorf("group %d has customers %v, want %v", i, ids, want[i].ids)
+		}
+
+		if !reflect.DeepEqual(group.Reasons, want[i].reasons) {
+			t.Errorf("group %d has reasons %v, want %v", i, group.Reasons, want[i].reasons)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type exchangeRateRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewExchangeRateRepo(db *pgxpool.Pool) *exchangeRateRepo {
+	return &exchangeRateRepo{
+		db: db,
+	}
+}
+
+// currencyOrDefault upper cases the code, an empty one is the default currency.
+func currencyOrDefault(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) <= 0 {
+		return money.DefaultCurrency, nil
+	}
+
+	if !money.ValidCurrency(code) {
+		return "", fmt.Errorf("invalid currency %s", code)
+	}
+
+	return code, nil
+}
+
+func (r *exchangeRateRepo) Create(ctx context.Context, req *models.CreateExchangeRate) (int, error) {
+	var id int
+
+	base, err := currencyOrDefault(req.BaseCurrency)
+	if err != nil {
+		return 0, err
+	}
+
+	quote, err := currencyOrDefault(req.QuoteCurrency)
+	if err != nil {
+		return 0, err
+	}
+
+	if base == quote {
+		return 0, errors.New("base and quote currency must differ")
+	}
+
+	if !req.Rate.IsPositive() {
+		return 0, errors.New("rate must be positive")
+	}
+
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO exchange_rates(base_currency, quote_currency, rate, valid_from)
+		VALUES ($1, $2, $3, COALESCE($4::TIMESTAMP, CURRENT_TIMESTAMP))
+		RETURNING exchange_rate_id
+	`, base, quote, req.Rate, helper.NewNullString(req.ValidFrom)).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *exchangeRateRepo) GetByID(ctx context.Context, req *models.ExchangeRatePrimaryKey) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			exchange_rate_id,
+			base_currency,
+			quote_currency,
+			rate,
+			CAST(valid_from AS VARCHAR)
+		FROM exchange_rates
+		WHERE exchange_rate_id = $1
+	`, req.ExchangeRateId).Scan(
+		&rate.ExchangeRateId,
+		&rate.BaseCurrency,
+		&rate.QuoteCurrency,
+		&rate.Rate,
+		&rate.ValidFrom,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+func (r *exchangeRateRepo) GetList(ctx context.Context, req *models.GetListExchangeRateRequest) (*models.GetListExchangeRateResponse, error) {
+	var (
+		resp   = models.GetListExchangeRateResponse{}
+		filter = " WHERE TRUE "
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		params = map[string]interface{}{}
+	)
+
+	query := `
+		SELECT
+			COUNT(*) OVER(),
+			exchange_rate_id,
+			base_currency,
+			quote_currency,
+			rate,
+			CAST(valid_from AS VARCHAR)
+		FROM exchange_rates
+	`
+
+	if len(req.Currency) > 0 {
+		filter += " AND (base_currency = :currency OR quote_currency = :currency) "
+		params["currency"] = strings.ToUpper(req.Currency)
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += filter + " ORDER BY base_currency, quote_currency, valid_from DESC " + offset + limit
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate models.ExchangeRate
+
+		err = rows.Scan(
+			&resp.Count,
+			&rate.ExchangeRateId,
+			&rate.BaseCurrency,
+			&rate.QuoteCurrency,
+			&rate.Rate,
+			&rate.ValidFrom,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Rates = append(resp.Rates, &rate)
+	}
+
+	return &resp, rows.Err()
+}
+
+// exchangeRate is how much of the currency to one unit of the currency from buys now. The
+// latest rate in effect is used, a rate kept the other way round is inverted.
+func exchangeRate(ctx context.Context, q querier, from, to string) (decimal.Decimal, error) {
+	var rate decimal.Decimal
+
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+
+	err := q.QueryRow(ctx, `
+		SELECT r.rate
+		FROM (
+			SELECT rate, valid_from
+			FROM exchange_rates
+			WHERE base_currency = $1 AND quote_currency = $2 AND valid_from <= NOW()
+			UNION ALL
+			SELECT 1 / rate, valid_from
+			FROM exchange_rates
+			WHERE base_currency = $2 AND quote_currency = $1 AND valid_from <= NOW()
+		) AS r
+		ORDER BY r.valid_from DESC
+		LIMIT 1
+	`, from, to).Scan(&rate)
+	if err == pgx.ErrNoRows {
+		return rate, fmt.Errorf("no exchange rate from %s to %s", from, to)
+	} else if err != nil {
+		return rate, err
+	}
+
+	return rate, nil
+}
+
+// convertTo gives the amount in the currency at the current exchange rate, an amount without
+// a currency is taken to be in it already.
+func convertTo(ctx context.Context, q querier, m money.Money, currency string) (money.Money, error) {
+	if len(m.Currency) <= 0 || m.Currency == currency {
+		return money.New(m.Amount, currency), nil
+	}
+
+	rate, err := exchangeRate(ctx, q, m.Currency, currency)
+	if err != nil {
+		return m, err
+	}
+
+	return m.Convert(rate, currency), nil
+}
+
+// exchangeRates converts the amounts of a report to one currency, looking every rate up once.
+type exchangeRates struct {
+	q     querier
+	to    string
+	rates map[string]decimal.Decimal
+}
+
+func newExchangeRates(q querier, to string) (*exchangeRates, error) {
+	to, err := currencyOrDefault(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exchangeRates{q: q, to: to, rates: map[string]decimal.Decimal{}}, nil
+}
+
+func (e *exchangeRates) convert(ctx context.Context, m money.Money) (money.Money, error) {
+	rate, ok := e.rates[m.Currency]
+	if !ok {
+		var err error
+
+		rate, err = exchangeRate(ctx, e.q, m.Currency, e.to)
+		if err != nil {
+			return m, err
+		}
+		e.rates[m.Currency] = rate
+	}
+
+	return m.Convert(rate, e.to), nil
+}
@@ -0,0 +1,401 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	giftCardCodeLength   = 16
+	giftCardCodeAttempts = 5
+)
+
+type giftCardRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewGiftCardRepo(db *pgxpool.Pool) *giftCardRepo {
+	return &giftCardRepo{
+		db: db,
+	}
+}
+
+// Create issues a card with a random code, a colliding code is simply generated again.
+func (r *giftCardRepo) Create(ctx context.Context, req *models.CreateGiftCard) (int, error) {
+	var id int
+
+	currency, err := currencyOrDefault(req.Amount.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	amount := money.New(req.Amount.Amount, currency)
+	if !amount.Amount.IsPositive() {
+		return 0, errors.New("gift card amount must be positive")
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	for attempt := 0; attempt < giftCardCodeAttempts && id == 0; attempt++ {
+		code, err := helper.GenerateOTP(giftCardCodeLength)
+		if err != nil {
+			return 0, err
+		}
+
+		err = tx.QueryRow(ctx, `
+			INSERT INTO gift_cards(
+				code,
+				customer_id,
+				initial_amount,
+				balance,
+				currency,
+				status,
+				expires_at
+			)
+			VALUES ($1, $2, $3, $3, $4, $5, $6)
+			ON CONFLICT (code) DO NOTHING
+			RETURNING gift_card_id
+		`,
+			code,
+			helper.NewNullInt32(req.CustomerId),
+			amount.Amount,
+			amount.Currency,
+			models.GiftCardStatusActive,
+			helper.NewNullString(req.ExpiresAt),
+		).Scan(&id)
+		if err != nil && err != pgx.ErrNoRows {
+			return 0, err
+		}
+	}
+
+	if id == 0 {
+		return 0, errors.New("could not generate a unique gift card code")
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO gift_card_ledger(gift_card_id, kind, amount, balance_after)
+		VALUES ($1, $2, $3, $3)
+	`, id, models.GiftCardLedgerIssue, amount.Amount)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *giftCardRepo) GetByID(ctx context.Context, req *models.GiftCardPrimaryKey) (*models.GiftCard, error) {
+
+	var (
+		query  string
+		card   models.GiftCard
+		ledger pgtype.JSONB
+	)
+
+	query = `
+		SELECT
+			g.gift_card_id,
+			g.code,
+			COALESCE(g.customer_id, 0),
+			g.initial_amount,
+			g.balance,
+			g.currency,
+			g.status,
+			COALESCE(CAST(g.expires_at AS VARCHAR), ''),
+			g.version,
+			CAST(g.created_at AS VARCHAR),
+			COALESCE(
+				(
+					SELECT
+						JSONB_AGG (
+							JSONB_BUILD_OBJECT (
+								'entry_id', l.entry_id,
+								'gift_card_id', l.gift_card_id,
+								'kind', l.kind,
+								'amount', JSONB_BUILD_OBJECT('amount', l.amount, 'currency', g.currency),
+								'balance_after', JSONB_BUILD_OBJECT('amount', l.balance_after, 'currency', g.currency),
+								'order_id', COALESCE(l.order_id, 0),
+								'payment_id', COALESCE(l.payment_id, 0),
+								'created_at', CAST(l.created_at AS VARCHAR)
+							) ORDER BY l.entry_id
+						)
+					FROM gift_card_ledger AS l
+					WHERE l.gift_card_id = g.gift_card_id
+				), '[]'
+			)
+		FROM gift_cards AS g
+		WHERE g.gift_card_id = $1
+	`
+
+	err := r.db.QueryRow(ctx, query, req.GiftCardId).Scan(
+		&card.GiftCardId,
+		&card.Code,
+		&card.CustomerId,
+		&card.InitialAmount.Amount,
+		&card.Balance.Amount,
+		&card.Balance.Currency,
+		&card.Status,
+		&card.ExpiresAt,
+		&card.Version,
+		&card.CreatedAt,
+		&ledger,
+	)
+	if err != nil {
+		return nil, err
+	}
+	card.InitialAmount.Currency = card.Balance.Currency
+
+	ledger.AssignTo(&card.Ledger)
+
+	return &card, nil
+}
+
+func (r *giftCardRepo) GetList(ctx context.Context, req *models.GetListGiftCardRequest) (resp *models.GetListGiftCardResponse, err error) {
+
+	resp = &models.GetListGiftCardResponse{}
+
+	var (
+		query  string
+		filter = " WHERE TRUE "
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		params = map[string]interface{}{}
+	)
+
+	query = `
+		SELECT
+			COUNT(*) OVER(),
+			g.gift_card_id,
+			g.code,
+			COALESCE(g.customer_id, 0),
+			g.initial_amount,
+			g.balance,
+			g.currency,
+			g.status,
+			COALESCE(CAST(g.expires_at AS VARCHAR), ''),
+			g.version,
+			CAST(g.created_at AS VARCHAR)
+		FROM gift_cards AS g
+	`
+
+	if len(req.Code) > 0 {
+		filter += " AND g.code = :code "
+		params["code"] = req.Code
+	}
+
+	if req.CustomerId > 0 {
+		filter += " AND g.customer_id = :customer_id "
+		params["customer_id"] = req.CustomerId
+	}
+
+	if len(req.Status) > 0 {
+		filter += " AND g.status = :status "
+		params["status"] = req.Status
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += filter + " ORDER BY g.gift_card_id DESC " + offset + limit
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var card models.GiftCard
+
+		err = rows.Scan(
+			&resp.Count,
+			&card.GiftCardId,
+			&card.Code,
+			&card.CustomerId,
+			&card.InitialAmount.Amount,
+			&card.Balance.Amount,
+			&card.Balance.Currency,
+			&card.Status,
+			&card.ExpiresAt,
+			&card.Version,
+			&card.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		card.InitialAmount.Currency = card.Balance.Currency
+
+		resp.GiftCards = append(resp.GiftCards, &card)
+	}
+
+	return resp, nil
+}
+
+func (r *giftCardRepo) UpdateStatus(ctx context.Context, req *models.UpdateGiftCardStatus) (int64, error) {
+
+	if req.Status != models.GiftCardStatusActive && req.Status != models.GiftCardStatusDisabled {
+		return 0, errors.New("invalid gift card status")
+	}
+
+	result, err := r.db.Exec(ctx,
+		`UPDATE gift_cards SET status = $2, version = version + 1 WHERE gift_card_id = $1 AND version = $3`,
+		req.GiftCardId,
+		req.Status,
+		req.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// giftCardEntry moves amount (negative for a redemption) on the card with the given code
+// and writes it to the ledger. An amount in another currency than the card's is converted at
+// the current exchange rate. Only active, unexpired cards can be redeemed.
+func giftCardEntry(ctx context.Context, tx pgx.Tx, code, kind string, amount money.Money, orderId, paymentId int) error {
+	var (
+		id      int
+		status  string
+		expired bool
+		balance money.Money
+	)
+
+	err := tx.QueryRow(ctx, `
+		SELECT
+			gift_card_id,
+			status,
+			COALESCE(expires_at < now(), FALSE),
+			balance,
+			currency
+		FROM gift_cards
+		WHERE code = $1
+		FOR UPDATE
+	`, code).Scan(&id, &status, &expired, &balance.Amount, &balance.Currency)
+	if err == pgx.ErrNoRows {
+		return errors.New("gift card is not found")
+	} else if err != nil {
+		return err
+	}
+
+	amount, err = convertTo(ctx, tx, amount, balance.Currency)
+	if err != nil {
+		return err
+	}
+
+	if amount.Amount.IsNegative() {
+		if status != models.GiftCardStatusActive {
+			return errors.New("gift card is disabled")
+		}
+
+		if expired {
+			return errors.New("gift card is expired")
+		}
+
+		if balance.Amount.Add(amount.Amount).IsNegative() {
+			return fmt.Errorf("gift card balance is %s", balance)
+		}
+	}
+
+	balance.Amount = balance.Amount.Add(amount.Amount)
+
+	_, err = tx.Exec(ctx, `UPDATE gift_cards SET balance = $2, version = version + 1 WHERE gift_card_id = $1`, id, balance.Amount)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO gift_card_ledger(gift_card_id, kind, amount, balance_after, order_id, payment_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`,
+		id,
+		kind,
+		amount.Amount,
+		balance.Amount,
+		helper.NewNullInt32(orderId),
+		helper.NewNullInt32(paymentId),
+	)
+
+	return err
+}
+
+// customerCreditEntry moves amount (negative for a redemption) on the customer's store credit
+// and writes it to the ledger, the credit can never go below zero. Credit is held in one
+// currency, a zero balance takes the currency of the amount, otherwise the amount is converted
+// at the current exchange rate.
+func customerCreditEntry(ctx context.Context, tx pgx.Tx, customerId int, kind string, amount money.Money, orderId, returnId, paymentId int) error {
+	var balance money.Money
+
+	err := tx.QueryRow(ctx,
+		`SELECT store_credit, store_credit_currency FROM customers WHERE customer_id = $1 FOR UPDATE`,
+		customerId,
+	).Scan(&balance.Amount, &balance.Currency)
+	if err == pgx.ErrNoRows {
+		return errors.New("customer is not found")
+	} else if err != nil {
+		return err
+	}
+
+	if balance.Amount.IsZero() && len(amount.Currency) > 0 {
+		balance.Currency = amount.Currency
+	}
+
+	amount, err = convertTo(ctx, tx, amount, balance.Currency)
+	if err != nil {
+		return err
+	}
+
+	balance.Amount = balance.Amount.Add(amount.Amount)
+	if balance.Amount.IsNegative() {
+		return errors.New("customer has not enough store credit")
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE customers SET store_credit = $2, store_credit_currency = $3 WHERE customer_id = $1`,
+		customerId,
+		balance.Amount,
+		balance.Currency,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO customer_credit_ledger(customer_id, kind, amount, balance_after, currency, order_id, return_id, payment_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		customerId,
+		kind,
+		amount.Amount,
+		balance.Amount,
+		balance.Currency,
+		helper.NewNullInt32(orderId),
+		helper.NewNullInt32(returnId),
+		helper.NewNullInt32(paymentId),
+	)
+
+	return err
+}
@@ -0,0 +1,91 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type idempotencyRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewIdempotencyRepo(db *pgxpool.Pool) *idempotencyRepo {
+	return &idempotencyRepo{
+		db: db,
+	}
+}
+
+// Reserve claims the key for the request. It returns nil when the key is new (or its
+// previous use has expired) and the stored key when it was already used.
+func (r *idempotencyRepo) Reserve(ctx context.Context, req *models.ReserveIdempotencyKey) (*models.IdempotencyKey, error) {
+
+	_, err := r.db.Exec(ctx,
+		`DELETE FROM idempotency_keys WHERE created_at < NOW() - make_interval(secs => $1)`,
+		req.TTL.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.db.Exec(ctx, `
+		INSERT INTO idempotency_keys(idempotency_key, request_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (idempotency_key) DO NOTHING
+	`, req.Key, req.RequestHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.RowsAffected() > 0 {
+		return nil, nil
+	}
+
+	var key models.IdempotencyKey
+
+	err = r.db.QueryRow(ctx, `
+		SELECT
+			idempotency_key,
+			request_hash,
+			COALESCE(status, 0),
+			COALESCE(response, ''),
+			CAST(created_at AS VARCHAR)
+		FROM idempotency_keys
+		WHERE idempotency_key = $1
+	`, req.Key).Scan(
+		&key.Key,
+		&key.RequestHash,
+		&key.Status,
+		&key.Response,
+		&key.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		// the first use expired between the insert and this read, try again
+		return r.Reserve(ctx, req)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *idempotencyRepo) Complete(ctx context.Context, req *models.CompleteIdempotencyKey) error {
+
+	_, err := r.db.Exec(ctx,
+		`UPDATE idempotency_keys SET status = $2, response = $3 WHERE idempotency_key = $1`,
+		req.Key,
+		req.Status,
+		req.Response,
+	)
+
+	return err
+}
+
+func (r *idempotencyRepo) Delete(ctx context.Context, req *models.IdempotencyKeyPrimaryKey) error {
+
+	_, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE idempotency_key = $1`, req.Key)
+
+	return err
+}
@@ -0,0 +1,583 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+// loyaltyCurrency is the base currency tier spend, point value and earned points are counted in,
+// amounts of orders in other currencies are converted at the current exchange rate.
+const loyaltyCurrency = money.DefaultCurrency
+
+type loyaltyRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewLoyaltyRepo(db *pgxpool.Pool) *loyaltyRepo {
+	return &loyaltyRepo{
+		db: db,
+	}
+}
+
+func (r *loyaltyRepo) GetListTier(ctx context.Context) ([]*models.LoyaltyTier, error) {
+	var tiers []*models.LoyaltyTier
+
+	rows, err := r.db.Query(ctx, `
+		SELECT
+			tier_id,
+			tier_name,
+			min_spend,
+			multiplier
+		FROM loyalty_tiers
+		ORDER BY min_spend
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tier = models.LoyaltyTier{MinSpend: money.Zero(loyaltyCurrency)}
+
+		err = rows.Scan(&tier.TierId, &tier.TierName, &tier.MinSpend.Amount, &tier.Multiplier)
+		if err != nil {
+			return nil, err
+		}
+
+		tiers = append(tiers, &tier)
+	}
+
+	return tiers, nil
+}
+
+func (r *loyaltyRepo) UpsertTier(ctx context.Context, req *models.UpsertLoyaltyTier) (int, error) {
+	var id int
+
+	minSpend, err := req.MinSpend.In(loyaltyCurrency)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(req.TierName) <= 0 || minSpend.Amount.IsNegative() || req.Multiplier <= 0 {
+		return 0, errors.New("invalid loyalty tier")
+	}
+
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO loyalty_tiers(tier_name, min_spend, multiplier)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tier_name)
+		DO UPDATE SET min_spend = EXCLUDED.min_spend, multiplier = EXCLUDED.multiplier
+		RETURNING tier_id
+	`, req.TierName, minSpend.Amount.Round(money.Cents), req.Multiplier).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *loyaltyRepo) GetListMultiplier(ctx context.Context) ([]*models.LoyaltyMultiplier, error) {
+	var multipliers []*models.LoyaltyMultiplier
+
+	rows, err := r.db.Query(ctx, `
+		SELECT
+			multiplier_id,
+			COALESCE(brand_id, 0),
+			COALESCE(category_id, 0),
+			multiplier
+		FROM loyalty_multipliers
+		ORDER BY multiplier_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var multiplier models.LoyaltyMultiplier
+
+		err = rows.Scan(
+			&multiplier.MultiplierId,
+			&multiplier.BrandId,
+			&multiplier.CategoryId,
+			&multiplier.Multiplier,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		multipliers = append(multipliers, &multiplier)
+	}
+
+	return multipliers, nil
+}
+
+func (r *loyaltyRepo) UpsertMultiplier(ctx context.Context, req *models.UpsertLoyaltyMultiplier) (int, error) {
+	var (
+		id     int
+		target string
+	)
+
+	switch {
+	case req.BrandId > 0 && req.CategoryId <= 0:
+		target = "brand_id"
+	case req.CategoryId > 0 && req.BrandId <= 0:
+		target = "category_id"
+	default:
+		return 0, errors.New("either brand_id or category_id is required")
+	}
+
+	if req.Multiplier <= 0 {
+		return 0, errors.New("multiplier must be positive")
+	}
+
+	query := `
+		INSERT INTO loyalty_multipliers(brand_id, category_id, multiplier)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (` + target + `)
+		DO UPDATE SET multiplier = EXCLUDED.multiplier
+		RETURNING multiplier_id
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		helper.NewNullInt32(req.BrandId),
+		helper.NewNullInt32(req.CategoryId),
+		req.Multiplier,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *loyaltyRepo) DeleteMultiplier(ctx context.Context, req *models.LoyaltyMultiplierPrimaryKey) (int64, error) {
+
+	result, err := r.db.Exec(ctx, `DELETE FROM loyalty_multipliers WHERE multiplier_id = $1`, req.MultiplierId)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// Customer is the customer's points balance, tier and points ledger, newest entries first.
+func (r *loyaltyRepo) Customer(ctx context.Context, req *models.GetCustomerLoyaltyRequest) (*models.CustomerLoyalty, error) {
+	var (
+		resp   = models.CustomerLoyalty{CustomerId: req.CustomerId}
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		err    error
+	)
+
+	err = r.db.QueryRow(ctx,
+		`SELECT loyalty_points FROM customers WHERE customer_id = $1`,
+		req.CustomerId,
+	).Scan(&resp.Points)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Spend, err = customerSpend(ctx, r.db, req.CustomerId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Tier, resp.NextTier, err = loyaltyTier(ctx, r.db, resp.Spend)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query := `
+		SELECT
+			COUNT(*) OVER(),
+			entry_id,
+			customer_id,
+			kind,
+			points,
+			balance_after,
+			COALESCE(order_id, 0),
+			CAST(created_at AS VARCHAR)
+		FROM loyalty_ledger
+		WHERE customer_id = $1
+		ORDER BY entry_id DESC
+	` + offset + limit
+
+	rows, err := r.db.Query(ctx, query, req.CustomerId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.LoyaltyLedgerEntry
+
+		err = rows.Scan(
+			&resp.Count,
+			&entry.EntryId,
+			&entry.CustomerId,
+			&entry.Kind,
+			&entry.Points,
+			&entry.BalanceAfter,
+			&entry.OrderId,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Ledger = append(resp.Ledger, &entry)
+	}
+
+	return &resp, nil
+}
+
+// Redeem takes points from the customer and turns them into a discount on the open order. Points
+// are valued in the base currency and converted to the currency of the order, the discount is
+// applied after the promo code and can not exceed what is left of the order before tax.
+func (r *loyaltyRepo) Redeem(ctx context.Context, req *models.RedeemLoyaltyPoints) error {
+	var (
+		status     int16
+		customerId int
+	)
+
+	if req.Points <= 0 {
+		return errors.New("points must be positive")
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`SELECT order_status, COALESCE(customer_id, 0) FROM orders WHERE order_id = $1 FOR UPDATE`,
+		req.OrderId,
+	).Scan(&status, &customerId)
+	if err == pgx.ErrNoRows {
+		return errors.New("Order is not found")
+	} else if err != nil {
+		return err
+	}
+
+	if status == models.OrderStatusCompleted || status == models.OrderStatusRejected {
+		return errors.New("points can only be redeemed on an open order")
+	}
+
+	if customerId <= 0 {
+		return errors.New("Order has no customer to redeem points from")
+	}
+
+	amount, _, err := orderAmount(ctx, tx, req.OrderId)
+	if err != nil {
+		return err
+	}
+
+	rate, err := exchangeRate(ctx, tx, loyaltyCurrency, amount.Total.Currency)
+	if err != nil {
+		return err
+	}
+
+	discount := money.New(decimal.NewFromInt(int64(req.Points)).Mul(req.PointValue).Mul(rate), amount.Total.Currency)
+
+	// the same room orderAmount leaves the loyalty discount, tax added on top is not discounted
+	rest := money.New(
+		amount.Subtotal.Amount.Sub(amount.LineDiscount.Amount).Sub(amount.PromoDiscount.Amount).Sub(amount.LoyaltyDiscount.Amount),
+		amount.Total.Currency,
+	)
+
+	if discount.Amount.GreaterThan(rest.Amount) {
+		return fmt.Errorf("points are worth %s but only %s is left to discount", discount, rest)
+	}
+
+	err = loyaltyEntry(ctx, tx, customerId, models.LoyaltyLedgerRedeem, -req.Points, req.OrderId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE orders
+		SET
+			loyalty_points = loyalty_points + $2,
+			loyalty_discount = loyalty_discount + $3,
+			version = version + 1
+		WHERE order_id = $1
+	`, req.OrderId, req.Points, discount.Amount)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Release gives the points redeemed on an open order back to the customer.
+func (r *loyaltyRepo) Release(ctx context.Context, req *models.OrderPrimaryKey) error {
+	var (
+		status     int16
+		customerId int
+		points     int
+	)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`SELECT order_status, COALESCE(customer_id, 0), loyalty_points FROM orders WHERE order_id = $1 FOR UPDATE`,
+		req.OrderId,
+	).Scan(&status, &customerId, &points)
+	if err == pgx.ErrNoRows {
+		return errors.New("Order is not found")
+	} else if err != nil {
+		return err
+	}
+
+	if status == models.OrderStatusCompleted {
+		return errors.New("points of a completed order can not be released")
+	}
+
+	if points <= 0 {
+		return errors.New("no points are redeemed on the order")
+	}
+
+	err = loyaltyEntry(ctx, tx, customerId, models.LoyaltyLedgerRelease, points, req.OrderId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE orders SET loyalty_points = 0, loyalty_discount = 0, version = version + 1 WHERE order_id = $1`,
+		req.OrderId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// earnLoyaltyPoints credits the customer for a completed order. Every line earns
+// rate points per unit of the base currency actually paid for it, times the larger of its
+// brand and category multipliers and the multiplier of the customer's tier.
+func earnLoyaltyPoints(ctx context.Context, tx pgx.Tx, customerId, orderId int, rate float64) error {
+	var (
+		earned      decimal.Decimal
+		multipliers = map[int]decimal.Decimal{}
+	)
+
+	amount, items, err := orderAmount(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+
+	fxRate, err := exchangeRate(ctx, tx, amount.Total.Currency, loyaltyCurrency)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT
+			oi.item_id,
+			GREATEST(COALESCE(bm.multiplier, 1), COALESCE(cm.multiplier, 1))
+		FROM order_items AS oi
+		JOIN products AS p ON p.product_id = oi.product_id
+		LEFT JOIN loyalty_multipliers AS bm ON bm.brand_id = p.brand_id
+		LEFT JOIN loyalty_multipliers AS cm ON cm.category_id = p.category_id
+		WHERE oi.order_id = $1
+	`, orderId)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var (
+			itemId     int
+			multiplier decimal.Decimal
+		)
+
+		err = rows.Scan(&itemId, &multiplier)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		multipliers[itemId] = multiplier
+	}
+	rows.Close()
+
+	spend, err := customerSpend(ctx, tx, customerId)
+	if err != nil {
+		return err
+	}
+
+	tier, _, err := loyaltyTier(ctx, tx, spend)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		multiplier, ok := multipliers[item.ItemId]
+		if !ok {
+			multiplier = decimal.NewFromInt(1)
+		}
+
+		// tax is not earned on, an inclusive price holds it
+		net := lineNet(amount, item)
+		if amount.TaxInclusive {
+			net = net.Sub(item.Tax.Amount)
+		}
+
+		paid := money.New(net, amount.Total.Currency).Convert(fxRate, loyaltyCurrency)
+		earned = earned.Add(paid.Amount.Mul(multiplier))
+	}
+
+	if tier != nil {
+		earned = earned.Mul(decimal.NewFromFloat(tier.Multiplier))
+	}
+
+	points := int(earned.Mul(decimal.NewFromFloat(rate)).Floor().IntPart())
+	if points <= 0 {
+		return nil
+	}
+
+	return loyaltyEntry(ctx, tx, customerId, models.LoyaltyLedgerEarn, points, orderId)
+}
+
+// customerSpend is what the customer spent on completed orders over the last 12 months,
+// less the refunds of approved returns on those orders, in the base currency.
+func customerSpend(ctx context.Context, db querier, customerId int) (money.Money, error) {
+	var (
+		spend   = money.Zero(loyaltyCurrency)
+		amounts []money.Money
+	)
+
+	rates, err := newExchangeRates(db, loyaltyCurrency)
+	if err != nil {
+		return spend, err
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT
+			s.currency,
+			SUM(s.amount)
+		FROM (
+			SELECT
+				o.currency,
+				oi.quantity * oi.list_price * (1 - oi.discount) AS amount
+			FROM orders AS o
+			JOIN order_items AS oi ON oi.order_id = o.order_id
+			WHERE o.customer_id = $1 AND o.order_status = $2
+				AND o.order_date >= CURRENT_DATE - INTERVAL '12 months'
+			UNION ALL
+			SELECT
+				rt.currency,
+				-rt.refund_amount
+			FROM returns AS rt
+			JOIN orders AS o ON o.order_id = rt.order_id
+			WHERE o.customer_id = $1 AND rt.status = $3
+				AND o.order_date >= CURRENT_DATE - INTERVAL '12 months'
+		) AS s
+		GROUP BY s.currency
+	`, customerId, models.OrderStatusCompleted, models.ReturnStatusApproved)
+	if err != nil {
+		return spend, err
+	}
+
+	for rows.Next() {
+		var amount money.Money
+
+		err = rows.Scan(&amount.Currency, &amount.Amount)
+		if err != nil {
+			rows.Close()
+			return spend, err
+		}
+
+		amounts = append(amounts, amount)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return spend, err
+	}
+
+	// rates are looked up once the rows are read, the connection can run one query at a time
+	for _, amount := range amounts {
+		converted, err := rates.convert(ctx, amount)
+		if err != nil {
+			return spend, err
+		}
+
+		spend.Amount = spend.Amount.Add(converted.Amount)
+	}
+
+	return spend, nil
+}
+
+// loyaltyTier is the highest tier the spend reaches and the one after it, either can be nil.
+func loyaltyTier(ctx context.Context, db querier, spend money.Money) (*models.LoyaltyTier, *models.LoyaltyTier, error) {
+	var tiers [2]*models.LoyaltyTier
+
+	queries := [2]string{
+		`SELECT tier_id, tier_name, min_spend, multiplier FROM loyalty_tiers WHERE min_spend <= $1 ORDER BY min_spend DESC LIMIT 1`,
+		`SELECT tier_id, tier_name, min_spend, multiplier FROM loyalty_tiers WHERE min_spend > $1 ORDER BY min_spend LIMIT 1`,
+	}
+
+	for i, query := range queries {
+		var tier = models.LoyaltyTier{MinSpend: money.Zero(loyaltyCurrency)}
+
+		err := db.QueryRow(ctx, query, spend.Amount).Scan(&tier.TierId, &tier.TierName, &tier.MinSpend.Amount, &tier.Multiplier)
+		if err == pgx.ErrNoRows {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		tiers[i] = &tier
+	}
+
+	return tiers[0], tiers[1], nil
+}
+
+// loyaltyEntry moves points (negative for a redemption) on the customer's balance
+// and writes them to the ledger, the balance can never go below zero.
+func loyaltyEntry(ctx context.Context, tx pgx.Tx, customerId int, kind string, points, orderId int) error {
+	var balance int
+
+	err := tx.QueryRow(ctx, `
+		UPDATE customers
+		SET loyalty_points = loyalty_points + $2
+		WHERE customer_id = $1 AND loyalty_points + $2 >= 0
+		RETURNING loyalty_points
+	`, customerId, points).Scan(&balance)
+	if err == pgx.ErrNoRows {
+		return errors.New("customer is not found or has not enough points")
+	} else if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO loyalty_ledger(customer_id, kind, points, balance_after, order_id)
+		VALUES ($1, $2, $3, $4, $5)
+	`, customerId, kind, points, balance, helper.NewNullInt32(orderId))
+
+	return err
+}
@@ -3,12 +3,18 @@ package postgresql
 import (
 	"app/api/models"
 	"app/pkg/helper"
+	"app/pkg/money"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/shopspring/decimal"
 )
 
 type orderRepo struct {
@@ -21,12 +27,68 @@ func NewOrderRepo(db *pgxpool.Pool) *orderRepo {
 	}
 }
 
+// Create opens the order and snapshots its delivery address, so later edits of the
+// customer's address book do not change where a past order went.
 func (r *orderRepo) Create(ctx context.Context, req *models.CreateOrder) (int, error) {
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := createOrder(ctx, tx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Checkout creates the order together with its items. When a line fails no order is
+// created and the problems of every failing line are returned.
+func (r *orderRepo) Checkout(ctx context.Context, req *models.Checkout) (int, []*models.OrderItemError, error) {
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := createOrder(ctx, tx, req.Order)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	lineErrors, err := addOrderItems(ctx, tx, req.Order.StoreId, id, req.Items)
+	if err != nil || len(lineErrors) > 0 {
+		return 0, lineErrors, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return id, nil, nil
+}
+
+func createOrder(ctx context.Context, tx pgx.Tx, req *models.CreateOrder) (int, error) {
 	var (
 		query string
 		id    int
 	)
 
+	addressId, err := deliveryAddress(ctx, tx, req.CustomerId, req.DeliveryAddressId)
+	if err != nil {
+		return 0, err
+	}
+
 	query = `
 		INSERT INTO orders(
 			order_id, 
@@ -37,17 +99,41 @@ func (r *orderRepo) Create(ctx context.Context, req *models.CreateOrder) (int, e
 			shipped_date,
 			store_id,
 			staff_id,
-			promo_code
+			promo_code,
+			delivery_address_id,
+			currency,
+			delivery_address
 		)
 		VALUES (
 			(
 				SELECT MAX(order_id) + 1 FROM orders
 			)
-			, $1, $2, now()::date, $3, $4, $5, $6, $7) RETURNING order_id
+			, $1, $2, now()::date, $3, $4, $5, $6, $7, $8,
+			(
+				SELECT currency FROM stores WHERE store_id = $5
+			),
+			(
+				SELECT
+					JSONB_BUILD_OBJECT (
+						'address_id', a.address_id,
+						'customer_id', a.customer_id,
+						'label', COALESCE(a.label, ''),
+						'address_type', a.address_type,
+						'street', COALESCE(a.street, ''),
+						'city', COALESCE(a.city, ''),
+						'state', COALESCE(a.state, ''),
+						'zip_code', COALESCE(a.zip_code, ''),
+						'is_default', a.is_default,
+						'created_at', CAST(a.created_at AS VARCHAR)
+					)
+				FROM customer_addresses AS a
+				WHERE a.address_id = $8
+			)
+		) RETURNING order_id
 	`
 	fmt.Println(query)
 
-	err := r.db.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		helper.NewNullInt32(req.CustomerId),
 		req.OrderStatus,
 		req.RequiredDate,
@@ -55,6 +141,7 @@ func (r *orderRepo) Create(ctx context.Context, req *models.CreateOrder) (int, e
 		req.StoreId,
 		req.StaffId,
 		req.PromoCode,
+		helper.NewNullInt32(addressId),
 	).Scan(&id)
 
 	if err != nil {
@@ -80,13 +167,32 @@ func (r *orderRepo) GetByID(ctx context.Context, req *models.OrderPrimaryKey) (*
 						'order_id', oi.order_id,
 						'item_id', oi.item_id,
 						'product_id', oi.product_id,
+						'variant_id', oi.variant_id,
+						'sku', v.sku,
 						'quantity', oi.quantity,
-						'list_price', oi.list_price,
-						'discount', oi.discount
+						'list_price', JSONB_BUILD_OBJECT('amount', oi.list_price, 'currency', o.currency),
+						'discount', oi.discount,
+						'catalog_price', JSONB_BUILD_OBJECT('amount', COALESCE(oi.catalog_price, oi.list_price), 'currency', o.currency),
+						'override_staff_id', COALESCE(oi.override_staff_id, 0),
+						'override_reason', COALESCE(oi.override_reason, ''),
+						'tax_rate_id', COALESCE(oi.tax_rate_id, 0),
+						'tax_name', COALESCE(oi.tax_name, ''),
+						'tax_rate', oi.tax_rate,
+						'reservation', COALESCE(
+							(
+								SELECT sr.status
+								FROM stock_reservations AS sr
+								WHERE sr.order_id = oi.order_id AND sr.item_id = oi.item_id
+								ORDER BY sr.reservation_id DESC
+								LIMIT 1
+							), ''
+						)
 					)
 				) AS order_items
 		
 			FROM order_items AS oi
+			JOIN orders AS o ON o.order_id = oi.order_id
+			JOIN product_variants AS v ON v.variant_id = oi.variant_id
 			WHERE oi.order_id = $1
 			GROUP BY oi.order_id
 		)
@@ -102,12 +208,13 @@ func (r *orderRepo) GetByID(ctx context.Context, req *models.OrderPrimaryKey) (*
 			COALESCE(c.street, ''),
 			COALESCE(c.city, ''),
 			COALESCE(c.state, ''),
-			COALESCE(c.zip_code, 0),
+			COALESCE(c.zip_code, ''),
 			
 			o.order_status,
 			CAST(o.order_date::timestamp AS VARCHAR),
 			CAST(o.required_date::timestamp AS VARCHAR),
 			COALESCE(CAST(o.shipped_date::timestamp AS VARCHAR), ''),
+			o.return_status,
 			o.store_id,
 		
 			s.store_id,
@@ -129,7 +236,12 @@ func (r *orderRepo) GetByID(ctx context.Context, req *models.OrderPrimaryKey) (*
 			st.store_id,
 			COALESCE(st.manager_id, 0),
 		
-			oi.order_items
+			oi.order_items,
+
+			COALESCE(o.delivery_address_id, 0),
+			o.delivery_address,
+			o.currency,
+			o.version
 		
 		FROM orders AS o
 		JOIN customers AS c ON c.customer_id = o.customer_id
@@ -142,6 +254,7 @@ func (r *orderRepo) GetByID(ctx context.Context, req *models.OrderPrimaryKey) (*
 	order.StoreData = &models.Store{}
 	order.StaffData = &models.Staff{}
 	orderItemObject := pgtype.JSON{}
+	deliveryAddressObject := pgtype.JSONB{}
 
 	err := r.db.QueryRow(ctx, query, req.OrderId).Scan(
 		&order.OrderId,
@@ -160,6 +273,7 @@ func (r *orderRepo) GetByID(ctx context.Context, req *models.OrderPrimaryKey) (*
 		&order.OrderDate,
 		&order.RequiredDate,
 		&order.ShippedDate,
+		&order.ReturnStatus,
 
 		&order.StoreId,
 
@@ -182,48 +296,61 @@ func (r *orderRepo) GetByID(ctx context.Context, req *models.OrderPrimaryKey) (*
 		&order.StaffData.ManagerId,
 
 		&orderItemObject,
+
+		&order.DeliveryAddressId,
+		&deliveryAddressObject,
+		&order.Currency,
+		&order.Version,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	orderItemObject.AssignTo(&order.OrderItems)
+	if deliveryAddressObject.Status == pgtype.Present {
+		deliveryAddressObject.AssignTo(&order.DeliveryAddress)
+	}
+
+	amount, items, err := orderAmount(ctx, r.db, order.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	order.Amount = amount
+
+	taxes := map[int]money.Money{}
+	for _, item := range items {
+		taxes[item.ItemId] = item.Tax
+	}
+
+	for _, item := range order.OrderItems {
+		item.Tax = taxes[item.ItemId]
+	}
 
 	return &order, nil
 }
 
+// GetByIDTotal is the total of the order with the name of its promo code, if it has one.
 func (r *orderRepo) GetByIDTotal(ctx context.Context, req *models.OrderPrimaryKey) (*models.OrderTotalSumm, error) {
 
-	var (
-		query string
-		order models.OrderTotalSumm
-	)
+	var order = models.OrderTotalSumm{OrderId: req.OrderId}
 
-	query = `
+	err := r.db.QueryRow(ctx, `
 		SELECT
-			DISTINCT o.order_id ,
-			pc.code_name as code,
-			SUM(oi.list_price * oi.quantity) as total_summ 	
-
-				
-			
-			FROM orders AS o
-			JOIN order_items AS oi ON oi.order_id = o.order_id
-			JOIN promo_code AS pc ON pc.code_id = o.promo_code
-			
-			WHERE o.order_id = 1
-			GROUP BY o.order_id, code
-			
-	`
+			COALESCE(pc.code_name, '')
+		FROM orders AS o
+		LEFT JOIN promo_code AS pc ON pc.code_id = o.promo_code
+		WHERE o.order_id = $1
+	`, req.OrderId).Scan(&order.PromoCode)
+	if err != nil {
+		return nil, err
+	}
 
-	err := r.db.QueryRow(ctx, query, req.OrderId).Scan(
-		&order.OrderId,
-		&order.PromoCode,
-		&order.TotalSumm,
-	)
+	amount, _, err := orderAmount(ctx, r.db, req.OrderId)
 	if err != nil {
 		return nil, err
 	}
+	order.TotalSumm = amount.Total
 
 	return &order, nil
 }
@@ -253,12 +380,13 @@ func (r *orderRepo) GetList(ctx context.Context, req *models.GetListOrderRequest
 			COALESCE(c.street, ''),
 			COALESCE(c.city, ''),
 			COALESCE(c.state, ''),
-			COALESCE(c.zip_code, 0),
+			COALESCE(c.zip_code, ''),
 
 			o.order_status,
 			CAST(o.order_date::timestamp AS VARCHAR),
 			CAST(o.required_date::timestamp AS VARCHAR),
 			COALESCE(CAST(o.shipped_date::timestamp AS VARCHAR), ''),
+			o.return_status,
 			o.store_id,
 
 			s.store_id,
@@ -278,7 +406,9 @@ func (r *orderRepo) GetList(ctx context.Context, req *models.GetListOrderRequest
 			COALESCE(st.phone, ''),
 			st.active,
 			st.store_id,
-			COALESCE(st.manager_id, 0)
+			COALESCE(st.manager_id, 0),
+			o.currency,
+			o.version
 
 		FROM orders AS o
 		JOIN customers AS c ON c.customer_id = o.customer_id
@@ -330,6 +460,7 @@ func (r *orderRepo) GetList(ctx context.Context, req *models.GetListOrderRequest
 			&order.OrderDate,
 			&order.RequiredDate,
 			&order.ShippedDate,
+			&order.ReturnStatus,
 
 			&order.StoreId,
 
@@ -350,6 +481,8 @@ func (r *orderRepo) GetList(ctx context.Context, req *models.GetListOrderRequest
 			&order.StaffData.Active,
 			&order.StaffData.StoreId,
 			&order.StaffData.ManagerId,
+			&order.Currency,
+			&order.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -361,53 +494,16 @@ func (r *orderRepo) GetList(ctx context.Context, req *models.GetListOrderRequest
 	return resp, nil
 }
 
-func (r *orderRepo) OrderTotalSum(ctx context.Context, req *models.OrderTotalSum) (string, error) {
-	var totalSum float64
-	var promocode models.Code
-
-	query := `
-		SELECT 
-			COALESCE(SUM(list_price * quantity), 0)
-		FROM order_items
-		WHERE order_id = $1
-	`
+// OrderTotalSum is the total of the order, priced with the given promo code in place of the
+// one attached to it when a code name is given.
+func (r *orderRepo) OrderTotalSum(ctx context.Context, req *models.OrderTotalSum) (*money.Money, error) {
 
-	err := r.db.QueryRow(ctx, query, req.OrderId).Scan(&totalSum)
-	if totalSum == 0.0 {
-		return "", errors.New("There is no order with this id")
-	}
+	amount, _, err := promoOrderAmount(ctx, r.db, req.OrderId, req.PromocodeName)
 	if err != nil {
-		return "", err
-	}
-
-	query = `
-		SELECT
-			promocode_id,
-			promocode_name,
-			discount,
-			discount_type,
-			order_limit_price
-		FROM promocodes
-		WHERE promocode_name ILIKE $1
-	`
-
-	if req.PromocodeName != "" {
-		err = r.db.QueryRow(ctx, query, req.PromocodeName).Scan(
-			&promocode.Code_Id,
-			&promocode.CodeName,
-			&promocode.Discount,
-			&promocode.DiscountType,
-			&promocode.OrderLimitPrice,
-		)
-
-		if promocode.DiscountType == "fixed" && totalSum > promocode.OrderLimitPrice {
-			totalSum -= promocode.Discount
-		} else if promocode.DiscountType == "proced" {
-			totalSum -= totalSum * promocode.Discount / 100
-		}
+		return nil, err
 	}
 
-	return fmt.Sprintf("%.2f", totalSum), nil
+	return &amount.Total, nil
 }
 
 func (r *orderRepo) Update(ctx context.Context, req *models.UpdateOrder) (int64, error) {
@@ -416,6 +512,18 @@ func (r *orderRepo) Update(ctx context.Context, req *models.UpdateOrder) (int64,
 		params map[string]interface{}
 	)
 
+	if req.OrderStatus == models.OrderStatusCompleted {
+		err := r.checkCompleted(ctx, req.OrderId)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err := r.checkCustomerChange(ctx, req.OrderId, req.CustomerId)
+	if err != nil {
+		return 0, err
+	}
+
 	query = `
 		UPDATE
 		orders
@@ -427,8 +535,9 @@ func (r *orderRepo) Update(ctx context.Context, req *models.UpdateOrder) (int64,
 			required_date = :required_date,
 			shipped_date = :shipped_date,
 			store_id = :store_id,
-			staff_id = :staff_id
-		WHERE order_id = :order_id
+			staff_id = :staff_id,
+			version = version + 1
+		WHERE order_id = :order_id AND version = :version
 	`
 
 	params = map[string]interface{}{
@@ -440,6 +549,7 @@ func (r *orderRepo) Update(ctx context.Context, req *models.UpdateOrder) (int64,
 		"shipped_date":  helper.NewNullString(req.ShippedDate),
 		"store_id":      req.StoreId,
 		"staff_id":      req.StaffId,
+		"version":       req.Version,
 	}
 
 	query, args := helper.ReplaceQueryParams(query, params)
@@ -452,39 +562,50 @@ func (r *orderRepo) Update(ctx context.Context, req *models.UpdateOrder) (int64,
 	return result.RowsAffected(), nil
 }
 
+// orderPatchFields are the columns a PATCH may change.
+var orderPatchFields = map[string]patchField{
+	"customer_id":   {kind: patchInt, nullable: true},
+	"order_status":  {kind: patchInt},
+	"required_date": {kind: patchDate},
+	"shipped_date":  {kind: patchDate, nullable: true},
+	"staff_id":      {kind: patchInt},
+}
+
 func (r *orderRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
-	var (
-		query string
-		set   string
-	)
 
-	if len(req.Fields) <= 0 {
-		return 0, errors.New("no fields")
+	set, args, err := patchSet(orderPatchFields, req.Fields)
+	if err != nil {
+		return 0, err
 	}
 
-	i := 0
-	for key := range req.Fields {
-		if i == len(req.Fields)-1 {
-			set += fmt.Sprintf(" %s = :%s ", key, key)
-		} else {
-			set += fmt.Sprintf(" %s = :%s, ", key, key)
+	if status, ok := req.Fields["order_status"]; ok && fmt.Sprint(status) == fmt.Sprint(models.OrderStatusCompleted) {
+		err = r.checkCompleted(ctx, req.ID)
+		if err != nil {
+			return 0, err
 		}
-		i++
 	}
 
-	query = `
+	if value, ok := req.Fields["customer_id"]; ok {
+		var customerId int
+		if n, ok := value.(float64); ok {
+			customerId = int(n)
+		}
+
+		err = r.checkCustomerChange(ctx, req.ID, customerId)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	query := fmt.Sprintf(`
 		UPDATE
 		orders
 		SET
-		` + set + `
-		WHERE order_id = :order_id
-	`
-
-	req.Fields["order_id"] = req.ID
+		%s, version = version + 1
+		WHERE order_id = $%d AND version = $%d
+	`, set, len(args)+1, len(args)+2)
 
-	query, args := helper.ReplaceQueryParams(query, req.Fields)
-
-	fmt.Println(query)
+	args = append(args, req.ID, req.Version)
 
 	result, err := r.db.Exec(ctx, query, args...)
 	if err != nil {
@@ -494,14 +615,67 @@ func (r *orderRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (
 	return result.RowsAffected(), nil
 }
 
+// checkCompleted stops updates from marking an order completed,
+// completion has to go through Complete so the stock is deducted.
+func (r *orderRepo) checkCompleted(ctx context.Context, orderId int) error {
+	var status int16
+
+	err := r.db.QueryRow(ctx, `SELECT order_status FROM orders WHERE order_id = $1`, orderId).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if status != models.OrderStatusCompleted {
+		return errors.New("order can only be completed with POST /order/{id}/complete")
+	}
+
+	return nil
+}
+
+// checkCustomerChange stops the customer of an order with redeemed points from changing,
+// releasing the points would credit them to the new customer. Redeeming and releasing bump
+// the version of the order, so the check holds for the versioned update that follows it.
+func (r *orderRepo) checkCustomerChange(ctx context.Context, orderId, customerId int) error {
+	var current, points int
+
+	err := r.db.QueryRow(ctx,
+		`SELECT COALESCE(customer_id, 0), loyalty_points FROM orders WHERE order_id = $1`,
+		orderId,
+	).Scan(&current, &points)
+	if err == pgx.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if points > 0 && current != customerId {
+		return errors.New("customer of an order with redeemed points can not change, release the points first")
+	}
+
+	return nil
+}
+
 func (r *orderRepo) Delete(ctx context.Context, req *models.OrderPrimaryKey) (int64, error) {
+	var invoiced bool
+
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM invoices WHERE order_id = $1)`, req.OrderId).Scan(&invoiced)
+	if err != nil {
+		return 0, err
+	}
+
+	if invoiced {
+		return 0, errors.New("Invoiced order can not be deleted")
+	}
+
 	query := `
 		DELETE 
 		FROM orders
-		WHERE order_id = $1
+		WHERE order_id = $1 AND version = $2
 	`
 
-	result, err := r.db.Exec(ctx, query, req.OrderId)
+	result, err := r.db.Exec(ctx, query, req.OrderId, req.Version)
 	if err != nil {
 		return 0, err
 	}
@@ -509,92 +683,1020 @@ func (r *orderRepo) Delete(ctx context.Context, req *models.OrderPrimaryKey) (in
 }
 
 // ------------------------------------------------------------------------------------------------------------
+// AddOrderItem adds the item and reserves its quantity in the order's store,
+// the stock itself is only decremented when the order is completed.
 func (r *orderRepo) AddOrderItem(ctx context.Context, req *models.CreateOrderItem) error {
 
-	query := `
-		INSERT INTO order_items(
-			order_id, 
-			item_id, 
-			product_id,
-			quantity,
-			list_price,
-			discount
-		)
-		VALUES (
-			$1, 
-			(
-				SELECT COALESCE(MAX(item_id), 0) + 1 FROM order_items WHERE order_id = $1
-			)
-			, $2, $3, $4, $5)
-	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
 
-	_, err := r.db.Exec(ctx, query,
-		req.OrderId,
-		req.ProductId,
-		req.Quantity,
-		req.ListPrice,
-		req.Discount,
-	)
+	storeId, err := lockOpenOrder(ctx, tx, req.OrderId)
+	if err != nil {
+		return err
+	}
 
+	err = addOrderItem(ctx, tx, storeId, req)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// the items are part of the order, a stale ETag must not overwrite them
+	_, err = tx.Exec(ctx, `UPDATE orders SET version = version + 1 WHERE order_id = $1`, req.OrderId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (r *orderRepo) RemoveOrderItem(ctx context.Context, req *models.OrderItemPrimaryKey) error {
+// AddOrderItems adds all the lines to the order in one transaction. When a line fails
+// nothing is added and the problems of every failing line are returned.
+func (r *orderRepo) AddOrderItems(ctx context.Context, req *models.CreateOrderItems) ([]*models.OrderItemError, error) {
 
-	query := `
-		DELETE FROM order_items WHERE order_id = $1 AND item_id = $2
-	`
-	_, err := r.db.Exec(ctx, query, req.OrderId, req.ItemId)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
+	storeId, err := lockOpenOrder(ctx, tx, req.OrderId)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	lineErrors, err := addOrderItems(ctx, tx, storeId, req.OrderId, req.Items)
+	if err != nil || len(lineErrors) > 0 {
+		return lineErrors, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE orders SET version = version + 1 WHERE order_id = $1`, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, tx.Commit(ctx)
 }
 
-func (r *orderRepo) Check(ctx context.Context, req *models.CreateOrderItem) error {
-	var quantity, store_id int
+// lockOpenOrder locks the order against concurrent item changes and returns its store.
+func lockOpenOrder(ctx context.Context, tx pgx.Tx, orderId int) (int, error) {
+	var (
+		storeId int
+		status  int16
+	)
+
+	err := tx.QueryRow(ctx,
+		`SELECT store_id, order_status FROM orders WHERE order_id = $1 FOR UPDATE`,
+		orderId,
+	).Scan(&storeId, &status)
+	if err == pgx.ErrNoRows {
+		return 0, errors.New("Order is not found")
+	} else if err != nil {
+		return 0, err
+	}
+
+	if status == models.OrderStatusCompleted || status == models.OrderStatusRejected {
+		return 0, errors.New("Items of a closed order can not be changed")
+	}
+
+	return storeId, nil
+}
+
+// addOrderItems adds every line under its own savepoint, so a failing line is reported
+// and the following lines are still checked against the stock the earlier ones reserved.
+func addOrderItems(ctx context.Context, tx pgx.Tx, storeId, orderId int, items []*models.CreateOrderItem) ([]*models.OrderItemError, error) {
+	var lineErrors []*models.OrderItemError
+
+	if len(items) <= 0 {
+		return nil, errors.New("no items")
+	}
+
+	for line, item := range items {
+		item.OrderId = orderId
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		err = addOrderItem(ctx, savepoint, storeId, item)
+		if err != nil {
+			if rollbackErr := savepoint.Rollback(ctx); rollbackErr != nil {
+				return nil, rollbackErr
+			}
+
+			lineErrors = append(lineErrors, &models.OrderItemError{
+				Line:      line,
+				ProductId: item.ProductId,
+				VariantId: item.VariantId,
+				Error:     err.Error(),
+			})
+			continue
+		}
+
+		err = savepoint.Commit(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return lineErrors, nil
+}
+
+func addOrderItem(ctx context.Context, tx pgx.Tx, storeId int, req *models.CreateOrderItem) error {
+	var (
+		itemId     int
+		deleted    bool
+		customerId int
+		currency   string
+	)
 
 	if req.Quantity <= 0 {
 		return errors.New("Invalid quantity")
 	}
 
-	query := `
-		SELECT 
-    		COALESCE(quantity, 0),
-			COALESCE(store_id, 0)
-		FROM stocks
-		WHERE product_id = $1 AND store_id = (
-    		SELECT
-        		store_id
-    		FROM orders
-   	 		WHERE order_id =$2
-		)
-	`
-
-	err := r.db.QueryRow(ctx, query, req.ProductId, req.OrderId).Scan(&quantity, &store_id)
+	productId, variantId, err := productVariant(ctx, tx, req.ProductId, req.VariantId)
 	if err != nil {
+		return err
+	}
+	req.ProductId, req.VariantId = productId, variantId
+
+	err = tx.QueryRow(ctx,
+		`SELECT deleted_at IS NOT NULL FROM products WHERE product_id = $1`,
+		req.ProductId,
+	).Scan(&deleted)
+	if err == pgx.ErrNoRows {
 		return errors.New("Product is not found")
+	} else if err != nil {
+		return err
 	}
 
-	if quantity < req.Quantity {
-		return errors.New("There is not enough of this product")
+	if deleted {
+		return errors.New("Product is deleted")
 	}
 
-	_, err = r.db.Exec(ctx,
-		`UPDATE stocks SET quantity = quantity - $1 WHERE store_id = $2 AND product_id = $3`,
-		req.Quantity,
-		store_id,
-		req.ProductId,
-	)
+	err = tx.QueryRow(ctx,
+		`SELECT COALESCE(customer_id, 0), currency FROM orders WHERE order_id = $1`,
+		req.OrderId,
+	).Scan(&customerId, &currency)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	// the catalog price is the one of the price lists in effect for the store and customer
+	listed, err := productPrice(ctx, tx, &models.GetProductPriceRequest{
+		ProductId:  req.ProductId,
+		StoreId:    storeId,
+		CustomerId: customerId,
+	})
+	if err != nil {
+		return err
+	}
+
+	rate, err := exchangeRate(ctx, tx, listed.Price.Currency, currency)
+	if err != nil {
+		return err
+	}
+	catalog := listed.Price.Convert(rate, currency)
+
+	price, override, err := itemPrice(ctx, tx, req, storeId, catalog)
+	if err != nil {
+		return err
+	}
+
+	tax, err := itemTaxRate(ctx, tx, storeId, req.ProductId)
+	if err != nil {
+		return err
+	}
+
+	overrideStaffId := 0
+	if override {
+		overrideStaffId = req.StaffId
+	}
+
+	available, err := availableQuantity(ctx, tx, storeId, req.VariantId, true)
+	if err != nil {
+		return err
+	}
+
+	if available < req.Quantity {
+		return errors.New("There is not enough of this product")
+	}
+
+	query := `
+		INSERT INTO order_items(
+			order_id, 
+			item_id, 
+			product_id,
+			variant_id,
+			quantity,
+			list_price,
+			discount,
+			catalog_price,
+			override_staff_id,
+			override_reason,
+			tax_rate_id,
+			tax_name,
+			tax_rate
+		)
+		VALUES (
+			$1, 
+			(
+				SELECT COALESCE(MAX(item_id), 0) + 1 FROM order_items WHERE order_id = $1
+			)
+			, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING item_id
+	`
+
+	err = tx.QueryRow(ctx, query,
+		req.OrderId,
+		req.ProductId,
+		req.VariantId,
+		req.Quantity,
+		price.Amount,
+		req.Discount,
+		catalog.Amount,
+		helper.NewNullInt32(overrideStaffId),
+		helper.NewNullString(strings.TrimSpace(req.OverrideReason)),
+		helper.NewNullInt32(tax.TaxRateId),
+		helper.NewNullString(tax.TaxName),
+		tax.Rate,
+	).Scan(&itemId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO stock_reservations(order_id, item_id, store_id, product_id, variant_id, quantity, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, req.OrderId, itemId, storeId, req.ProductId, req.VariantId, req.Quantity, models.ReservationStatusActive)
+	if err != nil {
+		return err
+	}
+
+	if override {
+		return auditPriceOverride(ctx, tx, req, itemId, catalog, price)
+	}
+
+	return nil
+}
+
+// auditPriceOverride records who overrode the price of the line and why, in the transaction
+// that adds the line.
+func auditPriceOverride(ctx context.Context, tx pgx.Tx, req *models.CreateOrderItem, itemId int, catalog, price money.Money) error {
+	var audit models.CreateAuditLog
+	if req.Audit != nil {
+		audit = *req.Audit
+	}
+
+	before, err := json.Marshal(map[string]interface{}{
+		"item_id":    itemId,
+		"list_price": catalog,
+		"discount":   0,
+	})
+	if err != nil {
+		return err
+	}
+
+	after, err := json.Marshal(map[string]interface{}{
+		"item_id":         itemId,
+		"list_price":      price,
+		"discount":        req.Discount,
+		"override_reason": strings.TrimSpace(req.OverrideReason),
+	})
+	if err != nil {
+		return err
+	}
+
+	audit.StaffId = req.StaffId
+	audit.EntityType = "order"
+	audit.EntityId = req.OrderId
+	audit.Action = models.AuditActionPriceOverride
+	audit.Before = before
+	audit.After = after
+
+	_, err = auditEntry(ctx, tx, &audit)
+	return err
+}
+
+// itemPrice is the price the line is sold at. It is the catalog price unless an active staff
+// of the order's store with the discount permission overrides it, by another list_price or a
+// discount, within the configured max percent and with a reason. override tells whether that
+// happened. The staff comes from the X-Staff-Id header and is trusted as sent.
+func itemPrice(ctx context.Context, tx pgx.Tx, req *models.CreateOrderItem, storeId int, catalog money.Money) (price money.Money, override bool, err error) {
+
+	price = catalog
+	if req.ListPrice.Amount.IsPositive() {
+		price, err = req.ListPrice.In(catalog.Currency)
+		if err != nil {
+			return price, false, err
+		}
+		price = money.New(price.Amount, price.Currency)
+	}
+
+	if req.Discount < 0 || req.Discount >= 1 {
+		return price, false, errors.New("discount must be a fraction from 0 to 1")
+	}
+
+	if price.Amount.Equal(catalog.Amount) && req.Discount == 0 {
+		return catalog, false, nil
+	}
+
+	if len(strings.TrimSpace(req.OverrideReason)) <= 0 {
+		return price, false, errors.New("a price override needs a reason")
+	}
+
+	var (
+		canDiscount  bool
+		active       bool
+		staffStoreId int
+	)
+
+	err = tx.QueryRow(ctx,
+		`SELECT can_discount, active = 1, store_id FROM staffs WHERE staff_id = $1 AND deleted_at IS NULL`,
+		req.StaffId,
+	).Scan(&canDiscount, &active, &staffStoreId)
+	if err == pgx.ErrNoRows {
+		return price, false, errors.New("a price override needs the X-Staff-Id of a staff")
+	} else if err != nil {
+		return price, false, err
+	}
+
+	if !active {
+		return price, false, errors.New("staff is not active")
+	}
+
+	if staffStoreId != storeId {
+		return price, false, errors.New("staff does not work at the store of the order")
+	}
+
+	if !canDiscount {
+		return price, false, errors.New("staff is not allowed to override prices")
+	}
+
+	if catalog.Amount.IsPositive() {
+		sold := price.Amount.Mul(decimal.NewFromInt(1).Sub(decimal.NewFromFloat(req.Discount)))
+		percent := decimal.NewFromInt(1).Sub(sold.Div(catalog.Amount)).Mul(decimal.NewFromInt(100)).Round(2)
+		if percent.GreaterThan(decimal.NewFromFloat(req.MaxDiscountPercent)) {
+			return price, false, fmt.Errorf("price override of %s%% exceeds the max of %.2f%%", percent.StringFixed(2), req.MaxDiscountPercent)
+		}
+	}
+
+	return price, true, nil
+}
+
+// itemTaxRate is the rate of the product's category in the store. A category rate comes before
+// one for every category, then a rate of the store before one of its state. Without a matching
+// rate the line is not taxed.
+func itemTaxRate(ctx context.Context, tx pgx.Tx, storeId, productId int) (*models.TaxRate, error) {
+	var rate models.TaxRate
+
+	err := tx.QueryRow(ctx, `
+		SELECT
+			t.tax_rate_id,
+			t.tax_name,
+			t.rate
+		FROM tax_rates AS t
+		JOIN stores AS s ON s.store_id = $1
+		JOIN products AS p ON p.product_id = $2
+		WHERE (t.store_id IS NULL OR t.store_id = s.store_id)
+			AND (t.state IS NULL OR t.state = s.state)
+			AND (t.category_id IS NULL OR t.category_id = p.category_id)
+		ORDER BY
+			t.category_id IS NOT NULL DESC,
+			t.store_id IS NOT NULL DESC,
+			t.state IS NOT NULL DESC,
+			t.tax_rate_id DESC
+		LIMIT 1
+	`, storeId, productId).Scan(&rate.TaxRateId, &rate.TaxName, &rate.Rate)
+	if err == pgx.ErrNoRows {
+		return &rate, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+// RemoveOrderItem deletes the line, with its reservation, from an open order of the given
+// version. Lines of a completed or rejected order are part of its invoice and are kept.
+func (r *orderRepo) RemoveOrderItem(ctx context.Context, req *models.OrderItemPrimaryKey) (int64, error) {
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = lockOpenOrder(ctx, tx, req.OrderId)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(ctx,
+		`UPDATE orders SET version = version + 1 WHERE order_id = $1 AND version = $2`,
+		req.OrderId,
+		req.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.RowsAffected() <= 0 {
+		return 0, nil
+	}
+
+	removed, err := tx.Exec(ctx, `DELETE FROM order_items WHERE order_id = $1 AND item_id = $2`, req.OrderId, req.ItemId)
+	if err != nil {
+		return 0, err
+	}
+
+	if removed.RowsAffected() <= 0 {
+		return 0, errors.New("Order item is not found")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return removed.RowsAffected(), nil
+}
+
+// Check validates that the order's store has enough unreserved stock for the item.
+func (r *orderRepo) Check(ctx context.Context, req *models.CreateOrderItem) error {
+	var storeId int
+
+	if req.Quantity <= 0 {
+		return errors.New("Invalid quantity")
+	}
+
+	err := r.db.QueryRow(ctx, `SELECT store_id FROM orders WHERE order_id = $1`, req.OrderId).Scan(&storeId)
+	if err != nil {
+		return errors.New("Order is not found")
+	}
+
+	_, variantId, err := productVariant(ctx, r.db, req.ProductId, req.VariantId)
+	if err != nil {
+		return err
+	}
+
+	available, err := availableQuantity(ctx, r.db, storeId, variantId, false)
+	if err != nil {
+		return err
+	}
+
+	if available < req.Quantity {
+		return errors.New("There is not enough of this product")
+	}
+
+	return nil
+}
+
+// Complete turns the order's reservations into stock deductions, marks the order completed
+// and credits the customer with the loyalty points the order earns.
+// Items whose reservation has expired are checked against the current available stock again.
+func (r *orderRepo) Complete(ctx context.Context, req *models.CompleteOrder) error {
+	var (
+		storeId    int
+		customerId int
+		status     int16
+		items      []*models.OrderItem
+	)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`SELECT store_id, COALESCE(customer_id, 0), order_status FROM orders WHERE order_id = $1 FOR UPDATE`,
+		req.OrderId,
+	).Scan(&storeId, &customerId, &status)
+	if err == pgx.ErrNoRows {
+		return errors.New("Order is not found")
+	} else if err != nil {
+		return err
+	}
+
+	switch status {
+	case models.OrderStatusCompleted:
+		return errors.New("Order is already completed")
+	case models.OrderStatusRejected:
+		return errors.New("Rejected order can not be completed")
+	}
+
+	balance, err := orderBalance(ctx, tx, req.OrderId)
+	if err != nil {
+		return err
+	}
+
+	if !balance.BalanceDue.Amount.IsZero() {
+		return fmt.Errorf("Order has a balance due of %s", balance.BalanceDue)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT
+			oi.item_id,
+			oi.product_id,
+			oi.variant_id,
+			oi.quantity,
+			COALESCE(sr.status, '')
+		FROM order_items AS oi
+		LEFT JOIN stock_reservations AS sr ON sr.order_id = oi.order_id AND sr.item_id = oi.item_id AND sr.status = $2
+		WHERE oi.order_id = $1
+		ORDER BY oi.variant_id
+	`, req.OrderId, models.ReservationStatusActive)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var item models.OrderItem
+
+		err = rows.Scan(&item.ItemId, &item.ProductId, &item.VariantId, &item.Quantity, &item.Reservation)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		items = append(items, &item)
+	}
+	rows.Close()
+
+	if len(items) <= 0 {
+		return errors.New("Order has no items")
+	}
+
+	// consumed before stock is checked, so the order's own reservations do not count
+	// against the lines whose reservation has expired
+	_, err = tx.Exec(ctx,
+		`UPDATE stock_reservations SET status = $2, updated_at = now() WHERE order_id = $1 AND status = $3`,
+		req.OrderId,
+		models.ReservationStatusConsumed,
+		models.ReservationStatusActive,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.Reservation != models.ReservationStatusActive {
+			available, err := availableQuantity(ctx, tx, storeId, item.VariantId, true)
+			if err != nil {
+				return err
+			}
+
+			if available < item.Quantity {
+				return fmt.Errorf("There is not enough of product %d variant %d", item.ProductId, item.VariantId)
+			}
+		}
+
+		result, err := tx.Exec(ctx,
+			`UPDATE stocks SET quantity = quantity - $1, version = version + 1 WHERE store_id = $2 AND variant_id = $3 AND quantity >= $1`,
+			item.Quantity,
+			storeId,
+			item.VariantId,
+		)
+		if err != nil {
+			return err
+		}
+
+		if result.RowsAffected() <= 0 {
+			return fmt.Errorf("There is not enough of product %d variant %d", item.ProductId, item.VariantId)
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE orders SET order_status = $2, version = version + 1 WHERE order_id = $1`,
+		req.OrderId,
+		models.OrderStatusCompleted,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = issueInvoice(ctx, tx, storeId, req.OrderId)
+	if err != nil {
+		return err
+	}
+
+	if customerId > 0 && req.LoyaltyPointsPerUnit > 0 {
+		err = earnLoyaltyPoints(ctx, tx, customerId, req.OrderId, req.LoyaltyPointsPerUnit)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// issueInvoice gives the order the next invoice number of its store. The sequence row stays
+// locked until the transaction ends, a rollback gives the number back so none is skipped.
+func issueInvoice(ctx context.Context, tx pgx.Tx, storeId, orderId int) error {
+	var number int
+
+	err := tx.QueryRow(ctx, `
+		INSERT INTO invoice_sequences (store_id, last_number)
+		VALUES ($1, 1)
+		ON CONFLICT (store_id) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number
+	`, storeId).Scan(&number)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO invoices (store_id, invoice_number, order_id) VALUES ($1, $2, $3)`,
+		storeId,
+		number,
+		orderId,
+	)
+
+	return err
+}
+
+// ExpireReservations frees stock held by pending orders for longer than ttl
+// and releases whatever is still reserved by rejected orders.
+func (r *orderRepo) ExpireReservations(ctx context.Context, ttl time.Duration) (int64, error) {
+
+	query := `
+		UPDATE stock_reservations AS sr
+		SET
+			status = (
+				CASE
+					WHEN o.order_status = $3 THEN $4
+					ELSE $5
+				END
+			),
+			updated_at = now()
+		FROM orders AS o
+		WHERE o.order_id = sr.order_id
+			AND sr.status = $1
+			AND (
+				(o.order_status = $2 AND sr.created_at < now() - make_interval(secs => $6))
+				OR o.order_status = $3
+			)
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		models.ReservationStatusActive,
+		models.OrderStatusPending,
+		models.OrderStatusRejected,
+		models.ReservationStatusReleased,
+		models.ReservationStatusExpired,
+		ttl.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// availableQuantity is the on hand quantity of the variant minus what active reservations hold,
+// lock takes a row lock on the stock so concurrent reservations are serialized.
+func availableQuantity(ctx context.Context, db querier, storeId, variantId int, lock bool) (int, error) {
+	var (
+		quantity int
+		reserved int
+		query    = `SELECT COALESCE(quantity, 0) FROM stocks WHERE store_id = $1 AND variant_id = $2`
+	)
+
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	err := db.QueryRow(ctx, query, storeId, variantId).Scan(&quantity)
+	if err == pgx.ErrNoRows {
+		return 0, errors.New("Product is not found")
+	} else if err != nil {
+		return 0, err
+	}
+
+	reserved, err = reservedQuantity(ctx, db, storeId, variantId)
+	if err != nil {
+		return 0, err
+	}
+
+	return quantity - reserved, nil
+}
+
+// reservedQuantity is what active reservations of open orders hold of the variant in the store.
+func reservedQuantity(ctx context.Context, db querier, storeId, variantId int) (int, error) {
+	var reserved int
+
+	err := db.QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(quantity), 0)
+		FROM stock_reservations
+		WHERE store_id = $1 AND variant_id = $2 AND status = $3
+	`, storeId, variantId, models.ReservationStatusActive).Scan(&reserved)
+
+	return reserved, err
+}
+
+// orderAmount prices the order from its lines, line discounts, the promo code attached to it
+// and the loyalty points redeemed on it, in the currency of the order.
+func orderAmount(ctx context.Context, db querier, orderId int) (*models.OrderAmount, []*models.OrderItem, error) {
+	return promoOrderAmount(ctx, db, orderId, "")
+}
+
+// promoOrderAmount prices the order as orderAmount does, with the promo code of the given
+// name in place of the attached one when codeName is not empty. An unknown code takes nothing off.
+func promoOrderAmount(ctx context.Context, db querier, orderId int, codeName string) (*models.OrderAmount, []*models.OrderItem, error) {
+	var (
+		amount   = models.OrderAmount{OrderId: orderId}
+		items    []*models.OrderItem
+		promo    models.Code
+		loyalty  decimal.Decimal
+		currency string
+	)
+
+	err := db.QueryRow(ctx, `
+		SELECT
+			COALESCE(pc.discount, 0),
+			COALESCE(pc.discount_type, ''),
+			COALESCE(pc.order_limit_price, 0),
+			COALESCE(pc.currency, o.currency),
+			o.loyalty_discount,
+			s.tax_inclusive,
+			COALESCE(c.tax_exempt, FALSE),
+			o.currency
+		FROM orders AS o
+		JOIN stores AS s ON s.store_id = o.store_id
+		LEFT JOIN customers AS c ON c.customer_id = o.customer_id
+		LEFT JOIN promo_code AS pc ON (
+			CASE WHEN $2 = '' THEN pc.code_id = o.promo_code ELSE pc.code_name ILIKE $2 END
+		)
+		WHERE o.order_id = $1
+		LIMIT 1
+	`, orderId, codeName).Scan(
+		&promo.Discount,
+		&promo.DiscountType,
+		&promo.OrderLimitPrice,
+		&promo.Currency,
+		&loyalty,
+		&amount.TaxInclusive,
+		&amount.TaxExempt,
+		&currency,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil, errors.New("Order is not found")
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT
+			order_id,
+			item_id,
+			product_id,
+			variant_id,
+			quantity,
+			list_price,
+			discount,
+			COALESCE(tax_rate_id, 0),
+			COALESCE(tax_name, ''),
+			tax_rate
+		FROM order_items
+		WHERE order_id = $1
+		ORDER BY item_id
+	`, orderId)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item = models.OrderItem{ListPrice: money.Zero(currency)}
+
+		err = rows.Scan(
+			&item.OrderId,
+			&item.ItemId,
+			&item.ProductId,
+			&item.VariantId,
+			&item.Quantity,
+			&item.ListPrice.Amount,
+			&item.Discount,
+			&item.TaxRateId,
+			&item.TaxName,
+			&item.TaxRate,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		items = append(items, &item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	rows.Close()
+
+	orderSubtotal(&amount, items, currency)
+
+	net := money.New(amount.Subtotal.Amount.Sub(amount.LineDiscount.Amount), currency)
+
+	promoAmount, err := promoDiscount(ctx, db, &promo, net)
+	if err != nil {
+		return nil, nil, err
+	}
+	amount.PromoDiscount = money.New(promoAmount, currency)
+
+	orderTotal(&amount, items, loyalty)
+
+	return &amount, items, nil
+}
+
+// promoDiscount is what the code takes off the net of an order. A fixed amount is converted
+// from the currency of the code and never takes the order below zero.
+func promoDiscount(ctx context.Context, db querier, code *models.Code, net money.Money) (decimal.Decimal, error) {
+	switch code.DiscountType {
+	case models.CodeDiscountTypeFixed:
+		rate, err := exchangeRate(ctx, db, code.Currency, net.Currency)
+		if err != nil {
+			return decimal.Zero, err
+		}
+
+		if net.Amount.GreaterThan(code.OrderLimitPrice.Mul(rate)) {
+			return decimal.Min(code.Discount.Mul(rate), net.Amount).Round(money.Cents), nil
+		}
+	case models.CodeDiscountTypePercent:
+		return net.Amount.Mul(code.Discount).Div(decimal.NewFromInt(100)).Round(money.Cents), nil
+	}
+
+	return decimal.Zero, nil
+}
+
+// Receipt gathers what is printed for the order: the store, the lines as sold, the amounts,
+// the payments and the invoice number once the order is completed.
+func (r *orderRepo) Receipt(ctx context.Context, req *models.OrderPrimaryKey) (*models.Receipt, error) {
+	var (
+		receipt       = models.Receipt{OrderId: req.OrderId, Store: &models.Store{}}
+		invoiceNumber int
+		lines         = map[int]*models.ReceiptLine{}
+	)
+
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			CAST(o.order_date AS VARCHAR),
+			o.order_status,
+			COALESCE(i.invoice_number, 0),
+			COALESCE(TO_CHAR(i.issued_at, 'YYYY-MM-DD HH24:MI'), ''),
+			s.store_id,
+			s.store_name,
+			COALESCE(s.phone, ''),
+			COALESCE(s.email, ''),
+			COALESCE(s.street, ''),
+			COALESCE(s.city, ''),
+			COALESCE(s.state, ''),
+			COALESCE(s.zip_code, ''),
+			s.tax_inclusive,
+			s.currency,
+			st.first_name || ' ' || st.last_name,
+			COALESCE(c.first_name || ' ' || c.last_name, ''),
+			COALESCE(pc.code_name, '')
+		FROM orders AS o
+		JOIN stores AS s ON s.store_id = o.store_id
+		JOIN staffs AS st ON st.staff_id = o.staff_id
+		LEFT JOIN customers AS c ON c.customer_id = o.customer_id
+		LEFT JOIN promo_code AS pc ON pc.code_id = o.promo_code
+		LEFT JOIN invoices AS i ON i.order_id = o.order_id
+		WHERE o.order_id = $1
+	`, req.OrderId).Scan(
+		&receipt.OrderDate,
+		&receipt.OrderStatus,
+		&invoiceNumber,
+		&receipt.IssuedAt,
+		&receipt.Store.StoreId,
+		&receipt.Store.StoreName,
+		&receipt.Store.Phone,
+		&receipt.Store.Email,
+		&receipt.Store.Street,
+		&receipt.Store.City,
+		&receipt.Store.State,
+		&receipt.Store.ZipCode,
+		&receipt.Store.TaxInclusive,
+		&receipt.Store.Currency,
+		&receipt.StaffName,
+		&receipt.CustomerName,
+		&receipt.PromoCode,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, errors.New("Order is not found")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if invoiceNumber > 0 {
+		receipt.InvoiceNumber = fmt.Sprintf("%d-%06d", receipt.Store.StoreId, invoiceNumber)
+	}
+
+	amount, items, err := orderAmount(ctx, r.db, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	receipt.Amount = amount
+
+	rows, err := r.db.Query(ctx, `
+		SELECT
+			oi.item_id,
+			p.product_name,
+			v.sku,
+			CONCAT_WS(' / ', v.size, v.color, v.frame_material)
+		FROM order_items AS oi
+		JOIN products AS p ON p.product_id = oi.product_id
+		JOIN product_variants AS v ON v.variant_id = oi.variant_id
+		WHERE oi.order_id = $1
+	`, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var line models.ReceiptLine
+
+		err = rows.Scan(&line.ItemId, &line.ProductName, &line.Sku, &line.Variant)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		lines[line.ItemId] = &line
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		line := item.ListPrice.Amount.
+			Mul(decimal.NewFromInt(int64(item.Quantity))).
+			Mul(decimal.NewFromInt(1).Sub(decimal.NewFromFloat(item.Discount)))
+
+		receiptLine, ok := lines[item.ItemId]
+		if !ok {
+			receiptLine = &models.ReceiptLine{ItemId: item.ItemId}
+		}
+
+		receiptLine.Quantity = item.Quantity
+		receiptLine.UnitPrice = item.ListPrice
+		receiptLine.Discount = item.Discount
+		receiptLine.LineTotal = money.New(line, item.ListPrice.Currency)
+		receiptLine.TaxName = item.TaxName
+		receiptLine.TaxRate = item.TaxRate
+
+		receipt.Lines = append(receipt.Lines, receiptLine)
+	}
+
+	rows, err = r.db.Query(ctx, `
+		SELECT
+			payment_id,
+			tender,
+			amount,
+			currency,
+			COALESCE(refund_of, 0),
+			CAST(created_at AS VARCHAR)
+		FROM payments
+		WHERE order_id = $1
+		ORDER BY payment_id
+	`, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var payment = models.Payment{OrderId: req.OrderId}
+
+		err = rows.Scan(
+			&payment.PaymentId,
+			&payment.Tender,
+			&payment.Amount.Amount,
+			&payment.Amount.Currency,
+			&payment.RefundOf,
+			&payment.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		receipt.Payments = append(receipt.Payments, &payment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	receipt.Balance, err = orderBalance(ctx, r.db, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &receipt, nil
 }
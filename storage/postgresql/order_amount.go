@@ -0,0 +1,126 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/money"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// orderSubtotal sums the lines of the order at their list prices and their line discounts.
+func orderSubtotal(amount *models.OrderAmount, items []*models.OrderItem, currency string) {
+	var subtotal, discount decimal.Decimal
+
+	for _, item := range items {
+		line := item.ListPrice.Amount.Mul(decimal.NewFromInt(int64(item.Quantity)))
+		subtotal = subtotal.Add(line)
+		discount = discount.Add(line.Mul(decimal.NewFromFloat(item.Discount)))
+	}
+
+	amount.Subtotal = money.New(subtotal, currency)
+	amount.LineDiscount = money.New(discount, currency)
+}
+
+// orderTotal takes the redeemed loyalty discount and the tax into the total of an order
+// whose subtotal, line discount and promo discount are set.
+func orderTotal(amount *models.OrderAmount, items []*models.OrderItem, loyalty decimal.Decimal) {
+	var (
+		currency = amount.Subtotal.Currency
+		net      = amount.Subtotal.Amount.Sub(amount.LineDiscount.Amount)
+	)
+
+	// redeemed loyalty points come after the promo code and never take the order below zero
+	rest := decimal.Max(net.Sub(amount.PromoDiscount.Amount), decimal.Zero)
+	amount.LoyaltyDiscount = money.New(decimal.Min(loyalty, rest), currency)
+
+	orderTax(amount, items)
+
+	total := net.Sub(amount.PromoDiscount.Amount).Sub(amount.LoyaltyDiscount.Amount)
+	if !amount.TaxInclusive {
+		total = total.Add(amount.Tax.Amount)
+	}
+	amount.Total = money.New(total, currency)
+}
+
+// orderTax taxes every line on its net, after its share of the order discounts, and sums
+// the lines by rate. An inclusive price already holds the tax, it is taken out of the price.
+func orderTax(amount *models.OrderAmount, items []*models.OrderItem) {
+	var (
+		taxes    = map[string]*models.OrderTax{}
+		currency = amount.Subtotal.Currency
+	)
+
+	amount.Tax = money.Zero(currency)
+
+	for _, item := range items {
+		item.Tax = money.Zero(currency)
+		if amount.TaxExempt || item.TaxRate <= 0 {
+			continue
+		}
+
+		var (
+			rate    = decimal.NewFromFloat(item.TaxRate)
+			net     = lineNet(amount, item)
+			taxable = net
+		)
+
+		if amount.TaxInclusive {
+			taxable = net.Div(decimal.NewFromInt(1).Add(rate))
+		}
+		item.Tax = money.New(taxable.Mul(rate), currency)
+
+		key := fmt.Sprintf("%d:%s:%g", item.TaxRateId, item.TaxName, item.TaxRate)
+		tax, ok := taxes[key]
+		if !ok {
+			tax = &models.OrderTax{
+				TaxRateId: item.TaxRateId,
+				TaxName:   item.TaxName,
+				Rate:      item.TaxRate,
+				Taxable:   money.Zero(currency),
+				Tax:       money.Zero(currency),
+			}
+			taxes[key] = tax
+			amount.Taxes = append(amount.Taxes, tax)
+		}
+
+		tax.Taxable = money.New(tax.Taxable.Amount.Add(taxable), currency)
+		tax.Tax = money.New(tax.Tax.Amount.Add(item.Tax.Amount), currency)
+		amount.Tax = money.New(amount.Tax.Amount.Add(item.Tax.Amount), currency)
+	}
+}
+
+// lineRefund is what returning quantity units of the item gives back, the line discount
+// and the item's share of the promo and loyalty discounts are not refunded, tax added on
+// top of the price is.
+func lineRefund(amount *models.OrderAmount, item *models.OrderItem, quantity int) money.Money {
+	if item.Quantity <= 0 {
+		return money.Zero(amount.Total.Currency)
+	}
+
+	share := decimal.NewFromInt(int64(quantity)).Div(decimal.NewFromInt(int64(item.Quantity)))
+
+	refund := lineNet(amount, item).Mul(share)
+	if !amount.TaxInclusive {
+		refund = refund.Add(item.Tax.Amount.Mul(share))
+	}
+
+	return money.New(refund, amount.Total.Currency)
+}
+
+// lineNet is what the whole line sells for after the line discount and its share of the
+// promo and loyalty discounts.
+func lineNet(amount *models.OrderAmount, item *models.OrderItem) decimal.Decimal {
+
+	net := item.ListPrice.Amount.
+		Mul(decimal.NewFromInt(int64(item.Quantity))).
+		Mul(decimal.NewFromInt(1).Sub(decimal.NewFromFloat(item.Discount)))
+
+	orderNet := amount.Subtotal.Amount.Sub(amount.LineDiscount.Amount)
+	if orderNet.IsPositive() {
+		discounts := amount.PromoDiscount.Amount.Add(amount.LoyaltyDiscount.Amount)
+		net = net.Sub(discounts.Mul(net).Div(orderNet))
+	}
+
+	return net
+}
@@ -0,0 +1,252 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/money"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+type testLine struct {
+	price    string
+	quantity int
+	discount float64
+	rate     float64
+}
+
+// testOrder prices the lines as orderAmount does, with the promo discount given.
+func testOrder(lines []testLine, promo, loyalty string, inclusive bool) (*models.OrderAmount, []*models.OrderItem) {
+	var (
+		amount = models.OrderAmount{TaxInclusive: inclusive}
+		items  []*models.OrderItem
+	)
+
+	for i, line := range lines {
+		items = append(items, &models.OrderItem{
+			ItemId:    i + 1,
+			Quantity:  line.quantity,
+			ListPrice: money.New(decimal.RequireFromString(line.price), money.DefaultCurrency),
+			Discount:  line.discount,
+			TaxRateId: int(line.rate * 1000),
+			TaxRate:   line.rate,
+		})
+	}
+
+	orderSubtotal(&amount, items, money.DefaultCurrency)
+	amount.PromoDiscount = money.New(decimal.RequireFromString(promo), money.DefaultCurrency)
+	orderTotal(&amount, items, decimal.RequireFromString(loyalty))
+
+	return &amount, items
+}
+
+func TestOrderTotal(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []testLine
+		promo     string
+		loyalty   string
+		inclusive bool
+		lineTaxes []string
+		tax       string
+		total     string
+	}{
+		{
+			name:      "tax added on top",
+			lines:     []testLine{{price: "100", quantity: 2, rate: 0.1}},
+			promo:     "0",
+			loyalty:   "0",
+			lineTaxes: []string{"20"},
+			tax:       "20",
+			total:     "220",
+		},
+		{
+			name:      "tax held in the price",
+			lines:     []testLine{{price: "110", quantity: 1, rate: 0.1}},
+			promo:     "0",
+			loyalty:   "0",
+			inclusive: true,
+			lineTaxes: []string{"10"},
+			tax:       "10",
+			total:     "110",
+		},
+		{
+			// 0.005 on each line rounds up to a cent, the order would owe 0.015
+			name:      "rounding per line",
+			lines:     []testLine{{price: "0.10", quantity: 1, rate: 0.05}, {price: "0.10", quantity: 1, rate: 0.05}, {price: "0.10", quantity: 1, rate: 0.05}},
+			promo:     "0",
+			loyalty:   "0",
+			lineTaxes: []string{"0.01", "0.01", "0.01"},
+			tax:       "0.03",
+			total:     "0.33",
+		},
+		{
+			// 100 less 10% is 90, the promo takes 10 more, tax is on the 80 left
+			name:      "line discount and promo before tax",
+			lines:     []testLine{{price: "100", quantity: 1, discount: 0.1, rate: 0.1}},
+			promo:     "10",
+			loyalty:   "0",
+			lineTaxes: []string{"8"},
+			tax:       "8",
+			total:     "88",
+		},
+		{
+			// the promo of 10 is shared 60:40, so the lines are taxed on 54 and 36
+			name:      "promo shared by the lines",
+			lines:     []testLine{{price: "20", quantity: 3, rate: 0.1}, {price: "40", quantity: 1, rate: 0.2}},
+			promo:     "10",
+			loyalty:   "0",
+			lineTaxes: []string{"5.4", "7.2"},
+			tax:       "12.6",
+			total:     "102.6",
+		},
+		{
+			name:      "loyalty never takes the order below zero",
+			lines:     []testLine{{price: "50", quantity: 1, rate: 0.1}},
+			promo:     "10",
+			loyalty:   "100",
+			lineTaxes: []string{"0"},
+			tax:       "0",
+			total:     "0",
+		},
+		{
+			name:      "untaxed line",
+			lines:     []testLine{{price: "9.99", quantity: 3}},
+			promo:     "0",
+			loyalty:   "0",
+			lineTaxes: []string{"0"},
+			tax:       "0",
+			total:     "29.97",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, items := testOrder(tt.lines, tt.promo, tt.loyalty, tt.inclusive)
+
+			for i, item := range items {
+				if want := decimal.RequireFromString(tt.lineTaxes[i]); !item.Tax.Amount.Equal(want) {
+					t.Errorf("line %d tax = %s, want %s", i, item.Tax.Amount, want)
+				}
+			}
+
+			if want := decimal.RequireFromString(tt.tax); !amount.Tax.Amount.Equal(want) {
+				t.Errorf("tax = %s, want %s", amount.Tax.Amount, want)
+			}
+
+			if want := decimal.RequireFromString(tt.total); !amount.Total.Amount.Equal(want) {
+				t.Errorf("total = %s, want %s", amount.Total.Amount, want)
+			}
+		})
+	}
+}
+
+func TestOrderTaxByRate(t *testing.T) {
+	amount, _ := testOrder([]testLine{
+		{price: "10", quantity: 1, rate: 0.1},
+		{price: "30", quantity: 1, rate: 0.2},
+		{price: "20", quantity: 1, rate: 0.1},
+	}, "0", "0", false)
+
+	want := []struct {
+		rate    float64
+		taxable string
+		tax     string
+	}{
+		{rate: 0.1, taxable: "30", tax: "3"},
+		{rate: 0.2, taxable: "30", tax: "6"},
+	}
+
+	if len(amount.Taxes) != len(want) {
+		t.Fatalf("got %d taxes, want %d", len(amount.Taxes), len(want))
+	}
+
+	for i, tax := range amount.Taxes {
+		if tax.Rate != want[i].rate {
+			t.Errorf("tax %d rate = %g, want %g", i, tax.Rate, want[i].rate)
+		}
+
+		if w := decimal.RequireFromString(want[i].taxable); !tax.Taxable.Amount.Equal(w) {
+			t.Errorf("tax %d taxable = %s, want %s", i, tax.Taxable.Amount, w)
+		}
+
+		if w := decimal.RequireFromString(want[i].tax); !tax.Tax.Amount.Equal(w) {
+			t.Errorf("tax %d tax = %s, want %s", i, tax.Tax.Amount, w)
+		}
+	}
+}
+
+func TestLineRefund(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []testLine
+		promo     string
+		inclusive bool
+		quantity  int
+		want      string
+	}{
+		{
+			name:     "whole line with tax on top",
+			lines:    []testLine{{price: "25", quantity: 4, rate: 0.1}},
+			promo:    "0",
+			quantity: 4,
+			want:     "110",
+		},
+		{
+			name:     "one of four",
+			lines:    []testLine{{price: "25", quantity: 4, rate: 0.1}},
+			promo:    "0",
+			quantity: 1,
+			want:     "27.5",
+		},
+		{
+			// the line nets 54 after its share of the promo and is taxed 5.40, a third of both
+			name:     "one of three after the promo",
+			lines:    []testLine{{price: "20", quantity: 3, rate: 0.1}, {price: "40", quantity: 1, rate: 0.1}},
+			promo:    "10",
+			quantity: 1,
+			want:     "19.8",
+		},
+		{
+			name:     "line discount is not refunded",
+			lines:    []testLine{{price: "50", quantity: 2, discount: 0.2}},
+			promo:    "0",
+			quantity: 1,
+			want:     "40",
+		},
+		{
+			name:      "tax held in the price",
+			lines:     []testLine{{price: "110", quantity: 2, rate: 0.1}},
+			promo:     "0",
+			inclusive: true,
+			quantity:  1,
+			want:      "110",
+		},
+		{
+			// 2.97 is taxed 0.245025, rounded to 0.25 on the line, a third of each is 1.0733
+			name:     "rounded once on the refund",
+			lines:    []testLine{{price: "0.99", quantity: 3, rate: 0.0825}},
+			promo:    "0",
+			quantity: 1,
+			want:     "1.07",
+		},
+		{
+			name:     "line without quantity",
+			lines:    []testLine{{price: "10", quantity: 0, rate: 0.1}},
+			promo:    "0",
+			quantity: 1,
+			want:     "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, items := testOrder(tt.lines, tt.promo, "0", tt.inclusive)
+
+			got := lineRefund(amount, items[0], tt.quantity)
+			if want := decimal.RequireFromString(tt.want); !got.Amount.Equal(want) {
+				t.Errorf("lineRefund(%d) = %s, want %s", tt.quantity, got.Amount, want)
+			}
+		})
+	}
+}
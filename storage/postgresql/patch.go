@@ -0,0 +1,117 @@
+package postgresql
+
+import (
+	"app/pkg/money"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type patchType int
+
+const (
+	patchString patchType = iota
+	patchInt
+	patchFloat
+	patchBool
+	patchDate
+	patchDecimal  // an amount as a JSON number or string
+	patchCurrency // an ISO 4217 code
+)
+
+// patchField is a column a JSON Merge Patch may change, nullable ones are cleared by an explicit null.
+type patchField struct {
+	kind     patchType
+	nullable bool
+}
+
+// patchSet checks an RFC 7396 merge patch of a flat entity against the whitelist of its
+// mutable columns and builds the SET list with positional arguments. Unknown fields, nulls
+// on required fields and values of the wrong type are rejected.
+func patchSet(fields map[string]patchField, patch map[string]interface{}) (string, []interface{}, error) {
+	var (
+		keys = make([]string, 0, len(patch))
+		set  = make([]string, 0, len(patch))
+		args = make([]interface{}, 0, len(patch))
+	)
+
+	if len(patch) <= 0 {
+		return "", nil, errors.New("no fields")
+	}
+
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			return "", nil, fmt.Errorf("field %s can not be patched", key)
+		}
+
+		value, err := patchValue(key, field, patch[key])
+		if err != nil {
+			return "", nil, err
+		}
+
+		args = append(args, value)
+		set = append(set, fmt.Sprintf("%s = $%d", key, len(args)))
+	}
+
+	return strings.Join(set, ", "), args, nil
+}
+
+func patchValue(key string, field patchField, value interface{}) (interface{}, error) {
+	if value == nil {
+		if !field.nullable {
+			return nil, fmt.Errorf("field %s can not be null", key)
+		}
+		return nil, nil
+	}
+
+	switch field.kind {
+	case patchString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case patchInt:
+		if n, ok := value.(float64); ok && n == math.Trunc(n) {
+			return int(n), nil
+		}
+	case patchFloat:
+		if n, ok := value.(float64); ok {
+			return n, nil
+		}
+	case patchBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case patchDate:
+		if s, ok := value.(string); ok {
+			if _, err := time.Parse("2006-01-02", s); err == nil {
+				return s, nil
+			}
+		}
+	case patchDecimal:
+		switch v := value.(type) {
+		case float64:
+			return decimal.NewFromFloat(v), nil
+		case string:
+			if d, err := decimal.NewFromString(v); err == nil {
+				return d, nil
+			}
+		}
+	case patchCurrency:
+		if s, ok := value.(string); ok && money.ValidCurrency(s) {
+			return s, nil
+		}
+	}
+
+	return nil, fmt.Errorf("field %s has an invalid value", key)
+}
@@ -0,0 +1,436 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/shopspring/decimal"
+)
+
+type paymentRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPaymentRepo(db *pgxpool.Pool) *paymentRepo {
+	return &paymentRepo{
+		db: db,
+	}
+}
+
+// Create records a tender against the order in the currency of the order, a payment can not
+// be larger than the balance due.
+func (r *paymentRepo) Create(ctx context.Context, req *models.CreatePayment) (int, error) {
+	var (
+		id         int
+		status     int16
+		customerId int
+		currency   string
+	)
+
+	if !req.Amount.Amount.IsPositive() {
+		return 0, errors.New("payment amount must be positive")
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`SELECT order_status, COALESCE(customer_id, 0), currency FROM orders WHERE order_id = $1 FOR UPDATE`,
+		req.OrderId,
+	).Scan(&status, &customerId, &currency)
+	if err == pgx.ErrNoRows {
+		return 0, errors.New("Order is not found")
+	} else if err != nil {
+		return 0, err
+	}
+
+	if status == models.OrderStatusRejected {
+		return 0, errors.New("Rejected order can not be paid")
+	}
+
+	amount, err := req.Amount.In(currency)
+	if err != nil {
+		return 0, err
+	}
+	amount = money.New(amount.Amount, currency)
+
+	balance, err := orderBalance(ctx, tx, req.OrderId)
+	if err != nil {
+		return 0, err
+	}
+
+	if amount.Amount.GreaterThan(balance.BalanceDue.Amount) {
+		return 0, fmt.Errorf("payment exceeds the balance due of %s", balance.BalanceDue)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO payments(
+			order_id,
+			tender,
+			amount,
+			provider,
+			transaction_id,
+			reference,
+			note,
+			currency
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING payment_id
+	`,
+		req.OrderId,
+		req.Tender,
+		amount.Amount,
+		req.Provider,
+		helper.NewNullString(req.TransactionId),
+		helper.NewNullString(req.Reference),
+		helper.NewNullString(req.Note),
+		currency,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	switch req.Tender {
+	case models.PaymentTenderGiftCard:
+		err = giftCardEntry(ctx, tx, req.Reference, models.GiftCardLedgerRedeem, amount.Neg(), req.OrderId, id)
+	case models.PaymentTenderStoreCredit:
+		if customerId <= 0 {
+			return 0, errors.New("Order has no customer to take store credit from")
+		}
+		err = customerCreditEntry(ctx, tx, customerId, models.CreditLedgerRedeem, amount.Neg(), req.OrderId, 0, id)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *paymentRepo) GetByID(ctx context.Context, req *models.PaymentPrimaryKey) (*models.Payment, error) {
+
+	var (
+		query   string
+		payment models.Payment
+	)
+
+	query = `
+		SELECT
+			p.payment_id,
+			p.order_id,
+			p.tender,
+			p.amount,
+			p.currency,
+			p.provider,
+			COALESCE(p.transaction_id, ''),
+			COALESCE(p.reference, ''),
+			COALESCE(p.refund_of, 0),
+			COALESCE((SELECT -SUM(rf.amount) FROM payments AS rf WHERE rf.refund_of = p.payment_id), 0),
+			COALESCE(p.note, ''),
+			CAST(p.created_at AS VARCHAR)
+		FROM payments AS p
+		WHERE p.payment_id = $1
+	`
+
+	err := r.db.QueryRow(ctx, query, req.PaymentId).Scan(
+		&payment.PaymentId,
+		&payment.OrderId,
+		&payment.Tender,
+		&payment.Amount.Amount,
+		&payment.Amount.Currency,
+		&payment.Provider,
+		&payment.TransactionId,
+		&payment.Reference,
+		&payment.RefundOf,
+		&payment.Refunded.Amount,
+		&payment.Note,
+		&payment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	payment.Refunded.Currency = payment.Amount.Currency
+
+	return &payment, nil
+}
+
+func (r *paymentRepo) GetList(ctx context.Context, req *models.GetListPaymentRequest) (resp *models.GetListPaymentResponse, err error) {
+
+	resp = &models.GetListPaymentResponse{}
+
+	var (
+		query  string
+		filter = " WHERE TRUE "
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		params = map[string]interface{}{}
+	)
+
+	query = `
+		SELECT
+			COUNT(*) OVER(),
+			p.payment_id,
+			p.order_id,
+			p.tender,
+			p.amount,
+			p.currency,
+			p.provider,
+			COALESCE(p.transaction_id, ''),
+			COALESCE(p.reference, ''),
+			COALESCE(p.refund_of, 0),
+			COALESCE((SELECT -SUM(rf.amount) FROM payments AS rf WHERE rf.refund_of = p.payment_id), 0),
+			COALESCE(p.note, ''),
+			CAST(p.created_at AS VARCHAR)
+		FROM payments AS p
+	`
+
+	if req.OrderId > 0 {
+		filter += " AND p.order_id = :order_id "
+		params["order_id"] = req.OrderId
+	}
+
+	if len(req.Tender) > 0 {
+		filter += " AND p.tender = :tender "
+		params["tender"] = req.Tender
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += filter + " ORDER BY p.payment_id " + offset + limit
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var payment models.Payment
+
+		err = rows.Scan(
+			&resp.Count,
+			&payment.PaymentId,
+			&payment.OrderId,
+			&payment.Tender,
+			&payment.Amount.Amount,
+			&payment.Amount.Currency,
+			&payment.Provider,
+			&payment.TransactionId,
+			&payment.Reference,
+			&payment.RefundOf,
+			&payment.Refunded.Amount,
+			&payment.Note,
+			&payment.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payment.Refunded.Currency = payment.Amount.Currency
+
+		resp.Payments = append(resp.Payments, &payment)
+	}
+
+	return resp, nil
+}
+
+// Refund records a negative payment against an earlier one. Once the order is
+// completed only what is owed back to the customer (e.g. approved returns) can be refunded.
+func (r *paymentRepo) Refund(ctx context.Context, req *models.RefundPayment) (int, error) {
+	var (
+		id         int
+		orderId    int
+		customerId int
+		status     int16
+		payment    models.Payment
+		refunded   decimal.Decimal
+	)
+
+	if !req.Amount.Amount.IsPositive() {
+		return 0, errors.New("refund amount must be positive")
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`SELECT order_id FROM payments WHERE payment_id = $1 AND refund_of IS NULL`,
+		req.PaymentId,
+	).Scan(&orderId)
+	if err == pgx.ErrNoRows {
+		return 0, errors.New("Payment is not found")
+	} else if err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRow(ctx,
+		`SELECT order_status, COALESCE(customer_id, 0) FROM orders WHERE order_id = $1 FOR UPDATE`,
+		orderId,
+	).Scan(&status, &customerId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRow(ctx, `
+		SELECT
+			p.tender,
+			p.amount,
+			p.currency,
+			COALESCE(p.reference, ''),
+			COALESCE((SELECT -SUM(rf.amount) FROM payments AS rf WHERE rf.refund_of = p.payment_id), 0)
+		FROM payments AS p
+		WHERE p.payment_id = $1
+	`, req.PaymentId).Scan(
+		&payment.Tender,
+		&payment.Amount.Amount,
+		&payment.Amount.Currency,
+		&payment.Reference,
+		&refunded,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	amount, err := req.Amount.In(payment.Amount.Currency)
+	if err != nil {
+		return 0, err
+	}
+	amount = money.New(amount.Amount, amount.Currency)
+
+	refundable := money.New(payment.Amount.Amount.Sub(refunded), amount.Currency)
+	if amount.Amount.GreaterThan(refundable.Amount) {
+		return 0, fmt.Errorf("refund exceeds the refundable amount of %s", refundable)
+	}
+
+	if status == models.OrderStatusCompleted {
+		balance, err := orderBalance(ctx, tx, orderId)
+		if err != nil {
+			return 0, err
+		}
+
+		owed := money.New(balance.BalanceDue.Amount.Neg(), amount.Currency)
+		if amount.Amount.GreaterThan(owed.Amount) {
+			return 0, fmt.Errorf("refund exceeds the %s owed to the customer", owed)
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO payments(
+			order_id,
+			tender,
+			amount,
+			provider,
+			transaction_id,
+			refund_of,
+			note,
+			currency
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING payment_id
+	`,
+		orderId,
+		payment.Tender,
+		amount.Amount.Neg(),
+		req.Provider,
+		helper.NewNullString(req.TransactionId),
+		req.PaymentId,
+		helper.NewNullString(req.Note),
+		amount.Currency,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	// money taken from a gift card or store credit goes back where it came from
+	switch payment.Tender {
+	case models.PaymentTenderGiftCard:
+		err = giftCardEntry(ctx, tx, payment.Reference, models.GiftCardLedgerRefund, amount, orderId, id)
+	case models.PaymentTenderStoreCredit:
+		err = customerCreditEntry(ctx, tx, customerId, models.CreditLedgerRefund, amount, orderId, 0, id)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *paymentRepo) Balance(ctx context.Context, req *models.OrderPrimaryKey) (*models.OrderBalance, error) {
+	return orderBalance(ctx, r.db, req.OrderId)
+}
+
+// orderBalance is the order total less approved returns and the net of its payments,
+// returns settled with store credit are already paid back and do not count.
+func orderBalance(ctx context.Context, db querier, orderId int) (*models.OrderBalance, error) {
+
+	amount, _, err := orderAmount(ctx, db, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		currency = amount.Total.Currency
+		balance  = models.OrderBalance{
+			OrderId:  orderId,
+			Total:    amount.Total,
+			Returned: money.Zero(currency),
+			Credited: money.Zero(currency),
+			Paid:     money.Zero(currency),
+			Refunded: money.Zero(currency),
+		}
+	)
+
+	err = db.QueryRow(ctx, `
+		SELECT
+			COALESCE((SELECT SUM(refund_amount) FROM returns WHERE order_id = $1 AND status = $2 AND refund_method = $3), 0),
+			COALESCE((SELECT SUM(refund_amount) FROM returns WHERE order_id = $1 AND status = $2 AND refund_method = $4), 0),
+			COALESCE((SELECT SUM(amount) FROM payments WHERE order_id = $1 AND amount > 0), 0),
+			COALESCE((SELECT -SUM(amount) FROM payments WHERE order_id = $1 AND amount < 0), 0)
+	`,
+		orderId,
+		models.ReturnStatusApproved,
+		models.ReturnRefundMethodOriginal,
+		models.ReturnRefundMethodStoreCredit,
+	).Scan(
+		&balance.Returned.Amount,
+		&balance.Credited.Amount,
+		&balance.Paid.Amount,
+		&balance.Refunded.Amount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	balance.BalanceDue = money.New(
+		balance.Total.Amount.Sub(balance.Returned.Amount).Sub(balance.Paid.Amount).Add(balance.Refunded.Amount),
+		currency,
+	)
+
+	return &balance, nil
+}
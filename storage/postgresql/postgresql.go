@@ -6,9 +6,16 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
 type Store struct {
 	db       *pgxpool.Pool
 	brand    storage.BrandRepoI
@@ -20,6 +27,20 @@ type Store struct {
 	staff    storage.StaffRepoI
 	order    storage.OrderRepoI
 	code     storage.CodeRepoI
+	supplier storage.SupplierRepoI
+	purchase storage.PurchaseOrderRepoI
+	returns  storage.ReturnRepoI
+	payment  storage.PaymentRepoI
+	giftCard storage.GiftCardRepoI
+	loyalty  storage.LoyaltyRepoI
+	address  storage.CustomerAddressRepoI
+	audit    storage.AuditRepoI
+	idem     storage.IdempotencyRepoI
+	price    storage.PriceListRepoI
+	tax      storage.TaxRateRepoI
+	exchange storage.ExchangeRateRepoI
+	variant  storage.ProductVariantRepoI
+	attr     storage.AttributeRepoI
 }
 
 func NewConnectPostgresql(cfg *config.Config) (storage.StorageI, error) {
@@ -51,6 +72,20 @@ func NewConnectPostgresql(cfg *config.Config) (storage.StorageI, error) {
 		staff:    NewStaffRepo(pgpool),
 		order:    NewOrderRepo(pgpool),
 		code:     NewCodeRepo(pgpool),
+		supplier: NewSupplierRepo(pgpool),
+		purchase: NewPurchaseOrderRepo(pgpool),
+		returns:  NewReturnRepo(pgpool),
+		payment:  NewPaymentRepo(pgpool),
+		giftCard: NewGiftCardRepo(pgpool),
+		loyalty:  NewLoyaltyRepo(pgpool),
+		address:  NewCustomerAddressRepo(pgpool),
+		audit:    NewAuditRepo(pgpool),
+		idem:     NewIdempotencyRepo(pgpool),
+		price:    NewPriceListRepo(pgpool),
+		tax:      NewTaxRateRepo(pgpool),
+		exchange: NewExchangeRateRepo(pgpool),
+		variant:  NewProductVariantRepo(pgpool),
+		attr:     NewAttributeRepo(pgpool),
 	}, nil
 }
 
@@ -129,3 +164,115 @@ func (s *Store) Code() storage.CodeRepoI {
 
 	return s.code
 }
+
+func (s *Store) Supplier() storage.SupplierRepoI {
+	if s.supplier == nil {
+		s.supplier = NewSupplierRepo(s.db)
+	}
+
+	return s.supplier
+}
+
+func (s *Store) PurchaseOrder() storage.PurchaseOrderRepoI {
+	if s.purchase == nil {
+		s.purchase = NewPurchaseOrderRepo(s.db)
+	}
+
+	return s.purchase
+}
+
+func (s *Store) Return() storage.ReturnRepoI {
+	if s.returns == nil {
+		s.returns = NewReturnRepo(s.db)
+	}
+
+	return s.returns
+}
+
+func (s *Store) Payment() storage.PaymentRepoI {
+	if s.payment == nil {
+		s.payment = NewPaymentRepo(s.db)
+	}
+
+	return s.payment
+}
+
+func (s *Store) GiftCard() storage.GiftCardRepoI {
+	if s.giftCard == nil {
+		s.giftCard = NewGiftCardRepo(s.db)
+	}
+
+	return s.giftCard
+}
+
+func (s *Store) Loyalty() storage.LoyaltyRepoI {
+	if s.loyalty == nil {
+		s.loyalty = NewLoyaltyRepo(s.db)
+	}
+
+	return s.loyalty
+}
+
+func (s *Store) CustomerAddress() storage.CustomerAddressRepoI {
+	if s.address == nil {
+		s.address = NewCustomerAddressRepo(s.db)
+	}
+
+	return s.address
+}
+
+func (s *Store) Audit() storage.AuditRepoI {
+	if s.audit == nil {
+		s.audit = NewAuditRepo(s.db)
+	}
+
+	return s.audit
+}
+
+func (s *Store) Idempotency() storage.IdempotencyRepoI {
+	if s.idem == nil {
+		s.idem = NewIdempotencyRepo(s.db)
+	}
+
+	return s.idem
+}
+
+func (s *Store) PriceList() storage.PriceListRepoI {
+	if s.price == nil {
+		s.price = NewPriceListRepo(s.db)
+	}
+
+	return s.price
+}
+
+func (s *Store) TaxRate() storage.TaxRateRepoI {
+	if s.tax == nil {
+		s.tax = NewTaxRateRepo(s.db)
+	}
+
+	return s.tax
+}
+
+func (s *Store) ExchangeRate() storage.ExchangeRateRepoI {
+	if s.exchange == nil {
+		s.exchange = NewExchangeRateRepo(s.db)
+	}
+
+	return s.exchange
+}
+
+func (s *Store) ProductVariant() storage.ProductVariantRepoI {
+	if s.variant == nil {
+		s.variant = NewProductVariantRepo(s.db)
+	}
+
+	return s.variant
+}
+
+func (s *Store) Attribute() storage.AttributeRepoI {
+	if s.attr == nil {
+		s.attr = NewAttributeRepo(s.db)
+	}
+
+	return s.attr
+}
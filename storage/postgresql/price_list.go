@@ -0,0 +1,592 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type priceListRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPriceListRepo(db *pgxpool.Pool) *priceListRepo {
+	return &priceListRepo{
+		db: db,
+	}
+}
+
+func (r *priceListRepo) Create(ctx context.Context, req *models.CreatePriceList) (int, error) {
+	var id int
+
+	switch req.Kind {
+	case models.PriceListKindRetail, models.PriceListKindWholesale, models.PriceListKindStaff:
+	default:
+		return 0, errors.New("price list kind must be retail, wholesale or staff")
+	}
+
+	if len(req.PriceListName) <= 0 {
+		return 0, errors.New("price list name is required")
+	}
+
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO price_lists(price_list_name, kind)
+		VALUES ($1, $2)
+		RETURNING price_list_id
+	`, req.PriceListName, req.Kind).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *priceListRepo) GetByID(ctx context.Context, req *models.PriceListPrimaryKey) (*models.PriceList, error) {
+	var list models.PriceList
+
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			price_list_id,
+			price_list_name,
+			kind
+		FROM price_lists
+		WHERE price_list_id = $1
+	`, req.PriceListId).Scan(&list.PriceListId, &list.PriceListName, &list.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+func (r *priceListRepo) GetList(ctx context.Context) ([]*models.PriceList, error) {
+	var lists []*models.PriceList
+
+	rows, err := r.db.Query(ctx, `
+		SELECT
+			price_list_id,
+			price_list_name,
+			kind
+		FROM price_lists
+		ORDER BY price_list_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var list models.PriceList
+
+		err = rows.Scan(&list.PriceListId, &list.PriceListName, &list.Kind)
+		if err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, &list)
+	}
+
+	return lists, nil
+}
+
+func (r *priceListRepo) CreateItem(ctx context.Context, req *models.CreatePriceListItem) (int, error) {
+	var (
+		id       int
+		currency string
+	)
+
+	if req.Price.Amount.IsNegative() {
+		return 0, errors.New("price can not be negative")
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `SELECT currency FROM products WHERE product_id = $1`, req.ProductId).Scan(&currency)
+	if err == pgx.ErrNoRows {
+		return 0, errors.New("product is not found")
+	} else if err != nil {
+		return 0, err
+	}
+
+	price, err := req.Price.In(currency)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO price_list_items(
+			price_list_id,
+			product_id,
+			store_id,
+			price,
+			currency,
+			valid_from,
+			valid_to
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING price_list_item_id
+	`,
+		req.PriceListId,
+		req.ProductId,
+		helper.NewNullInt32(req.StoreId),
+		price.Amount.Round(money.Cents),
+		price.Currency,
+		helper.NewNullString(req.ValidFrom),
+		helper.NewNullString(req.ValidTo),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	err = writePriceHistory(ctx, tx, models.PriceChangeCreate, id, money.Zero(price.Currency))
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *priceListRepo) GetItem(ctx context.Context, req *models.PriceListItemPrimaryKey) (*models.PriceListItem, error) {
+
+	var item models.PriceListItem
+
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			price_list_item_id,
+			price_list_id,
+			product_id,
+			COALESCE(store_id, 0),
+			price,
+			currency,
+			COALESCE(CAST(valid_from AS VARCHAR), ''),
+			COALESCE(CAST(valid_to AS VARCHAR), ''),
+			CAST(created_at AS VARCHAR),
+			version
+		FROM price_list_items
+		WHERE price_list_item_id = $1 AND price_list_id = $2
+	`, req.PriceListItemId, req.PriceListId).Scan(
+		&item.PriceListItemId,
+		&item.PriceListId,
+		&item.ProductId,
+		&item.StoreId,
+		&item.Price.Amount,
+		&item.Price.Currency,
+		&item.ValidFrom,
+		&item.ValidTo,
+		&item.CreatedAt,
+		&item.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func (r *priceListRepo) GetListItem(ctx context.Context, req *models.GetListPriceListItemRequest) (resp *models.GetListPriceListItemResponse, err error) {
+
+	resp = &models.GetListPriceListItemResponse{}
+
+	var (
+		query  string
+		filter = " WHERE i.price_list_id = :price_list_id "
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		params = map[string]interface{}{
+			"price_list_id": req.PriceListId,
+		}
+	)
+
+	query = `
+		SELECT
+			COUNT(*) OVER(),
+			i.price_list_item_id,
+			i.price_list_id,
+			i.product_id,
+			COALESCE(i.store_id, 0),
+			i.price,
+			i.currency,
+			COALESCE(CAST(i.valid_from AS VARCHAR), ''),
+			COALESCE(CAST(i.valid_to AS VARCHAR), ''),
+			CAST(i.created_at AS VARCHAR),
+			i.version
+		FROM price_list_items AS i
+	`
+
+	if req.ProductId > 0 {
+		filter += " AND i.product_id = :product_id "
+		params["product_id"] = req.ProductId
+	}
+
+	if req.StoreId > 0 {
+		filter += " AND i.store_id = :store_id "
+		params["store_id"] = req.StoreId
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += filter + " ORDER BY i.product_id, i.price_list_item_id " + offset + limit
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.PriceListItem
+
+		err = rows.Scan(
+			&resp.Count,
+			&item.PriceListItemId,
+			&item.PriceListId,
+			&item.ProductId,
+			&item.StoreId,
+			&item.Price.Amount,
+			&item.Price.Currency,
+			&item.ValidFrom,
+			&item.ValidTo,
+			&item.CreatedAt,
+			&item.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Items = append(resp.Items, &item)
+	}
+
+	return resp, nil
+}
+
+func (r *priceListRepo) UpdateItem(ctx context.Context, req *models.UpdatePriceListItem) (int64, error) {
+
+	if req.Price.Amount.IsNegative() {
+		return 0, errors.New("price can not be negative")
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		oldPrice money.Money
+		currency string
+	)
+
+	err = tx.QueryRow(ctx, `
+		SELECT i.price, i.currency, p.currency
+		FROM price_list_items AS i
+		JOIN products AS p ON p.product_id = i.product_id
+		WHERE i.price_list_item_id = $1 AND i.price_list_id = $2 AND i.version = $3
+		FOR UPDATE OF i
+	`, req.PriceListItemId, req.PriceListId, req.Version).Scan(&oldPrice.Amount, &oldPrice.Currency, &currency)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	// the item follows the product into a new currency only when it is priced again
+	price, err := req.Price.In(currency)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(ctx, `
+		UPDATE price_list_items
+		SET
+			store_id = $3,
+			price = $4,
+			currency = $5,
+			valid_from = $6,
+			valid_to = $7,
+			version = version + 1
+		WHERE price_list_item_id = $1 AND price_list_id = $2
+	`,
+		req.PriceListItemId,
+		req.PriceListId,
+		helper.NewNullInt32(req.StoreId),
+		price.Amount.Round(money.Cents),
+		price.Currency,
+		helper.NewNullString(req.ValidFrom),
+		helper.NewNullString(req.ValidTo),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	err = writePriceHistory(ctx, tx, models.PriceChangeUpdate, req.PriceListItemId, oldPrice)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *priceListRepo) DeleteItem(ctx context.Context, req *models.PriceListItemPrimaryKey) (int64, error) {
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// written before the delete, the item is gone afterwards
+	err = writePriceHistory(ctx, tx, models.PriceChangeDelete, req.PriceListItemId, money.Money{})
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(ctx,
+		`DELETE FROM price_list_items WHERE price_list_item_id = $1 AND price_list_id = $2 AND version = $3`,
+		req.PriceListItemId,
+		req.PriceListId,
+		req.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.RowsAffected() <= 0 {
+		return 0, nil
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// writePriceHistory records a change of a price list item. oldPrice is the price before an
+// update, a delete is written before the item is removed and keeps its price as old_price.
+func writePriceHistory(ctx context.Context, tx pgx.Tx, change string, itemId int, oldPrice money.Money) error {
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO price_history(product_id, price_list_id, price_list_item_id, store_id, change, old_price, old_currency, new_price, new_currency, valid_from, valid_to)
+		SELECT
+			product_id,
+			price_list_id,
+			price_list_item_id,
+			store_id,
+			$2::VARCHAR,
+			CASE $2::VARCHAR WHEN 'create' THEN NULL WHEN 'update' THEN $3::DECIMAL ELSE price END,
+			CASE $2::VARCHAR WHEN 'update' THEN $4::CHAR(3) ELSE currency END,
+			CASE $2::VARCHAR WHEN 'delete' THEN NULL ELSE price END,
+			currency,
+			valid_from,
+			valid_to
+		FROM price_list_items
+		WHERE price_list_item_id = $1
+	`, itemId, change, oldPrice.Amount, oldPrice.Currency)
+
+	return err
+}
+
+// writeListPriceHistory records a change of the product list_price, the price used when no
+// price list applies.
+func writeListPriceHistory(ctx context.Context, tx pgx.Tx, change string, productId int, oldPrice, newPrice money.Money) error {
+
+	if change == models.PriceChangeUpdate && oldPrice.Amount.Equal(newPrice.Amount) && oldPrice.Currency == newPrice.Currency {
+		return nil
+	}
+
+	var old interface{}
+	if change != models.PriceChangeCreate {
+		old = oldPrice.Amount
+	} else {
+		oldPrice.Currency = newPrice.Currency
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO price_history(product_id, change, old_price, old_currency, new_price, new_currency)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, productId, change, old, oldPrice.Currency, newPrice.Amount, newPrice.Currency)
+
+	return err
+}
+
+func (r *priceListRepo) ProductPrice(ctx context.Context, req *models.GetProductPriceRequest) (*models.ProductPrice, error) {
+	return productPrice(ctx, r.db, req)
+}
+
+// productPrice resolves the price a product sells at in a store for a customer. Items of the
+// customer's price list come before the retail list, in a list a store override comes before
+// the price for every store and a scheduled price before an open one. Without an active item
+// the product list_price applies.
+func productPrice(ctx context.Context, q querier, req *models.GetProductPriceRequest) (*models.ProductPrice, error) {
+	var (
+		price       = models.ProductPrice{ProductId: req.ProductId, StoreId: req.StoreId, CustomerId: req.CustomerId}
+		priceListId int
+	)
+
+	if req.CustomerId > 0 {
+		err := q.QueryRow(ctx,
+			`SELECT COALESCE(price_list_id, 0) FROM customers WHERE customer_id = $1`,
+			req.CustomerId,
+		).Scan(&priceListId)
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("customer is not found")
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	err := q.QueryRow(ctx, `
+		SELECT
+			p.list_price,
+			COALESCE(i.price, p.list_price),
+			p.currency,
+			COALESCE(i.price_list_id, 0),
+			COALESCE(i.price_list_name, ''),
+			COALESCE(i.price_list_item_id, 0),
+			i.store_id IS NOT NULL,
+			COALESCE(CAST(i.valid_to AS VARCHAR), '')
+		FROM products AS p
+		LEFT JOIN LATERAL (
+			SELECT
+				pi.price_list_item_id,
+				pi.price_list_id,
+				pl.price_list_name,
+				pi.store_id,
+				pi.price,
+				pi.valid_to
+			FROM price_list_items AS pi
+			JOIN price_lists AS pl ON pl.price_list_id = pi.price_list_id
+			WHERE pi.product_id = p.product_id
+				AND pi.currency = p.currency
+				AND (pi.price_list_id = $3 OR pl.kind = 'retail')
+				AND (pi.store_id IS NULL OR pi.store_id = $2)
+				AND (pi.valid_from IS NULL OR pi.valid_from <= NOW())
+				AND (pi.valid_to IS NULL OR pi.valid_to > NOW())
+			ORDER BY
+				pi.price_list_id = $3 DESC,
+				pi.store_id IS NOT NULL DESC,
+				pi.valid_from IS NOT NULL DESC,
+				pi.valid_from DESC,
+				pi.price_list_item_id DESC
+			LIMIT 1
+		) AS i ON TRUE
+		WHERE p.product_id = $1
+	`, req.ProductId, req.StoreId, priceListId).Scan(
+		&price.ListPrice.Amount,
+		&price.Price.Amount,
+		&price.ListPrice.Currency,
+		&price.PriceListId,
+		&price.PriceListName,
+		&price.PriceListItemId,
+		&price.StoreOverride,
+		&price.ValidTo,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, errors.New("product is not found")
+	} else if err != nil {
+		return nil, err
+	}
+	price.Price.Currency = price.ListPrice.Currency
+
+	return &price, nil
+}
+
+func (r *priceListRepo) GetListHistory(ctx context.Context, req *models.GetListPriceHistoryRequest) (resp *models.GetListPriceHistoryResponse, err error) {
+
+	resp = &models.GetListPriceHistoryResponse{}
+
+	var (
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+	)
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT
+			COUNT(*) OVER(),
+			history_id,
+			product_id,
+			COALESCE(price_list_id, 0),
+			COALESCE(price_list_item_id, 0),
+			COALESCE(store_id, 0),
+			change,
+			COALESCE(old_price, 0),
+			old_currency,
+			COALESCE(new_price, 0),
+			new_currency,
+			COALESCE(CAST(valid_from AS VARCHAR), ''),
+			COALESCE(CAST(valid_to AS VARCHAR), ''),
+			CAST(changed_at AS VARCHAR)
+		FROM price_history
+		WHERE product_id = $1
+		ORDER BY changed_at DESC, history_id DESC
+	`+offset+limit, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var history models.PriceHistory
+
+		err = rows.Scan(
+			&resp.Count,
+			&history.HistoryId,
+			&history.ProductId,
+			&history.PriceListId,
+			&history.PriceListItemId,
+			&history.StoreId,
+			&history.Change,
+			&history.OldPrice.Amount,
+			&history.OldPrice.Currency,
+			&history.NewPrice.Amount,
+			&history.NewPrice.Currency,
+			&history.ValidFrom,
+			&history.ValidTo,
+			&history.ChangedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.History = append(resp.History, &history)
+	}
+
+	return resp, nil
+}
@@ -3,9 +3,12 @@ package postgresql
 import (
 	"app/api/models"
 	"app/pkg/helper"
+	"app/pkg/money"
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -32,28 +35,71 @@ func (r *productRepo) Create(ctx context.Context, req *models.CreateProduct) (in
 			brand_id,
 			category_id,
 			model_year,
-			list_price
+			list_price,
+			currency
 		)
 		VALUES (
 			(
 				SELECT MAX(product_id) + 1 FROM products
 			)
-			, $1, $2, $3, $4, $5) RETURNING product_id
+			, $1, $2, $3, $4, $5, $6) RETURNING product_id
 	`
 	fmt.Println(query)
 
-	err := r.db.QueryRow(ctx, query,
+	currency, err := currencyOrDefault(req.ListPrice.Currency)
+	if err != nil {
+		return 0, err
+	}
+	price := money.New(req.ListPrice.Amount, currency)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query,
 		req.ProductName,
 		req.BrandId,
 		req.CategoryId,
 		req.ModelYear,
-		req.ListPrice,
+		price.Amount,
+		price.Currency,
 	).Scan(&id)
 
 	if err != nil {
 		return 0, err
 	}
 
+	err = writeListPriceHistory(ctx, tx, models.PriceChangeCreate, id, money.Money{}, price)
+	if err != nil {
+		return 0, err
+	}
+
+	// a product without options is still stocked and sold through one variant
+	variants := req.Variants
+	if len(variants) <= 0 {
+		sku := req.Sku
+		if len(strings.TrimSpace(sku)) <= 0 {
+			sku = fmt.Sprintf("P%06d", id)
+		}
+		variants = []*models.CreateProductVariant{{Sku: sku}}
+	}
+
+	for _, variant := range variants {
+		variant.ProductId = id
+
+		_, err = createProductVariant(ctx, tx, variant)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
 	return id, nil
 }
 
@@ -78,7 +124,10 @@ func (r *productRepo) GetByID(ctx context.Context, req *models.ProductPrimaryKey
 			c.category_name,
 			
 			p.model_year,
-			p.list_price
+			p.list_price,
+			p.currency,
+			COALESCE(CAST(p.deleted_at AS VARCHAR), ''),
+			p.version
 		FROM products AS p
 		JOIN brands AS b ON b.brand_id = p.brand_id
 		JOIN categories AS c ON c.category_id = p.category_id
@@ -97,12 +146,27 @@ func (r *productRepo) GetByID(ctx context.Context, req *models.ProductPrimaryKey
 		&product.CategoryData.CategoryId,
 		&product.CategoryData.CategoryName,
 		&product.ModelYear,
-		&product.ListPrice,
+		&product.ListPrice.Amount,
+		&product.ListPrice.Currency,
+		&product.DeletedAt,
+		&product.Version,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	variants, err := productVariants(ctx, r.db, []int{product.ProductId}, 0)
+	if err != nil {
+		return nil, err
+	}
+	product.Variants = variants[product.ProductId]
+
+	attributes, err := productAttributes(ctx, r.db, []int{product.ProductId})
+	if err != nil {
+		return nil, err
+	}
+	product.Attributes = attributes[product.ProductId]
+
 	return &product, nil
 }
 
@@ -111,10 +175,12 @@ func (r *productRepo) GetList(ctx context.Context, req *models.GetListProductReq
 	resp = &models.GetListProductResponse{}
 
 	var (
-		query  string
-		filter = " WHERE TRUE "
-		offset = " OFFSET 0"
-		limit  = " LIMIT 10"
+		query      string
+		filter     = " WHERE TRUE "
+		offset     = " OFFSET 0"
+		limit      = " LIMIT 10"
+		params     = map[string]interface{}{}
+		productIds []int
 	)
 
 	query = `
@@ -132,16 +198,31 @@ func (r *productRepo) GetList(ctx context.Context, req *models.GetListProductReq
 			c.category_name,
 			
 			p.model_year,
-			p.list_price
+			p.list_price,
+			p.currency,
+			COALESCE(CAST(p.deleted_at AS VARCHAR), ''),
+			p.version
 		FROM products AS p
 		JOIN brands AS b ON b.brand_id = p.brand_id
 		JOIN categories AS c ON c.category_id = p.category_id
 	`
 
+	if !req.IncludeDeleted {
+		filter += " AND p.deleted_at IS NULL "
+	}
+
 	if len(req.Search) > 0 {
 		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
 	}
 
+	for i, attribute := range req.Attributes {
+		condition, err := attributeFilter(ctx, r.db, i+1, attribute, params)
+		if err != nil {
+			return nil, err
+		}
+		filter += condition
+	}
+
 	if req.Offset > 0 {
 		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
 	}
@@ -152,7 +233,9 @@ func (r *productRepo) GetList(ctx context.Context, req *models.GetListProductReq
 
 	query += filter + offset + limit
 
-	rows, err := r.db.Query(ctx, query)
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -173,13 +256,37 @@ func (r *productRepo) GetList(ctx context.Context, req *models.GetListProductReq
 			&product.CategoryData.CategoryId,
 			&product.CategoryData.CategoryName,
 			&product.ModelYear,
-			&product.ListPrice,
+			&product.ListPrice.Amount,
+			&product.ListPrice.Currency,
+			&product.DeletedAt,
+			&product.Version,
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		resp.Products = append(resp.Products, &product)
+		productIds = append(productIds, product.ProductId)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	variants, err := productVariants(ctx, r.db, productIds, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := productAttributes(ctx, r.db, productIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range resp.Products {
+		product.Variants = variants[product.ProductId]
+		product.Attributes = attributes[product.ProductId]
 	}
 
 	return resp, nil
@@ -191,6 +298,15 @@ func (r *productRepo) Update(ctx context.Context, req *models.UpdateProduct) (in
 		params map[string]interface{}
 	)
 
+	// an empty currency keeps the one of the product
+	if len(req.ListPrice.Currency) > 0 {
+		currency, err := currencyOrDefault(req.ListPrice.Currency)
+		if err != nil {
+			return 0, err
+		}
+		req.ListPrice.Currency = currency
+	}
+
 	query = `
 		UPDATE
 		products
@@ -200,8 +316,10 @@ func (r *productRepo) Update(ctx context.Context, req *models.UpdateProduct) (in
 			brand_id = :brand_id,
 			category_id = :category_id,
 			model_year = :model_year,
-			list_price = :list_price
-		WHERE product_id = :product_id
+			list_price = :list_price,
+			currency = COALESCE(:currency, currency),
+			version = version + 1
+		WHERE product_id = :product_id AND version = :version
 	`
 
 	params = map[string]interface{}{
@@ -210,12 +328,90 @@ func (r *productRepo) Update(ctx context.Context, req *models.UpdateProduct) (in
 		"brand_id":     req.BrandId,
 		"category_id":  req.CategoryId,
 		"model_year":   req.ModelYear,
-		"list_price":   req.ListPrice,
+		"list_price":   req.ListPrice.Amount.Round(money.Cents),
+		"currency":     helper.NewNullString(req.ListPrice.Currency),
+		"version":      req.Version,
 	}
 
 	query, args := helper.ReplaceQueryParams(query, params)
 
-	result, err := r.db.Exec(ctx, query, args...)
+	return r.updateListPrice(ctx, req.ProductId, query, args)
+}
+
+// productPatchFields are the columns a PATCH may change.
+var productPatchFields = map[string]patchField{
+	"product_name": {kind: patchString},
+	"brand_id":     {kind: patchInt},
+	"category_id":  {kind: patchInt},
+	"model_year":   {kind: patchInt},
+	"list_price":   {kind: patchDecimal},
+	"currency":     {kind: patchCurrency},
+}
+
+func (r *productRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
+
+	set, args, err := patchSet(productPatchFields, req.Fields)
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE
+		products
+		SET
+		%s, version = version + 1
+		WHERE product_id = $%d AND version = $%d
+	`, set, len(args)+1, len(args)+2)
+
+	args = append(args, req.ID, req.Version)
+
+	return r.updateListPrice(ctx, req.ID, query, args)
+}
+
+// updateListPrice runs an update of the product and writes the price history when it
+// changed the list_price.
+func (r *productRepo) updateListPrice(ctx context.Context, productId int, query string, args []interface{}) (int64, error) {
+	var oldPrice, newPrice money.Money
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`SELECT list_price, currency FROM products WHERE product_id = $1 FOR UPDATE`,
+		productId,
+	).Scan(&oldPrice.Amount, &oldPrice.Currency)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.RowsAffected() <= 0 {
+		return 0, nil
+	}
+
+	err = tx.QueryRow(ctx,
+		`SELECT list_price, currency FROM products WHERE product_id = $1`,
+		productId,
+	).Scan(&newPrice.Amount, &newPrice.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	err = writeListPriceHistory(ctx, tx, models.PriceChangeUpdate, productId, oldPrice, newPrice)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -225,14 +421,29 @@ func (r *productRepo) Update(ctx context.Context, req *models.UpdateProduct) (in
 
 func (r *productRepo) Delete(ctx context.Context, req *models.ProductPrimaryKey) (int64, error) {
 	query := `
-		DELETE 
-		FROM products
-		WHERE product_id = $1
+		UPDATE products
+			SET deleted_at = NOW(), version = version + 1
+		WHERE product_id = $1 AND version = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, req.ProductId, req.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+func (r *productRepo) Restore(ctx context.Context, req *models.ProductPrimaryKey) (int64, error) {
+	query := `
+		UPDATE products
+			SET deleted_at = NULL, version = version + 1
+		WHERE product_id = $1 AND deleted_at IS NOT NULL
 	`
 
 	result, err := r.db.Exec(ctx, query, req.ProductId)
 	if err != nil {
 		return 0, err
 	}
+
 	return result.RowsAffected(), nil
 }
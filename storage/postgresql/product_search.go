@@ -0,0 +1,365 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxSearchWords keeps a pasted paragraph from turning into a query with a condition per word.
+const maxSearchWords = 8
+
+// Search finds products by the words of the query with full-text search on search_vector,
+// matching words by prefix, and trigram word similarity on search_text for misspelled ones.
+// Products rank by ts_rank_cd plus how similar their text is to the query, facets count the
+// brands, categories, model years and price bands of every product found.
+func (r *productRepo) Search(ctx context.Context, req *models.SearchProductRequest) (*models.SearchProductResponse, error) {
+
+	var (
+		resp = &models.SearchProductResponse{
+			Products: []*models.SearchProduct{},
+			Facets: &models.SearchProductFacets{
+				Brands:     []*models.SearchFacet{},
+				Categories: []*models.SearchFacet{},
+				ModelYears: []*models.SearchFacet{},
+				PriceBands: []*models.SearchFacet{},
+			},
+		}
+		filter     = " WHERE p.deleted_at IS NULL "
+		offset     = " OFFSET 0"
+		limit      = " LIMIT 10"
+		params     = map[string]interface{}{}
+		currencies []string
+		rates      []string
+	)
+
+	words := searchWords(req.Query)
+	if len(words) <= 0 {
+		return nil, errors.New("q must hold a word to search for")
+	}
+
+	if req.Similarity <= 0 || req.Similarity > 1 {
+		return nil, errors.New("similarity must be above 0 and at most 1")
+	}
+
+	for i := 1; i < len(req.PriceBands); i++ {
+		if req.PriceBands[i] <= req.PriceBands[i-1] {
+			return nil, errors.New("price bands must rise")
+		}
+	}
+
+	currency, err := currencyOrDefault(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// <% matches words at least as similar as the threshold of the transaction
+	_, err = tx.Exec(ctx,
+		`SELECT SET_CONFIG('pg_trgm.word_similarity_threshold', $1, TRUE)`,
+		strconv.FormatFloat(req.Similarity, 'f', -1, 64),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `SELECT DISTINCT currency FROM products WHERE deleted_at IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var code string
+
+		err = rows.Scan(&code)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		currencies = append(currencies, code)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, code := range currencies {
+		rate, err := exchangeRate(ctx, tx, code, currency)
+		if err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, rate.String())
+	}
+
+	var anyWord []string
+	for i, word := range words {
+		var (
+			query = fmt.Sprintf("t%d_query", i+1)
+			text  = fmt.Sprintf("t%d_word", i+1)
+		)
+
+		// a stop word turns into an empty tsquery, it neither matches nor rules anything out
+		filter += fmt.Sprintf(` AND (
+			p.search_vector @@ TO_TSQUERY('english', :%s)
+			OR NUMNODE(TO_TSQUERY('english', :%s)) = 0
+			OR :%s <%% p.search_text
+		) `, query, query, text)
+
+		params[query] = word + ":*"
+		params[text] = word
+		anyWord = append(anyWord, word+":*")
+	}
+
+	params["any_query"] = strings.Join(anyWord, " | ")
+	params["words"] = strings.Join(words, " ")
+	params["currencies"] = currencies
+	params["rates"] = rates
+	params["band_bounds"] = req.PriceBands // every query names it, an unused argument fails
+
+	if req.BrandId > 0 {
+		filter += " AND p.brand_id = :brand_id "
+		params["brand_id"] = req.BrandId
+	}
+
+	if req.CategoryId > 0 {
+		filter += " AND p.category_id = :category_id "
+		params["category_id"] = req.CategoryId
+	}
+
+	if req.ModelYear > 0 {
+		filter += " AND p.model_year = :model_year "
+		params["model_year"] = req.ModelYear
+	}
+
+	if len(req.PriceBand) > 0 {
+		band := -1
+		for i := 0; i <= len(req.PriceBands) && len(req.PriceBands) > 0; i++ {
+			if value, _ := priceBand(req.PriceBands, i, currency); value == req.PriceBand {
+				band = i
+			}
+		}
+
+		if band < 0 {
+			return nil, fmt.Errorf("unknown price_band %s", req.PriceBand)
+		}
+
+		filter += " AND WIDTH_BUCKET(ROUND(p.list_price * fx.rate, 2), CAST(:band_bounds AS NUMERIC[])) = :price_band "
+		params["price_band"] = band
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	matches := `
+		WITH matches AS (
+			SELECT
+				p.product_id,
+				p.brand_id,
+				b.brand_name,
+				p.category_id,
+				c.category_name,
+				p.model_year,
+				ROUND(p.list_price * fx.rate, 2) AS price,
+				WIDTH_BUCKET(ROUND(p.list_price * fx.rate, 2), CAST(:band_bounds AS NUMERIC[])) AS price_band,
+				CAST(
+					TS_RANK_CD(p.search_vector, TO_TSQUERY('english', :any_query))
+					+ WORD_SIMILARITY(:words, p.search_text)
+				AS FLOAT8) AS rank
+			FROM products AS p
+			JOIN brands AS b ON b.brand_id = p.brand_id
+			JOIN categories AS c ON c.category_id = p.category_id
+			JOIN UNNEST(CAST(:currencies AS TEXT[]), CAST(:rates AS NUMERIC[])) AS fx(currency, rate) ON fx.currency = p.currency
+			` + filter + `
+		)
+	`
+
+	// headlines are only worked out for the page, ts_headline is slow
+	query, args := helper.ReplaceQueryParams(matches+`
+		SELECT
+			m.count,
+			m.product_id,
+			p.product_name,
+			m.brand_id,
+			m.brand_name,
+			m.category_id,
+			m.category_name,
+			m.model_year,
+			p.list_price,
+			p.currency,
+			m.price,
+			m.rank,
+			TS_HEADLINE('english', p.product_name, TO_TSQUERY('english', :any_query),
+				'StartSel=<mark>, StopSel=</mark>, HighlightAll=TRUE'),
+			TS_HEADLINE('english', p.search_text, TO_TSQUERY('english', :any_query),
+				'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5')
+		FROM (
+			SELECT
+				COUNT(*) OVER() AS count,
+				*
+			FROM matches
+			ORDER BY rank DESC, product_id
+			`+offset+limit+`
+		) AS m
+		JOIN products AS p ON p.product_id = m.product_id
+		ORDER BY m.rank DESC, m.product_id
+	`, params)
+
+	rows, err = tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var product models.SearchProduct
+
+		err = rows.Scan(
+			&resp.Count,
+			&product.ProductId,
+			&product.ProductName,
+			&product.BrandId,
+			&product.BrandName,
+			&product.CategoryId,
+			&product.CategoryName,
+			&product.ModelYear,
+			&product.ListPrice.Amount,
+			&product.ListPrice.Currency,
+			&product.Price.Amount,
+			&product.Rank,
+			&product.Headline,
+			&product.Snippet,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		product.Price.Currency = currency
+
+		resp.Products = append(resp.Products, &product)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query, args = helper.ReplaceQueryParams(matches+`
+		SELECT
+			'brand',
+			CAST(brand_id AS TEXT),
+			brand_name,
+			COUNT(*),
+			ROW_NUMBER() OVER (ORDER BY COUNT(*) DESC, brand_name)
+		FROM matches
+		GROUP BY brand_id, brand_name
+		UNION ALL
+		SELECT
+			'category',
+			CAST(category_id AS TEXT),
+			category_name,
+			COUNT(*),
+			ROW_NUMBER() OVER (ORDER BY COUNT(*) DESC, category_name)
+		FROM matches
+		GROUP BY category_id, category_name
+		UNION ALL
+		SELECT
+			'model_year',
+			CAST(model_year AS TEXT),
+			CAST(model_year AS TEXT),
+			COUNT(*),
+			ROW_NUMBER() OVER (ORDER BY model_year DESC)
+		FROM matches
+		GROUP BY model_year
+		UNION ALL
+		SELECT
+			'price_band',
+			'',
+			'',
+			COUNT(*),
+			price_band
+		FROM matches
+		WHERE CARDINALITY(CAST(:band_bounds AS NUMERIC[])) > 0
+		GROUP BY price_band
+		ORDER BY 1, 5
+	`, params)
+
+	rows, err = tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			facet models.SearchFacet
+			kind  string
+			order int
+		)
+
+		err = rows.Scan(&kind, &facet.Value, &facet.Label, &facet.Count, &order)
+		if err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case models.SearchFacetBrand:
+			resp.Facets.Brands = append(resp.Facets.Brands, &facet)
+		case models.SearchFacetCategory:
+			resp.Facets.Categories = append(resp.Facets.Categories, &facet)
+		case models.SearchFacetModelYear:
+			resp.Facets.ModelYears = append(resp.Facets.ModelYears, &facet)
+		case models.SearchFacetPriceBand:
+			facet.Value, facet.Label = priceBand(req.PriceBands, order, currency)
+			resp.Facets.PriceBands = append(resp.Facets.PriceBands, &facet)
+		}
+	}
+
+	return resp, rows.Err()
+}
+
+// searchWords splits the query into lower case words, dropping punctuation and with it
+// anything TO_TSQUERY would read as an operator.
+func searchWords(query string) []string {
+
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if len(words) > maxSearchWords {
+		words = words[:maxSearchWords]
+	}
+
+	return words
+}
+
+// priceBand is the filter value and label of a band as WIDTH_BUCKET numbers them, band 0 is
+// below the first bound and the last one from the last bound up.
+func priceBand(bounds []int, band int, currency string) (string, string) {
+
+	switch {
+	case band <= 0:
+		return fmt.Sprintf("0-%d", bounds[0]), fmt.Sprintf("under %d %s", bounds[0], currency)
+	case band >= len(bounds):
+		last := bounds[len(bounds)-1]
+		return fmt.Sprintf("%d-", last), fmt.Sprintf("%d %s and over", last, currency)
+	}
+
+	return fmt.Sprintf("%d-%d", bounds[band-1], bounds[band]), fmt.Sprintf("%d - %d %s", bounds[band-1], bounds[band], currency)
+}
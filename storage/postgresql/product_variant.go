@@ -0,0 +1,347 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type productVariantRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewProductVariantRepo(db *pgxpool.Pool) *productVariantRepo {
+	return &productVariantRepo{
+		db: db,
+	}
+}
+
+func (r *productVariantRepo) Create(ctx context.Context, req *models.CreateProductVariant) (int, error) {
+	var deleted bool
+
+	err := r.db.QueryRow(ctx,
+		`SELECT deleted_at IS NOT NULL FROM products WHERE product_id = $1`,
+		req.ProductId,
+	).Scan(&deleted)
+	if err == pgx.ErrNoRows {
+		return 0, errors.New("Product is not found")
+	} else if err != nil {
+		return 0, err
+	}
+
+	if deleted {
+		return 0, errors.New("Product is deleted")
+	}
+
+	return createProductVariant(ctx, r.db, req)
+}
+
+func createProductVariant(ctx context.Context, q querier, req *models.CreateProductVariant) (int, error) {
+	var id int
+
+	sku := strings.ToUpper(strings.TrimSpace(req.Sku))
+	if len(sku) <= 0 {
+		return 0, errors.New("sku is required")
+	}
+
+	err := q.QueryRow(ctx, `
+		INSERT INTO product_variants(
+			product_id,
+			sku,
+			size,
+			color,
+			frame_material
+		)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING variant_id
+	`,
+		req.ProductId,
+		sku,
+		helper.NewNullString(strings.TrimSpace(req.Size)),
+		helper.NewNullString(strings.TrimSpace(req.Color)),
+		helper.NewNullString(strings.TrimSpace(req.FrameMaterial)),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *productVariantRepo) GetByID(ctx context.Context, req *models.ProductVariantPrimaryKey) (*models.ProductVariant, error) {
+
+	variants, err := productVariants(ctx, r.db, []int{req.ProductId}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, variant := range variants[req.ProductId] {
+		if variant.VariantId == req.VariantId {
+			return variant, nil
+		}
+	}
+
+	return nil, pgx.ErrNoRows
+}
+
+func (r *productVariantRepo) GetList(ctx context.Context, req *models.GetListProductVariantRequest) (resp *models.GetListProductVariantResponse, err error) {
+
+	resp = &models.GetListProductVariantResponse{}
+
+	variants, err := productVariants(ctx, r.db, []int{req.ProductId}, req.StoreId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Variants = variants[req.ProductId]
+	resp.Count = len(resp.Variants)
+
+	var sizes, colors, materials = map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, variant := range resp.Variants {
+		sizes[variant.Size] = true
+		colors[variant.Color] = true
+		materials[variant.FrameMaterial] = true
+	}
+
+	resp.Sizes = variantOptions(sizes)
+	resp.Colors = variantOptions(colors)
+	resp.FrameMaterials = variantOptions(materials)
+
+	return resp, nil
+}
+
+// variantOptions lists the options of an axis, a product that does not vary along it has none.
+func variantOptions(options map[string]bool) []string {
+	var list = []string{}
+
+	for option := range options {
+		if len(option) > 0 {
+			list = append(list, option)
+		}
+	}
+	sort.Strings(list)
+
+	return list
+}
+
+func (r *productVariantRepo) Update(ctx context.Context, req *models.UpdateProductVariant) (int64, error) {
+	var (
+		query  string
+		params map[string]interface{}
+	)
+
+	sku := strings.ToUpper(strings.TrimSpace(req.Sku))
+	if len(sku) <= 0 {
+		return 0, errors.New("sku is required")
+	}
+
+	query = `
+		UPDATE
+		product_variants
+		SET
+			sku = :sku,
+			size = :size,
+			color = :color,
+			frame_material = :frame_material,
+			version = version + 1
+		WHERE variant_id = :variant_id AND product_id = :product_id AND version = :version
+	`
+
+	params = map[string]interface{}{
+		"variant_id":     req.VariantId,
+		"product_id":     req.ProductId,
+		"sku":            sku,
+		"size":           helper.NewNullString(strings.TrimSpace(req.Size)),
+		"color":          helper.NewNullString(strings.TrimSpace(req.Color)),
+		"frame_material": helper.NewNullString(strings.TrimSpace(req.FrameMaterial)),
+		"version":        req.Version,
+	}
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// Delete removes a variant that was never stocked or sold, one with history stays.
+func (r *productVariantRepo) Delete(ctx context.Context, req *models.ProductVariantPrimaryKey) (int64, error) {
+	var used bool
+
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			EXISTS (SELECT 1 FROM stocks WHERE variant_id = $1 AND COALESCE(quantity, 0) <> 0)
+			OR EXISTS (SELECT 1 FROM order_items WHERE variant_id = $1)
+			OR EXISTS (SELECT 1 FROM purchase_order_items WHERE variant_id = $1)
+	`, req.VariantId).Scan(&used)
+	if err != nil {
+		return 0, err
+	}
+
+	if used {
+		return 0, errors.New("Variant with stock, orders or purchase orders can not be deleted")
+	}
+
+	result, err := r.db.Exec(ctx,
+		`DELETE FROM product_variants WHERE variant_id = $1 AND product_id = $2 AND version = $3`,
+		req.VariantId,
+		req.ProductId,
+		req.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// productVariants loads the variants of the products with their availability in every
+// store that stocks them, or in the one store when storeId is set.
+func productVariants(ctx context.Context, q querier, productIds []int, storeId int) (map[int][]*models.ProductVariant, error) {
+	var variants = map[int][]*models.ProductVariant{}
+
+	if len(productIds) <= 0 {
+		return variants, nil
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT
+			v.variant_id,
+			v.product_id,
+			v.sku,
+			COALESCE(v.size, ''),
+			COALESCE(v.color, ''),
+			COALESCE(v.frame_material, ''),
+			v.version,
+			CAST(v.created_at AS VARCHAR),
+			COALESCE(
+				JSONB_AGG (
+					JSONB_BUILD_OBJECT (
+						'store_id', s.store_id,
+						'store_name', st.store_name,
+						'quantity', COALESCE(s.quantity, 0),
+						'reserved', COALESCE(sr.reserved, 0),
+						'available', COALESCE(s.quantity, 0) - COALESCE(sr.reserved, 0)
+					) ORDER BY s.store_id
+				) FILTER (WHERE s.store_id IS NOT NULL),
+				'[]'
+			)
+		FROM product_variants AS v
+		LEFT JOIN stocks AS s ON s.variant_id = v.variant_id AND ($2 <= 0 OR s.store_id = $2)
+		LEFT JOIN stores AS st ON st.store_id = s.store_id
+		LEFT JOIN (
+			SELECT
+				store_id,
+				variant_id,
+				SUM(quantity) AS reserved
+			FROM stock_reservations
+			WHERE status = $3
+			GROUP BY store_id, variant_id
+		) AS sr ON sr.store_id = s.store_id AND sr.variant_id = s.variant_id
+		WHERE v.product_id = ANY($1)
+		GROUP BY v.variant_id
+		ORDER BY v.product_id, v.size, v.color, v.frame_material, v.variant_id
+	`, productIds, storeId, models.ReservationStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			variant models.ProductVariant
+			stores  pgtype.JSONB
+		)
+
+		err = rows.Scan(
+			&variant.VariantId,
+			&variant.ProductId,
+			&variant.Sku,
+			&variant.Size,
+			&variant.Color,
+			&variant.FrameMaterial,
+			&variant.Version,
+			&variant.CreatedAt,
+			&stores,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = stores.AssignTo(&variant.Stores)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, stock := range variant.Stores {
+			variant.Quantity += stock.Quantity
+			variant.Available += stock.Available
+		}
+
+		variants[variant.ProductId] = append(variants[variant.ProductId], &variant)
+	}
+
+	return variants, rows.Err()
+}
+
+// productVariant resolves the variant a line is for. A product sold as a single variant can
+// be given by its product alone, one that comes in several needs the variant.
+func productVariant(ctx context.Context, q querier, productId, variantId int) (int, int, error) {
+
+	if variantId > 0 {
+		var variantProductId int
+
+		err := q.QueryRow(ctx,
+			`SELECT product_id FROM product_variants WHERE variant_id = $1`,
+			variantId,
+		).Scan(&variantProductId)
+		if err == pgx.ErrNoRows {
+			return 0, 0, errors.New("Variant is not found")
+		} else if err != nil {
+			return 0, 0, err
+		}
+
+		if productId > 0 && productId != variantProductId {
+			return 0, 0, fmt.Errorf("variant %d is not a variant of product %d", variantId, productId)
+		}
+
+		return variantProductId, variantId, nil
+	}
+
+	if productId <= 0 {
+		return 0, 0, errors.New("product_id or variant_id is required")
+	}
+
+	var (
+		count int
+		id    int
+	)
+
+	err := q.QueryRow(ctx,
+		`SELECT COUNT(*), COALESCE(MIN(variant_id), 0) FROM product_variants WHERE product_id = $1`,
+		productId,
+	).Scan(&count, &id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case count <= 0:
+		return 0, 0, errors.New("Product is not found")
+	case count > 1:
+		return 0, 0, fmt.Errorf("product %d comes in several variants, variant_id is required", productId)
+	}
+
+	return productId, id, nil
+}
@@ -0,0 +1,615 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type purchaseOrderRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPurchaseOrderRepo(db *pgxpool.Pool) *purchaseOrderRepo {
+	return &purchaseOrderRepo{
+		db: db,
+	}
+}
+
+func (r *purchaseOrderRepo) Create(ctx context.Context, req *models.CreatePurchaseOrder) (int, error) {
+	var (
+		query string
+		id    int
+	)
+
+	currency, err := currencyOrDefault(req.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query = `
+		INSERT INTO purchase_orders(
+			supplier_id,
+			status,
+			expected_date,
+			note,
+			currency
+		)
+		VALUES ($1, $2, $3, $4, $5) RETURNING purchase_order_id
+	`
+
+	err = tx.QueryRow(ctx, query,
+		req.SupplierId,
+		models.PurchaseOrderStatusDraft,
+		helper.NewNullString(req.ExpectedDate),
+		helper.NewNullString(req.Note),
+		currency,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range req.Items {
+		item.PurchaseOrderId = id
+
+		err = r.addItem(ctx, tx, item, currency)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *purchaseOrderRepo) GetByID(ctx context.Context, req *models.PurchaseOrderPrimaryKey) (*models.PurchaseOrder, error) {
+
+	var (
+		query         string
+		purchaseOrder models.PurchaseOrder
+		items         pgtype.JSONB
+	)
+
+	query = `
+		WITH purchase_order_item_data AS (
+			SELECT
+				poi.purchase_order_id AS purchase_order_id,
+				SUM(poi.quantity * poi.cost_price) AS total_cost,
+				JSONB_AGG (
+					JSONB_BUILD_OBJECT (
+						'purchase_order_id', poi.purchase_order_id,
+						'item_id', poi.item_id,
+						'product_id', poi.product_id,
+						'variant_id', poi.variant_id,
+						'store_id', poi.store_id,
+						'quantity', poi.quantity,
+						'received_quantity', poi.received_quantity,
+						'cost_price', JSONB_BUILD_OBJECT('amount', poi.cost_price, 'currency', po.currency)
+					) ORDER BY poi.item_id
+				) AS items
+			FROM purchase_order_items AS poi
+			JOIN purchase_orders AS po ON po.purchase_order_id = poi.purchase_order_id
+			WHERE poi.purchase_order_id = $1
+			GROUP BY poi.purchase_order_id
+		)
+		SELECT
+			po.purchase_order_id,
+			po.supplier_id,
+
+			s.supplier_id,
+			s.supplier_name,
+			COALESCE(s.phone, ''),
+			COALESCE(s.email, ''),
+			COALESCE(s.street, ''),
+			COALESCE(s.city, ''),
+			COALESCE(s.state, ''),
+			COALESCE(s.zip_code, ''),
+
+			po.status,
+			CAST(po.order_date::timestamp AS VARCHAR),
+			COALESCE(CAST(po.expected_date::timestamp AS VARCHAR), ''),
+			COALESCE(po.note, ''),
+			po.currency,
+			COALESCE(poi.total_cost, 0),
+			COALESCE(poi.items, '[]'),
+			po.version
+
+		FROM purchase_orders AS po
+		JOIN suppliers AS s ON s.supplier_id = po.supplier_id
+		LEFT JOIN purchase_order_item_data AS poi ON poi.purchase_order_id = po.purchase_order_id
+		WHERE po.purchase_order_id = $1
+	`
+	purchaseOrder.SupplierData = &models.Supplier{}
+
+	err := r.db.QueryRow(ctx, query, req.PurchaseOrderId).Scan(
+		&purchaseOrder.PurchaseOrderId,
+		&purchaseOrder.SupplierId,
+
+		&purchaseOrder.SupplierData.SupplierId,
+		&purchaseOrder.SupplierData.SupplierName,
+		&purchaseOrder.SupplierData.Phone,
+		&purchaseOrder.SupplierData.Email,
+		&purchaseOrder.SupplierData.Street,
+		&purchaseOrder.SupplierData.City,
+		&purchaseOrder.SupplierData.State,
+		&purchaseOrder.SupplierData.ZipCode,
+
+		&purchaseOrder.Status,
+		&purchaseOrder.OrderDate,
+		&purchaseOrder.ExpectedDate,
+		&purchaseOrder.Note,
+		&purchaseOrder.Currency,
+		&purchaseOrder.TotalCost.Amount,
+		&items,
+		&purchaseOrder.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+	purchaseOrder.TotalCost.Currency = purchaseOrder.Currency
+
+	items.AssignTo(&purchaseOrder.Items)
+
+	return &purchaseOrder, nil
+}
+
+func (r *purchaseOrderRepo) GetList(ctx context.Context, req *models.GetListPurchaseOrderRequest) (resp *models.GetListPurchaseOrderResponse, err error) {
+
+	resp = &models.GetListPurchaseOrderResponse{}
+
+	var (
+		query  string
+		filter = " WHERE TRUE "
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		params = map[string]interface{}{}
+	)
+
+	query = `
+		SELECT
+			COUNT(*) OVER(),
+			po.purchase_order_id,
+			po.supplier_id,
+			s.supplier_id,
+			s.supplier_name,
+			po.status,
+			CAST(po.order_date::timestamp AS VARCHAR),
+			COALESCE(CAST(po.expected_date::timestamp AS VARCHAR), ''),
+			COALESCE(po.note, ''),
+			po.currency,
+			COALESCE(
+				(
+					SELECT SUM(poi.quantity * poi.cost_price)
+					FROM purchase_order_items AS poi
+					WHERE poi.purchase_order_id = po.purchase_order_id
+				), 0
+			),
+			po.version
+		FROM purchase_orders AS po
+		JOIN suppliers AS s ON s.supplier_id = po.supplier_id
+	`
+
+	if req.SupplierId > 0 {
+		filter += " AND po.supplier_id = :supplier_id "
+		params["supplier_id"] = req.SupplierId
+	}
+
+	if req.StoreId > 0 {
+		filter += " AND EXISTS (SELECT 1 FROM purchase_order_items AS poi WHERE poi.purchase_order_id = po.purchase_order_id AND poi.store_id = :store_id) "
+		params["store_id"] = req.StoreId
+	}
+
+	if len(req.Status) > 0 {
+		filter += " AND po.status = :status "
+		params["status"] = req.Status
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += filter + " ORDER BY po.purchase_order_id DESC " + offset + limit
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var purchaseOrder models.PurchaseOrder
+		purchaseOrder.SupplierData = &models.Supplier{}
+
+		err = rows.Scan(
+			&resp.Count,
+			&purchaseOrder.PurchaseOrderId,
+			&purchaseOrder.SupplierId,
+			&purchaseOrder.SupplierData.SupplierId,
+			&purchaseOrder.SupplierData.SupplierName,
+			&purchaseOrder.Status,
+			&purchaseOrder.OrderDate,
+			&purchaseOrder.ExpectedDate,
+			&purchaseOrder.Note,
+			&purchaseOrder.Currency,
+			&purchaseOrder.TotalCost.Amount,
+			&purchaseOrder.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		purchaseOrder.TotalCost.Currency = purchaseOrder.Currency
+
+		resp.PurchaseOrders = append(resp.PurchaseOrders, &purchaseOrder)
+	}
+
+	return resp, nil
+}
+
+func (r *purchaseOrderRepo) Update(ctx context.Context, req *models.UpdatePurchaseOrder) (int64, error) {
+	var (
+		query  string
+		params map[string]interface{}
+	)
+
+	query = `
+		UPDATE
+		purchase_orders
+		SET
+			supplier_id = :supplier_id,
+			expected_date = :expected_date,
+			note = :note,
+			version = version + 1
+		WHERE purchase_order_id = :purchase_order_id AND status = :status AND version = :version
+	`
+
+	params = map[string]interface{}{
+		"purchase_order_id": req.PurchaseOrderId,
+		"supplier_id":       req.SupplierId,
+		"expected_date":     helper.NewNullString(req.ExpectedDate),
+		"note":              helper.NewNullString(req.Note),
+		"status":            models.PurchaseOrderStatusDraft,
+		"version":           req.Version,
+	}
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// UpdateStatus only moves a purchase order between draft and sent,
+// the received statuses are set by Receive.
+func (r *purchaseOrderRepo) UpdateStatus(ctx context.Context, req *models.UpdatePurchaseOrderStatus) (int64, error) {
+	var from string
+
+	switch req.Status {
+	case models.PurchaseOrderStatusSent:
+		from = models.PurchaseOrderStatusDraft
+	case models.PurchaseOrderStatusDraft:
+		from = models.PurchaseOrderStatusSent
+	default:
+		return 0, errors.New("status can only be changed to draft or sent")
+	}
+
+	query := `
+		UPDATE purchase_orders
+		SET status = $1, version = version + 1
+		WHERE purchase_order_id = $2 AND status = $3 AND version = $4
+			AND EXISTS (SELECT 1 FROM purchase_order_items WHERE purchase_order_id = $2)
+	`
+
+	result, err := r.db.Exec(ctx, query, req.Status, req.PurchaseOrderId, from, req.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *purchaseOrderRepo) Delete(ctx context.Context, req *models.PurchaseOrderPrimaryKey) (int64, error) {
+	query := `
+		DELETE
+		FROM purchase_orders
+		WHERE purchase_order_id = $1 AND status = $2 AND version = $3
+	`
+
+	result, err := r.db.Exec(ctx, query, req.PurchaseOrderId, models.PurchaseOrderStatusDraft, req.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// ------------------------------------------------------------------------------------------------------------
+func (r *purchaseOrderRepo) AddItem(ctx context.Context, req *models.CreatePurchaseOrderItem) error {
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	status, currency, err := r.lockStatus(ctx, tx, req.PurchaseOrderId)
+	if err != nil {
+		return err
+	}
+
+	if status != models.PurchaseOrderStatusDraft {
+		return errors.New("items can only be added to a draft purchase order")
+	}
+
+	err = r.addItem(ctx, tx, req, currency)
+	if err != nil {
+		return err
+	}
+
+	// the items are part of the purchase order, a stale ETag must not overwrite them
+	_, err = tx.Exec(ctx, `UPDATE purchase_orders SET version = version + 1 WHERE purchase_order_id = $1`, req.PurchaseOrderId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RemoveItem deletes the line of a draft purchase order of the given version.
+func (r *purchaseOrderRepo) RemoveItem(ctx context.Context, req *models.PurchaseOrderItemPrimaryKey) (int64, error) {
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx,
+		`UPDATE purchase_orders SET version = version + 1 WHERE purchase_order_id = $1 AND status = $2 AND version = $3`,
+		req.PurchaseOrderId,
+		models.PurchaseOrderStatusDraft,
+		req.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.RowsAffected() <= 0 {
+		return 0, nil
+	}
+
+	removed, err := tx.Exec(ctx,
+		`DELETE FROM purchase_order_items WHERE purchase_order_id = $1 AND item_id = $2`,
+		req.PurchaseOrderId,
+		req.ItemId,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if removed.RowsAffected() <= 0 {
+		return 0, errors.New("purchase order item is not found")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return removed.RowsAffected(), nil
+}
+
+// Receive books the delivered quantities of a sent purchase order into stocks
+// of each line's target store and records the cost price paid for them.
+func (r *purchaseOrderRepo) Receive(ctx context.Context, req *models.ReceivePurchaseOrder) error {
+
+	if len(req.Lines) <= 0 {
+		return errors.New("no lines to receive")
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	status, currency, err := r.lockStatus(ctx, tx, req.PurchaseOrderId)
+	if err != nil {
+		return err
+	}
+
+	if status != models.PurchaseOrderStatusSent && status != models.PurchaseOrderStatusPartiallyReceived {
+		return errors.New("only sent purchase orders can be received")
+	}
+
+	for _, line := range req.Lines {
+		var item = models.PurchaseOrderItem{CostPrice: money.Zero(currency)}
+
+		if line.Quantity <= 0 {
+			return fmt.Errorf("invalid quantity for item %d", line.ItemId)
+		}
+
+		err = tx.QueryRow(ctx, `
+			SELECT
+				product_id,
+				variant_id,
+				store_id,
+				quantity,
+				received_quantity,
+				cost_price
+			FROM purchase_order_items
+			WHERE purchase_order_id = $1 AND item_id = $2
+			FOR UPDATE
+		`, req.PurchaseOrderId, line.ItemId).Scan(
+			&item.ProductId,
+			&item.VariantId,
+			&item.StoreId,
+			&item.Quantity,
+			&item.ReceivedQuantity,
+			&item.CostPrice.Amount,
+		)
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("item %d is not found", line.ItemId)
+		} else if err != nil {
+			return err
+		}
+
+		if item.ReceivedQuantity+line.Quantity > item.Quantity {
+			return fmt.Errorf("item %d: received quantity exceeds ordered quantity", line.ItemId)
+		}
+
+		if !line.CostPrice.Amount.IsZero() {
+			cost, err := line.CostPrice.In(currency)
+			if err != nil {
+				return err
+			}
+
+			if cost.Amount.IsNegative() {
+				return fmt.Errorf("item %d: cost price can not be negative", line.ItemId)
+			}
+
+			item.CostPrice = money.New(cost.Amount, currency)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE purchase_order_items
+			SET
+				received_quantity = received_quantity + $1,
+				cost_price = $2
+			WHERE purchase_order_id = $3 AND item_id = $4
+		`, line.Quantity, item.CostPrice.Amount, req.PurchaseOrderId, line.ItemId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO purchase_receipts(purchase_order_id, item_id, quantity, cost_price)
+			VALUES ($1, $2, $3, $4)
+		`, req.PurchaseOrderId, line.ItemId, line.Quantity, item.CostPrice.Amount)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO stocks(store_id, product_id, variant_id, quantity)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (store_id, variant_id)
+			DO UPDATE SET quantity = COALESCE(stocks.quantity, 0) + EXCLUDED.quantity, version = stocks.version + 1
+		`, item.StoreId, item.ProductId, item.VariantId, line.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE purchase_orders
+		SET status = (
+			CASE
+				WHEN (
+					SELECT BOOL_AND(received_quantity >= quantity)
+					FROM purchase_order_items
+					WHERE purchase_order_id = $1
+				) THEN $2
+				ELSE $3
+			END
+		),
+		version = version + 1
+		WHERE purchase_order_id = $1
+	`, req.PurchaseOrderId, models.PurchaseOrderStatusReceived, models.PurchaseOrderStatusPartiallyReceived)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// lockStatus locks the purchase order, giving its status and currency.
+func (r *purchaseOrderRepo) lockStatus(ctx context.Context, tx pgx.Tx, purchaseOrderId int) (string, string, error) {
+	var status, currency string
+
+	err := tx.QueryRow(ctx,
+		`SELECT status, currency FROM purchase_orders WHERE purchase_order_id = $1 FOR UPDATE`,
+		purchaseOrderId,
+	).Scan(&status, &currency)
+	if err == pgx.ErrNoRows {
+		return "", "", errors.New("purchase order is not found")
+	}
+
+	return status, currency, err
+}
+
+// addItem adds a line to the purchase order, its cost price has to be in the currency of the order.
+func (r *purchaseOrderRepo) addItem(ctx context.Context, tx pgx.Tx, req *models.CreatePurchaseOrderItem, currency string) error {
+
+	if req.Quantity <= 0 {
+		return errors.New("Invalid quantity")
+	}
+
+	cost, err := req.CostPrice.In(currency)
+	if err != nil {
+		return err
+	}
+
+	if cost.Amount.IsNegative() {
+		return errors.New("cost price can not be negative")
+	}
+
+	productId, variantId, err := productVariant(ctx, tx, req.ProductId, req.VariantId)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO purchase_order_items(
+			purchase_order_id,
+			item_id,
+			product_id,
+			variant_id,
+			store_id,
+			quantity,
+			cost_price
+		)
+		VALUES (
+			$1,
+			(
+				SELECT COALESCE(MAX(item_id), 0) + 1 FROM purchase_order_items WHERE purchase_order_id = $1
+			)
+			, $2, $3, $4, $5, $6)
+	`
+
+	_, err = tx.Exec(ctx, query,
+		req.PurchaseOrderId,
+		productId,
+		variantId,
+		req.StoreId,
+		req.Quantity,
+		cost.Amount.Round(money.Cents),
+	)
+
+	return err
+}
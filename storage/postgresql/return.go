@@ -0,0 +1,546 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"app/pkg/money"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type returnRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewReturnRepo(db *pgxpool.Pool) *returnRepo {
+	return &returnRepo{
+		db: db,
+	}
+}
+
+// Create opens an RMA against a completed order, the refund of every line is
+// priced from the original list price, line discount and the order's promo code.
+func (r *returnRepo) Create(ctx context.Context, req *models.CreateReturn) (int, error) {
+	var (
+		id       int
+		status   int16
+		returned = map[int]int{}
+	)
+
+	if len(req.Items) <= 0 {
+		return 0, errors.New("no items to return")
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`SELECT order_status FROM orders WHERE order_id = $1 FOR UPDATE`,
+		req.OrderId,
+	).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return 0, errors.New("Order is not found")
+	} else if err != nil {
+		return 0, err
+	}
+
+	if status != models.OrderStatusCompleted {
+		return 0, errors.New("only completed orders can be returned")
+	}
+
+	amount, orderItems, err := orderAmount(ctx, tx, req.OrderId)
+	if err != nil {
+		return 0, err
+	}
+
+	refundAmount := money.Zero(amount.Total.Currency)
+
+	items := map[int]*models.OrderItem{}
+	for _, item := range orderItems {
+		items[item.ItemId] = item
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT
+			ri.item_id,
+			SUM(ri.quantity)
+		FROM return_items AS ri
+		JOIN returns AS rt ON rt.return_id = ri.return_id
+		WHERE rt.order_id = $1 AND rt.status <> $2
+		GROUP BY ri.item_id
+	`, req.OrderId, models.ReturnStatusRejected)
+	if err != nil {
+		return 0, err
+	}
+
+	for rows.Next() {
+		var itemId, quantity int
+
+		err = rows.Scan(&itemId, &quantity)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		returned[itemId] = quantity
+	}
+	rows.Close()
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO returns(order_id, status, reason, currency)
+		VALUES ($1, $2, $3, $4) RETURNING return_id
+	`, req.OrderId, models.ReturnStatusRequested, helper.NewNullString(req.Reason), refundAmount.Currency).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range req.Items {
+		item, ok := items[line.ItemId]
+		if !ok {
+			return 0, fmt.Errorf("item %d is not found in order %d", line.ItemId, req.OrderId)
+		}
+
+		if line.Quantity <= 0 {
+			return 0, fmt.Errorf("invalid quantity for item %d", line.ItemId)
+		}
+
+		if returned[line.ItemId]+line.Quantity > item.Quantity {
+			return 0, fmt.Errorf("item %d: return quantity exceeds sold quantity", line.ItemId)
+		}
+		returned[line.ItemId] += line.Quantity
+
+		refund := lineRefund(amount, item, line.Quantity)
+		refundAmount.Amount = refundAmount.Amount.Add(refund.Amount)
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO return_items(
+				return_id,
+				item_id,
+				order_id,
+				product_id,
+				quantity,
+				reason,
+				refund_amount
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`,
+			id,
+			line.ItemId,
+			req.OrderId,
+			item.ProductId,
+			line.Quantity,
+			helper.NewNullString(line.Reason),
+			refund.Amount,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE returns SET refund_amount = $2 WHERE return_id = $1`,
+		id,
+		refundAmount.Amount,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *returnRepo) GetByID(ctx context.Context, req *models.ReturnPrimaryKey) (*models.Return, error) {
+
+	var (
+		query string
+		rma   models.Return
+		items pgtype.JSONB
+	)
+
+	query = `
+		SELECT
+			rt.return_id,
+			rt.order_id,
+			rt.status,
+			COALESCE(rt.reason, ''),
+			COALESCE(rt.store_id, 0),
+			rt.refund_amount,
+			rt.currency,
+			rt.refund_method,
+			COALESCE(rt.note, ''),
+			CAST(rt.created_at AS VARCHAR),
+			COALESCE(CAST(rt.processed_at AS VARCHAR), ''),
+			COALESCE(
+				(
+					SELECT
+						JSONB_AGG (
+							JSONB_BUILD_OBJECT (
+								'return_id', ri.return_id,
+								'item_id', ri.item_id,
+								'product_id', ri.product_id,
+								'quantity', ri.quantity,
+								'reason', COALESCE(ri.reason, ''),
+								'restock', ri.restock,
+								'refund_amount', JSONB_BUILD_OBJECT('amount', ri.refund_amount, 'currency', rt.currency)
+							) ORDER BY ri.item_id
+						)
+					FROM return_items AS ri
+					WHERE ri.return_id = rt.return_id
+				), '[]'
+			)
+		FROM returns AS rt
+		WHERE rt.return_id = $1
+	`
+
+	err := r.db.QueryRow(ctx, query, req.ReturnId).Scan(
+		&rma.ReturnId,
+		&rma.OrderId,
+		&rma.Status,
+		&rma.Reason,
+		&rma.StoreId,
+		&rma.RefundAmount.Amount,
+		&rma.RefundAmount.Currency,
+		&rma.RefundMethod,
+		&rma.Note,
+		&rma.CreatedAt,
+		&rma.ProcessedAt,
+		&items,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	items.AssignTo(&rma.Items)
+
+	return &rma, nil
+}
+
+func (r *returnRepo) GetList(ctx context.Context, req *models.GetListReturnRequest) (resp *models.GetListReturnResponse, err error) {
+
+	resp = &models.GetListReturnResponse{}
+
+	var (
+		query  string
+		filter = " WHERE TRUE "
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		params = map[string]interface{}{}
+	)
+
+	query = `
+		SELECT
+			COUNT(*) OVER(),
+			rt.return_id,
+			rt.order_id,
+			rt.status,
+			COALESCE(rt.reason, ''),
+			COALESCE(rt.store_id, 0),
+			rt.refund_amount,
+			rt.currency,
+			rt.refund_method,
+			COALESCE(rt.note, ''),
+			CAST(rt.created_at AS VARCHAR),
+			COALESCE(CAST(rt.processed_at AS VARCHAR), '')
+		FROM returns AS rt
+	`
+
+	if req.OrderId > 0 {
+		filter += " AND rt.order_id = :order_id "
+		params["order_id"] = req.OrderId
+	}
+
+	if len(req.Status) > 0 {
+		filter += " AND rt.status = :status "
+		params["status"] = req.Status
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += filter + " ORDER BY rt.return_id DESC " + offset + limit
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rma models.Return
+
+		err = rows.Scan(
+			&resp.Count,
+			&rma.ReturnId,
+			&rma.OrderId,
+			&rma.Status,
+			&rma.Reason,
+			&rma.StoreId,
+			&rma.RefundAmount.Amount,
+			&rma.RefundAmount.Currency,
+			&rma.RefundMethod,
+			&rma.Note,
+			&rma.CreatedAt,
+			&rma.ProcessedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Returns = append(resp.Returns, &rma)
+	}
+
+	return resp, nil
+}
+
+// Inspect records which returned items are fit to go back on the shelf.
+func (r *returnRepo) Inspect(ctx context.Context, req *models.InspectReturn) error {
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	status, _, err := r.lock(ctx, tx, req.ReturnId)
+	if err != nil {
+		return err
+	}
+
+	if status != models.ReturnStatusRequested {
+		return errors.New("only requested returns can be inspected")
+	}
+
+	for _, item := range req.Items {
+		result, err := tx.Exec(ctx,
+			`UPDATE return_items SET restock = $3 WHERE return_id = $1 AND item_id = $2`,
+			req.ReturnId,
+			item.ItemId,
+			item.Restock,
+		)
+		if err != nil {
+			return err
+		}
+
+		if result.RowsAffected() <= 0 {
+			return fmt.Errorf("item %d is not found in return %d", item.ItemId, req.ReturnId)
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE returns SET status = $2, note = COALESCE($3, note) WHERE return_id = $1`,
+		req.ReturnId,
+		models.ReturnStatusInspected,
+		helper.NewNullString(req.Note),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Approve restocks the items marked for restock into the chosen store, the order's store
+// when none is given, and updates the order's return status. With the store credit refund
+// method the refund amount is credited to the customer right away.
+func (r *returnRepo) Approve(ctx context.Context, req *models.ApproveReturn) error {
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	status, orderId, err := r.lock(ctx, tx, req.ReturnId)
+	if err != nil {
+		return err
+	}
+
+	if status != models.ReturnStatusRequested && status != models.ReturnStatusInspected {
+		return errors.New("return is already processed")
+	}
+
+	refundMethod := req.RefundMethod
+	if len(refundMethod) <= 0 {
+		refundMethod = models.ReturnRefundMethodOriginal
+	}
+
+	if refundMethod != models.ReturnRefundMethodOriginal && refundMethod != models.ReturnRefundMethodStoreCredit {
+		return errors.New("invalid refund method")
+	}
+
+	storeId := req.StoreId
+	if storeId <= 0 {
+		err = tx.QueryRow(ctx, `SELECT store_id FROM orders WHERE order_id = $1`, orderId).Scan(&storeId)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO stocks(store_id, product_id, variant_id, quantity)
+		SELECT
+			$2,
+			oi.product_id,
+			oi.variant_id,
+			SUM(ri.quantity)
+		FROM return_items AS ri
+		JOIN order_items AS oi ON oi.order_id = ri.order_id AND oi.item_id = ri.item_id
+		WHERE ri.return_id = $1 AND ri.restock
+		GROUP BY oi.product_id, oi.variant_id
+		ON CONFLICT (store_id, variant_id)
+		DO UPDATE SET quantity = COALESCE(stocks.quantity, 0) + EXCLUDED.quantity, version = stocks.version + 1
+	`, req.ReturnId, storeId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE returns
+		SET
+			status = $2,
+			store_id = $3,
+			refund_method = $4,
+			note = COALESCE($5, note),
+			processed_at = now()
+		WHERE return_id = $1
+	`, req.ReturnId, models.ReturnStatusApproved, storeId, refundMethod, helper.NewNullString(req.Note))
+	if err != nil {
+		return err
+	}
+
+	if refundMethod == models.ReturnRefundMethodStoreCredit {
+		var (
+			customerId   int
+			refundAmount money.Money
+		)
+
+		err = tx.QueryRow(ctx, `
+			SELECT
+				COALESCE(o.customer_id, 0),
+				rt.refund_amount,
+				rt.currency
+			FROM returns AS rt
+			JOIN orders AS o ON o.order_id = rt.order_id
+			WHERE rt.return_id = $1
+		`, req.ReturnId).Scan(&customerId, &refundAmount.Amount, &refundAmount.Currency)
+		if err != nil {
+			return err
+		}
+
+		if customerId <= 0 {
+			return errors.New("Order has no customer to issue store credit to")
+		}
+
+		if refundAmount.Amount.IsPositive() {
+			err = customerCreditEntry(ctx, tx, customerId, models.CreditLedgerReturn, refundAmount, orderId, req.ReturnId, 0)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE orders AS o
+		SET return_status = (
+			CASE
+				WHEN ret.quantity >= sold.quantity THEN $2
+				WHEN ret.quantity > 0 THEN $3
+				ELSE $4
+			END
+		), version = o.version + 1
+		FROM
+			(
+				SELECT COALESCE(SUM(ri.quantity), 0) AS quantity
+				FROM return_items AS ri
+				JOIN returns AS rt ON rt.return_id = ri.return_id
+				WHERE rt.order_id = $1 AND rt.status = $5
+			) AS ret,
+			(
+				SELECT COALESCE(SUM(quantity), 0) AS quantity
+				FROM order_items
+				WHERE order_id = $1
+			) AS sold
+		WHERE o.order_id = $1
+	`,
+		orderId,
+		models.OrderReturnStatusReturned,
+		models.OrderReturnStatusPartiallyReturned,
+		models.OrderReturnStatusNone,
+		models.ReturnStatusApproved,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *returnRepo) Reject(ctx context.Context, req *models.RejectReturn) error {
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	status, _, err := r.lock(ctx, tx, req.ReturnId)
+	if err != nil {
+		return err
+	}
+
+	if status != models.ReturnStatusRequested && status != models.ReturnStatusInspected {
+		return errors.New("return is already processed")
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE returns
+		SET
+			status = $2,
+			refund_amount = 0,
+			note = COALESCE($3, note),
+			processed_at = now()
+		WHERE return_id = $1
+	`, req.ReturnId, models.ReturnStatusRejected, helper.NewNullString(req.Note))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *returnRepo) lock(ctx context.Context, tx pgx.Tx, returnId int) (string, int, error) {
+	var (
+		status  string
+		orderId int
+	)
+
+	err := tx.QueryRow(ctx,
+		`SELECT status, order_id FROM returns WHERE return_id = $1 FOR UPDATE`,
+		returnId,
+	).Scan(&status, &orderId)
+	if err == pgx.ErrNoRows {
+		return "", 0, errors.New("return is not found")
+	}
+
+	return status, orderId, err
+}
@@ -4,7 +4,6 @@ import (
 	"app/api/models"
 	"app/pkg/helper"
 	"context"
-	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -35,13 +34,14 @@ func (r *staffRepo) Create(ctx context.Context, req *models.CreateStaff) (int, e
 			phone,
 			active,
 			store_id,
-			manager_id
+			manager_id,
+			can_discount
 		)
 		VALUES (
 			(
 				SELECT MAX(staff_id) + 1 FROM staffs
 			),
-			$1, $2, $3, $4, $5, $6, $7) RETURNING staff_id
+			$1, $2, $3, $4, $5, $6, $7, $8) RETURNING staff_id
 	`
 	err := r.db.QueryRow(ctx, query,
 		req.FirstName,
@@ -51,6 +51,7 @@ func (r *staffRepo) Create(ctx context.Context, req *models.CreateStaff) (int, e
 		req.Active,
 		req.StoreId,
 		helper.NewNullInt32(req.ManagerId),
+		req.CanDiscount,
 	).Scan(&id)
 	if err != nil {
 		return 0, err
@@ -95,7 +96,11 @@ func (r *staffRepo) GetByID(ctx context.Context, req *models.StaffPrimaryKey) (*
 			COALESCE(s2.phone, ''),
 			s2.active,
 			s2.store_id,
-			COALESCE(s2.manager_id, 0)
+			COALESCE(s2.manager_id, 0),
+
+			COALESCE(CAST(s1.deleted_at AS VARCHAR), ''),
+			s1.can_discount,
+			s1.version
 
 			FROM staffs AS s1
 		JOIN stores ON stores.store_id = s1.store_id
@@ -133,6 +138,10 @@ func (r *staffRepo) GetByID(ctx context.Context, req *models.StaffPrimaryKey) (*
 		&staff.ManagerData.Active,
 		&staff.ManagerData.StoreId,
 		&staff.ManagerData.ManagerId,
+
+		&staff.DeletedAt,
+		&staff.CanDiscount,
+		&staff.Version,
 	)
 	if err != nil {
 		return nil, err
@@ -183,12 +192,19 @@ func (r *staffRepo) GetList(ctx context.Context, req *models.GetListStaffRequest
 			COALESCE(s2.phone, ''),
 			s2.active,
 			s2.store_id,
-			COALESCE(s2.manager_id, 0)
+			COALESCE(s2.manager_id, 0),
+			COALESCE(CAST(s1.deleted_at AS VARCHAR), ''),
+			s1.can_discount,
+			s1.version
 		FROM staffs AS s1
 		JOIN stores ON stores.store_id = s1.store_id
 		INNER JOIN staffs AS s2 ON COALESCE(s1.manager_id, s1.staff_id)= s2.staff_id
 	`
 
+	if !req.IncludeDeleted {
+		filter += " AND s1.deleted_at IS NULL "
+	}
+
 	if len(req.Search) > 0 {
 		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
 	}
@@ -240,6 +256,9 @@ func (r *staffRepo) GetList(ctx context.Context, req *models.GetListStaffRequest
 			&staff.ManagerData.Active,
 			&staff.ManagerData.StoreId,
 			&staff.ManagerData.ManagerId,
+			&staff.DeletedAt,
+			&staff.CanDiscount,
+			&staff.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -263,6 +282,11 @@ func (r *staffRepo) GetListReport(ctx context.Context, req *models.GetListReport
 	)
 	// bug if manager_id null then get dont work
 
+	rates, err := newExchangeRates(r.db, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	query = `
 		SELECT
 		COUNT(*) OVER(),
@@ -272,6 +296,7 @@ func (r *staffRepo) GetListReport(ctx context.Context, req *models.GetListReport
 			p.product_name as product,
 			oi.quantity as count,
 			(oi.list_price * oi.quantity) as total_summ,
+			o.currency,
 			CAST(o.order_date::timestamp AS VARCHAR)
 			
 		FROM staffs AS s1
@@ -309,7 +334,8 @@ func (r *staffRepo) GetListReport(ctx context.Context, req *models.GetListReport
 			&report.Category,
 			&report.Product,
 			&report.Count,
-			&report.TotalSumm,
+			&report.TotalSumm.Amount,
+			&report.TotalSumm.Currency,
 			&report.Date,
 		)
 		if err != nil {
@@ -320,6 +346,18 @@ func (r *staffRepo) GetListReport(ctx context.Context, req *models.GetListReport
 		resp.Reports = append(resp.Reports, &report)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// every line is in the currency of its order, the report is in the reporting one
+	for _, report := range resp.Reports {
+		report.TotalSumm, err = rates.convert(ctx, report.TotalSumm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return resp, nil
 }
 
@@ -340,19 +378,23 @@ func (r *staffRepo) UpdatePut(ctx context.Context, req *models.UpdateStaff) (int
 			phone = :phone,
 			active = :active,
 			store_id = :store_id,
-			manager_id = :manager_id
-		WHERE staff_id = :staff_id
+			manager_id = :manager_id,
+			can_discount = :can_discount,
+			version = version + 1
+		WHERE staff_id = :staff_id AND version = :version
 	`
 
 	params = map[string]interface{}{
-		"staff_id":   req.StaffId,
-		"first_name": req.FirstName,
-		"last_name":  req.LastName,
-		"email":      req.Email,
-		"phone":      helper.NewNullString(req.Phone),
-		"active":     req.Active,
-		"store_id":   req.StoreId,
-		"manager_id": helper.NewNullInt32(req.ManagerId),
+		"staff_id":     req.StaffId,
+		"first_name":   req.FirstName,
+		"last_name":    req.LastName,
+		"email":        req.Email,
+		"phone":        helper.NewNullString(req.Phone),
+		"active":       req.Active,
+		"store_id":     req.StoreId,
+		"manager_id":   helper.NewNullInt32(req.ManagerId),
+		"can_discount": req.CanDiscount,
+		"version":      req.Version,
 	}
 
 	query, args := helper.ReplaceQueryParams(query, params)
@@ -365,39 +407,34 @@ func (r *staffRepo) UpdatePut(ctx context.Context, req *models.UpdateStaff) (int
 	return result.RowsAffected(), nil
 }
 
-func (r *staffRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
-	var (
-		query string
-		set   string
-	)
+// staffPatchFields are the columns a PATCH may change.
+var staffPatchFields = map[string]patchField{
+	"first_name":   {kind: patchString},
+	"last_name":    {kind: patchString},
+	"email":        {kind: patchString},
+	"phone":        {kind: patchString, nullable: true},
+	"active":       {kind: patchInt},
+	"store_id":     {kind: patchInt},
+	"manager_id":   {kind: patchInt, nullable: true},
+	"can_discount": {kind: patchBool},
+}
 
-	if len(req.Fields) <= 0 {
-		return 0, errors.New("no fields")
-	}
+func (r *staffRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
 
-	i := 0
-	for key := range req.Fields {
-		if i == len(req.Fields)-1 {
-			set += fmt.Sprintf(" %s = :%s ", key, key)
-		} else {
-			set += fmt.Sprintf(" %s = :%s, ", key, key)
-		}
-		i++
+	set, args, err := patchSet(staffPatchFields, req.Fields)
+	if err != nil {
+		return 0, err
 	}
 
-	query = `
+	query := fmt.Sprintf(`
 		UPDATE
 		staffs
 		SET
-		` + set + `
-		WHERE staff_id = :staff_id
-	`
-
-	req.Fields["staff_id"] = req.ID
-
-	query, args := helper.ReplaceQueryParams(query, req.Fields)
+		%s, version = version + 1
+		WHERE staff_id = $%d AND version = $%d
+	`, set, len(args)+1, len(args)+2)
 
-	fmt.Println(query)
+	args = append(args, req.ID, req.Version)
 
 	result, err := r.db.Exec(ctx, query, args...)
 	if err != nil {
@@ -409,9 +446,24 @@ func (r *staffRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (
 
 func (r *staffRepo) Delete(ctx context.Context, req *models.StaffPrimaryKey) (int64, error) {
 	query := `
-		DELETE 
-		FROM staffs
-		WHERE staff_id = $1
+		UPDATE staffs
+			SET deleted_at = NOW(), version = version + 1
+		WHERE staff_id = $1 AND version = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, req.StaffId, req.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *staffRepo) Restore(ctx context.Context, req *models.StaffPrimaryKey) (int64, error) {
+	query := `
+		UPDATE staffs
+			SET deleted_at = NULL, version = version + 1
+		WHERE staff_id = $1 AND deleted_at IS NOT NULL
 	`
 
 	result, err := r.db.Exec(ctx, query, req.StaffId)
@@ -3,12 +3,14 @@ package postgresql
 import (
 	"app/api/models"
 	"app/pkg/helper"
+	"app/pkg/money"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 
 	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/lib/pq"
 )
@@ -25,22 +27,28 @@ func NewStockRepo(db *pgxpool.Pool) *stockRepo {
 
 func (r *stockRepo) Create(ctx context.Context, req *models.CreateStock) (int, int, error) {
 	var (
-		query     string
-		storeId   int
-		productId int
+		query   string
+		storeId int
 	)
 
+	productId, variantId, err := productVariant(ctx, r.db, req.ProductId, req.VariantId)
+	if err != nil {
+		return 0, 0, err
+	}
+
 	query = `
 		INSERT INTO stocks(
 			store_id,
 			product_id,
+			variant_id,
 			quantity
 		)
-		VALUES ($1, $2, $3) RETURNING store_id, product_id
+		VALUES ($1, $2, $3, $4) RETURNING store_id, product_id
 	`
-	err := r.db.QueryRow(ctx, query,
+	err = r.db.QueryRow(ctx, query,
 		req.StoreId,
-		req.ProductId,
+		productId,
+		variantId,
 		req.Quantity,
 	).Scan(&storeId, &productId)
 	if err != nil {
@@ -67,20 +75,40 @@ func (r *stockRepo) GetByID(ctx context.Context, req *models.StockPrimaryKey) (r
 			JSONB_AGG (
 				JSONB_BUILD_OBJECT (
 					'product_id', p.product_id,
+					'variant_id', v.variant_id,
+					'sku', v.sku,
+					'size', COALESCE(v.size, ''),
+					'color', COALESCE(v.color, ''),
+					'frame_material', COALESCE(v.frame_material, ''),
 					'product_name', p.product_name,
 					'brand_id', p.brand_id,
 					'category_id', p.category_id,
 					'model_year', p.model_year,
-					'list_price', p.list_price,
-					'quantity', s.quantity
-				)
+					'list_price', JSONB_BUILD_OBJECT('amount', p.list_price, 'currency', p.currency),
+					'quantity', s.quantity,
+					'reserved', COALESCE(sr.reserved, 0),
+					'available', COALESCE(s.quantity, 0) - COALESCE(sr.reserved, 0),
+					'reorder_point', s.reorder_point,
+					'target_level', s.target_level,
+					'version', s.version
+				) ORDER BY s.product_id, s.variant_id
 			) AS product_data
 		FROM stocks AS s
 		LEFT JOIN products AS p ON p.product_id = s.product_id
-		WHERE s.store_id = $1
+		LEFT JOIN product_variants AS v ON v.variant_id = s.variant_id
+		LEFT JOIN (
+			SELECT
+				store_id,
+				variant_id,
+				SUM(quantity) AS reserved
+			FROM stock_reservations
+			WHERE store_id = $1 AND status = 'active'
+			GROUP BY store_id, variant_id
+		) AS sr ON sr.store_id = s.store_id AND sr.variant_id = s.variant_id
+		WHERE s.store_id = $1 AND ($2 <= 0 OR s.variant_id = $2)
 		GROUP BY s.store_id
 	`
-	err = r.db.QueryRow(ctx, query, req.StoreId).Scan(
+	err = r.db.QueryRow(ctx, query, req.StoreId, req.VariantId).Scan(
 		&storeId,
 		&quantity,
 		&products,
@@ -97,6 +125,27 @@ func (r *stockRepo) GetByID(ctx context.Context, req *models.StockPrimaryKey) (r
 	return resp, nil
 }
 
+// GetItem is the stock of one variant in the store, a product sold as a single variant can
+// be given by its product alone.
+func (r *stockRepo) GetItem(ctx context.Context, req *models.StockPrimaryKey) (*models.ProductData, error) {
+
+	_, variantId, err := productVariant(ctx, r.db, req.ProductId, req.VariantId)
+	if err != nil {
+		return nil, err
+	}
+
+	stock, err := r.GetByID(ctx, &models.StockPrimaryKey{StoreId: req.StoreId, VariantId: variantId})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stock.Products) <= 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return stock.Products[0], nil
+}
+
 func (r *stockRepo) GetList(ctx context.Context, req *models.GetListStockRequest) (resp *models.GetListStockResponse, err error) {
 
 	resp = &models.GetListStockResponse{}
@@ -113,6 +162,7 @@ func (r *stockRepo) GetList(ctx context.Context, req *models.GetListStockRequest
 			COUNT(*) OVER(),
 			store_id,
 			ARRAY_AGG(product_id),
+			ARRAY_AGG(variant_id),
 			ARRAY_AGG(quantity)
 		FROM stocks
 	`
@@ -142,6 +192,7 @@ func (r *stockRepo) GetList(ctx context.Context, req *models.GetListStockRequest
 		var (
 			stock      models.GetStock
 			productIds []sql.NullInt64
+			variantIds []sql.NullInt64
 			amounts    []sql.NullInt64
 		)
 
@@ -149,6 +200,7 @@ func (r *stockRepo) GetList(ctx context.Context, req *models.GetListStockRequest
 			&resp.Count,
 			&stock.StoreId,
 			pq.Array(&productIds),
+			pq.Array(&variantIds),
 			pq.Array(&amounts),
 		)
 		if err != nil {
@@ -158,6 +210,7 @@ func (r *stockRepo) GetList(ctx context.Context, req *models.GetListStockRequest
 		for i, id := range productIds {
 			data := models.ProductData{
 				ProductId: int(id.Int64),
+				VariantId: int(variantIds[i].Int64),
 				Quantity:  int(amounts[i].Int64),
 			}
 			stock.Products = append(stock.Products, &data)
@@ -175,23 +228,46 @@ func (r *stockRepo) Update(ctx context.Context, req *models.UpdateStock) (int64,
 		params map[string]interface{}
 	)
 
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, variantId, err := productVariant(ctx, tx, req.ProductId, req.VariantId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = checkReserved(ctx, tx, req.StoreId, variantId, req.Quantity)
+	if err != nil {
+		return 0, err
+	}
+
 	query = `
 		UPDATE
 		stocks
 		SET
-			quantity = :quantity
-		WHERE store_id = :store_id AND product_id = :product_id
+			quantity = :quantity,
+			version = version + 1
+		WHERE store_id = :store_id AND variant_id = :variant_id AND version = :version
 	`
 
 	params = map[string]interface{}{
 		"store_id":   req.StoreId,
-		"product_id": req.ProductId,
+		"variant_id": variantId,
 		"quantity":   req.Quantity,
+		"version":    req.Version,
 	}
 
 	query, args := helper.ReplaceQueryParams(query, params)
 
-	result, err := r.db.Exec(ctx, query, args...)
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -199,22 +275,80 @@ func (r *stockRepo) Update(ctx context.Context, req *models.UpdateStock) (int64,
 	return result.RowsAffected(), nil
 }
 
-func (r *stockRepo) Delete(ctx context.Context, req *models.StockPrimaryKey) (int64, error) {
+// checkReserved locks the stock row and stops its quantity from going below what active
+// reservations hold. A missing row is left to the versioned update to report.
+func checkReserved(ctx context.Context, tx pgx.Tx, storeId, variantId, quantity int) error {
 
-	var (
-		storeId string
+	_, err := tx.Exec(ctx,
+		`SELECT 1 FROM stocks WHERE store_id = $1 AND variant_id = $2 FOR UPDATE`,
+		storeId,
+		variantId,
 	)
-	if req.StoreId > 0 {
-		storeId = fmt.Sprintf(" store_id = %d ", req.StoreId)
+	if err != nil {
+		return err
 	}
 
-	query := `
-		DELETE
-		FROM stocks
-		WHERE 
-	` + storeId
+	reserved, err := reservedQuantity(ctx, tx, storeId, variantId)
+	if err != nil {
+		return err
+	}
+
+	if quantity < reserved {
+		return fmt.Errorf("quantity can not go below the %d reserved by open orders", reserved)
+	}
+
+	return nil
+}
+
+// stockPatchFields are the columns a PATCH may change.
+var stockPatchFields = map[string]patchField{
+	"quantity":      {kind: patchInt},
+	"reorder_point": {kind: patchInt},
+	"target_level":  {kind: patchInt},
+}
+
+func (r *stockRepo) UpdatePatch(ctx context.Context, req *models.PatchStockRequest) (int64, error) {
+
+	set, args, err := patchSet(stockPatchFields, req.Fields)
+	if err != nil {
+		return 0, err
+	}
 
-	result, err := r.db.Exec(ctx, query, req.StoreId)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, variantId, err := productVariant(ctx, tx, req.ProductId, req.VariantId)
+	if err != nil {
+		return 0, err
+	}
+
+	if quantity, ok := req.Fields["quantity"].(float64); ok {
+		err = checkReserved(ctx, tx, req.StoreId, variantId, int(quantity))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE
+		stocks
+		SET
+		%s,
+		version = version + 1
+		WHERE store_id = $%d AND variant_id = $%d AND version = $%d
+	`, set, len(args)+1, len(args)+2, len(args)+3)
+
+	args = append(args, req.StoreId, variantId, req.Version)
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -222,20 +356,45 @@ func (r *stockRepo) Delete(ctx context.Context, req *models.StockPrimaryKey) (in
 	return result.RowsAffected(), nil
 }
 
-func (r *stockRepo) SendProduct(ctx context.Context, req *models.SendProduct) error {
-	var (
-		senderStock int
+// Delete removes the stock of one variant in the store.
+func (r *stockRepo) Delete(ctx context.Context, req *models.StockPrimaryKey) (int64, error) {
+
+	_, variantId, err := productVariant(ctx, r.db, req.ProductId, req.VariantId)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.Exec(ctx,
+		`DELETE FROM stocks WHERE store_id = $1 AND variant_id = $2 AND version = $3`,
+		req.StoreId,
+		variantId,
+		req.Version,
 	)
+	if err != nil {
+		return 0, err
+	}
 
+	return result.RowsAffected(), nil
+}
+
+func (r *stockRepo) SendProduct(ctx context.Context, req *models.SendProduct) error {
 	if req.Quantity <= 0 {
 		return errors.New("Invalid quantity")
 	}
 
-	err := r.db.QueryRow(ctx,
-		`SELECT quantity FROM stocks WHERE store_id = $1 AND product_id = $2`,
-		req.SenderId,
-		req.ProductId,
-	).Scan(&senderStock)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	productId, variantId, err := productVariant(ctx, tx, req.ProductId, req.VariantId)
+	if err != nil {
+		return err
+	}
+
+	// stock reserved by pending orders of the sender can not be sent away
+	senderStock, err := availableQuantity(ctx, tx, req.SenderId, variantId, true)
 	if err != nil {
 		return err
 	}
@@ -244,25 +403,285 @@ func (r *stockRepo) SendProduct(ctx context.Context, req *models.SendProduct) er
 		return errors.New("Sender doesn't have enough of this product")
 	}
 
-	_, err = r.db.Exec(ctx,
-		`UPDATE stocks SET quantity = quantity - $1 WHERE store_id = $2 AND product_id = $3`,
+	_, err = tx.Exec(ctx,
+		`UPDATE stocks SET quantity = quantity - $1, version = version + 1 WHERE store_id = $2 AND variant_id = $3`,
 		req.Quantity,
 		req.SenderId,
-		req.ProductId,
+		variantId,
 	)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.db.Exec(ctx,
-		`UPDATE stocks SET quantity = quantity + $1 WHERE store_id = $2 AND product_id = $3`,
-		req.Quantity,
+	// the receiver may not have stocked the variant before
+	_, err = tx.Exec(ctx, `
+		INSERT INTO stocks(store_id, product_id, variant_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (store_id, variant_id)
+		DO UPDATE SET quantity = COALESCE(stocks.quantity, 0) + EXCLUDED.quantity, version = stocks.version + 1
+	`,
 		req.ReceiverId,
-		req.ProductId,
+		productId,
+		variantId,
+		req.Quantity,
 	)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit(ctx)
+}
+
+func (r *stockRepo) UpdateThreshold(ctx context.Context, req *models.StockThreshold) (int64, error) {
+
+	if req.ReorderPoint < 0 || req.TargetLevel < req.ReorderPoint {
+		return 0, errors.New("target_level must be greater than or equal to reorder_point")
+	}
+
+	productId, variantId, err := productVariant(ctx, r.db, req.ProductId, req.VariantId)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO stocks(
+			store_id,
+			product_id,
+			variant_id,
+			quantity,
+			reorder_point,
+			target_level
+		)
+		VALUES ($1, $2, $3, 0, $4, $5)
+		ON CONFLICT (store_id, variant_id)
+		DO UPDATE SET
+			reorder_point = EXCLUDED.reorder_point,
+			target_level = EXCLUDED.target_level,
+			version = stocks.version + 1
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		req.StoreId,
+		productId,
+		variantId,
+		req.ReorderPoint,
+		req.TargetLevel,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *stockRepo) GetListLow(ctx context.Context, req *models.GetListLowStockRequest) (resp *models.GetListLowStockResponse, err error) {
+
+	resp = &models.GetListLowStockResponse{}
+
+	var (
+		query  string
+		filter = " WHERE s.reorder_point > 0 AND COALESCE(s.quantity, 0) <= s.reorder_point "
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		params = map[string]interface{}{}
+	)
+
+	query = `
+		SELECT
+			COUNT(*) OVER(),
+			s.store_id,
+			st.store_name,
+			s.product_id,
+			p.product_name,
+			s.variant_id,
+			v.sku,
+			COALESCE(s.quantity, 0),
+			s.reorder_point,
+			s.target_level,
+			GREATEST(s.target_level, s.reorder_point) - COALESCE(s.quantity, 0)
+		FROM stocks AS s
+		JOIN stores AS st ON st.store_id = s.store_id
+		JOIN products AS p ON p.product_id = s.product_id
+		JOIN product_variants AS v ON v.variant_id = s.variant_id
+	`
+
+	if req.StoreId > 0 {
+		filter += " AND s.store_id = :store_id "
+		params["store_id"] = req.StoreId
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += filter + " ORDER BY s.store_id, s.product_id, s.variant_id " + offset + limit
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stock models.LowStock
+
+		err = rows.Scan(
+			&resp.Count,
+			&stock.StoreId,
+			&stock.StoreName,
+			&stock.ProductId,
+			&stock.ProductName,
+			&stock.VariantId,
+			&stock.Sku,
+			&stock.Quantity,
+			&stock.ReorderPoint,
+			&stock.TargetLevel,
+			&stock.Shortage,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Stocks = append(resp.Stocks, &stock)
+	}
+
+	return resp, nil
+}
+
+// ReorderSuggestion proposes quantities for stocks that are at their reorder point
+// or would run out within CoverDays at the sales velocity of completed orders of the last SalesDays.
+func (r *stockRepo) ReorderSuggestion(ctx context.Context, req *models.ReorderSuggestionRequest) (resp *models.ReorderSuggestionResponse, err error) {
+
+	resp = &models.ReorderSuggestionResponse{}
+
+	if req.SalesDays <= 0 || req.CoverDays <= 0 {
+		return nil, errors.New("sales_days and cover_days must be positive")
+	}
+
+	var (
+		query  string
+		filter = " WHERE ((st.reorder_point > 0 AND st.quantity <= st.reorder_point) OR st.quantity < st.cover) "
+		params = map[string]interface{}{
+			"sales_days":       req.SalesDays,
+			"cover_days":       req.CoverDays,
+			"default_currency": money.DefaultCurrency,
+			"completed":        models.OrderStatusCompleted,
+		}
+	)
+
+	query = `
+		WITH sales AS (
+			SELECT
+				o.store_id,
+				oi.variant_id,
+				SUM(oi.quantity) AS sold
+			FROM order_items AS oi
+			JOIN orders AS o ON o.order_id = oi.order_id
+			WHERE o.order_status = :completed AND o.order_date >= CURRENT_DATE - CAST(:sales_days AS INT)
+			GROUP BY o.store_id, oi.variant_id
+		),
+		last_cost AS (
+			SELECT DISTINCT ON (poi.variant_id)
+				poi.variant_id,
+				pr.cost_price,
+				po.currency
+			FROM purchase_receipts AS pr
+			JOIN purchase_order_items AS poi ON poi.purchase_order_id = pr.purchase_order_id AND poi.item_id = pr.item_id
+			JOIN purchase_orders AS po ON po.purchase_order_id = pr.purchase_order_id
+			ORDER BY poi.variant_id, pr.received_at DESC
+		),
+		stock_data AS (
+			SELECT
+				s.store_id,
+				s.product_id,
+				p.product_name,
+				s.variant_id,
+				v.sku,
+				p.brand_id,
+				COALESCE(s.quantity, 0) AS quantity,
+				s.reorder_point,
+				s.target_level,
+				COALESCE(sa.sold, 0) AS sold,
+				COALESCE(sa.sold, 0) / CAST(:sales_days AS NUMERIC) AS velocity,
+				CEIL(COALESCE(sa.sold, 0) / CAST(:sales_days AS NUMERIC) * CAST(:cover_days AS INT)) AS cover
+			FROM stocks AS s
+			JOIN products AS p ON p.product_id = s.product_id
+			JOIN product_variants AS v ON v.variant_id = s.variant_id
+			LEFT JOIN sales AS sa ON sa.store_id = s.store_id AND sa.variant_id = s.variant_id
+		)
+		SELECT
+			st.store_id,
+			st.product_id,
+			st.product_name,
+			st.variant_id,
+			st.sku,
+			st.brand_id,
+			COALESCE(
+				(
+					SELECT MIN(sb.supplier_id) FROM supplier_brands AS sb WHERE sb.brand_id = st.brand_id
+				), 0
+			),
+			st.quantity,
+			st.reorder_point,
+			st.target_level,
+			st.sold,
+			CAST(st.velocity AS DOUBLE PRECISION),
+			CAST(GREATEST(st.target_level, st.cover) - st.quantity AS INT),
+			COALESCE(lc.cost_price, 0),
+			COALESCE(lc.currency, :default_currency)
+		FROM stock_data AS st
+		LEFT JOIN last_cost AS lc ON lc.variant_id = st.variant_id
+	`
+
+	if req.StoreId > 0 {
+		filter += " AND st.store_id = :store_id "
+		params["store_id"] = req.StoreId
+	}
+
+	query += filter + " AND GREATEST(st.target_level, st.cover) > st.quantity ORDER BY st.store_id, st.product_id, st.variant_id "
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var suggestion models.ReorderSuggestion
+
+		err = rows.Scan(
+			&suggestion.StoreId,
+			&suggestion.ProductId,
+			&suggestion.ProductName,
+			&suggestion.VariantId,
+			&suggestion.Sku,
+			&suggestion.BrandId,
+			&suggestion.SupplierId,
+			&suggestion.Quantity,
+			&suggestion.ReorderPoint,
+			&suggestion.TargetLevel,
+			&suggestion.SoldQuantity,
+			&suggestion.DailyVelocity,
+			&suggestion.SuggestedQuantity,
+			&suggestion.LastCostPrice.Amount,
+			&suggestion.LastCostPrice.Currency,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Suggestions = append(resp.Suggestions, &suggestion)
+	}
+
+	resp.Count = len(resp.Suggestions)
+
+	return resp, nil
 }
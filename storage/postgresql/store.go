@@ -4,7 +4,6 @@ import (
 	"app/api/models"
 	"app/pkg/helper"
 	"context"
-	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -35,15 +34,23 @@ func (r *storeRepo) Create(ctx context.Context, req *models.CreateStore) (int, e
 			street,
 			city,
 			state,
-			zip_code
+			zip_code,
+			tax_inclusive,
+			currency
 		)
 		VALUES (
 			(
 				SELECT MAX(store_id) + 1 FROM stores
 			),
-			$1, $2, $3, $4, $5, $6, $7) RETURNING store_id
+			$1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING store_id
 	`
-	err := r.db.QueryRow(ctx, query,
+
+	currency, err := currencyOrDefault(req.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	err = r.db.QueryRow(ctx, query,
 		req.StoreName,
 		helper.NewNullString(req.Phone),
 		helper.NewNullString(req.Email),
@@ -51,6 +58,8 @@ func (r *storeRepo) Create(ctx context.Context, req *models.CreateStore) (int, e
 		helper.NewNullString(req.City),
 		helper.NewNullString(req.State),
 		helper.NewNullString(req.ZipCode),
+		req.TaxInclusive,
+		currency,
 	).Scan(&id)
 	if err != nil {
 		return 0, err
@@ -75,7 +84,11 @@ func (r *storeRepo) GetByID(ctx context.Context, req *models.StorePrimaryKey) (*
 			COASLESCE(street, ''),
 			COASLESCE(city, ''),
 			COASLESCE(state, ''),
-			COASLESCE(zip_code, '')
+			COASLESCE(zip_code, ''),
+			tax_inclusive,
+			currency,
+			COALESCE(CAST(deleted_at AS VARCHAR), ''),
+			version
 		FROM stores
 		WHERE store_id = $1
 	`
@@ -89,6 +102,10 @@ func (r *storeRepo) GetByID(ctx context.Context, req *models.StorePrimaryKey) (*
 		&store.City,
 		&store.State,
 		&store.ZipCode,
+		&store.TaxInclusive,
+		&store.Currency,
+		&store.DeletedAt,
+		&store.Version,
 	)
 	if err != nil {
 		return nil, err
@@ -118,10 +135,18 @@ func (r *storeRepo) GetList(ctx context.Context, req *models.GetListStoreRequest
 			COALESCE(street, ''),
 			COALESCE(city, ''),
 			COALESCE(state, ''),
-			COALESCE(zip_code, '')
+			COALESCE(zip_code, ''),
+			tax_inclusive,
+			currency,
+			COALESCE(CAST(deleted_at AS VARCHAR), ''),
+			version
 		FROM stores
 	`
 
+	if !req.IncludeDeleted {
+		filter += " AND deleted_at IS NULL "
+	}
+
 	if len(req.Search) > 0 {
 		filter += " AND name ILIKE '%' || '" + req.Search + "' || '%' "
 	}
@@ -154,6 +179,10 @@ func (r *storeRepo) GetList(ctx context.Context, req *models.GetListStoreRequest
 			&store.City,
 			&store.State,
 			&store.ZipCode,
+			&store.TaxInclusive,
+			&store.Currency,
+			&store.DeletedAt,
+			&store.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -171,6 +200,15 @@ func (r *storeRepo) UpdatePut(ctx context.Context, req *models.UpdateStore) (int
 		params map[string]interface{}
 	)
 
+	// an empty currency keeps the one of the store, open orders keep theirs either way
+	if len(req.Currency) > 0 {
+		currency, err := currencyOrDefault(req.Currency)
+		if err != nil {
+			return 0, err
+		}
+		req.Currency = currency
+	}
+
 	query = `
 		UPDATE
 		stores
@@ -182,8 +220,11 @@ func (r *storeRepo) UpdatePut(ctx context.Context, req *models.UpdateStore) (int
 			street = :street,
 			city = :city,
 			state = :state,
-			zip_code = :zip_code
-		WHERE store_id = :store_id
+			zip_code = :zip_code,
+			tax_inclusive = :tax_inclusive,
+			currency = COALESCE(:currency, currency),
+			version = version + 1
+		WHERE store_id = :store_id AND version = :version
 	`
 
 	params = map[string]interface{}{
@@ -195,6 +236,10 @@ func (r *storeRepo) UpdatePut(ctx context.Context, req *models.UpdateStore) (int
 		"city":       helper.NewNullString(req.City),
 		"state":      helper.NewNullString(req.State),
 		"zip_code":   helper.NewNullString(req.ZipCode),
+		"version":    req.Version,
+
+		"tax_inclusive": req.TaxInclusive,
+		"currency":      helper.NewNullString(req.Currency),
 	}
 
 	query, args := helper.ReplaceQueryParams(query, params)
@@ -207,39 +252,36 @@ func (r *storeRepo) UpdatePut(ctx context.Context, req *models.UpdateStore) (int
 	return result.RowsAffected(), nil
 }
 
-func (r *storeRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
-	var (
-		query string
-		set   string
-	)
+// storePatchFields are the columns a PATCH may change.
+var storePatchFields = map[string]patchField{
+	"store_name": {kind: patchString},
+	"phone":      {kind: patchString, nullable: true},
+	"email":      {kind: patchString, nullable: true},
+	"street":     {kind: patchString, nullable: true},
+	"city":       {kind: patchString, nullable: true},
+	"state":      {kind: patchString, nullable: true},
+	"zip_code":   {kind: patchString, nullable: true},
+
+	"tax_inclusive": {kind: patchBool},
+	"currency":      {kind: patchCurrency},
+}
 
-	if len(req.Fields) <= 0 {
-		return 0, errors.New("no fields")
-	}
+func (r *storeRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error) {
 
-	i := 0
-	for key := range req.Fields {
-		if i == len(req.Fields)-1 {
-			set += fmt.Sprintf(" %s = :%s ", key, key)
-		} else {
-			set += fmt.Sprintf(" %s = :%s, ", key, key)
-		}
-		i++
+	set, args, err := patchSet(storePatchFields, req.Fields)
+	if err != nil {
+		return 0, err
 	}
 
-	query = `
+	query := fmt.Sprintf(`
 		UPDATE
 		stores
 		SET
-		` + set + `
-		WHERE store_id = :store_id
-	`
+		%s, version = version + 1
+		WHERE store_id = $%d AND version = $%d
+	`, set, len(args)+1, len(args)+2)
 
-	req.Fields["store_id"] = req.ID
-
-	query, args := helper.ReplaceQueryParams(query, req.Fields)
-
-	fmt.Println(query)
+	args = append(args, req.ID, req.Version)
 
 	result, err := r.db.Exec(ctx, query, args...)
 	if err != nil {
@@ -251,9 +293,24 @@ func (r *storeRepo) UpdatePatch(ctx context.Context, req *models.PatchRequest) (
 
 func (r *storeRepo) Delete(ctx context.Context, req *models.StorePrimaryKey) (int64, error) {
 	query := `
-		DELETE 
-		FROM stores
-		WHERE store_id = $1
+		UPDATE stores
+			SET deleted_at = NOW(), version = version + 1
+		WHERE store_id = $1 AND version = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, req.StoreId, req.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *storeRepo) Restore(ctx context.Context, req *models.StorePrimaryKey) (int64, error) {
+	query := `
+		UPDATE stores
+			SET deleted_at = NULL, version = version + 1
+		WHERE store_id = $1 AND deleted_at IS NOT NULL
 	`
 
 	result, err := r.db.Exec(ctx, query, req.StoreId)
@@ -0,0 +1,307 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type supplierRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewSupplierRepo(db *pgxpool.Pool) *supplierRepo {
+	return &supplierRepo{
+		db: db,
+	}
+}
+
+func (r *supplierRepo) Create(ctx context.Context, req *models.CreateSupplier) (int, error) {
+	var (
+		query string
+		id    int
+	)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query = `
+		INSERT INTO suppliers(
+			supplier_name,
+			phone,
+			email,
+			street,
+			city,
+			state,
+			zip_code
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING supplier_id
+	`
+	err = tx.QueryRow(ctx, query,
+		req.SupplierName,
+		helper.NewNullString(req.Phone),
+		helper.NewNullString(req.Email),
+		helper.NewNullString(req.Street),
+		helper.NewNullString(req.City),
+		helper.NewNullString(req.State),
+		helper.NewNullString(req.ZipCode),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	err = r.setBrands(ctx, tx, id, req.BrandIds)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *supplierRepo) GetByID(ctx context.Context, req *models.SupplierPrimaryKey) (*models.Supplier, error) {
+
+	var (
+		query    string
+		supplier models.Supplier
+		brands   pgtype.JSONB
+	)
+
+	query = `
+		SELECT
+			s.supplier_id,
+			s.supplier_name,
+			COALESCE(s.phone, ''),
+			COALESCE(s.email, ''),
+			COALESCE(s.street, ''),
+			COALESCE(s.city, ''),
+			COALESCE(s.state, ''),
+			COALESCE(s.zip_code, ''),
+			COALESCE(
+				(
+					SELECT
+						JSONB_AGG (
+							JSONB_BUILD_OBJECT (
+								'brand_id', b.brand_id,
+								'brand_name', b.brand_name
+							)
+						)
+					FROM supplier_brands AS sb
+					JOIN brands AS b ON b.brand_id = sb.brand_id
+					WHERE sb.supplier_id = s.supplier_id
+				), '[]'
+			),
+			s.version
+		FROM suppliers AS s
+		WHERE s.supplier_id = $1
+	`
+
+	err := r.db.QueryRow(ctx, query, req.SupplierId).Scan(
+		&supplier.SupplierId,
+		&supplier.SupplierName,
+		&supplier.Phone,
+		&supplier.Email,
+		&supplier.Street,
+		&supplier.City,
+		&supplier.State,
+		&supplier.ZipCode,
+		&brands,
+		&supplier.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	brands.AssignTo(&supplier.Brands)
+
+	return &supplier, nil
+}
+
+func (r *supplierRepo) GetList(ctx context.Context, req *models.GetListSupplierRequest) (resp *models.GetListSupplierResponse, err error) {
+
+	resp = &models.GetListSupplierResponse{}
+
+	var (
+		query  string
+		filter = " WHERE TRUE "
+		offset = " OFFSET 0"
+		limit  = " LIMIT 10"
+		params = map[string]interface{}{}
+	)
+
+	query = `
+		SELECT
+			COUNT(*) OVER(),
+			s.supplier_id,
+			s.supplier_name,
+			COALESCE(s.phone, ''),
+			COALESCE(s.email, ''),
+			COALESCE(s.street, ''),
+			COALESCE(s.city, ''),
+			COALESCE(s.state, ''),
+			COALESCE(s.zip_code, ''),
+			s.version
+		FROM suppliers AS s
+	`
+
+	if len(req.Search) > 0 {
+		filter += " AND s.supplier_name ILIKE '%' || :search || '%' "
+		params["search"] = req.Search
+	}
+
+	if req.BrandId > 0 {
+		filter += " AND EXISTS (SELECT 1 FROM supplier_brands AS sb WHERE sb.supplier_id = s.supplier_id AND sb.brand_id = :brand_id) "
+		params["brand_id"] = req.BrandId
+	}
+
+	if req.Offset > 0 {
+		offset = fmt.Sprintf(" OFFSET %d", req.Offset)
+	}
+
+	if req.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", req.Limit)
+	}
+
+	query += filter + " ORDER BY s.supplier_id " + offset + limit
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var supplier models.Supplier
+		err = rows.Scan(
+			&resp.Count,
+			&supplier.SupplierId,
+			&supplier.SupplierName,
+			&supplier.Phone,
+			&supplier.Email,
+			&supplier.Street,
+			&supplier.City,
+			&supplier.State,
+			&supplier.ZipCode,
+			&supplier.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Suppliers = append(resp.Suppliers, &supplier)
+	}
+
+	return resp, nil
+}
+
+func (r *supplierRepo) Update(ctx context.Context, req *models.UpdateSupplier) (int64, error) {
+	var (
+		query  string
+		params map[string]interface{}
+	)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query = `
+		UPDATE
+		suppliers
+		SET
+			supplier_name = :supplier_name,
+			phone = :phone,
+			email = :email,
+			street = :street,
+			city = :city,
+			state = :state,
+			zip_code = :zip_code,
+			version = version + 1
+		WHERE supplier_id = :supplier_id AND version = :version
+	`
+
+	params = map[string]interface{}{
+		"supplier_id":   req.SupplierId,
+		"supplier_name": req.SupplierName,
+		"phone":         helper.NewNullString(req.Phone),
+		"email":         helper.NewNullString(req.Email),
+		"street":        helper.NewNullString(req.Street),
+		"city":          helper.NewNullString(req.City),
+		"state":         helper.NewNullString(req.State),
+		"zip_code":      helper.NewNullString(req.ZipCode),
+		"version":       req.Version,
+	}
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.RowsAffected() <= 0 {
+		return 0, nil
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM supplier_brands WHERE supplier_id = $1`, req.SupplierId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = r.setBrands(ctx, tx, req.SupplierId, req.BrandIds)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *supplierRepo) Delete(ctx context.Context, req *models.SupplierPrimaryKey) (int64, error) {
+	query := `
+		DELETE
+		FROM suppliers
+		WHERE supplier_id = $1 AND version = $2
+	`
+
+	result, err := r.db.Exec(ctx, query, req.SupplierId, req.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *supplierRepo) setBrands(ctx context.Context, tx pgx.Tx, supplierId int, brandIds []int) error {
+	for _, brandId := range brandIds {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO supplier_brands(supplier_id, brand_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			supplierId,
+			brandId,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
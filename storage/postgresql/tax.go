@@ -0,0 +1,197 @@
+package postgresql
+
+import (
+	"app/api/models"
+	"app/pkg/helper"
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type taxRateRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewTaxRateRepo(db *pgxpool.Pool) *taxRateRepo {
+	return &taxRateRepo{
+		db: db,
+	}
+}
+
+func checkTaxRate(name string, rate float64) error {
+	if len(name) <= 0 {
+		return errors.New("tax name is required")
+	}
+
+	if rate < 0 || rate >= 1 {
+		return errors.New("rate must be a fraction from 0 to 1")
+	}
+
+	return nil
+}
+
+func (r *taxRateRepo) Create(ctx context.Context, req *models.CreateTaxRate) (int, error) {
+	var id int
+
+	err := checkTaxRate(req.TaxName, req.Rate)
+	if err != nil {
+		return 0, err
+	}
+
+	err = r.db.QueryRow(ctx, `
+		INSERT INTO tax_rates(tax_name, state, store_id, category_id, rate)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING tax_rate_id
+	`,
+		req.TaxName,
+		helper.NewNullString(req.State),
+		helper.NewNullInt32(req.StoreId),
+		helper.NewNullInt32(req.CategoryId),
+		req.Rate,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *taxRateRepo) GetByID(ctx context.Context, req *models.TaxRatePrimaryKey) (*models.TaxRate, error) {
+	var rate models.TaxRate
+
+	err := r.db.QueryRow(ctx, `
+		SELECT
+			tax_rate_id,
+			tax_name,
+			COALESCE(state, ''),
+			COALESCE(store_id, 0),
+			COALESCE(category_id, 0),
+			rate,
+			version
+		FROM tax_rates
+		WHERE tax_rate_id = $1
+	`, req.TaxRateId).Scan(
+		&rate.TaxRateId,
+		&rate.TaxName,
+		&rate.State,
+		&rate.StoreId,
+		&rate.CategoryId,
+		&rate.Rate,
+		&rate.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+func (r *taxRateRepo) GetList(ctx context.Context, req *models.GetListTaxRateRequest) ([]*models.TaxRate, error) {
+	var (
+		rates  []*models.TaxRate
+		filter = " WHERE TRUE "
+		params = map[string]interface{}{}
+	)
+
+	query := `
+		SELECT
+			tax_rate_id,
+			tax_name,
+			COALESCE(state, ''),
+			COALESCE(store_id, 0),
+			COALESCE(category_id, 0),
+			rate,
+			version
+		FROM tax_rates
+	`
+
+	if len(req.State) > 0 {
+		filter += " AND state = :state "
+		params["state"] = req.State
+	}
+
+	if req.StoreId > 0 {
+		filter += " AND store_id = :store_id "
+		params["store_id"] = req.StoreId
+	}
+
+	if req.CategoryId > 0 {
+		filter += " AND category_id = :category_id "
+		params["category_id"] = req.CategoryId
+	}
+
+	query += filter + " ORDER BY tax_rate_id "
+
+	query, args := helper.ReplaceQueryParams(query, params)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate models.TaxRate
+
+		err = rows.Scan(
+			&rate.TaxRateId,
+			&rate.TaxName,
+			&rate.State,
+			&rate.StoreId,
+			&rate.CategoryId,
+			&rate.Rate,
+			&rate.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, &rate)
+	}
+
+	return rates, nil
+}
+
+func (r *taxRateRepo) Update(ctx context.Context, req *models.UpdateTaxRate) (int64, error) {
+
+	err := checkTaxRate(req.TaxName, req.Rate)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.Exec(ctx, `
+		UPDATE tax_rates
+		SET
+			tax_name = $2,
+			state = $3,
+			store_id = $4,
+			category_id = $5,
+			rate = $6,
+			version = version + 1
+		WHERE tax_rate_id = $1 AND version = $7
+	`,
+		req.TaxRateId,
+		req.TaxName,
+		helper.NewNullString(req.State),
+		helper.NewNullInt32(req.StoreId),
+		helper.NewNullInt32(req.CategoryId),
+		req.Rate,
+		req.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (r *taxRateRepo) Delete(ctx context.Context, req *models.TaxRatePrimaryKey) (int64, error) {
+
+	result, err := r.db.Exec(ctx, `DELETE FROM tax_rates WHERE tax_rate_id = $1 AND version = $2`, req.TaxRateId, req.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
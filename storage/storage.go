@@ -2,7 +2,9 @@ package storage
 
 import (
 	"app/api/models"
+	"app/pkg/money"
 	"context"
+	"time"
 )
 
 type StorageI interface {
@@ -16,6 +18,20 @@ type StorageI interface {
 	Staff() StaffRepoI
 	Order() OrderRepoI
 	Code() CodeRepoI
+	Supplier() SupplierRepoI
+	PurchaseOrder() PurchaseOrderRepoI
+	Return() ReturnRepoI
+	Payment() PaymentRepoI
+	GiftCard() GiftCardRepoI
+	Loyalty() LoyaltyRepoI
+	CustomerAddress() CustomerAddressRepoI
+	Audit() AuditRepoI
+	Idempotency() IdempotencyRepoI
+	PriceList() PriceListRepoI
+	TaxRate() TaxRateRepoI
+	ExchangeRate() ExchangeRateRepoI
+	ProductVariant() ProductVariantRepoI
+	Attribute() AttributeRepoI
 }
 
 type ProductRepoI interface {
@@ -23,14 +39,19 @@ type ProductRepoI interface {
 	GetByID(context.Context, *models.ProductPrimaryKey) (*models.Product, error)
 	GetList(context.Context, *models.GetListProductRequest) (*models.GetListProductResponse, error)
 	Update(ctx context.Context, req *models.UpdateProduct) (int64, error)
+	UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error)
 	Delete(ctx context.Context, req *models.ProductPrimaryKey) (int64, error)
+	Restore(ctx context.Context, req *models.ProductPrimaryKey) (int64, error)
+	Search(ctx context.Context, req *models.SearchProductRequest) (*models.SearchProductResponse, error)
 }
 
 type CategoryRepoI interface {
 	Create(context.Context, *models.CreateCategory) (int, error)
 	GetByID(context.Context, *models.CategoryPrimaryKey) (*models.Category, error)
 	GetList(context.Context, *models.GetListCategoryRequest) (*models.GetListCategoryResponse, error)
+	UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error)
 	Delete(ctx context.Context, req *models.CategoryPrimaryKey) (int64, error)
+	Restore(ctx context.Context, req *models.CategoryPrimaryKey) (int64, error)
 	Update(ctx context.Context, req *models.UpdateCategory) (int64, error)
 }
 
@@ -39,16 +60,23 @@ type BrandRepoI interface {
 	GetByID(context.Context, *models.BrandPrimaryKey) (*models.Brand, error)
 	GetList(context.Context, *models.GetListBrandRequest) (*models.GetListBrandResponse, error)
 	Update(ctx context.Context, req *models.UpdateBrand) (int64, error)
+	UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error)
 	Delete(ctx context.Context, req *models.BrandPrimaryKey) (int64, error)
+	Restore(ctx context.Context, req *models.BrandPrimaryKey) (int64, error)
 }
 
 type StockRepoI interface {
 	Create(ctx context.Context, req *models.CreateStock) (int, int, error)
 	GetByID(ctx context.Context, req *models.StockPrimaryKey) (*models.GetStock, error)
+	GetItem(ctx context.Context, req *models.StockPrimaryKey) (*models.ProductData, error)
 	GetList(ctx context.Context, req *models.GetListStockRequest) (resp *models.GetListStockResponse, err error)
 	Update(ctx context.Context, req *models.UpdateStock) (int64, error)
+	UpdatePatch(ctx context.Context, req *models.PatchStockRequest) (int64, error)
 	Delete(ctx context.Context, req *models.StockPrimaryKey) (int64, error)
 	SendProduct(ctx context.Context, req *models.SendProduct) error
+	UpdateThreshold(ctx context.Context, req *models.StockThreshold) (int64, error)
+	GetListLow(ctx context.Context, req *models.GetListLowStockRequest) (resp *models.GetListLowStockResponse, err error)
+	ReorderSuggestion(ctx context.Context, req *models.ReorderSuggestionRequest) (resp *models.ReorderSuggestionResponse, err error)
 }
 
 type StoreRepoI interface {
@@ -58,6 +86,7 @@ type StoreRepoI interface {
 	UpdatePut(ctx context.Context, req *models.UpdateStore) (int64, error)
 	UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error)
 	Delete(ctx context.Context, req *models.StorePrimaryKey) (int64, error)
+	Restore(ctx context.Context, req *models.StorePrimaryKey) (int64, error)
 }
 
 type CustomerRepoI interface {
@@ -67,6 +96,22 @@ type CustomerRepoI interface {
 	UpdatePut(ctx context.Context, req *models.UpdateCustomer) (int64, error)
 	UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error)
 	Delete(ctx context.Context, req *models.CustomerPrimaryKey) (int64, error)
+	Restore(ctx context.Context, req *models.CustomerPrimaryKey) (int64, error)
+	Credit(ctx context.Context, req *models.GetCustomerCreditRequest) (*models.CustomerCredit, error)
+	Orders(ctx context.Context, req *models.GetListCustomerOrderRequest) (*models.GetListCustomerOrderResponse, error)
+	Summary(ctx context.Context, req *models.GetCustomerSummaryRequest) (*models.CustomerSummary, error)
+	Duplicates(ctx context.Context, req *models.GetListDuplicateCustomerRequest) (*models.GetListDuplicateCustomerResponse, error)
+	Merge(ctx context.Context, req *models.MergeCustomer) error
+	Export(ctx context.Context, req *models.CustomerPrivacyRequest) (*models.CustomerExport, error)
+	Anonymize(ctx context.Context, req *models.CustomerPrivacyRequest) error
+}
+
+type CustomerAddressRepoI interface {
+	Create(ctx context.Context, req *models.CreateCustomerAddress) (int, error)
+	GetByID(ctx context.Context, req *models.CustomerAddressPrimaryKey) (*models.CustomerAddress, error)
+	GetList(ctx context.Context, req *models.GetListCustomerAddressRequest) (resp *models.GetListCustomerAddressResponse, err error)
+	Update(ctx context.Context, req *models.UpdateCustomerAddress) (int64, error)
+	Delete(ctx context.Context, req *models.CustomerAddressPrimaryKey) (int64, error)
 }
 
 type StaffRepoI interface {
@@ -77,19 +122,25 @@ type StaffRepoI interface {
 	UpdatePut(ctx context.Context, req *models.UpdateStaff) (int64, error)
 	UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error)
 	Delete(ctx context.Context, req *models.StaffPrimaryKey) (int64, error)
+	Restore(ctx context.Context, req *models.StaffPrimaryKey) (int64, error)
 }
 
 type OrderRepoI interface {
 	Create(ctx context.Context, req *models.CreateOrder) (int, error)
+	Checkout(ctx context.Context, req *models.Checkout) (int, []*models.OrderItemError, error)
 	GetByID(ctx context.Context, req *models.OrderPrimaryKey) (*models.Order, error)
 	GetList(ctx context.Context, req *models.GetListOrderRequest) (resp *models.GetListOrderResponse, err error)
 	Update(ctx context.Context, req *models.UpdateOrder) (int64, error)
 	UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error)
 	Delete(ctx context.Context, req *models.OrderPrimaryKey) (int64, error)
 	AddOrderItem(ctx context.Context, req *models.CreateOrderItem) error
-	RemoveOrderItem(ctx context.Context, req *models.OrderItemPrimaryKey) error
-	OrderTotalSum(ctx context.Context, req *models.OrderTotalSum) (string, error)
+	AddOrderItems(ctx context.Context, req *models.CreateOrderItems) ([]*models.OrderItemError, error)
+	RemoveOrderItem(ctx context.Context, req *models.OrderItemPrimaryKey) (int64, error)
+	OrderTotalSum(ctx context.Context, req *models.OrderTotalSum) (*money.Money, error)
 	Check(ctx context.Context, req *models.CreateOrderItem) error
+	Complete(ctx context.Context, req *models.CompleteOrder) error
+	ExpireReservations(ctx context.Context, ttl time.Duration) (int64, error)
+	Receipt(ctx context.Context, req *models.OrderPrimaryKey) (*models.Receipt, error)
 }
 
 type CodeRepoI interface {
@@ -97,5 +148,117 @@ type CodeRepoI interface {
 	GetByID(ctx context.Context, req *models.CodePrimaryKey) (*models.Code, error)
 	GetList(ctx context.Context, req *models.GetListCodeRequest) (resp *models.GetListCodeResponse, err error)
 	Update(ctx context.Context, req *models.UpdateCode) (int64, error)
+	UpdatePatch(ctx context.Context, req *models.PatchRequest) (int64, error)
 	Delete(ctx context.Context, req *models.CodePrimaryKey) (int64, error)
 }
+
+type SupplierRepoI interface {
+	Create(ctx context.Context, req *models.CreateSupplier) (int, error)
+	GetByID(ctx context.Context, req *models.SupplierPrimaryKey) (*models.Supplier, error)
+	GetList(ctx context.Context, req *models.GetListSupplierRequest) (resp *models.GetListSupplierResponse, err error)
+	Update(ctx context.Context, req *models.UpdateSupplier) (int64, error)
+	Delete(ctx context.Context, req *models.SupplierPrimaryKey) (int64, error)
+}
+
+type PurchaseOrderRepoI interface {
+	Create(ctx context.Context, req *models.CreatePurchaseOrder) (int, error)
+	GetByID(ctx context.Context, req *models.PurchaseOrderPrimaryKey) (*models.PurchaseOrder, error)
+	GetList(ctx context.Context, req *models.GetListPurchaseOrderRequest) (resp *models.GetListPurchaseOrderResponse, err error)
+	Update(ctx context.Context, req *models.UpdatePurchaseOrder) (int64, error)
+	UpdateStatus(ctx context.Context, req *models.UpdatePurchaseOrderStatus) (int64, error)
+	Delete(ctx context.Context, req *models.PurchaseOrderPrimaryKey) (int64, error)
+	AddItem(ctx context.Context, req *models.CreatePurchaseOrderItem) error
+	RemoveItem(ctx context.Context, req *models.PurchaseOrderItemPrimaryKey) (int64, error)
+	Receive(ctx context.Context, req *models.ReceivePurchaseOrder) error
+}
+
+type ReturnRepoI interface {
+	Create(ctx context.Context, req *models.CreateReturn) (int, error)
+	GetByID(ctx context.Context, req *models.ReturnPrimaryKey) (*models.Return, error)
+	GetList(ctx context.Context, req *models.GetListReturnRequest) (resp *models.GetListReturnResponse, err error)
+	Inspect(ctx context.Context, req *models.InspectReturn) error
+	Approve(ctx context.Context, req *models.ApproveReturn) error
+	Reject(ctx context.Context, req *models.RejectReturn) error
+}
+
+type PaymentRepoI interface {
+	Create(ctx context.Context, req *models.CreatePayment) (int, error)
+	GetByID(ctx context.Context, req *models.PaymentPrimaryKey) (*models.Payment, error)
+	GetList(ctx context.Context, req *models.GetListPaymentRequest) (resp *models.GetListPaymentResponse, err error)
+	Refund(ctx context.Context, req *models.RefundPayment) (int, error)
+	Balance(ctx context.Context, req *models.OrderPrimaryKey) (*models.OrderBalance, error)
+}
+
+type GiftCardRepoI interface {
+	Create(ctx context.Context, req *models.CreateGiftCard) (int, error)
+	GetByID(ctx context.Context, req *models.GiftCardPrimaryKey) (*models.GiftCard, error)
+	GetList(ctx context.Context, req *models.GetListGiftCardRequest) (resp *models.GetListGiftCardResponse, err error)
+	UpdateStatus(ctx context.Context, req *models.UpdateGiftCardStatus) (int64, error)
+}
+
+type LoyaltyRepoI interface {
+	GetListTier(ctx context.Context) ([]*models.LoyaltyTier, error)
+	UpsertTier(ctx context.Context, req *models.UpsertLoyaltyTier) (int, error)
+	GetListMultiplier(ctx context.Context) ([]*models.LoyaltyMultiplier, error)
+	UpsertMultiplier(ctx context.Context, req *models.UpsertLoyaltyMultiplier) (int, error)
+	DeleteMultiplier(ctx context.Context, req *models.LoyaltyMultiplierPrimaryKey) (int64, error)
+	Customer(ctx context.Context, req *models.GetCustomerLoyaltyRequest) (*models.CustomerLoyalty, error)
+	Redeem(ctx context.Context, req *models.RedeemLoyaltyPoints) error
+	Release(ctx context.Context, req *models.OrderPrimaryKey) error
+}
+
+type AuditRepoI interface {
+	Create(ctx context.Context, req *models.CreateAuditLog) (int, error)
+	GetList(ctx context.Context, req *models.GetListAuditLogRequest) (resp *models.GetListAuditLogResponse, err error)
+}
+
+type IdempotencyRepoI interface {
+	Reserve(ctx context.Context, req *models.ReserveIdempotencyKey) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, req *models.CompleteIdempotencyKey) error
+	Delete(ctx context.Context, req *models.IdempotencyKeyPrimaryKey) error
+}
+
+type PriceListRepoI interface {
+	Create(ctx context.Context, req *models.CreatePriceList) (int, error)
+	GetByID(ctx context.Context, req *models.PriceListPrimaryKey) (*models.PriceList, error)
+	GetList(ctx context.Context) ([]*models.PriceList, error)
+	CreateItem(ctx context.Context, req *models.CreatePriceListItem) (int, error)
+	GetItem(ctx context.Context, req *models.PriceListItemPrimaryKey) (*models.PriceListItem, error)
+	GetListItem(ctx context.Context, req *models.GetListPriceListItemRequest) (*models.GetListPriceListItemResponse, error)
+	UpdateItem(ctx context.Context, req *models.UpdatePriceListItem) (int64, error)
+	DeleteItem(ctx context.Context, req *models.PriceListItemPrimaryKey) (int64, error)
+	ProductPrice(ctx context.Context, req *models.GetProductPriceRequest) (*models.ProductPrice, error)
+	GetListHistory(ctx context.Context, req *models.GetListPriceHistoryRequest) (*models.GetListPriceHistoryResponse, error)
+}
+
+type TaxRateRepoI interface {
+	Create(ctx context.Context, req *models.CreateTaxRate) (int, error)
+	GetByID(ctx context.Context, req *models.TaxRatePrimaryKey) (*models.TaxRate, error)
+	GetList(ctx context.Context, req *models.GetListTaxRateRequest) ([]*models.TaxRate, error)
+	Update(ctx context.Context, req *models.UpdateTaxRate) (int64, error)
+	Delete(ctx context.Context, req *models.TaxRatePrimaryKey) (int64, error)
+}
+
+type ExchangeRateRepoI interface {
+	Create(ctx context.Context, req *models.CreateExchangeRate) (int, error)
+	GetByID(ctx context.Context, req *models.ExchangeRatePrimaryKey) (*models.ExchangeRate, error)
+	GetList(ctx context.Context, req *models.GetListExchangeRateRequest) (*models.GetListExchangeRateResponse, error)
+}
+
+type ProductVariantRepoI interface {
+	Create(ctx context.Context, req *models.CreateProductVariant) (int, error)
+	GetByID(ctx context.Context, req *models.ProductVariantPrimaryKey) (*models.ProductVariant, error)
+	GetList(ctx context.Context, req *models.GetListProductVariantRequest) (resp *models.GetListProductVariantResponse, err error)
+	Update(ctx context.Context, req *models.UpdateProductVariant) (int64, error)
+	Delete(ctx context.Context, req *models.ProductVariantPrimaryKey) (int64, error)
+}
+
+type AttributeRepoI interface {
+	Create(ctx context.Context, req *models.CreateAttributeDefinition) (int, error)
+	GetByID(ctx context.Context, req *models.AttributeDefinitionPrimaryKey) (*models.AttributeDefinition, error)
+	GetList(ctx context.Context, req *models.GetListAttributeDefinitionRequest) (resp *models.GetListAttributeDefinitionResponse, err error)
+	Update(ctx context.Context, req *models.UpdateAttributeDefinition) (int64, error)
+	Delete(ctx context.Context, req *models.AttributeDefinitionPrimaryKey) (int64, error)
+	GetProductValues(ctx context.Context, req *models.ProductPrimaryKey) ([]*models.ProductAttribute, error)
+	SetProductValues(ctx context.Context, req *models.SetProductAttributes) error
+}